@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Manifest describes the phylum endpoints of a generated client.
+type Manifest struct {
+	// Package is the package name of the generated file.
+	Package string `json:"package" yaml:"package"`
+	// Type is the name of the generated client struct.  Defaults to
+	// "Client".
+	Type string `json:"type" yaml:"type"`
+	// Imports maps package aliases used by endpoint types to import paths.
+	Imports map[string]string `json:"imports" yaml:"imports"`
+	// Endpoints are the phylum endpoints of the client.
+	Endpoints []*Endpoint `json:"endpoints" yaml:"endpoints"`
+}
+
+// Endpoint describes a phylum endpoint.
+type Endpoint struct {
+	// Name is the Go method name.
+	Name string `json:"name" yaml:"name"`
+	// Method is the phylum endpoint name.
+	Method string `json:"method" yaml:"method"`
+	// Request is the request proto message type, e.g. "pb.GetRequest".
+	Request string `json:"request" yaml:"request"`
+	// Response is the response proto message type, e.g. "pb.GetResponse".
+	Response string `json:"response" yaml:"response"`
+	// Private wraps the endpoint with phylum.WrapCall.
+	Private bool `json:"private" yaml:"private"`
+	// Transforms is a Go expression of type []*private.Transform used to
+	// encode the request of a private endpoint, e.g. "pb.GetTransforms".
+	Transforms string `json:"transforms" yaml:"transforms"`
+}
+
+// validate checks the manifest and fills in defaults.
+func (m *Manifest) validate() error {
+	if !token.IsIdentifier(m.Package) {
+		return fmt.Errorf("manifest: invalid package %q", m.Package)
+	}
+	if m.Type == "" {
+		m.Type = "Client"
+	}
+	if !token.IsIdentifier(m.Type) {
+		return fmt.Errorf("manifest: invalid type %q", m.Type)
+	}
+	for alias := range m.Imports {
+		if !token.IsIdentifier(alias) {
+			return fmt.Errorf("manifest: invalid import alias %q", alias)
+		}
+	}
+	names := make(map[string]bool)
+	for i, e := range m.Endpoints {
+		if !token.IsExported(e.Name) || !token.IsIdentifier(e.Name) {
+			return fmt.Errorf("manifest: endpoint %d: invalid name %q", i, e.Name)
+		}
+		if names[e.Name] {
+			return fmt.Errorf("manifest: endpoint %s: duplicate name", e.Name)
+		}
+		names[e.Name] = true
+		if e.Method == "" {
+			return fmt.Errorf("manifest: endpoint %s: missing method", e.Name)
+		}
+		for _, typ := range []string{e.Request, e.Response} {
+			if err := m.checkType(typ); err != nil {
+				return fmt.Errorf("manifest: endpoint %s: %w", e.Name, err)
+			}
+		}
+		if e.Transforms != "" && !e.Private {
+			return fmt.Errorf("manifest: endpoint %s: transforms require private", e.Name)
+		}
+	}
+	return nil
+}
+
+// checkType checks that typ is a local or imported type name.
+func (m *Manifest) checkType(typ string) error {
+	alias, name, ok := strings.Cut(typ, ".")
+	if !ok {
+		alias, name = "", typ
+	}
+	if !token.IsIdentifier(name) {
+		return fmt.Errorf("invalid type %q", typ)
+	}
+	if alias != "" {
+		if _, ok := m.Imports[alias]; !ok {
+			return fmt.Errorf("type %q uses unknown import %q", typ, alias)
+		}
+	}
+	return nil
+}
+
+// importSpec is an aliased import of the generated file.
+type importSpec struct {
+	Alias string
+	Path  string
+}
+
+// SortedImports returns the manifest imports sorted by path.
+func (m *Manifest) SortedImports() []importSpec {
+	specs := make([]importSpec, 0, len(m.Imports))
+	for alias, path := range m.Imports {
+		specs = append(specs, importSpec{Alias: alias, Path: path})
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Path < specs[j].Path })
+	return specs
+}
+
+// HasPrivate reports whether any endpoint is private.
+func (m *Manifest) HasPrivate() bool {
+	for _, e := range m.Endpoints {
+		if e.Private {
+			return true
+		}
+	}
+	return false
+}
+
+var clientTemplate = template.Must(template.New("client").Parse(`// Code generated by phylumgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/phylum"
+{{- if .HasPrivate}}
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+{{- end}}
+{{range .SortedImports}}
+	{{.Alias}} "{{.Path}}"
+{{- end}}
+)
+
+// {{.Type}} is a typed client for phylum endpoints.
+type {{.Type}} struct {
+	Phylum *phylum.Client
+}
+
+// New{{.Type}} returns a {{.Type}} that calls endpoints using p.
+func New{{.Type}}(p *phylum.Client) *{{.Type}} {
+	return &{{.Type}}{Phylum: p}
+}
+{{range .Endpoints}}{{if .Private}}
+// {{.Name}} calls the private phylum endpoint {{printf "%q" .Method}}.
+func (c *{{$.Type}}) {{.Name}}(ctx context.Context, req *{{.Request}}, configs ...shiroclient.Config) (*{{.Response}}, *private.CallResult, error) {
+	resp := &{{.Response}}{}
+	result, err := phylum.WrapCall(c.Phylum, {{printf "%q" .Method}}{{if .Transforms}}, {{.Transforms}}...{{end}})(ctx, req, resp, configs...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, result, nil
+}
+{{else}}
+// {{.Name}} calls the phylum endpoint {{printf "%q" .Method}}.
+func (c *{{$.Type}}) {{.Name}}(ctx context.Context, req *{{.Request}}, configs ...shiroclient.Config) (*{{.Response}}, error) {
+	return phylum.Call(c.Phylum, ctx, {{printf "%q" .Method}}, req, &{{.Response}}{}, configs...)
+}
+{{end}}{{end}}`))
+
+// Generate returns the formatted Go source of the client for m.
+func Generate(m *Manifest) ([]byte, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := clientTemplate.Execute(&buf, m); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated client: %w", err)
+	}
+	return src, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerate(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "manifest.yaml"))
+	require.NoError(t, err)
+	m := &Manifest{}
+	require.NoError(t, yaml.Unmarshal(b, m))
+	src, err := Generate(m)
+	require.NoError(t, err)
+
+	golden := filepath.Join("testdata", "client.golden")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, src, 0o600))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(src))
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	for name, m := range map[string]*Manifest{
+		"package": {Package: "not valid"},
+		"unexported": {Package: "p", Endpoints: []*Endpoint{
+			{Name: "get", Method: "get", Request: "Req", Response: "Resp"},
+		}},
+		"duplicate": {Package: "p", Endpoints: []*Endpoint{
+			{Name: "Get", Method: "get", Request: "Req", Response: "Resp"},
+			{Name: "Get", Method: "get2", Request: "Req", Response: "Resp"},
+		}},
+		"import": {Package: "p", Endpoints: []*Endpoint{
+			{Name: "Get", Method: "get", Request: "pb.Req", Response: "pb.Resp"},
+		}},
+		"transforms": {Package: "p", Endpoints: []*Endpoint{
+			{Name: "Get", Method: "get", Request: "Req", Response: "Resp", Transforms: "t"},
+		}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Generate(m)
+			require.Error(t, err)
+		})
+	}
+}
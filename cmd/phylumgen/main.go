@@ -0,0 +1,80 @@
+// Command phylumgen generates typed phylum clients from a manifest of
+// endpoints.  It is intended to be run with go:generate:
+//
+//	//go:generate go run github.com/luthersystems/shiroclient-sdk-go/cmd/phylumgen -manifest phylum.yaml -out phylum_client.go
+//
+// The manifest lists the endpoints of the client with their request and
+// response proto messages:
+//
+//	package: app
+//	type: PhylumClient
+//	imports:
+//	  pb: github.com/example/app/api/pb/v1
+//	endpoints:
+//	  - name: GetAccount
+//	    method: get_account
+//	    request: pb.GetAccountRequest
+//	    response: pb.GetAccountResponse
+//	  - name: CreateAccount
+//	    method: create_account
+//	    request: pb.CreateAccountRequest
+//	    response: pb.CreateAccountResponse
+//	    private: true
+//	    transforms: accountTransforms
+//
+// Each endpoint produces a method built on phylum.Call.  Private endpoints
+// use phylum.WrapCall, encoding the request with the []*private.Transform
+// given by the transforms expression, and additionally return the
+// private.CallResult.  Files with a .json extension are parsed as JSON, all
+// others as YAML.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	manifestPath := flag.String("manifest", "", "endpoint manifest `file` (YAML or JSON)")
+	out := flag.String("out", "", "output `file` (default stdout)")
+	flag.Parse()
+	if *manifestPath == "" || flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*manifestPath, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "phylumgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(manifestPath string, out string) error {
+	b, err := os.ReadFile(manifestPath) // #nosec G304
+	if err != nil {
+		return err
+	}
+	m := &Manifest{}
+	if strings.ToLower(filepath.Ext(manifestPath)) == ".json" {
+		err = json.Unmarshal(b, m)
+	} else {
+		err = yaml.Unmarshal(b, m)
+	}
+	if err != nil {
+		return fmt.Errorf("parse manifest %s: %w", manifestPath, err)
+	}
+	src, err := Generate(m)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644) // #nosec G306
+}
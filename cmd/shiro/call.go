@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// callOutput is the JSON representation of a Call response.
+type callOutput struct {
+	TransactionID  string          `json:"transaction_id,omitempty"`
+	CommitBlockNum uint64          `json:"commit_block_num,omitempty"`
+	Result         json.RawMessage `json:"result,omitempty"`
+	Error          *callError      `json:"error,omitempty"`
+}
+
+// callError is the JSON representation of a phylum error.
+type callError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func runCall(ctx context.Context, env *cmdEnv, args []string) error {
+	fs := flag.NewFlagSet("call", flag.ContinueOnError)
+	paramsFile := fs.String("params", "", "JSON params `file` (- for stdin)")
+	transientFile := fs.String("transient", "", "JSON `file` with an object of transient string values")
+	phylumVersion := fs.String("phylum-version", "", "phylum `version` to target")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: expected a method", errUsage)
+	}
+
+	configs := []shiroclient.Config{}
+	if *paramsFile != "" {
+		b, err := readInput(*paramsFile)
+		if err != nil {
+			return fmt.Errorf("params: %w", err)
+		}
+		if !json.Valid(b) {
+			return fmt.Errorf("params: %s is not valid JSON", *paramsFile)
+		}
+		configs = append(configs, shiroclient.WithParams(json.RawMessage(b)))
+	}
+	if *transientFile != "" {
+		b, err := readInput(*transientFile)
+		if err != nil {
+			return fmt.Errorf("transient: %w", err)
+		}
+		values := map[string]string{}
+		if err := json.Unmarshal(b, &values); err != nil {
+			return fmt.Errorf("transient: %w", err)
+		}
+		transient := make(map[string][]byte, len(values))
+		for k, v := range values {
+			transient[k] = []byte(v)
+		}
+		configs = append(configs, shiroclient.WithTransientDataMap(transient))
+	}
+	if *phylumVersion != "" {
+		configs = append(configs, shiroclient.WithPhylumVersion(*phylumVersion))
+	}
+
+	client, err := env.client()
+	if err != nil {
+		return err
+	}
+	resp, err := client.Call(ctx, fs.Arg(0), configs...)
+	if err != nil {
+		return err
+	}
+	out := &callOutput{
+		TransactionID:  resp.TransactionID(),
+		CommitBlockNum: resp.CommitBlockNum(),
+	}
+	if perr := resp.Error(); perr != nil {
+		out.Error = &callError{
+			Code:    perr.Code(),
+			Message: perr.Message(),
+			Data:    perr.DataJSON(),
+		}
+	} else {
+		out.Result = resp.ResultJSON()
+	}
+	if err := env.writeJSON(out); err != nil {
+		return err
+	}
+	if out.Error != nil {
+		return fmt.Errorf("phylum error %d: %s", out.Error.Code, out.Error.Message)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/phylumbench"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/shiroload"
+)
+
+// latencyOutput is the JSON representation of latency percentiles.
+type latencyOutput struct {
+	Min  string `json:"min"`
+	Mean string `json:"mean"`
+	P50  string `json:"p50"`
+	P90  string `json:"p90"`
+	P99  string `json:"p99"`
+	Max  string `json:"max"`
+}
+
+func newLatencyOutput(p phylumbench.Percentiles) *latencyOutput {
+	return &latencyOutput{
+		Min:  p.Min.String(),
+		Mean: p.Mean.String(),
+		P50:  p.P50.String(),
+		P90:  p.P90.String(),
+		P99:  p.P99.String(),
+		Max:  p.Max.String(),
+	}
+}
+
+// methodLoadOutput is the JSON representation of a method load report.
+type methodLoadOutput struct {
+	Name         string         `json:"name,omitempty"`
+	Requests     int            `json:"requests"`
+	Errors       int            `json:"errors"`
+	PhylumErrors int            `json:"phylum_errors"`
+	ErrorRate    float64        `json:"error_rate"`
+	Latency      *latencyOutput `json:"latency"`
+}
+
+func newMethodLoadOutput(name string, r *shiroload.MethodReport) *methodLoadOutput {
+	return &methodLoadOutput{
+		Name:         name,
+		Requests:     r.Requests,
+		Errors:       r.Errors,
+		PhylumErrors: r.PhylumErrors,
+		ErrorRate:    r.ErrorRate(),
+		Latency:      newLatencyOutput(r.Latency),
+	}
+}
+
+// loadOutput is the JSON representation of a load report.
+type loadOutput struct {
+	*methodLoadOutput
+	Dropped    int                 `json:"dropped"`
+	Elapsed    string              `json:"elapsed"`
+	Throughput float64             `json:"throughput"`
+	FirstError string              `json:"first_error,omitempty"`
+	Methods    []*methodLoadOutput `json:"methods"`
+}
+
+func runLoad(ctx context.Context, env *cmdEnv, args []string) error {
+	fs := flag.NewFlagSet("load", flag.ContinueOnError)
+	maxErrorRate := fs.Float64("max-error-rate", 1, "fail if the error rate exceeds `rate`")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: expected a workload file", errUsage)
+	}
+	w, err := shiroload.LoadWorkload(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	client, err := env.client()
+	if err != nil {
+		return err
+	}
+	report, err := shiroload.Run(ctx, client, w)
+	if err != nil {
+		return err
+	}
+	out := &loadOutput{
+		methodLoadOutput: newMethodLoadOutput("", &report.MethodReport),
+		Dropped:          report.Dropped,
+		Elapsed:          report.Elapsed.String(),
+		Throughput:       report.Throughput(),
+	}
+	if report.FirstError != nil {
+		out.FirstError = report.FirstError.Error()
+	}
+	names := make([]string, 0, len(report.Methods))
+	for name := range report.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out.Methods = append(out.Methods, newMethodLoadOutput(name, report.Methods[name]))
+	}
+	if err := env.writeJSON(out); err != nil {
+		return err
+	}
+	if rate := report.ErrorRate(); rate > *maxErrorRate {
+		return fmt.Errorf("error rate %.4f exceeds %.4f", rate, *maxErrorRate)
+	}
+	return nil
+}
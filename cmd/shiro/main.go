@@ -0,0 +1,271 @@
+// Command shiro performs manual operations against a shiroclient gateway,
+// such as calling phylum endpoints, querying blocks, managing installed
+// phylum versions and running load tests (see shiroload.LoadWorkload).
+//
+//	shiro [global flags] <command> [flags] [args]
+//
+// Client configuration is read from the file given by -config, then from
+// SHIROCLIENT_* environment variables (see shiroclient.ConfigFromEnv), then
+// from the global flags, with later sources taking precedence.  Results are
+// written to stdout as JSON.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// errUsage indicates invalid command line arguments.
+var errUsage = errors.New("invalid usage")
+
+// command is a shiro subcommand.
+type command struct {
+	usage string
+	run   func(ctx context.Context, env *cmdEnv, args []string) error
+}
+
+var commands = map[string]*command{
+	"call": {
+		usage: "call [-params file] [-transient file] [-phylum-version version] <method>",
+		run:   runCall,
+	},
+	"query-info": {
+		usage: "query-info",
+		run:   runQueryInfo,
+	},
+	"query-block": {
+		usage: "query-block <number>",
+		run:   runQueryBlock,
+	},
+	"load": {
+		usage: "load [-max-error-rate rate] <workload file>",
+		run:   runLoad,
+	},
+	"health": {
+		usage: "health [-services name,...]",
+		run:   runHealth,
+	},
+	"phylum": {
+		usage: "phylum list | install -version <version> <file> | enable <version> | disable <version>",
+		run:   runPhylum,
+	},
+	"snapshot": {
+		usage: "snapshot inspect [-plugin path] <file>",
+		run:   runSnapshot,
+	},
+}
+
+// cmdEnv is the state shared by subcommands.
+type cmdEnv struct {
+	configs []shiroclient.Config
+	stdout  io.Writer
+}
+
+// client returns an RPC client for the configured gateway.
+func (env *cmdEnv) client() (shiroclient.ShiroClient, error) {
+	if err := shiroclient.ValidateConfigs(env.configs...); err != nil {
+		return nil, err
+	}
+	return shiroclient.NewRPC(env.configs), nil
+}
+
+// writeJSON writes v to stdout as indented JSON.
+func (env *cmdEnv) writeJSON(v interface{}) error {
+	enc := json.NewEncoder(env.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "shiro: %v\n", err)
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "usage: shiro [global flags] <command> [flags] [args]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(w, "\nglobal flags:")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+// run executes the command line args, writing results to stdout and usage
+// messages to stderr.
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	fs := flag.NewFlagSet("shiro", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configFile := fs.String("config", "", "client configuration `file` (YAML or JSON)")
+	endpoint := fs.String("endpoint", "", "gateway `url`")
+	authToken := fs.String("auth-token", "", "bearer `token` for requests")
+	timeout := fs.Duration("timeout", 0, "timeout for each request")
+	if err := fs.Parse(args); err != nil {
+		usage(stderr, fs)
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if fs.NArg() == 0 {
+		usage(stderr, fs)
+		return fmt.Errorf("%w: missing command", errUsage)
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		usage(stderr, fs)
+		return fmt.Errorf("%w: unknown command %q", errUsage, fs.Arg(0))
+	}
+
+	env := &cmdEnv{stdout: stdout}
+	if *configFile != "" {
+		configs, err := shiroclient.ConfigFromFile(*configFile)
+		if err != nil {
+			return err
+		}
+		env.configs = append(env.configs, configs...)
+	}
+	configs, err := shiroclient.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	env.configs = append(env.configs, configs...)
+	if *endpoint != "" {
+		env.configs = append(env.configs, shiroclient.WithEndpoint(*endpoint))
+	}
+	if *authToken != "" {
+		env.configs = append(env.configs, shiroclient.WithAuthToken(*authToken))
+	}
+	if *timeout > 0 {
+		env.configs = append(env.configs, shiroclient.WithTimeout(*timeout))
+	}
+
+	err = cmd.run(ctx, env, fs.Args()[1:])
+	if errors.Is(err, errUsage) {
+		fmt.Fprintf(stderr, "usage: shiro %s\n", cmd.usage)
+	}
+	return err
+}
+
+// parseFlags parses the flags of a subcommand, reporting errors as usage
+// errors.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return nil
+}
+
+// readInput reads the named file, or stdin if name is "-".
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name) // #nosec G304
+}
+
+func runQueryInfo(ctx context.Context, env *cmdEnv, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: unexpected arguments", errUsage)
+	}
+	client, err := env.client()
+	if err != nil {
+		return err
+	}
+	height, err := client.QueryInfo(ctx)
+	if err != nil {
+		return err
+	}
+	return env.writeJSON(map[string]interface{}{"height": height})
+}
+
+// transactionOutput is the JSON representation of a block transaction.
+type transactionOutput struct {
+	ID          string `json:"id"`
+	Reason      string `json:"reason"`
+	ChaincodeID string `json:"chaincode_id"`
+	Event       []byte `json:"event,omitempty"`
+}
+
+func runQueryBlock(ctx context.Context, env *cmdEnv, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected a block number", errUsage)
+	}
+	var blockNum uint64
+	if _, err := fmt.Sscan(args[0], &blockNum); err != nil {
+		return fmt.Errorf("%w: invalid block number %q", errUsage, args[0])
+	}
+	client, err := env.client()
+	if err != nil {
+		return err
+	}
+	block, err := client.QueryBlock(ctx, blockNum)
+	if err != nil {
+		return err
+	}
+	txs := make([]*transactionOutput, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		txs = append(txs, &transactionOutput{
+			ID:          tx.ID(),
+			Reason:      tx.Reason(),
+			ChaincodeID: tx.ChaincodeID(),
+			Event:       tx.Event(),
+		})
+	}
+	return env.writeJSON(map[string]interface{}{
+		"hash":         block.Hash(),
+		"transactions": txs,
+	})
+}
+
+func runHealth(ctx context.Context, env *cmdEnv, args []string) error {
+	fs := flag.NewFlagSet("health", flag.ContinueOnError)
+	services := fs.String("services", "", "comma-separated upstream service names")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	var names []string
+	if *services != "" {
+		names = strings.Split(*services, ",")
+	}
+	client, err := env.client()
+	if err != nil {
+		return err
+	}
+	health, err := shiroclient.RemoteHealthCheck(ctx, client, names)
+	if err != nil {
+		return err
+	}
+	reports := make([]map[string]string, 0, len(health.Reports()))
+	for _, report := range health.Reports() {
+		reports = append(reports, map[string]string{
+			"service_name":    report.ServiceName(),
+			"service_version": report.ServiceVersion(),
+			"status":          report.Status(),
+			"timestamp":       report.Timestamp(),
+		})
+	}
+	return env.writeJSON(map[string]interface{}{"reports": reports})
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testGateway responds to Call requests by echoing their params and to
+// other requests with height 7.
+func testGateway(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			Method string `json:"method"`
+			Params struct {
+				Params    interface{}       `json:"params"`
+				Transient map[string]string `json:"transient"`
+			} `json:"params"`
+		}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var result interface{} = 7
+		if req.Method == "Call" {
+			result = map[string]interface{}{
+				"params":    req.Params.Params,
+				"transient": req.Params.Transient,
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc":       "2.0",
+			"id":            "1",
+			"$commit_tx_id": "tx1",
+			"result": map[string]interface{}{
+				"error_level": 0,
+				"result":      result,
+				"code":        0,
+				"message":     "",
+				"data":        nil,
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestQueryInfo(t *testing.T) {
+	srv := testGateway(t)
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"-endpoint", srv.URL, "query-info"}, &stdout, &stderr)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"height": 7}`, stdout.String())
+}
+
+func TestCall(t *testing.T) {
+	srv := testGateway(t)
+	dir := t.TempDir()
+	params := filepath.Join(dir, "params.json")
+	require.NoError(t, os.WriteFile(params, []byte(`["a", 1]`), 0o600))
+	transient := filepath.Join(dir, "transient.json")
+	require.NoError(t, os.WriteFile(transient, []byte(`{"key": "value"}`), 0o600))
+
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{
+		"-endpoint", srv.URL,
+		"call", "-params", params, "-transient", transient, "hello",
+	}, &stdout, &stderr)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"transaction_id": "tx1",
+		"result": {"params": ["a", 1], "transient": {"key": "76616c7565"}}
+	}`, stdout.String())
+}
+
+func TestLoad(t *testing.T) {
+	srv := testGateway(t)
+	workload := filepath.Join(t.TempDir(), "workload.yaml")
+	require.NoError(t, os.WriteFile(workload, []byte(`
+start_rps: 50
+stages: [{duration: 200ms, rps: 50}]
+methods: [{name: hello, params: '[{{.Seq}}]'}]
+`), 0o600))
+
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"-endpoint", srv.URL, "load", workload}, &stdout, &stderr)
+	require.NoError(t, err)
+	out := struct {
+		Requests  int     `json:"requests"`
+		ErrorRate float64 `json:"error_rate"`
+		Methods   []struct {
+			Name string `json:"name"`
+		} `json:"methods"`
+	}{}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &out))
+	require.InDelta(t, 10, out.Requests, 3)
+	require.Zero(t, out.ErrorRate)
+	require.Len(t, out.Methods, 1)
+	require.Equal(t, "hello", out.Methods[0].Name)
+}
+
+func TestUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"bogus"}, &stdout, &stderr)
+	require.True(t, errors.Is(err, errUsage))
+	require.Contains(t, stderr.String(), "query-block <number>")
+
+	stderr.Reset()
+	err = run(context.Background(), []string{"-endpoint", "http://localhost", "call"}, &stdout, &stderr)
+	require.True(t, errors.Is(err, errUsage))
+	require.Contains(t, stderr.String(), "usage: shiro call")
+}
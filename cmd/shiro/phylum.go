@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/update"
+)
+
+func runPhylum(ctx context.Context, env *cmdEnv, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: expected a phylum command", errUsage)
+	}
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: unexpected arguments", errUsage)
+		}
+		client, err := env.client()
+		if err != nil {
+			return err
+		}
+		phyla, err := update.GetPhyla(ctx, client)
+		if err != nil {
+			return err
+		}
+		return env.writeJSON(phyla)
+	case "install":
+		fs := flag.NewFlagSet("install", flag.ContinueOnError)
+		version := fs.String("version", "", "`version` of the installed phylum")
+		if err := parseFlags(fs, args[1:]); err != nil {
+			return err
+		}
+		if *version == "" || fs.NArg() != 1 {
+			return fmt.Errorf("%w: expected a version and a phylum file", errUsage)
+		}
+		phylum, err := readInput(fs.Arg(0))
+		if err != nil {
+			return fmt.Errorf("phylum: %w", err)
+		}
+		client, err := env.client()
+		if err != nil {
+			return err
+		}
+		return update.Install(ctx, client, *version, phylum)
+	case "enable", "disable":
+		if len(args) != 2 {
+			return fmt.Errorf("%w: expected a version", errUsage)
+		}
+		client, err := env.client()
+		if err != nil {
+			return err
+		}
+		if args[0] == "enable" {
+			return update.Enable(ctx, client, args[1])
+		}
+		return update.Disable(ctx, client, args[1])
+	default:
+		return fmt.Errorf("%w: unknown phylum command %q", errUsage, args[0])
+	}
+}
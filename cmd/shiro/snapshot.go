@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/mock"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/update"
+)
+
+func runSnapshot(ctx context.Context, env *cmdEnv, args []string) error {
+	if len(args) == 0 || args[0] != "inspect" {
+		return fmt.Errorf("%w: expected inspect", errUsage)
+	}
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	pluginPath := fs.String("plugin", "", "substrate plugin `path` (default $SUBSTRATEHCP_FILE)")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: expected a snapshot file", errUsage)
+	}
+	snapshot, err := readInput(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+
+	opts := []mock.Option{
+		mock.WithSnapshotReader(bytes.NewReader(snapshot)),
+		mock.WithLogWriter(io.Discard),
+	}
+	if *pluginPath != "" {
+		opts = append(opts, mock.WithPluginPath(*pluginPath))
+	}
+	client, err := shiroclient.NewMock(nil, opts...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	height, err := client.QueryInfo(ctx)
+	if err != nil {
+		return err
+	}
+	phyla, err := update.GetPhyla(ctx, client)
+	if err != nil {
+		return err
+	}
+	return env.writeJSON(map[string]interface{}{
+		"size":   len(snapshot),
+		"height": height,
+		"phyla":  phyla.Phyla,
+	})
+}
@@ -0,0 +1,78 @@
+package mock
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/mockint"
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSubstrate is an in-process plugin.Substrate whose Call responses are
+// produced by a handler.
+type fakeSubstrate struct {
+	mu     sync.Mutex
+	handle func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response
+	calls  []*plugin.ConcreteRequestOptions
+	height uint64
+	closed int
+}
+
+var _ plugin.Substrate = (*fakeSubstrate)(nil)
+
+func (f *fakeSubstrate) HealthCheck(n int) (int, error) { return n, nil }
+
+func (f *fakeSubstrate) NewMockFrom(name string, version string, snapshot []byte) (string, error) {
+	return "tag", nil
+}
+
+func (f *fakeSubstrate) SetCreatorWithAttributesMock(tag string, creator string, attrs map[string]string) error {
+	return nil
+}
+
+func (f *fakeSubstrate) SnapshotMock(tag string) ([]byte, error) { return nil, nil }
+
+func (f *fakeSubstrate) CloseMock(tag string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed++
+	return nil
+}
+
+func (f *fakeSubstrate) Init(tag string, phylum string, opts *plugin.ConcreteRequestOptions) error {
+	return nil
+}
+
+func (f *fakeSubstrate) Call(tag string, method string, opts *plugin.ConcreteRequestOptions) (*plugin.Response, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, opts)
+	f.height++
+	f.mu.Unlock()
+	return f.handle(method, opts), nil
+}
+
+func (f *fakeSubstrate) QueryInfo(tag string, opts *plugin.ConcreteRequestOptions) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.height, nil
+}
+
+// QueryBlock returns a block holding the transaction "tx<height>".
+func (f *fakeSubstrate) QueryBlock(tag string, height uint64, opts *plugin.ConcreteRequestOptions) (*plugin.Block, error) {
+	return &plugin.Block{Transactions: []*plugin.Transaction{{ID: fmt.Sprintf("tx%d", height)}}}, nil
+}
+
+// newFakeMock returns a mock client backed by fake.
+func newFakeMock(t *testing.T, fake *fakeSubstrate, config *mockint.Config, configs ...types.Config) *mockShiroClient {
+	t.Helper()
+	if config == nil {
+		config = &mockint.Config{}
+	}
+	client, err := newMock(plugin.NewLocalConnection(fake), config, configs)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, client.Close()) })
+	return client
+}
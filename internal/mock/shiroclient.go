@@ -2,11 +2,14 @@ package mock
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/url"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
@@ -14,6 +17,7 @@ import (
 	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
 	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/mock"
 	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
 )
 
 var _ types.ShiroClient = (*mockShiroClient)(nil)
@@ -24,22 +28,86 @@ type MockShiroClient interface {
 	types.ShiroClient
 	Close() error
 	Snapshot(w io.Writer) error
+	SnapshotSince(w io.Writer, base string) (string, error)
+	ApplySnapshot(r io.Reader) error
 	SetCreatorWithAttributes(creator string, attrs map[string]string) error
+	StubChaincode(stub plugin.ChaincodeStub) error
+	ClearChaincodeStubs() error
+	Mocks() ([]*plugin.MockInfo, error)
+	Height(ctx context.Context, configs ...types.Config) (uint64, error)
+	TxCount(ctx context.Context, configs ...types.Config) (int, error)
+	LastTxID(ctx context.Context, configs ...types.Config) (string, error)
+	CoverageReport(w io.Writer) error
 }
 
 type mockShiroClient struct {
-	baseConfig  []types.Config
+	baseConfig  types.ConfigSet
 	conn        *plugin.SubstrateConnection
 	tag         string
 	shiroPhylum string
+	errorStacks bool
+	// phylumOutput is shared with derived clients.
+	phylumOutput *lockedWriter
+	// rand is shared with derived clients, if set.
+	rand *lockedRand
+	// dependencyWait enables dependency checks, if set.
+	dependencyWait *time.Duration
+	// snapshotCipher encrypts snapshots, if set.
+	snapshotCipher *snapshotCipher
+	// coverage is true if coverage collection is enabled.
+	coverage bool
+	// strictConfig fails requests using configs that are not supported in
+	// mock mode.
+	strictConfig bool
+	// release, if set, is called when the client is closed instead of
+	// closing the plugin connection, which the client shares.
+	release func()
+	// derived is true for clients returned by With, which do not own the
+	// mock ledger or the plugin connection.
+	derived bool
+	// closeOnce makes Close idempotent, since a mock may be closed both by
+	// its owner and by a pool closing all its mocks.
+	closeOnce sync.Once
+	closeErr  error
 }
 
-func (c *mockShiroClient) flatten(ctx context.Context, configs ...types.Config) (*plugin.ConcreteRequestOptions, error) {
-	opt := types.ApplyConfigs(nil, append(c.baseConfig, configs...)...)
+func (c *mockShiroClient) flatten(ctx context.Context, configs ...types.Config) (*plugin.ConcreteRequestOptions, *types.RequestOptions, error) {
+	return c.flattenCall(ctx, "", configs...)
+}
 
-	params, err := json.Marshal(opt.Params)
+// flattenCall is like flatten but applies the method configs of the client
+// for method between the base configs and configs.
+func (c *mockShiroClient) flattenCall(ctx context.Context, method string, configs ...types.Config) (*plugin.ConcreteRequestOptions, *types.RequestOptions, error) {
+	opt, err := c.baseConfig.Apply(nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if defaults := opt.MethodConfigs[method]; len(defaults) > 0 {
+		if err := opt.Apply(defaults...); err != nil {
+			return nil, nil, err
+		}
+	}
+	// the method policy of the client cannot be relaxed per call.
+	methods := opt.MethodPolicy
+	if err := opt.Apply(configs...); err != nil {
+		return nil, nil, err
+	}
+	opt.MethodPolicy = methods
+	if err := opt.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if c.strictConfig && len(opt.MspFilter) > 0 {
+		return nil, nil, &types.ConfigError{Field: "MspFilter", Reason: "not supported in mock mode"}
+	}
+
+	authToken, err := opt.ResolveAuthToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	params, err := opt.JSON.Marshal(opt.Params)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	tsg := (func(ctx context.Context, tg func(context.Context) string) string {
@@ -64,7 +132,7 @@ func (c *mockShiroClient) flatten(ctx context.Context, configs ...types.Config)
 		Headers:             opt.Headers,
 		Endpoint:            opt.Endpoint,
 		ID:                  opt.ID,
-		AuthToken:           opt.AuthToken,
+		AuthToken:           authToken,
 		Params:              params,
 		Transient:           opt.Transient,
 		Timestamp:           tsg(ctx, opt.TimestampGenerator),
@@ -78,7 +146,42 @@ func (c *mockShiroClient) flatten(ctx context.Context, configs ...types.Config)
 		NewPhylumVersion:    opt.NewPhylumVersion,
 		CCFetchURLDowngrade: opt.CcFetchURLDowngrade,
 		CCFetchURLProxy:     url(opt.CcFetchURLProxy),
-	}, nil
+		IdempotencyKey:      opt.IdempotencyKey,
+		IncludeErrorStack:   c.errorStacks,
+		CapturePhylumOutput: c.phylumOutput != nil,
+		PrivateCollections:  opt.PrivateCollections,
+		RandSeed:            c.rand.seed(),
+	}, opt, nil
+}
+
+// With returns a client backed by the same mock ledger that applies configs
+// after the base configs of c.  Closing the returned client has no effect;
+// the mock is shut down when the original client is closed.
+func (c *mockShiroClient) With(configs ...types.Config) types.ShiroClient {
+	return &mockShiroClient{
+		baseConfig:     c.baseConfig.With(configs...),
+		conn:           c.conn,
+		tag:            c.tag,
+		shiroPhylum:    c.shiroPhylum,
+		errorStacks:    c.errorStacks,
+		phylumOutput:   c.phylumOutput,
+		rand:           c.rand,
+		dependencyWait: c.dependencyWait,
+		snapshotCipher: c.snapshotCipher,
+		coverage:       c.coverage,
+		strictConfig:   c.strictConfig,
+		derived:        true,
+	}
+}
+
+// BaseOptions returns a copy of the options resolved from the base configs
+// of c, as they would be before per-call configs are applied.
+func (c *mockShiroClient) BaseOptions() (types.RequestOptions, error) {
+	opt, err := c.baseConfig.Apply(nil)
+	if err != nil {
+		return types.RequestOptions{}, err
+	}
+	return opt.Clone(), nil
 }
 
 // Seed implements the ShiroClient interface.
@@ -93,50 +196,206 @@ func (c *mockShiroClient) ShiroPhylum(_ context.Context, configs ...types.Config
 
 // Init implements the ShiroClient interface.
 func (c *mockShiroClient) Init(ctx context.Context, phylum string, configs ...types.Config) error {
-	cro, err := c.flatten(ctx, configs...)
+	cro, opt, err := c.flatten(ctx, configs...)
 	if err != nil {
 		return err
 	}
-	return c.conn.GetSubstrate().Init(c.tag, phylum, cro)
+	start := time.Now()
+	err = c.conn.GetSubstrate().Init(c.tag, phylum, cro)
+	reportStats(opt, &types.CallStats{Method: rpc.MethodInit, RequestSize: len(phylum)}, start, err)
+	return err
+}
+
+// reportStats calls the Stats callback of opt, if set, for a request
+// started at start.  The substrate time of a mock request is its duration.
+func reportStats(opt *types.RequestOptions, stats *types.CallStats, start time.Time, err error) {
+	if opt.Stats == nil {
+		return
+	}
+	stats.Duration = time.Since(start)
+	stats.SubstrateTime = stats.Duration
+	stats.Err = err
+	opt.Stats(*stats)
 }
 
 // Call implements the ShiroClient interface.
 func (c *mockShiroClient) Call(ctx context.Context, method string, configs ...types.Config) (types.ShiroResponse, error) {
-	cro, err := c.flatten(ctx, configs...)
+	cro, opt, err := c.flattenCall(ctx, method, configs...)
 	if err != nil {
 		return nil, err
 	}
+	start := time.Now()
+	resp, err := c.call(ctx, method, cro, opt)
+	resp, err = opt.ValidateResponse(method, resp, err)
+	opt.RecordCall(ctx, method, start, resp, err)
+	return resp, err
+}
+
+// call calls method if the method policy of opt allows it.
+func (c *mockShiroClient) call(ctx context.Context, method string, cro *plugin.ConcreteRequestOptions, opt *types.RequestOptions) (types.ShiroResponse, error) {
+	if err := opt.MethodPolicy.CheckMethod(method); err != nil {
+		return nil, err
+	}
+	// cro shares the transient map of opt.
+	opt.InjectTraceTransient(ctx)
+	if err := c.awaitDependencies(ctx, cro); err != nil {
+		return nil, err
+	}
 
+	start := time.Now()
 	resp, err := c.conn.GetSubstrate().Call(c.tag, method, cro)
+	if opt.SubstrateTimer != nil {
+		opt.SubstrateTimer(time.Since(start))
+	}
+	stats := &types.CallStats{Method: rpc.MethodCall, PhylumMethod: method, RequestSize: len(cro.Params)}
+	if resp != nil {
+		stats.ResponseSize = len(resp.ResultJSON)
+		if resp.HasError {
+			stats.ErrorLevel = rpc.ErrorLevelPhylum
+			stats.ResponseSize = len(resp.ErrorJSON)
+		}
+	}
+	reportStats(opt, stats, start, err)
 	if err != nil {
 		return nil, err
 	}
+	if c.phylumOutput != nil && len(resp.PhylumOutput) > 0 {
+		c.phylumOutput.Write(resp.PhylumOutput)
+	}
 
 	if resp.HasError {
-		return types.NewFailureResponse(resp.ErrorCode, resp.ErrorMessage, resp.ErrorJSON), nil
+		failure := types.NewFailureResponse(resp.ErrorCode, resp.ErrorMessage, resp.ErrorJSON)
+		failure.SetStack(resp.ErrorStack)
+		receive(opt, failure)
+		return failure, nil
 	}
 
-	return types.NewSuccessResponse(resp.ResultJSON, resp.TransactionID, 0, 0), nil
+	commit := responseCommit(resp)
+	if opt.WriteProgress != nil {
+		// the mock ledger commits synchronously.
+		opt.WriteProgress(types.WriteProgress{Stage: types.WriteSimulated, TxID: commit.TxID, BlockNum: commit.MaxSimulatedBlock})
+		if commit.TxID != "" {
+			opt.WriteProgress(types.WriteProgress{Stage: types.WriteSubmitted, TxID: commit.TxID})
+			opt.WriteProgress(types.WriteProgress{Stage: types.WriteCommitted, TxID: commit.TxID, BlockNum: commit.CommitBlock})
+		}
+	}
+	if opt.CommitStats != nil && commit.TxID != "" {
+		opt.CommitStats(types.CommitStats{PhylumMethod: method, TxID: commit.TxID, CommitBlock: commit.CommitBlock})
+	}
+
+	success := types.NewSuccessResponse(resp.ResultJSON, "", 0, 0)
+	success.SetCommit(commit)
+	receive(opt, success)
+	return success, nil
+}
+
+// receive passes resp to the ResponseReceiver of opt, if set.
+func receive(opt *types.RequestOptions, resp types.ShiroResponse) {
+	if opt.ResponseReceiver != nil {
+		opt.ResponseReceiver(resp)
+	}
+}
+
+// ErrDependencyNotFound is returned by calls whose dependency on a
+// transaction or block is not met when dependency checks are enabled.
+var ErrDependencyNotFound = errors.New("shiroclient: request dependency not found")
+
+// ErrCoverageDisabled is returned by CoverageReport for clients created
+// without coverage collection.
+var ErrCoverageDisabled = errors.New("shiroclient: coverage not enabled")
+
+// dependencyPollInterval is the interval between dependency checks of a
+// waiting call.
+const dependencyPollInterval = 10 * time.Millisecond
+
+// awaitDependencies waits until the dependencies of cro are on the ledger,
+// if dependency checks are enabled.
+func (c *mockShiroClient) awaitDependencies(ctx context.Context, cro *plugin.ConcreteRequestOptions) error {
+	if c.dependencyWait == nil || cro.DependentTxID == "" && cro.DependentBlock == "" {
+		return nil
+	}
+	deadline := time.Now().Add(*c.dependencyWait)
+	for {
+		missing, err := c.missingDependency(cro)
+		if err != nil || missing == "" {
+			return err
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%w: %s", ErrDependencyNotFound, missing)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s: %w", ErrDependencyNotFound, missing, ctx.Err())
+		case <-time.After(dependencyPollInterval):
+		}
+	}
+}
+
+// missingDependency describes the first dependency of cro that is not on
+// the ledger, or returns an empty string if all are.
+func (c *mockShiroClient) missingDependency(cro *plugin.ConcreteRequestOptions) (string, error) {
+	substrate := c.conn.GetSubstrate()
+	height, err := substrate.QueryInfo(c.tag, cro)
+	if err != nil {
+		return "", err
+	}
+	if cro.DependentBlock != "" {
+		// validated by flatten.
+		block, _ := strconv.ParseUint(cro.DependentBlock, 10, 64)
+		if block >= height {
+			return fmt.Sprintf("block %d", block), nil
+		}
+	}
+	if cro.DependentTxID == "" {
+		return "", nil
+	}
+	// recent transactions are the likeliest dependencies.
+	for n := height; n > 0; n-- {
+		blk, err := substrate.QueryBlock(c.tag, n-1, cro)
+		if err != nil {
+			return "", err
+		}
+		for _, tx := range blk.Transactions {
+			if tx.ID == cro.DependentTxID {
+				return "", nil
+			}
+		}
+	}
+	return fmt.Sprintf("transaction %s", cro.DependentTxID), nil
+}
+
+// responseCommit returns the commit metadata of a substrate response,
+// falling back to the transaction ID for substrates that do not report it.
+func responseCommit(resp *plugin.Response) *types.CommitMetadata {
+	if resp.Commit != nil {
+		return resp.Commit
+	}
+	return &types.CommitMetadata{TxID: resp.TransactionID}
 }
 
 // QueryInfo implements the ShiroClient interface.
 func (c *mockShiroClient) QueryInfo(ctx context.Context, configs ...types.Config) (uint64, error) {
-	cro, err := c.flatten(ctx, configs...)
+	cro, opt, err := c.flatten(ctx, configs...)
 	if err != nil {
 		return 0, err
 	}
 
-	return c.conn.GetSubstrate().QueryInfo(c.tag, cro)
+	start := time.Now()
+	height, err := c.conn.GetSubstrate().QueryInfo(c.tag, cro)
+	reportStats(opt, &types.CallStats{Method: rpc.MethodQueryInfo}, start, err)
+	return height, err
 }
 
 // QueryBlock implements the ShiroClient interface.
 func (c *mockShiroClient) QueryBlock(ctx context.Context, blockNumber uint64, configs ...types.Config) (types.Block, error) {
-	cro, err := c.flatten(ctx, configs...)
+	cro, opt, err := c.flatten(ctx, configs...)
 	if err != nil {
 		return nil, err
 	}
 
+	start := time.Now()
 	blk, err := c.conn.GetSubstrate().QueryBlock(c.tag, blockNumber, cro)
+	reportStats(opt, &types.CallStats{Method: rpc.MethodQueryBlock}, start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -152,15 +411,97 @@ func (c *mockShiroClient) QueryBlock(ctx context.Context, blockNumber uint64, co
 	return types.NewBlock(blk.Hash, transactions), nil
 }
 
+// Height returns the number of blocks of the mock ledger, as QueryInfo.
+func (c *mockShiroClient) Height(ctx context.Context, configs ...types.Config) (uint64, error) {
+	return c.QueryInfo(ctx, configs...)
+}
+
+// TxCount returns the number of transactions on the mock ledger.  It
+// queries every block, which is cheap for mock ledgers.
+func (c *mockShiroClient) TxCount(ctx context.Context, configs ...types.Config) (int, error) {
+	height, err := c.QueryInfo(ctx, configs...)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for n := uint64(0); n < height; n++ {
+		blk, err := c.QueryBlock(ctx, n, configs...)
+		if err != nil {
+			return 0, err
+		}
+		count += len(blk.Transactions())
+	}
+	return count, nil
+}
+
+// LastTxID returns the ID of the most recent transaction on the mock
+// ledger, or an empty string if there is none.
+func (c *mockShiroClient) LastTxID(ctx context.Context, configs ...types.Config) (string, error) {
+	height, err := c.QueryInfo(ctx, configs...)
+	if err != nil {
+		return "", err
+	}
+	for n := height; n > 0; n-- {
+		blk, err := c.QueryBlock(ctx, n-1, configs...)
+		if err != nil {
+			return "", err
+		}
+		if txs := blk.Transactions(); len(txs) > 0 {
+			return txs[len(txs)-1].ID(), nil
+		}
+	}
+	return "", nil
+}
+
+func enableCoverage(substrate plugin.Substrate, tag string) error {
+	reporter, ok := substrate.(plugin.CoverageReporter)
+	if !ok {
+		return plugin.ErrUnsupported
+	}
+	return reporter.EnableCoverageMock(tag)
+}
+
+// CoverageReport writes the coverage of the phylum collected since the
+// client was created to w, as an LCOV tracefile.  The client must have been
+// created with mock.WithCoverage.
+func (c *mockShiroClient) CoverageReport(w io.Writer) error {
+	if !c.coverage {
+		return ErrCoverageDisabled
+	}
+	report, err := c.conn.GetSubstrate().(plugin.CoverageReporter).CoverageReportMock(c.tag)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(report)
+	return err
+}
+
 // Snapshot copies the current state of the mock backend out to the supplied
 // io.Writer.
 func (c *mockShiroClient) Snapshot(w io.Writer) error {
+	if _, ok := c.conn.GetSubstrate().(plugin.SnapshotStreamer); ok {
+		_, err := c.SnapshotSince(w, "")
+		return err
+	}
 	bytes, err := c.conn.GetSubstrate().SnapshotMock(c.tag)
 	if err != nil {
 		return err
 	}
-	_, err = w.Write(bytes)
-	return err
+	if c.snapshotCipher == nil {
+		_, err = w.Write(bytes)
+		return err
+	}
+	sealed, err := c.snapshotCipher.encryptWriter(w)
+	if err == nil {
+		_, err = sealed.Write(bytes)
+	}
+	if err == nil {
+		err = sealed.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encrypt snapshot: %w", err)
+	}
+	return nil
 }
 
 // SetCreatorWithAttributes sets the transaction creator and their attributes.
@@ -169,10 +510,56 @@ func (c *mockShiroClient) SetCreatorWithAttributes(creator string, attrs map[str
 	return c.conn.GetSubstrate().SetCreatorWithAttributesMock(c.tag, creator, attrs)
 }
 
-// Close shuts down the mock backing database
+// StubChaincode makes invocations of another chaincode by the phylum that
+// match stub return its response instead of failing.
+func (c *mockShiroClient) StubChaincode(stub plugin.ChaincodeStub) error {
+	stubber, ok := c.conn.GetSubstrate().(plugin.ChaincodeStubber)
+	if !ok {
+		return plugin.ErrUnsupported
+	}
+	return stubber.StubChaincodeMock(c.tag, &stub)
+}
+
+// ClearChaincodeStubs removes the stubs registered with StubChaincode.
+func (c *mockShiroClient) ClearChaincodeStubs() error {
+	stubber, ok := c.conn.GetSubstrate().(plugin.ChaincodeStubber)
+	if !ok {
+		return plugin.ErrUnsupported
+	}
+	return stubber.ClearChaincodeStubsMock(c.tag)
+}
+
+// Mocks lists the mocks open in the plugin of the client, including its
+// own.
+func (c *mockShiroClient) Mocks() ([]*plugin.MockInfo, error) {
+	inspector, ok := c.conn.GetSubstrate().(plugin.MockInspector)
+	if !ok {
+		return nil, plugin.ErrUnsupported
+	}
+	return inspector.ListMocks()
+}
+
+// Close shuts down the mock backing database.  Calling Close more than once
+// is safe; subsequent calls return the error of the first.
 func (c *mockShiroClient) Close() error {
+	if c.derived {
+		return nil
+	}
+	c.closeOnce.Do(func() {
+		c.closeErr = c.close()
+	})
+	return c.closeErr
+}
+
+// close closes the mock ledger and releases the plugin connection.
+func (c *mockShiroClient) close() error {
 	errMock := c.conn.GetSubstrate().CloseMock(c.tag)
-	errPlugin := c.conn.Close()
+	var errPlugin error
+	if c.release != nil {
+		c.release()
+	} else {
+		errPlugin = c.conn.Close()
+	}
 	if errMock != nil {
 		return fmt.Errorf("failed to close mock client: %w", errMock)
 	}
@@ -198,12 +585,36 @@ func hcpLogLevel(mockLevel mockint.LogLevel) hclog.Level {
 }
 
 func NewMock(clientConfigs []types.Config, opts ...mock.Option) (MockShiroClient, error) {
+	config := NewConfig(opts...)
+	conn, err := Connect(config)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newMock(conn, config, clientConfigs)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// NewConfig returns the mock configuration set by opts.
+func NewConfig(opts ...mock.Option) *mockint.Config {
 	config := &mockint.Config{
 		LogWriter: os.Stdout,
 	}
 	for _, opt := range opts {
 		opt(config)
 	}
+	return config
+}
+
+// connectMu serializes plugin startup.
+var connectMu sync.Mutex
+
+// Connect starts the plugin configured by config and connects to it.  The
+// plugin path defaults to the SUBSTRATEHCP_FILE environment variable.
+func Connect(config *mockint.Config) (*plugin.SubstrateConnection, error) {
 	if config.PluginPath == "" {
 		config.PluginPath = os.Getenv(mockint.DefaultPluginEnv)
 		if config.PluginPath == "" {
@@ -215,26 +626,97 @@ func NewMock(clientConfigs []types.Config, opts ...mock.Option) (MockShiroClient
 		plugin.ConnectWithLogLevel(hcpLogLevel(config.LogLevel)),
 		plugin.ConnectWithAttachStdamp(config.LogWriter),
 	}
+	connectMu.Lock()
+	defer connectMu.Unlock()
 	conn, err := plugin.NewSubstrateConnection(pluginOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to connect to plugin: %w", err)
 	}
-	var snapshot []byte
-	if config.SnapshotReader != nil {
-		snapshot, err = io.ReadAll(config.SnapshotReader)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read snapshot: %w", err)
-		}
+	return conn, nil
+}
+
+// NewShared creates a mock client with its own ledger on conn, which is
+// shared with other clients.  Closing the client closes its ledger and calls
+// release, but leaves conn open.
+func NewShared(conn *plugin.SubstrateConnection, config *mockint.Config, clientConfigs []types.Config, release func()) (MockShiroClient, error) {
+	client, err := newMock(conn, config, clientConfigs)
+	if err != nil {
+		return nil, err
+	}
+	client.release = release
+	if client.release == nil {
+		client.release = func() {}
 	}
-	var tag string
-	tag, err = conn.GetSubstrate().NewMockFrom(mockint.PhylumName, mockint.PhylumVersion, snapshot)
+	return client, nil
+}
+
+// newMock creates a mock ledger on conn.  If it fails it closes the ledger
+// but leaves conn, which may be shared, open.
+func newMock(conn *plugin.SubstrateConnection, config *mockint.Config, clientConfigs []types.Config) (*mockShiroClient, error) {
+	crypt, err := newSnapshotCipher(config.SnapshotKey, config.SnapshotPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := restoreMock(conn.GetSubstrate(), config.SnapshotReader, crypt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create mock client: %w", err)
 	}
-	return &mockShiroClient{
-		baseConfig:  clientConfigs,
+	client := &mockShiroClient{
+		baseConfig:  types.NewConfigSet(clientConfigs...),
 		conn:        conn,
 		tag:         tag,
 		shiroPhylum: mockint.PhylumName,
-	}, nil
+		errorStacks: config.ErrorStacks,
+	}
+	client.strictConfig = config.StrictConfig
+	client.snapshotCipher = crypt
+	if config.PhylumOutput != nil {
+		client.phylumOutput = &lockedWriter{w: config.PhylumOutput}
+	}
+	client.dependencyWait = config.DependencyWait
+	if config.Coverage {
+		if err := enableCoverage(conn.GetSubstrate(), tag); err != nil {
+			_ = conn.GetSubstrate().CloseMock(tag)
+			return nil, fmt.Errorf("failed to enable coverage: %w", err)
+		}
+		client.coverage = true
+	}
+	if config.RandSeed != nil {
+		client.rand = &lockedRand{r: rand.New(rand.NewSource(*config.RandSeed))}
+	}
+	return client, nil
+}
+
+// randSeedSize is the size of the seeds passed to the substrate.
+const randSeedSize = 32
+
+// lockedRand serializes draws of request seeds from r.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// seed returns the next request seed, or nil if l is nil.
+func (l *lockedRand) seed() []byte {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := make([]byte, randSeedSize)
+	_, _ = l.r.Read(b)
+	return b
+}
+
+// lockedWriter serializes writes to w.  Write errors are ignored because
+// phylum output is diagnostic.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(p)
 }
@@ -0,0 +1,481 @@
+package mock
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/mockint"
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhylumOutput(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			resp := &plugin.Response{ResultJSON: []byte(`true`)}
+			if opts.CapturePhylumOutput {
+				resp.PhylumOutput = []byte(method + " called\n")
+			}
+			return resp
+		},
+	}
+	var out bytes.Buffer
+	client := newFakeMock(t, fake, &mockint.Config{PhylumOutput: &out})
+	ctx := context.Background()
+	_, err := client.Call(ctx, "first")
+	require.NoError(t, err)
+	_, err = client.With().Call(ctx, "second")
+	require.NoError(t, err)
+	require.Equal(t, "first called\nsecond called\n", out.String())
+
+	quiet := newFakeMock(t, fake, nil)
+	_, err = quiet.Call(ctx, "third")
+	require.NoError(t, err)
+	require.False(t, fake.calls[len(fake.calls)-1].CapturePhylumOutput)
+}
+
+func TestStats(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			return &plugin.Response{HasError: true, ErrorCode: 1, ErrorJSON: []byte(`"bad"`)}
+		},
+	}
+	var stats []types.CallStats
+	client := newFakeMock(t, fake, nil, types.Opt(func(r *types.RequestOptions) {
+		r.Stats = func(s types.CallStats) { stats = append(stats, s) }
+	}))
+	ctx := context.Background()
+	_, err := client.Call(ctx, "fail")
+	require.NoError(t, err)
+	_, err = client.QueryInfo(ctx)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	require.Equal(t, rpc.MethodCall, stats[0].Method)
+	require.Equal(t, "fail", stats[0].PhylumMethod)
+	require.Equal(t, rpc.ErrorLevelPhylum, stats[0].ErrorLevel)
+	require.Equal(t, 5, stats[0].ResponseSize)
+	require.Equal(t, rpc.MethodQueryInfo, stats[1].Method)
+}
+
+func TestConcreteRequestOptions(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			return &plugin.Response{ResultJSON: []byte(`true`)}
+		},
+	}
+	client := newFakeMock(t, fake, nil)
+	_, err := client.Call(context.Background(), "get", types.Opt(func(r *types.RequestOptions) {
+		r.Params = []string{"a"}
+		r.Creator = "Org1MSP"
+		r.MinEndorsers = 2
+		r.DependentTxID = "tx1"
+		r.DependentBlock = "3"
+		r.DisableWritePolling = true
+		r.PhylumVersion = "v1"
+		r.NewPhylumVersion = "v2"
+		r.IdempotencyKey = "key"
+		r.PrivateCollections = []string{"pdc"}
+		r.TimestampGenerator = func(context.Context) string { return "2024-01-01T00:00:00Z" }
+	}))
+	require.NoError(t, err)
+	cro := fake.calls[0]
+	require.JSONEq(t, `["a"]`, string(cro.Params))
+	require.Equal(t, "Org1MSP", cro.Creator)
+	require.Equal(t, 2, cro.MinEndorsers)
+	require.Equal(t, "tx1", cro.DependentTxID)
+	require.Equal(t, "3", cro.DependentBlock)
+	require.True(t, cro.DisableWritePolling)
+	require.Equal(t, "v1", cro.PhylumVersion)
+	require.Equal(t, "v2", cro.NewPhylumVersion)
+	require.Equal(t, "key", cro.IdempotencyKey)
+	require.Equal(t, []string{"pdc"}, cro.PrivateCollections)
+	require.Equal(t, "2024-01-01T00:00:00Z", cro.Timestamp)
+}
+
+func TestCommitMetadata(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			resp := &plugin.Response{ResultJSON: []byte(`true`), TransactionID: "tx-" + method}
+			if method == "endorsed" {
+				resp.Commit = &plugin.CommitMetadata{
+					TxID:              resp.TransactionID,
+					CommitBlock:       3,
+					MaxSimulatedBlock: 2,
+					Endorsers:         []string{"peer0"},
+					ValidationCode:    "VALID",
+				}
+			}
+			return resp
+		},
+	}
+	client := newFakeMock(t, fake, nil)
+	ctx := context.Background()
+
+	resp, err := client.Call(ctx, "plain")
+	require.NoError(t, err)
+	require.Equal(t, &types.CommitMetadata{TxID: "tx-plain"}, resp.(types.CommitResponse).Commit())
+
+	resp, err = client.Call(ctx, "endorsed")
+	require.NoError(t, err)
+	require.Equal(t, &types.CommitMetadata{
+		TxID:              "tx-endorsed",
+		CommitBlock:       3,
+		MaxSimulatedBlock: 2,
+		Endorsers:         []string{"peer0"},
+		ValidationCode:    "VALID",
+	}, resp.(types.CommitResponse).Commit())
+	require.Equal(t, uint64(3), resp.CommitBlockNum())
+	require.Equal(t, uint64(2), resp.MaxSimBlockNum())
+}
+
+func TestResponseReceiver(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			if method == "fail" {
+				return &plugin.Response{HasError: true, ErrorCode: 1, ErrorMessage: "bad", ErrorJSON: []byte(`null`)}
+			}
+			return &plugin.Response{ResultJSON: []byte(`true`), TransactionID: "tx1"}
+		},
+	}
+	var received []types.ShiroResponse
+	client := newFakeMock(t, fake, nil, types.Opt(func(r *types.RequestOptions) {
+		r.ResponseReceiver = func(resp types.ShiroResponse) { received = append(received, resp) }
+	}))
+	ctx := context.Background()
+
+	resp, err := client.Call(ctx, "ok")
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	require.Same(t, resp, received[0])
+
+	resp, err = client.Call(ctx, "fail")
+	require.NoError(t, err)
+	require.Len(t, received, 2)
+	require.Same(t, resp, received[1])
+	require.Equal(t, "bad", received[1].Error().Message())
+}
+
+func TestRandSeed(t *testing.T) {
+	seeds := func(config *mockint.Config) [][]byte {
+		fake := &fakeSubstrate{
+			handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+				return &plugin.Response{ResultJSON: []byte(`true`)}
+			},
+		}
+		client := newFakeMock(t, fake, config)
+		_, err := client.Call(context.Background(), "first")
+		require.NoError(t, err)
+		_, err = client.With().Call(context.Background(), "second")
+		require.NoError(t, err)
+		return [][]byte{fake.calls[0].RandSeed, fake.calls[1].RandSeed}
+	}
+	seed := int64(42)
+	a := seeds(&mockint.Config{RandSeed: &seed})
+	b := seeds(&mockint.Config{RandSeed: &seed})
+	require.Equal(t, a, b)
+	require.Len(t, a[0], 32)
+	require.NotEqual(t, a[0], a[1])
+	require.Equal(t, [][]byte{nil, nil}, seeds(nil))
+}
+
+func TestDependencyChecks(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			return &plugin.Response{ResultJSON: []byte(`true`)}
+		},
+	}
+	wait := time.Duration(0)
+	client := newFakeMock(t, fake, &mockint.Config{DependencyWait: &wait})
+	ctx := context.Background()
+	withDeps := func(txID string, block string) types.Config {
+		return types.Opt(func(r *types.RequestOptions) {
+			r.DependentTxID = txID
+			r.DependentBlock = block
+		})
+	}
+
+	_, err := client.Call(ctx, "write")
+	require.NoError(t, err)
+	_, err = client.Call(ctx, "read", withDeps("tx0", "0"))
+	require.NoError(t, err)
+
+	_, err = client.Call(ctx, "read", withDeps("", "5"))
+	require.ErrorIs(t, err, ErrDependencyNotFound)
+	require.ErrorContains(t, err, "block 5")
+	_, err = client.Call(ctx, "read", withDeps("tx9", ""))
+	require.ErrorIs(t, err, ErrDependencyNotFound)
+	require.ErrorContains(t, err, "transaction tx9")
+
+	// waiting calls observe dependencies committed by other clients.
+	wait = time.Second
+	waiting := newFakeMock(t, fake, &mockint.Config{DependencyWait: &wait})
+	done := make(chan error, 1)
+	go func() {
+		_, err := waiting.Call(ctx, "read", withDeps("tx2", ""))
+		done <- err
+	}()
+	time.Sleep(3 * dependencyPollInterval)
+	_, err = client.Call(ctx, "write")
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+
+	// dependencies are ignored unless checks are enabled.
+	_, err = newFakeMock(t, fake, nil).Call(ctx, "read", withDeps("tx9", "9"))
+	require.NoError(t, err)
+}
+
+// stubbingSubstrate is a fakeSubstrate that supports chaincode stubs.
+type stubbingSubstrate struct {
+	fakeSubstrate
+	stubs map[string][]*plugin.ChaincodeStub
+}
+
+func (s *stubbingSubstrate) StubChaincodeMock(tag string, stub *plugin.ChaincodeStub) error {
+	s.stubs[tag] = append(s.stubs[tag], stub)
+	return nil
+}
+
+func (s *stubbingSubstrate) ClearChaincodeStubsMock(tag string) error {
+	delete(s.stubs, tag)
+	return nil
+}
+
+func TestStubChaincode(t *testing.T) {
+	stub := plugin.ChaincodeStub{ChaincodeName: "other", Status: 200, Payload: []byte(`"ok"`)}
+	fake := &stubbingSubstrate{stubs: make(map[string][]*plugin.ChaincodeStub)}
+	client, err := newMock(plugin.NewLocalConnection(fake), &mockint.Config{}, nil)
+	require.NoError(t, err)
+	require.NoError(t, client.StubChaincode(stub))
+	require.Equal(t, []*plugin.ChaincodeStub{&stub}, fake.stubs["tag"])
+	require.NoError(t, client.ClearChaincodeStubs())
+	require.Empty(t, fake.stubs)
+
+	unsupported := newFakeMock(t, &fakeSubstrate{}, nil)
+	require.ErrorIs(t, unsupported.StubChaincode(stub), plugin.ErrUnsupported)
+}
+
+func TestLedgerStats(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			return &plugin.Response{ResultJSON: []byte(`true`)}
+		},
+	}
+	client := newFakeMock(t, fake, nil)
+	ctx := context.Background()
+	txID, err := client.LastTxID(ctx)
+	require.NoError(t, err)
+	require.Empty(t, txID)
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Call(ctx, "put")
+		require.NoError(t, err)
+	}
+	height, err := client.Height(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), height)
+	count, err := client.TxCount(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	txID, err = client.LastTxID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "tx2", txID)
+}
+
+// coverageSubstrate reports the number of calls since coverage was
+// enabled.
+type coverageSubstrate struct {
+	fakeSubstrate
+	enabled bool
+}
+
+func (s *coverageSubstrate) EnableCoverageMock(tag string) error {
+	s.enabled = true
+	return nil
+}
+
+func (s *coverageSubstrate) CoverageReportMock(tag string) ([]byte, error) {
+	return []byte(fmt.Sprintf("DA:1,%d\n", len(s.calls))), nil
+}
+
+func TestCoverage(t *testing.T) {
+	fake := &coverageSubstrate{fakeSubstrate: fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			return &plugin.Response{ResultJSON: []byte(`true`)}
+		},
+	}}
+	client, err := newMock(plugin.NewLocalConnection(fake), &mockint.Config{Coverage: true}, nil)
+	require.NoError(t, err)
+	require.True(t, fake.enabled)
+	_, err = client.With().Call(context.Background(), "covered")
+	require.NoError(t, err)
+	var report bytes.Buffer
+	require.NoError(t, client.With().(MockShiroClient).CoverageReport(&report))
+	require.Equal(t, "DA:1,1\n", report.String())
+
+	disabled := newFakeMock(t, &fakeSubstrate{}, nil)
+	require.ErrorIs(t, disabled.CoverageReport(&report), ErrCoverageDisabled)
+	_, err = newMock(plugin.NewLocalConnection(&fakeSubstrate{}), &mockint.Config{Coverage: true}, nil)
+	require.ErrorIs(t, err, plugin.ErrUnsupported)
+}
+
+// TestConcurrentCalls issues calls from many goroutines through derived
+// clients sharing base configs.  Run with -race.
+func TestConcurrentCalls(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			return &plugin.Response{ResultJSON: opts.Transient["worker"]}
+		},
+	}
+	base := make([]types.Config, 0, 8)
+	client := newFakeMock(t, fake, nil, base...)
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		worker := fmt.Sprintf(`"w%d"`, i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config := types.Opt(func(r *types.RequestOptions) {
+				r.Transient["worker"] = []byte(worker)
+			})
+			derived := client.With(config)
+			for j := 0; j < 4; j++ {
+				var resp types.ShiroResponse
+				var err error
+				if j%2 == 0 {
+					resp, err = client.Call(ctx, "echo", config)
+				} else {
+					resp, err = derived.Call(ctx, "echo")
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if got := string(resp.ResultJSON()); got != worker {
+					t.Errorf("got %s, want %s", got, worker)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.Len(t, fake.calls, 64)
+}
+
+func TestMethodPolicy(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			return &plugin.Response{ResultJSON: []byte(`true`)}
+		},
+	}
+	client := newFakeMock(t, fake, nil, types.Opt(func(r *types.RequestOptions) {
+		r.MethodPolicy = types.MethodPolicy{Restricted: true, Allowed: []string{"get", "private_purge"}, Denied: []string{"private_purge"}}
+	}))
+	ctx := context.Background()
+	_, err := client.Call(ctx, "get")
+	require.NoError(t, err)
+	_, err = client.Call(ctx, "put")
+	require.ErrorIs(t, err, types.ErrMethodNotAllowed)
+	_, err = client.Call(ctx, "private_purge")
+	require.ErrorIs(t, err, types.ErrMethodNotAllowed)
+
+	// per-call configs cannot relax the policy.
+	_, err = client.Call(ctx, "put", types.Opt(func(r *types.RequestOptions) {
+		r.MethodPolicy = types.MethodPolicy{}
+	}))
+	require.ErrorIs(t, err, types.ErrMethodNotAllowed)
+	require.Len(t, fake.calls, 1)
+}
+
+func TestMethodConfigs(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			return &plugin.Response{ResultJSON: []byte(`true`)}
+		},
+	}
+	minEndorsers := func(n int) types.Config {
+		return types.Opt(func(r *types.RequestOptions) {
+			r.MinEndorsers = n
+		})
+	}
+	client := newFakeMock(t, fake, nil, types.Opt(func(r *types.RequestOptions) {
+		r.MethodConfigs = map[string][]types.Config{"put": {minEndorsers(2)}}
+	}))
+	ctx := context.Background()
+	_, err := client.Call(ctx, "put")
+	require.NoError(t, err)
+	_, err = client.Call(ctx, "get")
+	require.NoError(t, err)
+	// per-call configs override method configs.
+	_, err = client.Call(ctx, "put", minEndorsers(3))
+	require.NoError(t, err)
+	require.Len(t, fake.calls, 3)
+	require.Equal(t, 2, fake.calls[0].MinEndorsers)
+	require.Equal(t, 0, fake.calls[1].MinEndorsers)
+	require.Equal(t, 3, fake.calls[2].MinEndorsers)
+}
+
+func TestCallRecorder(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			return &plugin.Response{ResultJSON: []byte(`true`)}
+		},
+	}
+	var records []types.CallRecord
+	client := newFakeMock(t, fake, nil, types.Opt(func(r *types.RequestOptions) {
+		r.CallRecorder = func(ctx context.Context, record types.CallRecord) {
+			records = append(records, record)
+		}
+		r.MethodPolicy.Denied = []string{"purge"}
+	}))
+	ctx := context.Background()
+	_, err := client.Call(ctx, "get")
+	require.NoError(t, err)
+	_, err = client.Call(ctx, "purge")
+	require.Error(t, err)
+
+	require.Len(t, records, 2)
+	require.Equal(t, "get", records[0].Method)
+	require.NotNil(t, records[0].Response)
+	require.NoError(t, records[0].Err)
+	require.Equal(t, "purge", records[1].Method)
+	require.ErrorIs(t, records[1].Err, types.ErrMethodNotAllowed)
+}
+
+func TestCloseIdempotent(t *testing.T) {
+	fake := &fakeSubstrate{}
+	client := newFakeMock(t, fake, nil)
+	require.NoError(t, client.Close())
+	require.NoError(t, client.Close())
+	require.Equal(t, 1, fake.closed)
+}
+
+func TestStrictConfig(t *testing.T) {
+	fake := &fakeSubstrate{
+		handle: func(method string, opts *plugin.ConcreteRequestOptions) *plugin.Response {
+			return &plugin.Response{ResultJSON: []byte(`true`)}
+		},
+	}
+	mspFilter := types.Opt(func(r *types.RequestOptions) {
+		r.MspFilter = []string{"Org1MSP"}
+	})
+	ctx := context.Background()
+
+	// the MSP filter is ignored by default.
+	client := newFakeMock(t, fake, nil, mspFilter)
+	_, err := client.Call(ctx, "ok")
+	require.NoError(t, err)
+
+	strict := newFakeMock(t, fake, &mockint.Config{StrictConfig: true}, mspFilter)
+	_, err = strict.Call(ctx, "ok")
+	var cerr *types.ConfigError
+	require.ErrorAs(t, err, &cerr)
+	require.Equal(t, "MspFilter", cerr.Field)
+	_, err = strict.With().Call(ctx, "ok")
+	require.ErrorAs(t, err, &cerr)
+}
@@ -0,0 +1,145 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/mockint"
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+)
+
+// restoreMock creates a mock from the snapshot read from r, or an empty
+// mock if r is nil.  Snapshots are streamed to substrates that support it,
+// decrypting them on the fly, so that their size is not bounded by the
+// size of a plugin message or of memory.
+func restoreMock(substrate plugin.Substrate, r io.Reader, crypt *snapshotCipher) (string, error) {
+	if r != nil && crypt != nil {
+		var err error
+		r, err = crypt.decryptReader(r)
+		if err != nil {
+			return "", err
+		}
+	}
+	if streamer, ok := substrate.(plugin.SnapshotStreamer); ok && r != nil {
+		return writeSnapshot(streamer, "", r)
+	}
+	var snapshot []byte
+	if r != nil {
+		var err error
+		snapshot, err = io.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("failed to read snapshot: %w", err)
+		}
+	}
+	return substrate.NewMockFrom(mockint.PhylumName, mockint.PhylumVersion, snapshot)
+}
+
+// writeSnapshot streams the snapshot read from r to a new mock, or applies
+// it to the mock tag if tag is not empty.  The restore is aborted if the
+// snapshot cannot be read or written, so that no partial snapshot is
+// applied or left in the plugin.
+func writeSnapshot(streamer plugin.SnapshotStreamer, tag string, r io.Reader) (_ string, err error) {
+	handle, err := streamer.OpenRestoreMock(mockint.PhylumName, mockint.PhylumVersion, tag)
+	if err != nil {
+		return "", err
+	}
+	// a failed FinishRestoreMock ends the restore too.
+	finishing := false
+	defer func() {
+		if err == nil || finishing {
+			return
+		}
+		if aerr := streamer.AbortRestoreMock(handle); aerr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to abort restore: %w", aerr))
+		}
+	}()
+	buf := make([]byte, plugin.SnapshotChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if err := streamer.WriteRestoreMock(handle, buf[:n]); err != nil {
+				return "", err
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read snapshot: %w", err)
+		}
+	}
+	finishing = true
+	return streamer.FinishRestoreMock(handle)
+}
+
+// readSnapshot streams a snapshot of the mock tag to w and returns its ID.
+func readSnapshot(streamer plugin.SnapshotStreamer, tag string, base string, w io.Writer) (id string, err error) {
+	info, err := streamer.OpenSnapshotMock(tag, base)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if cerr := streamer.CloseSnapshotMock(info.Handle); err == nil {
+			err = cerr
+		}
+	}()
+	for {
+		chunk, err := streamer.ReadSnapshotMock(info.Handle, plugin.SnapshotChunkSize)
+		if err != nil {
+			return "", err
+		}
+		if len(chunk) == 0 {
+			return info.ID, nil
+		}
+		if _, err := w.Write(chunk); err != nil {
+			return "", err
+		}
+	}
+}
+
+// SnapshotSince writes a snapshot of the mock ledger to w and returns its
+// ID.  If base is not empty it is the ID of a previous snapshot of the
+// client and the snapshot only holds the changes since base, to restore
+// with ApplySnapshot on a client restored from base.  An empty base takes
+// a complete snapshot, like Snapshot.  The plugin must support streaming
+// snapshots.
+func (c *mockShiroClient) SnapshotSince(w io.Writer, base string) (string, error) {
+	streamer, ok := c.conn.GetSubstrate().(plugin.SnapshotStreamer)
+	if !ok {
+		return "", plugin.ErrUnsupported
+	}
+	if c.snapshotCipher == nil {
+		return readSnapshot(streamer, c.tag, base, w)
+	}
+	sealed, err := c.snapshotCipher.encryptWriter(w)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt snapshot: %w", err)
+	}
+	id, err := readSnapshot(streamer, c.tag, base, sealed)
+	if err != nil {
+		return "", err
+	}
+	if err := sealed.Close(); err != nil {
+		return "", fmt.Errorf("failed to encrypt snapshot: %w", err)
+	}
+	return id, nil
+}
+
+// ApplySnapshot applies an incremental snapshot written by SnapshotSince
+// to the mock ledger.  The plugin must support streaming snapshots.
+func (c *mockShiroClient) ApplySnapshot(r io.Reader) error {
+	streamer, ok := c.conn.GetSubstrate().(plugin.SnapshotStreamer)
+	if !ok {
+		return plugin.ErrUnsupported
+	}
+	if c.snapshotCipher != nil {
+		var err error
+		r, err = c.snapshotCipher.decryptReader(r)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := writeSnapshot(streamer, c.tag, r)
+	return err
+}
@@ -0,0 +1,194 @@
+package mock
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/mockint"
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingSubstrate streams snapshots of mock ledgers that are lists of
+// records.  A snapshot holds the records added since its base, and its ID
+// is the number of records of the ledger.
+type streamingSubstrate struct {
+	fakeSubstrate
+	ledgers  map[string][]byte
+	next     int
+	reads    map[string]*bytes.Reader
+	restores map[string]*streamRestore
+	chunks   int
+	aborted  int
+}
+
+type streamRestore struct {
+	tag  string
+	data bytes.Buffer
+}
+
+func newStreamingSubstrate() *streamingSubstrate {
+	return &streamingSubstrate{
+		ledgers:  make(map[string][]byte),
+		reads:    make(map[string]*bytes.Reader),
+		restores: make(map[string]*streamRestore),
+	}
+}
+
+func (s *streamingSubstrate) NewMockFrom(name string, version string, snapshot []byte) (string, error) {
+	s.next++
+	tag := fmt.Sprint("mock", s.next)
+	s.ledgers[tag] = snapshot
+	return tag, nil
+}
+
+func (s *streamingSubstrate) OpenSnapshotMock(tag string, base string) (*plugin.SnapshotInfo, error) {
+	ledger := s.ledgers[tag]
+	from := 0
+	if base != "" {
+		var err error
+		if from, err = strconv.Atoi(base); err != nil || from > len(ledger) {
+			return nil, fmt.Errorf("unknown snapshot %q", base)
+		}
+	}
+	handle := fmt.Sprint("read", len(s.reads))
+	s.reads[handle] = bytes.NewReader(ledger[from:])
+	return &plugin.SnapshotInfo{Handle: handle, ID: strconv.Itoa(len(ledger)), Incremental: base != ""}, nil
+}
+
+func (s *streamingSubstrate) ReadSnapshotMock(handle string, max int) ([]byte, error) {
+	chunk := make([]byte, max)
+	n, _ := s.reads[handle].Read(chunk)
+	if n > 0 {
+		s.chunks++
+	}
+	return chunk[:n], nil
+}
+
+func (s *streamingSubstrate) CloseSnapshotMock(handle string) error {
+	delete(s.reads, handle)
+	return nil
+}
+
+func (s *streamingSubstrate) OpenRestoreMock(name string, version string, tag string) (string, error) {
+	handle := fmt.Sprint("restore", len(s.restores))
+	s.restores[handle] = &streamRestore{tag: tag}
+	return handle, nil
+}
+
+func (s *streamingSubstrate) WriteRestoreMock(handle string, chunk []byte) error {
+	s.chunks++
+	s.restores[handle].data.Write(chunk)
+	return nil
+}
+
+func (s *streamingSubstrate) FinishRestoreMock(handle string) (string, error) {
+	r := s.restores[handle]
+	delete(s.restores, handle)
+	if r.tag == "" {
+		return s.NewMockFrom("", "", r.data.Bytes())
+	}
+	s.ledgers[r.tag] = append(s.ledgers[r.tag], r.data.Bytes()...)
+	return r.tag, nil
+}
+
+func (s *streamingSubstrate) AbortRestoreMock(handle string) error {
+	if _, ok := s.restores[handle]; !ok {
+		return fmt.Errorf("unknown restore %q", handle)
+	}
+	delete(s.restores, handle)
+	s.aborted++
+	return nil
+}
+
+func TestSnapshotStreaming(t *testing.T) {
+	fake := newStreamingSubstrate()
+	conn := plugin.NewLocalConnection(fake)
+	large := bytes.Repeat([]byte("r"), 2*plugin.SnapshotChunkSize+1)
+
+	client, err := newMock(conn, &mockint.Config{SnapshotReader: bytes.NewReader(large)}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, fake.chunks)
+	require.Equal(t, large, fake.ledgers[client.tag])
+
+	var full bytes.Buffer
+	require.NoError(t, client.Snapshot(&full))
+	require.Equal(t, large, full.Bytes())
+	require.Equal(t, 6, fake.chunks)
+	require.Empty(t, fake.reads)
+
+	// incremental snapshots hold the changes since their base.
+	var base bytes.Buffer
+	id, err := client.SnapshotSince(&base, "")
+	require.NoError(t, err)
+	fake.ledgers[client.tag] = append(fake.ledgers[client.tag], "new"...)
+	var delta bytes.Buffer
+	_, err = client.SnapshotSince(&delta, id)
+	require.NoError(t, err)
+	require.Equal(t, "new", delta.String())
+
+	restored, err := newMock(conn, &mockint.Config{SnapshotReader: &base}, nil)
+	require.NoError(t, err)
+	require.NoError(t, restored.ApplySnapshot(&delta))
+	require.Equal(t, fake.ledgers[client.tag], fake.ledgers[restored.tag])
+
+	unsupported := newFakeMock(t, &fakeSubstrate{}, nil)
+	_, err = unsupported.SnapshotSince(&delta, "")
+	require.ErrorIs(t, err, plugin.ErrUnsupported)
+	require.ErrorIs(t, unsupported.ApplySnapshot(&delta), plugin.ErrUnsupported)
+}
+
+func TestSnapshotStreamingEncrypted(t *testing.T) {
+	fake := newStreamingSubstrate()
+	conn := plugin.NewLocalConnection(fake)
+	config := &mockint.Config{SnapshotPassphrase: "secret"}
+	client, err := newMock(conn, config, nil)
+	require.NoError(t, err)
+	fake.ledgers[client.tag] = []byte("ledger")
+
+	var full bytes.Buffer
+	id, err := client.SnapshotSince(&full, "")
+	require.NoError(t, err)
+	fake.ledgers[client.tag] = append(fake.ledgers[client.tag], "new"...)
+	var delta bytes.Buffer
+	_, err = client.SnapshotSince(&delta, id)
+	require.NoError(t, err)
+	require.NotContains(t, delta.String(), "new")
+
+	config.SnapshotReader = &full
+	restored, err := newMock(conn, config, nil)
+	require.NoError(t, err)
+	sealed := delta.Bytes()
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+	require.NoError(t, restored.ApplySnapshot(&delta))
+	require.Equal(t, "ledgernew", string(fake.ledgers[restored.tag]))
+
+	// corrupt snapshots abort the restore without changing the ledger.
+	require.ErrorContains(t, restored.ApplySnapshot(bytes.NewReader(tampered)), "failed to decrypt")
+	require.Equal(t, 1, fake.aborted)
+	require.Empty(t, fake.restores)
+	require.Equal(t, "ledgernew", string(fake.ledgers[restored.tag]))
+}
+
+func TestSnapshotStreamingEncryptedLarge(t *testing.T) {
+	fake := newStreamingSubstrate()
+	conn := plugin.NewLocalConnection(fake)
+	config := &mockint.Config{SnapshotKey: bytes.Repeat([]byte{1}, 32)}
+	client, err := newMock(conn, config, nil)
+	require.NoError(t, err)
+	large := bytes.Repeat([]byte("r"), 2*plugin.SnapshotChunkSize+1)
+	fake.ledgers[client.tag] = large
+
+	var full bytes.Buffer
+	require.NoError(t, client.Snapshot(&full))
+	require.Equal(t, 3, fake.chunks)
+
+	config.SnapshotReader = &full
+	restored, err := newMock(conn, config, nil)
+	require.NoError(t, err)
+	require.Equal(t, large, fake.ledgers[restored.tag])
+	require.Equal(t, 6, fake.chunks)
+}
@@ -0,0 +1,304 @@
+package mock
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// snapshotMagic prefixes encrypted snapshots.
+var snapshotMagic = []byte("SHIROENC1")
+
+// Key derivation modes of encrypted snapshots.
+const (
+	snapshotModeKey        byte = 0
+	snapshotModePassphrase byte = 1
+)
+
+const snapshotSaltSize = 16
+
+// scrypt parameters for passphrases, as recommended for interactive logins.
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrSnapshotNotEncrypted is returned when a snapshot read by a client
+// configured to decrypt snapshots is not encrypted.
+var ErrSnapshotNotEncrypted = errors.New("shiroclient: snapshot is not encrypted")
+
+// snapshotCipher encrypts snapshots with AES-GCM, using either a key or a
+// key derived from a passphrase with scrypt.
+//
+// Snapshots are encrypted as they are streamed, in segments of
+// snapshotSegmentSize bytes.  Encrypted snapshots are laid out as the
+// magic, the mode, a salt in passphrase mode and a nonce prefix, followed
+// by the sealed segments.  The nonce of a segment is the prefix, the
+// big-endian index of the segment and a byte set to 1 for the last
+// segment only, so that segments cannot be reordered, dropped or
+// truncated without detection.
+type snapshotCipher struct {
+	key        []byte
+	passphrase string
+}
+
+// snapshotSegmentSize is the size of the plaintext segments of encrypted
+// snapshots.
+const snapshotSegmentSize = 64 << 10
+
+// snapshotNonceSuffix is the size of the index and last segment flag
+// ending the nonce of a segment.
+const snapshotNonceSuffix = 5
+
+func newSnapshotCipher(key []byte, passphrase string) (*snapshotCipher, error) {
+	switch {
+	case key != nil && passphrase != "":
+		return nil, errors.New("snapshot key and passphrase are mutually exclusive")
+	case key != nil:
+		switch len(key) {
+		case 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("invalid snapshot key size %d: must be 16, 24 or 32 bytes", len(key))
+		}
+		return &snapshotCipher{key: append([]byte(nil), key...)}, nil
+	case passphrase != "":
+		return &snapshotCipher{passphrase: passphrase}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (s *snapshotCipher) aead(salt []byte) (cipher.AEAD, error) {
+	key := s.key
+	if s.passphrase != "" {
+		var err error
+		key, err = scrypt.Key([]byte(s.passphrase), salt, scryptN, scryptR, scryptP, 32)
+		if err != nil {
+			return nil, err
+		}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *snapshotCipher) mode() byte {
+	if s.passphrase != "" {
+		return snapshotModePassphrase
+	}
+	return snapshotModeKey
+}
+
+// snapshotNonce returns the nonce of segment index of a snapshot.
+func snapshotNonce(prefix []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, len(prefix)+snapshotNonceSuffix)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[len(prefix):], index)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// encryptWriter returns a writer encrypting the snapshot written to it to
+// w.  The snapshot is incomplete until the writer is closed.
+func (s *snapshotCipher) encryptWriter(w io.Writer) (io.WriteCloser, error) {
+	header := append(append([]byte(nil), snapshotMagic...), s.mode())
+	var salt []byte
+	if s.passphrase != "" {
+		salt = make([]byte, snapshotSaltSize)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, err
+		}
+		header = append(header, salt...)
+	}
+	aead, err := s.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, aead.NonceSize()-snapshotNonceSuffix)
+	if _, err := io.ReadFull(rand.Reader, prefix); err != nil {
+		return nil, err
+	}
+	header = append(header, prefix...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &snapshotEncrypter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		prefix: prefix,
+		buf:    make([]byte, 0, snapshotSegmentSize),
+	}, nil
+}
+
+// snapshotEncrypter seals snapshot segments as they are written.  A full
+// segment is only sealed once more data is written, since the last
+// segment is sealed differently.
+type snapshotEncrypter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	buf    []byte
+	sealed []byte
+	index  uint32
+	closed bool
+}
+
+func (e *snapshotEncrypter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to closed snapshot")
+	}
+	n := 0
+	for len(p) > 0 {
+		if len(e.buf) == snapshotSegmentSize {
+			if err := e.seal(false); err != nil {
+				return n, err
+			}
+		}
+		k := copy(e.buf[len(e.buf):snapshotSegmentSize], p)
+		e.buf = e.buf[:len(e.buf)+k]
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// Close seals the last segment.
+func (e *snapshotEncrypter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(true)
+}
+
+func (e *snapshotEncrypter) seal(last bool) error {
+	if e.index == math.MaxUint32 {
+		return errors.New("snapshot too large to encrypt")
+	}
+	// the header is authenticated so the mode cannot be altered.
+	e.sealed = e.aead.Seal(e.sealed[:0], snapshotNonce(e.prefix, e.index, last), e.buf, e.header)
+	e.index++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(e.sealed)
+	return err
+}
+
+// decryptReader returns a reader of the snapshot encrypted by
+// encryptWriter to r.  Segments are authenticated before they are
+// returned; a corrupt or truncated snapshot fails with an error once its
+// damaged segment is read.
+func (s *snapshotCipher) decryptReader(r io.Reader) (io.Reader, error) {
+	magic := make([]byte, len(snapshotMagic)+1)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrSnapshotNotEncrypted
+		}
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if !bytes.HasPrefix(magic, snapshotMagic) {
+		return nil, ErrSnapshotNotEncrypted
+	}
+	if mode := magic[len(snapshotMagic)]; mode != s.mode() {
+		if mode == snapshotModePassphrase {
+			return nil, errors.New("snapshot is encrypted with a passphrase, not a key")
+		}
+		return nil, errors.New("snapshot is encrypted with a key, not a passphrase")
+	}
+	header := magic
+	var salt []byte
+	if s.passphrase != "" {
+		salt = make([]byte, snapshotSaltSize)
+		if _, err := io.ReadFull(r, salt); err != nil {
+			return nil, errors.New("truncated encrypted snapshot")
+		}
+		header = append(header, salt...)
+	}
+	aead, err := s.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, aead.NonceSize()-snapshotNonceSuffix)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, errors.New("truncated encrypted snapshot")
+	}
+	header = append(header, prefix...)
+	return &snapshotDecrypter{
+		r:      bufio.NewReader(r),
+		aead:   aead,
+		header: header,
+		prefix: prefix,
+		sealed: make([]byte, snapshotSegmentSize+aead.Overhead()),
+	}, nil
+}
+
+// snapshotDecrypter opens snapshot segments as they are read.
+type snapshotDecrypter struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	sealed []byte
+	buf    []byte
+	index  uint32
+	done   bool
+}
+
+func (d *snapshotDecrypter) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// open reads and opens the next segment.  A segment is the last if it is
+// shorter than a full segment or if it is followed by the end of the
+// snapshot.
+func (d *snapshotDecrypter) open() error {
+	n, err := io.ReadFull(d.r, d.sealed)
+	var last bool
+	switch {
+	case errors.Is(err, io.EOF):
+		return errors.New("truncated encrypted snapshot")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		last = true
+	case err != nil:
+		return err
+	default:
+		_, err := d.r.Peek(1)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		last = err != nil
+	}
+	plain, err := d.aead.Open(d.sealed[:0], snapshotNonce(d.prefix, d.index, last), d.sealed[:n], d.header)
+	if err != nil {
+		return errors.New("failed to decrypt snapshot: wrong key or corrupt data")
+	}
+	d.index++
+	d.buf = plain
+	d.done = last
+	return nil
+}
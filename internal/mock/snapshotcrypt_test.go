@@ -0,0 +1,123 @@
+package mock
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/mockint"
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+	"github.com/stretchr/testify/require"
+)
+
+// snapshotSubstrate records the snapshot each mock is created from.
+type snapshotSubstrate struct {
+	fakeSubstrate
+	restored []byte
+}
+
+func (s *snapshotSubstrate) NewMockFrom(name string, version string, snapshot []byte) (string, error) {
+	s.restored = snapshot
+	return "tag", nil
+}
+
+func (s *snapshotSubstrate) SnapshotMock(tag string) ([]byte, error) {
+	return []byte("ledger"), nil
+}
+
+func TestSnapshotEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	for name, config := range map[string]*mockint.Config{
+		"key":        {SnapshotKey: key},
+		"passphrase": {SnapshotPassphrase: "secret"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fake := &snapshotSubstrate{}
+			client, err := newMock(plugin.NewLocalConnection(fake), config, nil)
+			require.NoError(t, err)
+			var snap bytes.Buffer
+			require.NoError(t, client.Snapshot(&snap))
+			require.NotContains(t, snap.String(), "ledger")
+
+			config.SnapshotReader = bytes.NewReader(snap.Bytes())
+			_, err = newMock(plugin.NewLocalConnection(fake), config, nil)
+			require.NoError(t, err)
+			require.Equal(t, []byte("ledger"), fake.restored)
+
+			// tampered snapshots are rejected.
+			tampered := append([]byte(nil), snap.Bytes()...)
+			tampered[len(tampered)-1] ^= 1
+			config.SnapshotReader = bytes.NewReader(tampered)
+			_, err = newMock(plugin.NewLocalConnection(fake), config, nil)
+			require.ErrorContains(t, err, "failed to decrypt")
+
+			config.SnapshotReader = bytes.NewReader([]byte("ledger"))
+			_, err = newMock(plugin.NewLocalConnection(fake), config, nil)
+			require.ErrorIs(t, err, ErrSnapshotNotEncrypted)
+		})
+	}
+
+	c, err := newSnapshotCipher(key, "")
+	require.NoError(t, err)
+	p, err := newSnapshotCipher(nil, "secret")
+	require.NoError(t, err)
+	sealed := encryptSnapshot(t, c, []byte("ledger"))
+	_, err = p.decryptReader(bytes.NewReader(sealed))
+	require.ErrorContains(t, err, "encrypted with a key")
+	wrong, err := newSnapshotCipher(bytes.Repeat([]byte{2}, 32), "")
+	require.NoError(t, err)
+	r, err := wrong.decryptReader(bytes.NewReader(sealed))
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.ErrorContains(t, err, "wrong key")
+
+	_, err = newSnapshotCipher([]byte("short"), "")
+	require.ErrorContains(t, err, "invalid snapshot key size")
+	_, err = newSnapshotCipher(key, "secret")
+	require.ErrorContains(t, err, "mutually exclusive")
+}
+
+func encryptSnapshot(t *testing.T, c *snapshotCipher, snapshot []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := c.encryptWriter(&buf)
+	require.NoError(t, err)
+	_, err = w.Write(snapshot)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestSnapshotEncryptionSegments(t *testing.T) {
+	c, err := newSnapshotCipher(bytes.Repeat([]byte{1}, 32), "")
+	require.NoError(t, err)
+	for _, size := range []int{0, 1, snapshotSegmentSize, 2*snapshotSegmentSize + 1} {
+		snapshot := bytes.Repeat([]byte("s"), size)
+		sealed := encryptSnapshot(t, c, snapshot)
+
+		r, err := c.decryptReader(bytes.NewReader(sealed))
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, snapshot, data)
+
+		// truncated and extended snapshots are rejected, including at
+		// segment boundaries.
+		header := len(snapshotMagic) + 1 + 12 - snapshotNonceSuffix
+		damaged := [][]byte{
+			sealed[:len(sealed)-1],
+			append(append([]byte(nil), sealed...), sealed[len(sealed)-16:]...),
+		}
+		if size > snapshotSegmentSize {
+			damaged = append(damaged, sealed[:header+snapshotSegmentSize+16])
+		}
+		for _, damaged := range damaged {
+			r, err := c.decryptReader(bytes.NewReader(damaged))
+			if err != nil {
+				continue
+			}
+			_, err = io.ReadAll(r)
+			require.Error(t, err, "size %d", size)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package mockint
 
 import (
 	"io"
+	"time"
 )
 
 const (
@@ -23,4 +24,21 @@ type Config struct {
 	LogWriter      io.Writer
 	LogLevel       LogLevel
 	SnapshotReader io.Reader
+	ErrorStacks    bool
+	PhylumOutput   io.Writer
+	// RandSeed, if set, seeds the random values of the substrate.
+	RandSeed *int64
+	// DependencyWait, if set, enables dependency checks and bounds the
+	// time a call waits for its dependencies.
+	DependencyWait *time.Duration
+	// SnapshotKey, if set, encrypts and decrypts snapshots.
+	SnapshotKey []byte
+	// SnapshotPassphrase, if set, encrypts and decrypts snapshots with a
+	// key derived from it.
+	SnapshotPassphrase string
+	// Coverage enables coverage collection for the phylum.
+	Coverage bool
+	// StrictConfig fails requests using configs that are not supported in
+	// mock mode, instead of ignoring them.
+	StrictConfig bool
 }
@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+)
+
+// capabilityCache remembers the optional features supported by each
+// gateway endpoint.
+type capabilityCache struct {
+	mu    sync.Mutex
+	byURL map[string]map[string]bool
+}
+
+func newCapabilityCache() *capabilityCache {
+	return &capabilityCache{byURL: make(map[string]map[string]bool)}
+}
+
+// Capabilities returns the optional protocol features supported by the
+// gateway.  Gateways that predate capability negotiation report none.
+// Results are cached per endpoint; transport failures are not cached.
+func (c *rpcShiroClient) Capabilities(ctx context.Context, configs ...types.Config) (map[string]bool, error) {
+	opt, err := c.applyConfigs(configs...)
+	if err != nil {
+		return nil, err
+	}
+	return c.capabilities(ctx, opt)
+}
+
+func (c *rpcShiroClient) capabilities(ctx context.Context, opt *types.RequestOptions) (map[string]bool, error) {
+	c.caps.mu.Lock()
+	caps, ok := c.caps.byURL[opt.Endpoint]
+	c.caps.mu.Unlock()
+	if ok {
+		return caps, nil
+	}
+
+	req := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      opt.ID,
+		"method":  rpc.MethodCapabilities,
+		"params":  map[string]interface{}{},
+	}
+	// the probe must not fail because of strict decoding of a response
+	// from an older gateway.
+	probeOpt := *opt
+	probeOpt.StrictDecoding = false
+	probeOpt.Target = nil
+	res, err := c.reqres(ctx, req, &probeOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	caps = make(map[string]bool)
+	if res.errorLevel == rpc.ErrorLevelNoError {
+		list, _ := res.result.([]interface{})
+		for _, v := range list {
+			if name, ok := v.(string); ok {
+				caps[name] = true
+			}
+		}
+	}
+
+	c.caps.mu.Lock()
+	c.caps.byURL[opt.Endpoint] = caps
+	c.caps.mu.Unlock()
+	return caps, nil
+}
+
+// transientEncoding returns the transient encoding to use for a request.
+func (c *rpcShiroClient) transientEncoding(ctx context.Context, opt *types.RequestOptions) string {
+	switch opt.TransientEncoding {
+	case types.TransientEncodingBase64:
+		return rpc.TransientEncodingBase64
+	case types.TransientEncodingAuto:
+		caps, err := c.capabilities(ctx, opt)
+		if err != nil {
+			if opt.Log != nil {
+				opt.Log.WithFields(opt.LogFields).WithError(err).
+					Debug("ShiroClient: capability check failed, using hex transient encoding")
+			}
+			return rpc.TransientEncodingHex
+		}
+		if caps[rpc.CapabilityTransientBase64] {
+			return rpc.TransientEncodingBase64
+		}
+		return rpc.TransientEncodingHex
+	default:
+		return rpc.TransientEncodingHex
+	}
+}
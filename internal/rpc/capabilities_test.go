@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransientEncodingAuto(t *testing.T) {
+	value := []byte("secret")
+	for _, test := range []struct {
+		name     string
+		caps     interface{}
+		level    int
+		encoding interface{}
+		encoded  string
+	}{
+		{"base64 gateway", []string{rpc.CapabilityTransientBase64}, rpc.ErrorLevelNoError, "base64", base64.StdEncoding.EncodeToString(value)},
+		{"old gateway", nil, rpc.ErrorLevelShiroClient, nil, hex.EncodeToString(value)},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+				if req.Method == rpc.MethodCapabilities {
+					return test.caps, test.level
+				}
+				return nil, rpc.ErrorLevelNoError
+			})
+			client := gw.client(types.Opt(func(r *types.RequestOptions) {
+				r.TransientEncoding = types.TransientEncodingAuto
+				r.Transient["k"] = value
+			}))
+			for i := 0; i < 2; i++ {
+				_, err := client.Call(context.Background(), "m")
+				require.NoError(t, err)
+			}
+			reqs := gw.Requests()
+			// capabilities are checked once
+			require.Len(t, reqs, 3)
+			require.Equal(t, rpc.MethodCapabilities, reqs[0].Method)
+			call := reqs[2]
+			require.Equal(t, test.encoding, call.Params["transient_encoding"])
+			require.Equal(t, test.encoded, call.Params["transient"].(map[string]interface{})["k"])
+		})
+	}
+}
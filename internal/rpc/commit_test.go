@@ -0,0 +1,54 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitMetadata(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		if req.Params["method"] == "fail" {
+			return nil, rpc.ErrorLevelPhylum
+		}
+		return "ok", rpc.ErrorLevelNoError
+	})
+	gw.envelope = func(req *gatewayRequest) map[string]interface{} {
+		return map[string]interface{}{
+			"$commit_tx_id":    "tx1",
+			"$com_block_num":   "12",
+			"$sim_block_num":   11,
+			"$endorsers":       []string{"peer0.org1", "peer0.org2"},
+			"$validation_code": "VALID",
+		}
+	}
+	client := gw.client()
+	ctx := context.Background()
+
+	resp, err := client.Call(ctx, "write", types.Opt(func(r *types.RequestOptions) {
+		r.StrictDecoding = true
+	}))
+	require.NoError(t, err)
+	commit := resp.(types.CommitResponse).Commit()
+	require.Equal(t, &types.CommitMetadata{
+		TxID:              "tx1",
+		CommitBlock:       12,
+		MaxSimulatedBlock: 11,
+		Endorsers:         []string{"peer0.org1", "peer0.org2"},
+		ValidationCode:    "VALID",
+	}, commit)
+	require.True(t, commit.Committed())
+	require.Equal(t, "tx1", resp.TransactionID())
+	require.Equal(t, uint64(12), resp.CommitBlockNum())
+	require.Equal(t, uint64(11), resp.MaxSimBlockNum())
+
+	commit.Endorsers[0] = "changed"
+	require.Equal(t, "peer0.org1", resp.(types.CommitResponse).Commit().Endorsers[0])
+
+	resp, err = client.Call(ctx, "fail")
+	require.NoError(t, err)
+	require.Nil(t, resp.(types.CommitResponse).Commit())
+}
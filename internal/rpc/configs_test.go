@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func withPhylumVersion(version string) types.Config {
+	return types.Opt(func(r *types.RequestOptions) { r.PhylumVersion = version })
+}
+
+func TestConfigSet(t *testing.T) {
+	base := make([]types.Config, 1, 8)
+	base[0] = withPhylumVersion("base")
+	set := types.NewConfigSet(base...)
+	base[0] = withPhylumVersion("changed")
+
+	a := set.With(withPhylumVersion("a"))
+	b := set.With(withPhylumVersion("b"))
+	require.Equal(t, 1, set.Len())
+	require.Equal(t, 2, a.Len())
+
+	for _, tc := range []struct {
+		set  types.ConfigSet
+		want string
+	}{{set, "base"}, {a, "a"}, {b, "b"}} {
+		opt, err := tc.set.Apply(nil)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, opt.PhylumVersion)
+	}
+}
+
+// TestConcurrentCalls issues calls from many goroutines through derived
+// clients sharing base configs.  Run with -race.
+func TestConcurrentCalls(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		return req.Params["phylum_version"], rpc.ErrorLevelNoError
+	})
+	base := make([]types.Config, 0, 8)
+	base = append(base, types.Opt(func(r *types.RequestOptions) { r.Endpoint = gw.URL }))
+	client := NewRPC(base).(*rpcShiroClient)
+	// the client must not observe later changes to the caller's slice.
+	base[0] = types.Opt(func(r *types.RequestOptions) { r.Endpoint = "" })
+
+	shared := make([]types.Config, 0, 8)
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			version := fmt.Sprintf("v%d", i)
+			derived := client.With(shared...).(*rpcShiroClient).With(withPhylumVersion(version))
+			for j := 0; j < 4; j++ {
+				resp, err := derived.Call(ctx, "echo")
+				if !assertNoError(t, err) {
+					return
+				}
+				var got string
+				if !assertNoError(t, resp.UnmarshalTo(&got)) {
+					return
+				}
+				if got != version {
+					t.Errorf("got phylum version %q, want %q", got, version)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.Len(t, gw.Requests(), 64)
+}
+
+// assertNoError reports err from a goroutine other than the test's.
+func assertNoError(t *testing.T, err error) bool {
+	if err != nil {
+		t.Error(err)
+		return false
+	}
+	return true
+}
+
+func TestEndpointPolicy(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		return 1, rpc.ErrorLevelNoError
+	})
+	ctx := context.Background()
+	withEndpoint := func(endpoint string) types.Config {
+		return types.Opt(func(r *types.RequestOptions) { r.Endpoint = endpoint })
+	}
+	unlock := types.Opt(func(r *types.RequestOptions) {
+		r.EndpointPolicy = types.EndpointPolicy{}
+	})
+
+	locked := gw.client(types.Opt(func(r *types.RequestOptions) { r.EndpointPolicy.Locked = true }))
+	_, err := locked.QueryInfo(ctx)
+	require.NoError(t, err)
+	_, err = locked.QueryInfo(ctx, withEndpoint(gw.URL))
+	require.NoError(t, err)
+	_, err = locked.QueryInfo(ctx, unlock, withEndpoint("http://stale.example:8082"))
+	var cerr *types.ConfigError
+	require.ErrorAs(t, err, &cerr)
+	require.Equal(t, "Endpoint", cerr.Field)
+	require.Len(t, gw.Requests(), 2)
+
+	allowed := gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.EndpointPolicy.AllowedHosts = []string{"127.0.0.1"}
+	}))
+	_, err = allowed.QueryInfo(ctx, withEndpoint(gw.URL+"/"))
+	require.NoError(t, err)
+	_, err = allowed.QueryInfo(ctx, withEndpoint("http://stale.example:8082"))
+	require.ErrorAs(t, err, &cerr)
+
+	// derived clients configure the endpoint like the client.
+	_, err = locked.With(withEndpoint(gw.URL + "/")).QueryInfo(ctx)
+	require.NoError(t, err)
+}
@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// conflictGateway returns a gateway whose Call transaction N is committed in
+// block N, invalidated by an MVCC read conflict unless N is validAttempt.
+func conflictGateway(t *testing.T, validAttempt int32) (*testGateway, *int32) {
+	var calls int32
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		switch req.Method {
+		case rpc.MethodCall:
+			return "ok", rpc.ErrorLevelNoError
+		case rpc.MethodQueryInfo:
+			return atomic.LoadInt32(&calls) + 1, rpc.ErrorLevelNoError
+		case rpc.MethodQueryBlock:
+			n := int32(req.Params["block_number"].(float64))
+			blk := block(fmt.Sprintf("tx%d", n))
+			if n != validAttempt {
+				blk["transaction_reasons"] = []string{string(types.ValidationMVCCReadConflict)}
+			}
+			return blk, rpc.ErrorLevelNoError
+		}
+		return nil, rpc.ErrorLevelShiroClient
+	})
+	gw.envelope = func(req *gatewayRequest) map[string]interface{} {
+		if req.Method != rpc.MethodCall {
+			return nil
+		}
+		n := atomic.AddInt32(&calls, 1)
+		return map[string]interface{}{"$commit_tx_id": fmt.Sprintf("tx%d", n), "$sim_block_num": n - 1}
+	}
+	return gw, &calls
+}
+
+func withConflictRetry(maxAttempts int) types.Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.ConflictRetry = types.RetryPolicy{MaxAttempts: maxAttempts}
+		r.IdempotencyKey = "key"
+	})
+}
+
+func TestConflictRetry(t *testing.T) {
+	gw, calls := conflictGateway(t, 3)
+	var received []types.ShiroResponse
+	client := gw.client(withConflictRetry(5), types.Opt(func(r *types.RequestOptions) {
+		r.ResponseReceiver = func(resp types.ShiroResponse) { received = append(received, resp) }
+	}))
+	resp, err := client.Call(context.Background(), "write")
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(calls))
+	commit := resp.(types.CommitResponse).Commit()
+	require.Equal(t, "tx3", commit.TxID)
+	require.Equal(t, uint64(3), commit.CommitBlock)
+	require.Equal(t, types.ValidationValid, commit.ValidationCode)
+	require.Equal(t, []types.ShiroResponse{resp}, received)
+
+	var keys []interface{}
+	for _, req := range gw.Requests() {
+		if req.Method == rpc.MethodCall {
+			keys = append(keys, req.Params["idempotency_key"])
+			require.Equal(t, true, req.Params["disable_write_polling"])
+		}
+	}
+	require.Equal(t, []interface{}{"key", "key-conflict-2", "key-conflict-3"}, keys)
+}
+
+func TestConflictRetryExhausted(t *testing.T) {
+	gw, calls := conflictGateway(t, 0)
+	_, err := gw.client(withConflictRetry(2)).Call(context.Background(), "write")
+	var cerr *ConflictError
+	require.True(t, errors.As(err, &cerr), "unexpected error: %v", err)
+	require.Equal(t, &ConflictError{TxID: "tx2", Code: types.ValidationMVCCReadConflict, Attempts: 2}, cerr)
+	require.Equal(t, int32(2), atomic.LoadInt32(calls))
+
+	// without conflict retries the invalidated response is returned as is.
+	gw, calls = conflictGateway(t, 0)
+	resp, err := gw.client().Call(context.Background(), "write")
+	require.NoError(t, err)
+	require.Equal(t, "tx1", resp.TransactionID())
+	require.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+)
+
+// connTracer collects ConnStats for a single HTTP request.  Hooks may be
+// called from other goroutines, e.g. for parallel dials.
+type connTracer struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	stats        types.ConnStats
+}
+
+// withTrace returns ctx with opt's client trace, if any, and a tracer
+// collecting ConnStats if opt requests them.  The returned tracer is nil if
+// ConnStats are not requested.
+func withTrace(ctx context.Context, opt *types.RequestOptions, attempt int) (context.Context, *connTracer) {
+	if opt.ClientTrace != nil {
+		ctx = httptrace.WithClientTrace(ctx, opt.ClientTrace)
+	}
+	if opt.ConnStats == nil {
+		return ctx, nil
+	}
+	t := &connTracer{start: time.Now()}
+	t.stats.Attempt = attempt
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.stats.DNS = time.Since(t.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.connectStart = time.Now()
+		},
+		ConnectDone: func(string, string, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.stats.Connect = time.Since(t.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.stats.TLSHandshake = time.Since(t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.stats.Reused = info.Reused
+			if info.Conn != nil {
+				t.stats.RemoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.stats.TimeToFirstByte = time.Since(t.start)
+		},
+	})
+	return ctx, t
+}
+
+// report passes the collected stats to opt.ConnStats.
+func (t *connTracer) report(opt *types.RequestOptions) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	stats := t.stats
+	t.mu.Unlock()
+	stats.Total = time.Since(t.start)
+	opt.ConnStats(stats)
+}
@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"context"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnStats(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		return 1, rpc.ErrorLevelNoError
+	})
+	var stats []types.ConnStats
+	var gotConn int
+	client := gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.ConnStats = func(s types.ConnStats) { stats = append(stats, s) }
+		r.ClientTrace = &httptrace.ClientTrace{
+			GotConn: func(httptrace.GotConnInfo) { gotConn++ },
+		}
+	}))
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := client.QueryInfo(ctx)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, gotConn)
+	require.Len(t, stats, 2)
+	require.False(t, stats[0].Reused)
+	require.Greater(t, stats[0].Connect, time.Duration(0))
+	require.True(t, stats[1].Reused)
+	require.Zero(t, stats[1].Connect)
+	for _, s := range stats {
+		require.Equal(t, 1, s.Attempt)
+		require.Equal(t, gw.Listener.Addr().String(), s.RemoteAddr)
+		require.Greater(t, s.TimeToFirstByte, time.Duration(0))
+		require.GreaterOrEqual(t, s.Total, s.TimeToFirstByte)
+	}
+}
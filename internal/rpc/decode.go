@@ -0,0 +1,175 @@
+package rpc
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+)
+
+// DecodeError describes a gateway response that does not match the
+// JSON-RPC response schema.  The Error message contains the path and type
+// of the offending field but never its value, since responses may contain
+// sensitive data.  The full response is available in Payload.
+type DecodeError struct {
+	// Path locates the offending field, e.g. "$.result.error_level".
+	Path string
+	// Expected describes what was expected at Path.
+	Expected string
+	// Got describes what was found at Path.
+	Got string
+	// Method is the gateway method that was invoked.
+	Method string
+	// Payload is the raw response body.
+	Payload []byte
+}
+
+// Error implements error.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("ShiroClient.%s response at %s: expected %s, got %s", e.Method, e.Path, e.Expected, e.Got)
+}
+
+// jsonType describes the JSON type of a decoded value.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+type fieldSpec struct {
+	types    []string
+	required bool
+}
+
+var strictEnvelope = map[string]fieldSpec{
+	"jsonrpc":          {types: []string{"string"}, required: true},
+	"id":               {types: []string{"string", "number", "null"}},
+	"result":           {types: []string{"object"}, required: true},
+	"$commit_tx_id":    {types: []string{"string"}},
+	"$com_block_num":   {types: []string{"number", "string"}},
+	"$sim_block_num":   {types: []string{"number", "string"}},
+	"$endorsers":       {types: []string{"array", "null"}},
+	"$validation_code": {types: []string{"string"}},
+}
+
+var strictResult = map[string]fieldSpec{
+	"error_level": {types: []string{"number"}, required: true},
+	"result":      {required: true},
+	"code":        {types: []string{"number", "null"}, required: true},
+	"message":     {types: []string{"string", "null"}, required: true},
+	"data":        {required: true},
+}
+
+// validateStrict checks a decoded response against the JSON-RPC schema
+// used by the gateway.
+func validateStrict(method string, res interface{}, payload []byte) error {
+	fail := func(path, expected, got string) error {
+		return &DecodeError{
+			Path:     path,
+			Expected: expected,
+			Got:      got,
+			Method:   method,
+			Payload:  payload,
+		}
+	}
+	envelope, ok := res.(map[string]interface{})
+	if !ok {
+		return fail("$", "object", jsonType(res))
+	}
+	if err := checkFields("$", envelope, strictEnvelope, fail); err != nil {
+		return err
+	}
+	if v := envelope["jsonrpc"]; v != "2.0" {
+		return fail("$.jsonrpc", `"2.0"`, "another version")
+	}
+	result := envelope["result"].(map[string]interface{})
+	if err := checkFields("$.result", result, strictResult, fail); err != nil {
+		return err
+	}
+	level := result["error_level"].(float64)
+	switch level {
+	case rpc.ErrorLevelNoError, rpc.ErrorLevelShiroClient, rpc.ErrorLevelPhylum:
+	default:
+		return fail("$.result.error_level", "a known error level", strconv.FormatFloat(level, 'f', -1, 64))
+	}
+	for _, key := range []string{"$com_block_num", "$sim_block_num"} {
+		if v, ok := envelope[key]; ok {
+			if _, err := convertToUint64(v); err != nil {
+				return fail("$."+key, "an unsigned block number", jsonType(v))
+			}
+		}
+	}
+	if level == rpc.ErrorLevelNoError {
+		txID, hasTxID := envelope["$commit_tx_id"]
+		committed := false
+		if v, ok := envelope["$com_block_num"]; ok {
+			n, _ := convertToUint64(v)
+			committed = n > 0
+		}
+		if (method == rpc.MethodInit || committed) && (!hasTxID || txID == "") {
+			return fail("$.$commit_tx_id", "a transaction ID for a committed write", "none")
+		}
+	}
+	return nil
+}
+
+func checkFields(path string, obj map[string]interface{}, spec map[string]fieldSpec, fail func(path, expected, got string) error) error {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fs, ok := spec[k]
+		if !ok {
+			return fail(path+"."+k, "no field", "unexpected field")
+		}
+		if len(fs.types) == 0 {
+			continue
+		}
+		got := jsonType(obj[k])
+		if !slices.Contains(fs.types, got) {
+			return fail(path+"."+k, joinTypes(fs.types), got)
+		}
+	}
+	required := make([]string, 0, len(spec))
+	for k, fs := range spec {
+		if fs.required {
+			required = append(required, k)
+		}
+	}
+	sort.Strings(required)
+	for _, k := range required {
+		if _, ok := obj[k]; !ok {
+			return fail(path+"."+k, "a required field", "missing field")
+		}
+	}
+	return nil
+}
+
+func joinTypes(types []string) string {
+	out := types[0]
+	for i := 1; i < len(types); i++ {
+		if i == len(types)-1 {
+			out += " or " + types[i]
+		} else {
+			out += ", " + types[i]
+		}
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateStrict(t *testing.T) {
+	const ok = `"result":{"error_level":0,"result":1,"code":0,"message":"","data":null}`
+	for _, test := range []struct {
+		method string
+		body   string
+		path   string
+	}{
+		{rpc.MethodQueryInfo, `{"jsonrpc":"2.0","id":"1",` + ok + `}`, ""},
+		{rpc.MethodCall, `{"jsonrpc":"2.0",` + ok + `,"$commit_tx_id":"tx1","$com_block_num":4}`, ""},
+		{rpc.MethodCall, `[]`, "$"},
+		{rpc.MethodCall, `{"jsonrpc":"2.0",` + ok + `,"extra":true}`, "$.extra"},
+		{rpc.MethodCall, `{"jsonrpc":2,` + ok + `}`, "$.jsonrpc"},
+		{rpc.MethodCall, `{"jsonrpc":"1.0",` + ok + `}`, "$.jsonrpc"},
+		{rpc.MethodCall, `{"jsonrpc":"2.0","result":{"error_level":"0","result":1,"code":0,"message":"","data":null}}`, "$.result.error_level"},
+		{rpc.MethodCall, `{"jsonrpc":"2.0","result":{"error_level":0,"result":1,"code":0,"data":null}}`, "$.result.message"},
+		{rpc.MethodCall, `{"jsonrpc":"2.0","result":{"error_level":9,"result":1,"code":0,"message":"","data":null}}`, "$.result.error_level"},
+		{rpc.MethodCall, `{"jsonrpc":"2.0",` + ok + `,"$com_block_num":"x"}`, "$.$com_block_num"},
+		{rpc.MethodCall, `{"jsonrpc":"2.0",` + ok + `,"$com_block_num":4}`, "$.$commit_tx_id"},
+		{rpc.MethodInit, `{"jsonrpc":"2.0",` + ok + `}`, "$.$commit_tx_id"},
+	} {
+		var res interface{}
+		require.NoError(t, json.Unmarshal([]byte(test.body), &res))
+		err := validateStrict(test.method, res, []byte(test.body))
+		if test.path == "" {
+			require.NoError(t, err, test.body)
+			continue
+		}
+		var derr *DecodeError
+		require.True(t, errors.As(err, &derr), "%s: %v", test.body, err)
+		require.Equal(t, test.path, derr.Path, test.body)
+		require.Equal(t, test.body, string(derr.Payload))
+	}
+}
@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SchemeDNSSRV is the endpoint scheme for gateways discovered with DNS
+	// SRV records and reached over HTTP.
+	SchemeDNSSRV = "dnssrv"
+	// SchemeDNSSRVHTTPS is the endpoint scheme for gateways discovered with
+	// DNS SRV records and reached over HTTPS.
+	SchemeDNSSRVHTTPS = "dnssrv+https"
+)
+
+// DefaultEndpointRefresh is the interval after which the SRV records of a
+// dnssrv endpoint are resolved again.
+const DefaultEndpointRefresh = 30 * time.Second
+
+// srvLookupFunc has the signature of net.Resolver.LookupSRV.
+type srvLookupFunc func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+
+// srvResolver resolves dnssrv endpoints into gateway URLs.  The Go resolver
+// does not expose record TTLs, so records are resolved again after a fixed
+// refresh interval.  If resolution fails the previous addresses are kept.
+type srvResolver struct {
+	mu      sync.Mutex
+	entries map[string]*srvEntry
+	lookup  srvLookupFunc
+	now     func() time.Time
+}
+
+type srvEntry struct {
+	urls    []string
+	primary int // number of urls with the best priority
+	expires time.Time
+	next    int
+}
+
+func newSRVResolver() *srvResolver {
+	return &srvResolver{
+		entries: make(map[string]*srvEntry),
+		lookup:  net.DefaultResolver.LookupSRV,
+		now:     time.Now,
+	}
+}
+
+// isDNSSRV reports whether endpoint uses service discovery.
+func isDNSSRV(endpoint string) bool {
+	return strings.HasPrefix(endpoint, SchemeDNSSRV+"://") ||
+		strings.HasPrefix(endpoint, SchemeDNSSRVHTTPS+"://")
+}
+
+// endpoints returns the gateway URLs to try for a request to endpoint, in
+// order.  Requests are spread round-robin over the records with the best
+// priority, which are followed by the remaining records for failover.
+// Endpoints that do not use service discovery are returned as is.
+func (r *srvResolver) endpoints(ctx context.Context, endpoint string, refresh time.Duration) ([]string, error) {
+	if !isDNSSRV(endpoint) {
+		return []string{endpoint}, nil
+	}
+	if refresh <= 0 {
+		refresh = DefaultEndpointRefresh
+	}
+
+	r.mu.Lock()
+	entry := r.entries[endpoint]
+	fresh := entry != nil && r.now().Before(entry.expires)
+	r.mu.Unlock()
+
+	if !fresh {
+		resolved, err := r.resolve(ctx, endpoint)
+		r.mu.Lock()
+		entry = r.entries[endpoint]
+		switch {
+		case err == nil:
+			resolved.expires = r.now().Add(refresh)
+			if entry != nil {
+				resolved.next = entry.next
+			}
+			entry = resolved
+			r.entries[endpoint] = entry
+		case entry == nil:
+			r.mu.Unlock()
+			return nil, err
+		default:
+			// keep serving the stale addresses until the next refresh.
+			entry.expires = r.now().Add(refresh)
+		}
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	start := entry.next % entry.primary
+	entry.next++
+	urls := make([]string, 0, len(entry.urls))
+	urls = append(urls, entry.urls[start:entry.primary]...)
+	urls = append(urls, entry.urls[:start]...)
+	urls = append(urls, entry.urls[entry.primary:]...)
+	return urls, nil
+}
+
+// resolve looks up the SRV records of endpoint.
+func (r *srvResolver) resolve(ctx context.Context, endpoint string) (*srvEntry, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dnssrv endpoint: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid dnssrv endpoint %q: missing service name", endpoint)
+	}
+	scheme := "http"
+	if u.Scheme == SchemeDNSSRVHTTPS {
+		scheme = "https"
+	}
+	_, records, err := r.lookup(ctx, "", "", u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", endpoint, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("resolve %s: no SRV records", endpoint)
+	}
+	// records are sorted by priority and randomized by weight.
+	entry := &srvEntry{}
+	for _, rec := range records {
+		target := *u
+		target.Scheme = scheme
+		target.Host = net.JoinHostPort(strings.TrimSuffix(rec.Target, "."), strconv.Itoa(int(rec.Port)))
+		entry.urls = append(entry.urls, target.String())
+		if rec.Priority == records[0].Priority {
+			entry.primary++
+		}
+	}
+	return entry, nil
+}
@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+// srvRecord returns an SRV record targeting the host and port of rawURL.
+func srvRecord(t *testing.T, rawURL string, priority uint16) *net.SRV {
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+	return &net.SRV{Target: u.Hostname() + ".", Port: uint16(port), Priority: priority}
+}
+
+func TestSRVResolver(t *testing.T) {
+	var lookups int32
+	records := []*net.SRV{
+		{Target: "gw1.example.", Port: 8080, Priority: 1},
+		{Target: "gw2.example.", Port: 8080, Priority: 1},
+		{Target: "backup.example.", Port: 9090, Priority: 2},
+	}
+	var lookupErr error
+	now := time.Unix(0, 0)
+	r := newSRVResolver()
+	r.now = func() time.Time { return now }
+	r.lookup = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		atomic.AddInt32(&lookups, 1)
+		require.Equal(t, "gateway.service.consul", name)
+		return "", records, lookupErr
+	}
+	ctx := context.Background()
+
+	urls, err := r.endpoints(ctx, "dnssrv://gateway.service.consul/rpc", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://gw1.example:8080/rpc", "http://gw2.example:8080/rpc", "http://backup.example:9090/rpc"}, urls)
+	urls, err = r.endpoints(ctx, "dnssrv://gateway.service.consul/rpc", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://gw2.example:8080/rpc", "http://gw1.example:8080/rpc", "http://backup.example:9090/rpc"}, urls)
+	require.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+
+	// stale addresses are kept when resolution fails after the refresh.
+	now = now.Add(2 * time.Minute)
+	lookupErr = errors.New("no such host")
+	urls, err = r.endpoints(ctx, "dnssrv://gateway.service.consul/rpc", time.Minute)
+	require.NoError(t, err)
+	require.Len(t, urls, 3)
+	require.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+
+	_, err = r.endpoints(ctx, "dnssrv+https://gateway.service.consul", time.Minute)
+	require.ErrorContains(t, err, "no such host")
+
+	lookupErr = nil
+	urls, err = r.endpoints(ctx, "dnssrv+https://gateway.service.consul", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, "https://gw1.example:8080", urls[0])
+
+	urls, err = r.endpoints(ctx, "http://localhost:8080", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://localhost:8080"}, urls)
+}
+
+func TestDNSSRVFailover(t *testing.T) {
+	down, downCount := flakyServer(t, 100)
+	up, upCount := flakyServer(t, 0)
+	client := NewRPC([]types.Config{
+		types.Opt(func(r *types.RequestOptions) {
+			r.Endpoint = "dnssrv://gateway.service.consul"
+			r.Retry = types.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}
+		}),
+	}).(*rpcShiroClient)
+	client.srv.lookup = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		return "", []*net.SRV{srvRecord(t, down.URL, 1), srvRecord(t, up.URL, 2)}, nil
+	}
+	var stats types.CallStats
+	height, err := client.QueryInfo(context.Background(), types.Opt(func(r *types.RequestOptions) {
+		r.Stats = func(s types.CallStats) { stats = s }
+	}))
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), height)
+	require.Equal(t, int32(1), atomic.LoadInt32(downCount))
+	require.Equal(t, int32(1), atomic.LoadInt32(upCount))
+	require.Equal(t, up.URL, stats.Endpoint)
+}
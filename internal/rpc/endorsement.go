@@ -0,0 +1,138 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+)
+
+// ErrEndorsementPolicyUnsupported is returned by GetEndorsementPolicy for
+// clients that cannot inspect the endorsement policy.
+var ErrEndorsementPolicyUnsupported = errors.New("shiroclient: endorsement policy inspection is not supported")
+
+// EndorsingPeer is a peer eligible to endorse transactions of the
+// chaincode.
+type EndorsingPeer struct {
+	// Name is the name of the peer, usable with WithTargetEndpoints.
+	Name string
+	// MSPID is the MSP of the organization of the peer.
+	MSPID string
+	// Endpoint is the address of the peer, if reported.
+	Endpoint string
+}
+
+// EndorsementPolicy describes the endorsement policy of the chaincode and
+// the peers currently eligible to endorse its transactions.
+type EndorsementPolicy struct {
+	// Expression is the policy in Fabric signature policy syntax, e.g.
+	// "OR('Org1MSP.peer','Org2MSP.peer')".
+	Expression string
+	// MinEndorsements is the smallest number of endorsements that can
+	// satisfy the policy, or 0 if the gateway did not report it.
+	MinEndorsements int
+	// Peers lists the peers currently eligible to endorse.
+	Peers []EndorsingPeer
+}
+
+// MSPIDs returns the distinct MSP IDs of the eligible peers in the order
+// they first appear.
+func (p *EndorsementPolicy) MSPIDs() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, peer := range p.Peers {
+		if !seen[peer.MSPID] {
+			seen[peer.MSPID] = true
+			ids = append(ids, peer.MSPID)
+		}
+	}
+	return ids
+}
+
+// endorsementInspector is an internal interface implemented by clients that
+// can inspect the endorsement policy.
+type endorsementInspector interface {
+	endorsementPolicy(ctx context.Context, configs ...types.Config) (*EndorsementPolicy, error)
+}
+
+var _ endorsementInspector = (*rpcShiroClient)(nil)
+
+// GetEndorsementPolicy returns the endorsement policy of the chaincode
+// served by client.  ErrEndorsementPolicyUnsupported is returned for
+// clients other than RPC clients.
+func GetEndorsementPolicy(ctx context.Context, client types.ShiroClient, configs ...types.Config) (*EndorsementPolicy, error) {
+	e, ok := client.(endorsementInspector)
+	if !ok {
+		return nil, ErrEndorsementPolicyUnsupported
+	}
+	return e.endorsementPolicy(ctx, configs...)
+}
+
+func (c *rpcShiroClient) endorsementPolicy(ctx context.Context, configs ...types.Config) (*EndorsementPolicy, error) {
+	ctx, span := c.tracer.Start(ctx, "sdk:GetEndorsementPolicy")
+	defer span.End()
+	opt, err := c.applyConfigs(configs...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      opt.ID,
+		"method":  rpc.MethodGetEndorsementPolicy,
+		"params":  map[string]interface{}{},
+	}
+
+	res, err := c.reqres(ctx, req, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.errorLevel {
+	case rpc.ErrorLevelNoError:
+		return decodeEndorsementPolicy(res.result)
+
+	case rpc.ErrorLevelShiroClient:
+		return nil, res.getShiroClientError()
+
+	default:
+		return nil, fmt.Errorf("ShiroClient.GetEndorsementPolicy unexpected error level %d", res.errorLevel)
+	}
+}
+
+func decodeEndorsementPolicy(result interface{}) (*EndorsementPolicy, error) {
+	const errdesc = "ShiroClient.GetEndorsementPolicy"
+	obj, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("ShiroClient.GetEndorsementPolicy expected an object result field")
+	}
+	policy := &EndorsementPolicy{}
+	if policy.Expression, ok = obj["policy"].(string); !ok {
+		return nil, stringFieldError(errdesc, "policy")
+	}
+	if n, ok := obj["min_endorsements"].(float64); ok {
+		policy.MinEndorsements = int(n)
+	}
+	peers, ok := obj["peers"].([]interface{})
+	if !ok && obj["peers"] != nil {
+		return nil, &jsonFieldError{errdesc, "array", "peers"}
+	}
+	for _, p := range peers {
+		m, ok := p.(map[string]interface{})
+		if !ok {
+			return nil, &jsonFieldError{errdesc, "object", "peers member"}
+		}
+		var peer EndorsingPeer
+		if peer.Name, ok = m["name"].(string); !ok {
+			return nil, stringFieldError(errdesc, "peer name")
+		}
+		if peer.MSPID, ok = m["msp_id"].(string); !ok {
+			return nil, stringFieldError(errdesc, "peer msp_id")
+		}
+		peer.Endpoint, _ = m["endpoint"].(string)
+		policy.Peers = append(policy.Peers, peer)
+	}
+	return policy, nil
+}
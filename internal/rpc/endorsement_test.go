@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEndorsementPolicy(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		require.Equal(t, rpc.MethodGetEndorsementPolicy, req.Method)
+		return map[string]interface{}{
+			"policy":           "OR('Org1MSP.peer','Org2MSP.peer')",
+			"min_endorsements": 1,
+			"peers": []interface{}{
+				map[string]interface{}{"name": "peer0.org1", "msp_id": "Org1MSP", "endpoint": "peer0.org1:7051"},
+				map[string]interface{}{"name": "peer1.org1", "msp_id": "Org1MSP"},
+				map[string]interface{}{"name": "peer0.org2", "msp_id": "Org2MSP"},
+			},
+		}, rpc.ErrorLevelNoError
+	})
+	policy, err := GetEndorsementPolicy(context.Background(), gw.client())
+	require.NoError(t, err)
+	require.Equal(t, "OR('Org1MSP.peer','Org2MSP.peer')", policy.Expression)
+	require.Equal(t, 1, policy.MinEndorsements)
+	require.Len(t, policy.Peers, 3)
+	require.Equal(t, EndorsingPeer{Name: "peer0.org1", MSPID: "Org1MSP", Endpoint: "peer0.org1:7051"}, policy.Peers[0])
+	require.Equal(t, []string{"Org1MSP", "Org2MSP"}, policy.MSPIDs())
+}
+
+func TestGetEndorsementPolicyInvalid(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		return map[string]interface{}{
+			"policy": "OR('Org1MSP.peer')",
+			"peers":  []interface{}{map[string]interface{}{"name": "peer0.org1"}},
+		}, rpc.ErrorLevelNoError
+	})
+	_, err := GetEndorsementPolicy(context.Background(), gw.client())
+	require.ErrorContains(t, err, "msp_id")
+
+	_, err = GetEndorsementPolicy(context.Background(), nil)
+	require.ErrorIs(t, err, ErrEndorsementPolicyUnsupported)
+}
@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// gatewayRequest is a JSON-RPC request received by a test gateway.
+type gatewayRequest struct {
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params"`
+	Header http.Header            `json:"-"`
+}
+
+// testGateway is a minimal fake shiroclient gateway.  handle returns the
+// inner result and error level for each request.
+type testGateway struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*gatewayRequest
+	// envelope optionally returns additional top-level response fields.
+	envelope func(req *gatewayRequest) map[string]interface{}
+}
+
+func newTestGateway(t *testing.T, handle func(req *gatewayRequest) (interface{}, int)) *testGateway {
+	gw := &testGateway{}
+	gw.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &gatewayRequest{Header: r.Header.Clone()}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		gw.mu.Lock()
+		gw.requests = append(gw.requests, req)
+		w.Header().Set(rpc.HeaderRequestID, fmt.Sprintf("req-%d", len(gw.requests)))
+		gw.mu.Unlock()
+		result, level := handle(req)
+		var code interface{} = 0
+		var message interface{} = ""
+		if level != 0 {
+			code, message = 1, "error"
+		}
+		res := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      "1",
+			"result": map[string]interface{}{
+				"error_level": level,
+				"result":      result,
+				"code":        code,
+				"message":     message,
+				"data":        nil,
+			},
+		}
+		if gw.envelope != nil {
+			for k, v := range gw.envelope(req) {
+				res[k] = v
+			}
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(gw.Close)
+	return gw
+}
+
+// block returns a QueryBlock result containing txIDs.
+func block(txIDs ...string) map[string]interface{} {
+	n := len(txIDs)
+	empty := make([]string, n)
+	return map[string]interface{}{
+		"block_hash":          "hash",
+		"transaction_ids":     append([]string{}, txIDs...),
+		"transaction_reasons": empty,
+		"transaction_events":  empty,
+		"chaincode_ids":       empty,
+	}
+}
+
+// Requests returns the requests received so far.
+func (gw *testGateway) Requests() []*gatewayRequest {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	return append([]*gatewayRequest(nil), gw.requests...)
+}
+
+// client returns an RPC client targeting the gateway.
+func (gw *testGateway) client(configs ...types.Config) *rpcShiroClient {
+	base := []types.Config{types.Opt(func(r *types.RequestOptions) { r.Endpoint = gw.URL })}
+	return NewRPC(append(base, configs...)).(*rpcShiroClient)
+}
+
+func TestTestGateway(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		return 1, 0
+	})
+	_, err := gw.client().QueryInfo(context.Background())
+	require.NoError(t, err)
+	require.Len(t, gw.Requests(), 1)
+}
+
+func TestPrivateCollections(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		return "ok", rpc.ErrorLevelNoError
+	})
+	client := gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.PrivateCollections = []string{"org1Private", "sharedPrivate"}
+	}))
+	_, err := client.Call(context.Background(), "write")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"org1Private", "sharedPrivate"}, gw.Requests()[0].Params["private_collections"])
+
+	_, err = gw.client().Call(context.Background(), "write")
+	require.NoError(t, err)
+	require.NotContains(t, gw.Requests()[1].Params, "private_collections")
+}
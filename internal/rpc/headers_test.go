@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextHeaders(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		return true, rpc.ErrorLevelNoError
+	})
+	client := gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.Headers["X-Tenant"] = "configured"
+	}))
+	ctx := types.ContextWithHeaders(context.Background(), map[string]string{
+		"X-Correlation-Id": "abc",
+		"X-Tenant":         "context",
+	})
+	ctx = types.ContextWithHeaders(ctx, map[string]string{"X-Request-Source": "api"})
+
+	_, err := client.Call(ctx, "ok")
+	require.NoError(t, err)
+	header := gw.Requests()[0].Header
+	require.Equal(t, "abc", header.Get("X-Correlation-Id"))
+	require.Equal(t, "api", header.Get("X-Request-Source"))
+	require.Equal(t, "configured", header.Get("X-Tenant"))
+
+	_, err = client.Call(context.Background(), "ok")
+	require.NoError(t, err)
+	require.Empty(t, gw.Requests()[1].Header.Get("X-Correlation-Id"))
+}
+
+func TestAuthHeaders(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		return true, rpc.ErrorLevelNoError
+	})
+	ctx := context.Background()
+
+	_, err := gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.AuthToken = "token"
+	})).Call(ctx, "ok")
+	require.NoError(t, err)
+	require.Equal(t, "Bearer token", gw.Requests()[0].Header.Get("Authorization"))
+
+	_, err = gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.AuthScheme = "Token"
+		r.AuthToken = "creds"
+		r.APIKeyHeader = "X-API-Key"
+		r.APIKey = "key"
+	})).Call(ctx, "ok")
+	require.NoError(t, err)
+	require.Equal(t, "Token creds", gw.Requests()[1].Header.Get("Authorization"))
+	require.Equal(t, "key", gw.Requests()[1].Header.Get("X-API-Key"))
+
+	_, err = gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.APIKeyHeader = "Authorization"
+		r.APIKey = "ApiKey key"
+	})).Call(ctx, "ok")
+	require.NoError(t, err)
+	require.Equal(t, "ApiKey key", gw.Requests()[2].Header.Get("Authorization"))
+}
+
+func TestForwardLogFields(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		return true, rpc.ErrorLevelNoError
+	})
+	client := gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.ForwardLogFields = []string{"tenant", "request_id", "missing"}
+		r.LogFields["tenant"] = "acme"
+		r.LogFields["password"] = "secret"
+	}))
+	ctx := context.Background()
+
+	_, err := client.Call(ctx, "ok", types.Opt(func(r *types.RequestOptions) {
+		r.LogFields["request_id"] = 42
+	}))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"tenant":"acme","request_id":42}`, gw.Requests()[0].Header.Get(rpc.HeaderContext))
+
+	_, err = gw.client().Call(ctx, "ok", types.Opt(func(r *types.RequestOptions) {
+		r.LogFields["tenant"] = "acme"
+	}))
+	require.NoError(t, err)
+	require.Empty(t, gw.Requests()[1].Header.Get(rpc.HeaderContext))
+}
+
+func TestIdempotencyKeyHeader(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		if req.Method == rpc.MethodQueryInfo {
+			return 7, rpc.ErrorLevelNoError
+		}
+		return true, rpc.ErrorLevelNoError
+	})
+	client := gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.IdempotencyKey = "payment-1"
+	}))
+	ctx := context.Background()
+
+	_, err := client.QueryInfo(ctx)
+	require.NoError(t, err)
+	_, err = client.Call(ctx, "ok")
+	require.NoError(t, err)
+	// only phylum calls carry the idempotency key.
+	require.Empty(t, gw.Requests()[0].Header.Get(rpc.HeaderIdempotencyKey))
+	require.Equal(t, "payment-1", gw.Requests()[1].Header.Get(rpc.HeaderIdempotencyKey))
+}
@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+)
+
+// warmer is an internal interface implemented by clients that hold
+// connections to the gateway.
+type warmer interface {
+	WarmUp(ctx context.Context, configs ...types.Config) error
+}
+
+var _ warmer = (*rpcShiroClient)(nil)
+
+// WarmUp opens connections to the gateway ahead of the first request.
+// Clients that do not connect to a gateway return nil.
+func WarmUp(ctx context.Context, client types.ShiroClient, configs ...types.Config) error {
+	w, ok := client.(warmer)
+	if !ok {
+		return nil
+	}
+	return w.WarmUp(ctx, configs...)
+}
+
+// WarmUp resolves the gateway endpoint and sends a health check request to
+// each gateway, so that DNS resolution, connection and TLS handshakes are
+// done before the first request.  Errors from all gateways are joined.
+// WarmUp is not part of the ShiroClient interface but it is recognized by
+// the WarmUp function.
+func (c *rpcShiroClient) WarmUp(ctx context.Context, configs ...types.Config) error {
+	opt, err := c.applyConfigs(configs...)
+	if err != nil {
+		return fmt.Errorf("warm up config: %w", err)
+	}
+	var errs []error
+	err = c.ping(ctx, opt, func(endpoint string, err error) {
+		errs = append(errs, fmt.Errorf("warm up %s: %w", endpoint, err))
+	})
+	if err != nil {
+		return fmt.Errorf("warm up: %w", err)
+	}
+	return errors.Join(errs...)
+}
+
+// keepAlive pings the gateway every opt.KeepAlive until the client is shut
+// down.
+func (c *rpcShiroClient) keepAlive(opt *types.RequestOptions) {
+	ticker := time.NewTicker(opt.KeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.lifecycle.closing:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), opt.KeepAlive)
+		err := c.ping(ctx, opt, func(endpoint string, err error) {
+			c.keepAliveFailed(opt, endpoint, err)
+		})
+		cancel()
+		if err != nil && !errors.Is(err, ErrClientClosed) {
+			c.keepAliveFailed(opt, opt.Endpoint, err)
+		}
+	}
+}
+
+func (c *rpcShiroClient) keepAliveFailed(opt *types.RequestOptions, endpoint string, err error) {
+	if opt.Log != nil {
+		opt.Log.WithFields(opt.LogFields).
+			WithError(err).
+			WithField("endpoint", endpoint).
+			Warn("ShiroClient keepalive failed")
+	}
+	if opt.KeepAliveFailure != nil {
+		opt.KeepAliveFailure(endpoint, err)
+	}
+}
+
+// ping sends a health check request to every gateway of opt.Endpoint, with
+// the headers of other requests, and reports the gateways that fail to
+// report.  The returned error is set if
+// the gateways could not be determined.
+func (c *rpcShiroClient) ping(ctx context.Context, opt *types.RequestOptions, report func(endpoint string, err error)) error {
+	ctx, done, err := c.lifecycle.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	endpoints, err := c.srv.endpoints(ctx, opt.Endpoint, opt.EndpointRefresh)
+	if err != nil {
+		return err
+	}
+	for _, endpoint := range endpoints {
+		checkURL, err := gatewayHealthCheckURL(endpoint, nil)
+		if err != nil {
+			report(endpoint, err)
+			continue
+		}
+		req, err := http.NewRequest("GET", checkURL, nil)
+		if err != nil {
+			report(endpoint, err)
+			continue
+		}
+		setHeaders(ctx, req, opt)
+		res, err := c.doRequest(ctx, opt.HTTPClient, req, opt.Log)
+		if err != nil {
+			report(endpoint, err)
+			continue
+		}
+		if res.statusCode < 200 || res.statusCode > 299 {
+			report(endpoint, fmt.Errorf("unexpected status %d", res.statusCode))
+		}
+	}
+	return nil
+}
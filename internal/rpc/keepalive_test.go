@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmUp(t *testing.T) {
+	var checks int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/health_check", r.URL.Path)
+		require.Equal(t, "key", r.Header.Get("X-API-Key"))
+		require.Equal(t, "a", r.Header.Get("X-Tenant"))
+		require.Equal(t, "b", r.Header.Get("X-Context"))
+		atomic.AddInt32(&checks, 1)
+	}))
+	t.Cleanup(srv.Close)
+	client := NewRPC([]types.Config{
+		types.Opt(func(r *types.RequestOptions) {
+			r.Endpoint = srv.URL
+			r.APIKeyHeader = "X-API-Key"
+			r.APIKey = "key"
+			r.Headers = map[string]string{"X-Tenant": "a"}
+		}),
+	})
+	ctx := types.ContextWithHeaders(context.Background(), map[string]string{"X-Context": "b"})
+	require.NoError(t, WarmUp(ctx, client))
+	require.Equal(t, int32(1), atomic.LoadInt32(&checks))
+
+	srv.Close()
+	require.Error(t, WarmUp(context.Background(), client))
+}
+
+func TestKeepAlive(t *testing.T) {
+	var checks int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&checks, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	failures := make(chan string, 10)
+	client := NewRPC([]types.Config{
+		types.Opt(func(r *types.RequestOptions) {
+			r.Endpoint = srv.URL
+			r.KeepAlive = 5 * time.Millisecond
+			r.KeepAliveFailure = func(endpoint string, err error) {
+				require.ErrorContains(t, err, "unexpected status 503")
+				select {
+				case failures <- endpoint:
+				default:
+				}
+			}
+		}),
+	}).(*rpcShiroClient)
+	require.Equal(t, srv.URL, <-failures)
+	require.NoError(t, client.Close())
+	n := atomic.LoadInt32(&checks)
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, n, atomic.LoadInt32(&checks))
+}
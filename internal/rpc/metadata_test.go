@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseMetadata(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		if req.Params["method"] == "fail" {
+			return nil, rpc.ErrorLevelPhylum
+		}
+		return "ok", rpc.ErrorLevelNoError
+	})
+	client := gw.client()
+	ctx := context.Background()
+
+	resp, err := client.Call(ctx, "succeed")
+	require.NoError(t, err)
+	meta := resp.(types.MetadataResponse).Metadata()
+	require.NotNil(t, meta)
+	require.Equal(t, http.StatusOK, meta.StatusCode)
+	require.Equal(t, "req-1", meta.RequestID())
+	var envelope map[string]interface{}
+	require.NoError(t, json.Unmarshal(meta.Envelope, &envelope))
+	require.Equal(t, "2.0", envelope["jsonrpc"])
+
+	resp, err = client.Call(ctx, "fail")
+	require.NoError(t, err)
+	require.NotNil(t, resp.Error())
+	meta = resp.(types.MetadataResponse).Metadata()
+	require.Equal(t, "req-2", meta.RequestID())
+
+	var nilMeta *types.ResponseMetadata
+	require.Equal(t, "", nilMeta.RequestID())
+}
@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseReceiver(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		switch req.Params["method"] {
+		case "fail":
+			return nil, rpc.ErrorLevelPhylum
+		case "broken":
+			return nil, rpc.ErrorLevelShiroClient
+		}
+		return true, rpc.ErrorLevelNoError
+	})
+	var received []types.ShiroResponse
+	client := gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.ResponseReceiver = func(resp types.ShiroResponse) { received = append(received, resp) }
+	}))
+	ctx := context.Background()
+
+	resp, err := client.Call(ctx, "ok")
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	require.Same(t, resp, received[0])
+
+	resp, err = client.Call(ctx, "fail")
+	require.NoError(t, err)
+	require.Len(t, received, 2)
+	require.Same(t, resp, received[1])
+	require.NotNil(t, received[1].Error())
+
+	_, err = client.Call(ctx, "broken")
+	require.Error(t, err)
+	require.Len(t, received, 2)
+}
@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/backoff"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+)
+
+// readOnlyMethods are gateway methods that never modify the ledger and are
+// safe to retry after any transport failure.
+var readOnlyMethods = map[string]bool{
+	rpc.MethodShiroPhylum: true,
+	rpc.MethodQueryInfo:   true,
+	rpc.MethodQueryBlock:  true,
+}
+
+// retryable returns true if a request for the gateway method that failed
+// with err may be sent again.  Requests carrying an idempotency key are
+// deduplicated by the gateway and can always be retried.
+func retryable(method string, idempotent bool, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		// the request never reached the gateway.
+		return true
+	}
+	return idempotent || readOnlyMethods[method]
+}
+
+// retryDelay returns the delay before retry number n (starting at 1).
+func retryDelay(policy types.RetryPolicy, n int) time.Duration {
+	return backoff.Policy{Initial: policy.Backoff, Max: policy.MaxBackoff}.Delay(n)
+}
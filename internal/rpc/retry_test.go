@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer drops the connection for the first n requests and then
+// answers QueryInfo requests with a height of 7.
+func flakyServer(t *testing.T, n int32) (*httptest.Server, *int32) {
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= n {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":"1","result":{"error_level":0,"result":7,"code":0,"message":"","data":null}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &count
+}
+
+func TestRetryReadOnly(t *testing.T) {
+	srv, count := flakyServer(t, 2)
+	client := NewRPC([]types.Config{
+		types.Opt(func(r *types.RequestOptions) {
+			r.Endpoint = srv.URL
+			r.Retry = types.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+		}),
+	})
+	height, err := client.QueryInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), height)
+	require.Equal(t, int32(3), atomic.LoadInt32(count))
+}
+
+func TestRetryCallNotRetried(t *testing.T) {
+	srv, count := flakyServer(t, 1)
+	client := NewRPC([]types.Config{
+		types.Opt(func(r *types.RequestOptions) {
+			r.Endpoint = srv.URL
+			r.Retry = types.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+		}),
+	})
+	_, err := client.Call(context.Background(), "write")
+	require.Error(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(count))
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := types.RetryPolicy{Backoff: 10 * time.Millisecond, MaxBackoff: 35 * time.Millisecond}
+	require.Equal(t, 10*time.Millisecond, retryDelay(policy, 1))
+	require.Equal(t, 20*time.Millisecond, retryDelay(policy, 2))
+	require.Equal(t, 35*time.Millisecond, retryDelay(policy, 3))
+	require.Equal(t, 35*time.Millisecond, retryDelay(policy, 10))
+}
+
+func TestRetryCallWithIdempotencyKey(t *testing.T) {
+	srv, count := flakyServer(t, 1)
+	client := NewRPC([]types.Config{
+		types.Opt(func(r *types.RequestOptions) {
+			r.Endpoint = srv.URL
+			r.Retry = types.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+			r.IdempotencyKey = "payment-1"
+		}),
+	})
+	_, err := client.Call(context.Background(), "write")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(count))
+}
@@ -11,12 +11,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"path"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/backoff"
 	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
@@ -28,23 +32,135 @@ var _ types.ShiroClient = (*rpcShiroClient)(nil)
 
 var tracePropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{})
 
+// ErrClientClosed is returned for requests issued after a client was closed.
+var ErrClientClosed = errors.New("shiroclient: client closed")
+
 type rpcShiroClient struct {
 	tracer     trace.Tracer
 	defaultLog *logrus.Logger
-	httpClient http.Client
-	baseConfig []types.Config
+	transport  *sharedTransport
+	baseConfig types.ConfigSet
+	lifecycle  *lifecycle
+	caps       *capabilityCache
+	srv        *srvResolver
+}
+
+// sharedTransport is the HTTP client shared by a client and the clients
+// derived from it with With.  Its idle connections are closed once all of
+// them are shut down.
+type sharedTransport struct {
+	client http.Client
+
+	mu   sync.Mutex
+	refs int
+}
+
+func newSharedTransport() *sharedTransport {
+	return &sharedTransport{
+		client: http.Client{
+			// a dedicated transport lets Close release the connections
+			// of a client and its derived clients without affecting
+			// other clients.
+			Transport: http.DefaultTransport.(*http.Transport).Clone(),
+		},
+		refs: 1,
+	}
+}
+
+// acquire registers a client using t.
+func (t *sharedTransport) acquire() *sharedTransport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refs++
+	return t
+}
+
+// release unregisters a client using t, closing idle connections if it was
+// the last.
+func (t *sharedTransport) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refs--
+	if t.refs == 0 {
+		t.client.CloseIdleConnections()
+	}
+}
+
+// lifecycle tracks outstanding requests so a client can be shut down.
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+	ctx      context.Context
+	cancel   context.CancelFunc
+	// closing is closed when shutdown begins.
+	closing chan struct{}
+}
+
+func newLifecycle() *lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &lifecycle{ctx: ctx, cancel: cancel, closing: make(chan struct{})}
+}
+
+// begin registers an outstanding request.  The returned context is
+// canceled when ctx is done or when the client is closed.  The returned
+// function must be called when the request completes.
+func (l *lifecycle) begin(ctx context.Context) (context.Context, func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, nil, ErrClientClosed
+	}
+	l.inflight.Add(1)
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(l.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		l.inflight.Done()
+	}, nil
+}
+
+// shutdown rejects new requests and waits for outstanding requests until
+// ctx is done, at which point outstanding requests are canceled.  It
+// returns false if the lifecycle was already shut down.
+func (l *lifecycle) shutdown(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return false, nil
+	}
+	l.closed = true
+	close(l.closing)
+	l.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		l.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		l.cancel()
+		return true, nil
+	case <-ctx.Done():
+		l.cancel()
+		<-done
+		return true, ctx.Err()
+	}
 }
 
 // rpcres is a type for a partially decoded RPC response.
 type rpcres struct {
-	result      interface{}
-	code        interface{}
-	message     interface{}
-	data        interface{}
-	txID        string
-	comBlockNum uint64
-	simBlockNum uint64
-	errorLevel  int
+	result     interface{}
+	code       interface{}
+	message    interface{}
+	data       interface{}
+	commit     types.CommitMetadata
+	errorLevel int
+	meta       *types.ResponseMetadata
+	// endpoint is the gateway endpoint that returned the response.
+	endpoint string
 }
 
 // scError wraps errors from shiroclient.
@@ -64,14 +180,41 @@ func (e *scError) Error() string {
 	return e.message
 }
 
+// statusError is returned for gateway responses with an HTTP error status
+// whose body is not a JSON-RPC response, e.g. errors of a load balancer in
+// front of the gateway.
+type statusError struct {
+	statusCode int
+	err        error
+}
+
+// Unwrap implements the Wrapper interface from the errors package.
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// Error implements error.
+func (e *statusError) Error() string {
+	return fmt.Sprintf("gateway returned HTTP status %d: %v", e.statusCode, e.err)
+}
+
 // IsTimeoutError inspects an error returned from shiroclient and returns true
-// if it's a timeout.
+// if it's a timeout: a gateway timeout, an exceeded context deadline, a
+// network timeout or an HTTP 504 response.
 func IsTimeoutError(err error) bool {
 	var se *scError
-	if errors.As(err, &se) {
-		return se.code == rpc.ErrorCodeShiroClientTimeout
+	if errors.As(err, &se) && se.code == rpc.ErrorCodeShiroClientTimeout {
+		return true
+	}
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.statusCode == http.StatusGatewayTimeout {
+		return true
 	}
-	return false
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
 // Returns an error object with the same detail message as the
@@ -90,15 +233,22 @@ func (r *rpcres) getShiroClientError() error {
 	}
 }
 
-func (c *rpcShiroClient) doRequest(ctx context.Context, httpClient *http.Client, httpReq *http.Request, log *logrus.Logger) ([]byte, error) {
+// httpResponse is the body and metadata of a completed HTTP request.
+type httpResponse struct {
+	body       []byte
+	statusCode int
+	header     http.Header
+}
+
+func (c *rpcShiroClient) doRequest(ctx context.Context, httpClient *http.Client, httpReq *http.Request, log *logrus.Logger) (*httpResponse, error) {
 	type result struct {
 		err error
-		msg []byte
+		res *httpResponse
 	}
 	resultCh := make(chan result, 1)
 
 	if httpClient == nil {
-		httpClient = &c.httpClient
+		httpClient = &c.transport.client
 	}
 
 	go func() {
@@ -135,7 +285,11 @@ func (c *rpcShiroClient) doRequest(ctx context.Context, httpClient *http.Client,
 		if err != nil {
 			resultCh <- result{err, nil}
 		} else {
-			resultCh <- result{nil, msg}
+			resultCh <- result{nil, &httpResponse{
+				body:       msg,
+				statusCode: httpRes.StatusCode,
+				header:     httpRes.Header,
+			}}
 		}
 	}()
 
@@ -161,7 +315,7 @@ func (c *rpcShiroClient) doRequest(ctx context.Context, httpClient *http.Client,
 			}
 			return nil, err
 		}
-		return res.msg, nil
+		return res.res, nil
 	}
 }
 
@@ -182,38 +336,195 @@ func convertToUint64(value interface{}) (uint64, error) {
 	}
 }
 
+// parseCommit reads the commit metadata from the top-level fields of a
+// response envelope.  Missing or malformed fields are left empty.
+func parseCommit(envelope map[string]interface{}) types.CommitMetadata {
+	var commit types.CommitMetadata
+	commit.TxID, _ = envelope["$commit_tx_id"].(string)
+	commit.CommitBlock, _ = convertToUint64(envelope["$com_block_num"])
+	commit.MaxSimulatedBlock, _ = convertToUint64(envelope["$sim_block_num"])
+	if endorsers, ok := envelope["$endorsers"].([]interface{}); ok {
+		for _, e := range endorsers {
+			if s, ok := e.(string); ok {
+				commit.Endorsers = append(commit.Endorsers, s)
+			}
+		}
+	}
+	if code, ok := envelope["$validation_code"].(string); ok {
+		commit.ValidationCode = types.ParseValidationCode(code)
+	}
+	return commit
+}
+
+// setHeaders sets the headers carried by ctx on req, followed by the
+// headers of opt, which take precedence, the API key and the forwarded log
+// fields.
+func setHeaders(ctx context.Context, req *http.Request, opt *types.RequestOptions) {
+	for k, v := range types.HeadersFromContext(ctx) {
+		req.Header.Set(k, v)
+	}
+	for k, v := range opt.Headers {
+		req.Header.Set(k, v)
+	}
+	if opt.APIKey != "" {
+		req.Header.Set(opt.APIKeyHeader, opt.APIKey)
+	}
+	if len(opt.ForwardLogFields) > 0 {
+		setContextHeader(req, opt)
+	}
+}
+
+// setContextHeader sets the log fields of opt selected by ForwardLogFields
+// on req as a JSON object.  Fields that are not set are omitted.
+func setContextHeader(req *http.Request, opt *types.RequestOptions) {
+	fields := make(map[string]interface{}, len(opt.ForwardLogFields))
+	for _, key := range opt.ForwardLogFields {
+		if v, ok := opt.LogFields[key]; ok {
+			fields[key] = v
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	b, err := opt.JSON.Marshal(fields)
+	if err != nil {
+		if opt.Log != nil {
+			opt.Log.WithFields(opt.LogFields).
+				WithError(err).
+				Warn("ShiroClient: failed to encode forwarded log fields")
+		}
+		return
+	}
+	req.Header.Set(rpc.HeaderContext, string(b))
+}
+
 // reqres is a round-trip "request/response" helper. Marshals "req",
 // logs it at debug level, makes the HTTP request, reads and logs the
 // response at debug level, unmarshals, parses into rpcres.
 func (c *rpcShiroClient) reqres(ctx context.Context, req interface{}, opt *types.RequestOptions) (*rpcres, error) {
-	outmsg, err := json.Marshal(req)
+	stats := &types.CallStats{Endpoint: opt.Endpoint}
+	if r, ok := req.(map[string]interface{}); ok {
+		stats.Method, _ = r["method"].(string)
+		if params, ok := r["params"].(map[string]interface{}); ok && stats.Method == rpc.MethodCall {
+			stats.PhylumMethod, _ = params["method"].(string)
+		}
+	}
+	start := time.Now()
+	res, err := c.roundTrip(ctx, req, opt, stats)
+	if res != nil {
+		res.endpoint = stats.Endpoint
+	}
+	if opt.Stats != nil {
+		stats.Duration = time.Since(start)
+		stats.Err = err
+		if res != nil {
+			stats.ErrorLevel = res.errorLevel
+		}
+		opt.Stats(*stats)
+	}
+	return res, err
+}
+
+// roundTrip implements reqres, recording sizes and retries in stats.
+func (c *rpcShiroClient) roundTrip(ctx context.Context, req interface{}, opt *types.RequestOptions, stats *types.CallStats) (*rpcres, error) {
+	ctx, done, err := c.lifecycle.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	outmsg, err := opt.JSON.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
+	stats.RequestSize = len(outmsg)
 
 	if opt.Endpoint == "" {
 		return nil, errors.New("ShiroClient.reqres expected an endpoint to be set")
 	}
 
-	httpReq, err := http.NewRequest("POST", opt.Endpoint, bytes.NewReader(outmsg))
+	if opt.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opt.Timeout)
+		defer cancel()
+	}
+
+	authToken, err := opt.ResolveAuthToken(ctx)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("ShiroClient.reqres: %w", err)
 	}
 
-	for k, v := range opt.Headers {
-		httpReq.Header.Set(k, v)
+	method := stats.Method
+	// only phylum calls are deduplicated by their idempotency key.
+	idempotencyKey := ""
+	if method == rpc.MethodCall {
+		idempotencyKey = opt.IdempotencyKey
 	}
-	if opt.AuthToken != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+opt.AuthToken)
+
+	var signature, signerCert string
+	if opt.Signer != nil {
+		sig, cert, err := opt.Signer.SignRequest(ctx, outmsg)
+		if err != nil {
+			return nil, fmt.Errorf("ShiroClient.reqres: sign request: %w", err)
+		}
+		signature = base64.StdEncoding.EncodeToString(sig)
+		signerCert = base64.StdEncoding.EncodeToString(cert)
 	}
 
-	// if present, propagate trace from context over HTTP headers
-	tracePropagator.Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
-	msg, err := c.doRequest(ctx, opt.HTTPClient, httpReq, opt.Log)
+	endpoints, err := c.srv.endpoints(ctx, opt.Endpoint, opt.EndpointRefresh)
 	if err != nil {
 		return nil, fmt.Errorf("ShiroClient.reqres: %w", err)
 	}
 
+	var httpRes *httpResponse
+	for attempt := 1; ; attempt++ {
+		// retries fail over to the next discovered gateway, if any.
+		stats.Endpoint = endpoints[(attempt-1)%len(endpoints)]
+		httpReq, err := http.NewRequest("POST", stats.Endpoint, bytes.NewReader(outmsg))
+		if err != nil {
+			return nil, err
+		}
+
+		setHeaders(ctx, httpReq, opt)
+		if authToken != "" {
+			httpReq.Header.Set("Authorization", opt.Authorization(authToken))
+		}
+		if idempotencyKey != "" {
+			httpReq.Header.Set(rpc.HeaderIdempotencyKey, idempotencyKey)
+		}
+		if signature != "" {
+			httpReq.Header.Set(rpc.HeaderSignature, signature)
+		}
+		if signerCert != "" {
+			httpReq.Header.Set(rpc.HeaderSignerCertificate, signerCert)
+		}
+
+		// if present, propagate trace from context over HTTP headers
+		tracePropagator.Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+		traceCtx, tracer := withTrace(ctx, opt, attempt)
+		httpRes, err = c.doRequest(traceCtx, opt.HTTPClient, httpReq, opt.Log)
+		tracer.report(opt)
+		stats.Retries = attempt - 1
+		if err == nil {
+			break
+		}
+		if attempt >= opt.Retry.MaxAttempts || !retryable(method, idempotencyKey != "", err) {
+			return nil, fmt.Errorf("ShiroClient.reqres: %w", err)
+		}
+		if opt.Log != nil {
+			opt.Log.WithFields(opt.LogFields).
+				WithError(err).
+				WithField("attempt", attempt).
+				Warn("ShiroClient.reqres: retrying request")
+		}
+		if err := backoff.Sleep(ctx, retryDelay(opt.Retry, attempt)); err != nil {
+			return nil, fmt.Errorf("ShiroClient.reqres: %w", err)
+		}
+	}
+
+	msg := httpRes.body
+	stats.ResponseSize = len(msg)
+
 	var target *interface{}
 
 	if opt.Target == nil {
@@ -223,13 +534,22 @@ func (c *rpcShiroClient) reqres(ctx context.Context, req interface{}, opt *types
 		target = opt.Target
 	}
 
-	err = json.Unmarshal(msg, target)
+	err = opt.JSON.Unmarshal(msg, target)
 	if err != nil {
+		if httpRes.statusCode >= http.StatusBadRequest {
+			return nil, &statusError{statusCode: httpRes.statusCode, err: err}
+		}
 		return nil, err
 	}
 
 	resArb := *target
 
+	if opt.StrictDecoding {
+		if err := validateStrict(method, resArb, msg); err != nil {
+			return nil, err
+		}
+	}
+
 	resCurly, ok := resArb.(map[string]interface{})
 	if !ok {
 		return nil, errors.New("ShiroClient.reqres expected an object")
@@ -289,32 +609,107 @@ func (c *rpcShiroClient) reqres(ctx context.Context, req interface{}, opt *types
 		return nil, errors.New("ShiroClient.reqres expected a data field")
 	}
 
-	// $transaction_id appears on some requests
-	txID, _ := resCurly["$commit_tx_id"].(string)
-
-	comBlockNum, _ := convertToUint64(resCurly["$com_block_num"])
-
-	simBlockNum, _ := convertToUint64(resCurly["$sim_block_num"])
-
 	return &rpcres{
-		errorLevel:  int(errorLevel),
-		result:      result,
-		code:        code,
-		message:     message,
-		data:        data,
-		txID:        txID,
-		comBlockNum: comBlockNum,
-		simBlockNum: simBlockNum,
+		errorLevel: int(errorLevel),
+		result:     result,
+		code:       code,
+		message:    message,
+		data:       data,
+		commit:     parseCommit(resCurly),
+		meta: &types.ResponseMetadata{
+			Envelope:   msg,
+			StatusCode: httpRes.statusCode,
+			Header:     httpRes.header,
+		},
 	}, nil
 }
 
 // applyConfigs applies configs -- baseConfigs supplied in the
 // constructor first, followed by configs arguments.
 func (c *rpcShiroClient) applyConfigs(configs ...types.Config) (*types.RequestOptions, error) {
-	tConfigs := make([]types.Config, 0, len(c.baseConfig)+len(configs))
-	tConfigs = append(tConfigs, c.baseConfig...)
-	tConfigs = append(tConfigs, configs...)
-	return types.ApplyConfigs(c.defaultLog, tConfigs...), nil
+	return c.applyCallConfigs("", configs...)
+}
+
+// applyCallConfigs is like applyConfigs but applies the method configs of
+// the client for method between the base configs and configs.
+func (c *rpcShiroClient) applyCallConfigs(method string, configs ...types.Config) (*types.RequestOptions, error) {
+	opt, err := c.baseConfig.Apply(c.defaultLog)
+	if err != nil {
+		return nil, err
+	}
+	if defaults := opt.MethodConfigs[method]; len(defaults) > 0 {
+		if err := opt.Apply(defaults...); err != nil {
+			return nil, err
+		}
+	}
+	// the endpoint and method policies of the client cannot be relaxed per
+	// call.
+	base, policy, methods := opt.Endpoint, opt.EndpointPolicy, opt.MethodPolicy
+	if err := opt.Apply(configs...); err != nil {
+		return nil, err
+	}
+	if err := policy.CheckOverride(base, opt.Endpoint); err != nil {
+		return nil, err
+	}
+	opt.MethodPolicy = methods
+	if err := opt.Validate(); err != nil {
+		return nil, err
+	}
+	if opt.Endpoint == "" {
+		return nil, &types.ConfigError{Field: "Endpoint", Reason: "an endpoint is required for RPC"}
+	}
+	return opt, nil
+}
+
+// With returns a client that applies configs after the base configs of c.
+// The returned client shares the HTTP client of c but has its own
+// lifecycle: shutting down either client does not shut down the other, and
+// the shared connections are closed once both are shut down.
+func (c *rpcShiroClient) With(configs ...types.Config) types.ShiroClient {
+	return &rpcShiroClient{
+		baseConfig: c.baseConfig.With(configs...),
+		defaultLog: c.defaultLog,
+		transport:  c.transport.acquire(),
+		tracer:     c.tracer,
+		lifecycle:  newLifecycle(),
+		caps:       c.caps,
+		srv:        c.srv,
+	}
+}
+
+// Shutdown stops the client from accepting new requests and waits for
+// outstanding requests to complete.  If ctx is done first, outstanding
+// requests are canceled and the context error is returned.  Idle
+// connections are closed once no requests remain, unless they are shared
+// with a derived client that is not shut down.  Calling Shutdown or Close
+// more than once is safe; subsequent calls return nil.
+func (c *rpcShiroClient) Shutdown(ctx context.Context) error {
+	first, err := c.lifecycle.shutdown(ctx)
+	if first {
+		c.transport.release()
+	}
+	return err
+}
+
+// Close cancels outstanding requests and releases idle connections.
+func (c *rpcShiroClient) Close() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := c.Shutdown(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// BaseOptions returns a copy of the options resolved from the base configs
+// of c, as they would be before per-call configs are applied.
+func (c *rpcShiroClient) BaseOptions() (types.RequestOptions, error) {
+	opt, err := c.baseConfig.Apply(c.defaultLog)
+	if err != nil {
+		return types.RequestOptions{}, err
+	}
+	return opt.Clone(), nil
 }
 
 // HealthCheck uses the RPC gateway server's health endpoint to check
@@ -330,7 +725,18 @@ func (c *rpcShiroClient) HealthCheck(ctx context.Context, services []string, con
 	if opt.Endpoint == "" {
 		return nil, errors.New("ShiroClient.HealthCheck expected an endpoint to be set")
 	}
-	checkURL, err := gatewayHealthCheckURL(opt.Endpoint, services)
+
+	ctx, done, err := c.lifecycle.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	endpoints, err := c.srv.endpoints(ctx, opt.Endpoint, opt.EndpointRefresh)
+	if err != nil {
+		return nil, fmt.Errorf("healthcheck endpoint: %w", err)
+	}
+	checkURL, err := gatewayHealthCheckURL(endpoints[0], services)
 	if err != nil {
 		return nil, fmt.Errorf("healthcheck invalid endpoint: %w", err)
 	}
@@ -340,13 +746,16 @@ func (c *rpcShiroClient) HealthCheck(ctx context.Context, services []string, con
 	if err != nil {
 		return nil, fmt.Errorf("healthcheck request: %w", err)
 	}
+	setHeaders(ctx, hreq, opt)
 
-	body, err := c.doRequest(ctx, opt.HTTPClient, hreq, c.defaultLog)
+	traceCtx, tracer := withTrace(ctx, opt, 1)
+	hres, err := c.doRequest(traceCtx, opt.HTTPClient, hreq, c.defaultLog)
+	tracer.report(opt)
 	if err != nil {
 		return nil, fmt.Errorf("healthcheck perform: %w", err)
 	}
 
-	resp, err := unmarshalHealthResponse(body)
+	resp, err := unmarshalHealthResponse(hres.body)
 	if err != nil {
 		return nil, fmt.Errorf("healthcheck bad response: %w", err)
 	}
@@ -479,8 +888,11 @@ func (c *rpcShiroClient) Init(ctx context.Context, phylum string, configs ...typ
 
 	switch res.errorLevel {
 	case rpc.ErrorLevelNoError:
-		resultJSON, _ := json.Marshal(res.result)
-		res := types.NewSuccessResponse(resultJSON, res.txID, res.comBlockNum, res.simBlockNum)
+		resultJSON, _ := opt.JSON.Marshal(res.result)
+		meta, commit := res.meta, res.commit
+		res := types.NewSuccessResponse(resultJSON, "", 0, 0)
+		res.SetCommit(&commit)
+		res.SetMetadata(meta)
 		if opt.ResponseReceiver != nil {
 			opt.ResponseReceiver(res)
 		}
@@ -499,19 +911,47 @@ func (c *rpcShiroClient) Init(ctx context.Context, phylum string, configs ...typ
 func (c *rpcShiroClient) Call(ctx context.Context, method string, configs ...types.Config) (types.ShiroResponse, error) {
 	ctx, span := c.tracer.Start(ctx, "sdk:Call "+method)
 	defer span.End()
-	opt, err := c.applyConfigs(configs...)
+	opt, err := c.applyCallConfigs(method, configs...)
 	if err != nil {
 		return nil, err
 	}
+	start := time.Now()
+	resp, err := c.callPolicy(ctx, method, opt)
+	resp, err = opt.ValidateResponse(method, resp, err)
+	opt.RecordCall(ctx, method, start, resp, err)
+	return resp, err
+}
+
+// callPolicy calls method if the method policy of opt allows it.
+func (c *rpcShiroClient) callPolicy(ctx context.Context, method string, opt *types.RequestOptions) (types.ShiroResponse, error) {
+	if err := opt.MethodPolicy.CheckMethod(method); err != nil {
+		return nil, err
+	}
+
+	opt.InjectTraceTransient(ctx)
+
+	if opt.ConflictRetry.MaxAttempts > 1 {
+		return c.callRetryConflicts(ctx, method, opt)
+	}
+	return c.call(ctx, method, opt)
+}
+
+// call sends a single Call request for method.
+func (c *rpcShiroClient) call(ctx context.Context, method string, opt *types.RequestOptions) (types.ShiroResponse, error) {
+	encoding := c.transientEncoding(ctx, opt)
+	encode := hex.EncodeToString
+	if encoding == rpc.TransientEncodingBase64 {
+		encode = base64.StdEncoding.EncodeToString
+	}
 
 	transientJSON := make(map[string]interface{})
 
 	for k, v := range opt.Transient {
-		transientJSON[k] = hex.EncodeToString(v)
+		transientJSON[k] = encode(v)
 	}
 
 	if opt.TimestampGenerator != nil {
-		transientJSON["timestamp_override"] = hex.EncodeToString([]byte(opt.TimestampGenerator(ctx)))
+		transientJSON["timestamp_override"] = encode([]byte(opt.TimestampGenerator(ctx)))
 	}
 
 	params := map[string]interface{}{
@@ -519,6 +959,9 @@ func (c *rpcShiroClient) Call(ctx context.Context, method string, configs ...typ
 		"params":    opt.Params,
 		"transient": transientJSON,
 	}
+	if encoding != rpc.TransientEncodingHex {
+		params["transient_encoding"] = encoding
+	}
 	if opt.DependentTxID != "" {
 		params["dependent_txid"] = opt.DependentTxID
 	}
@@ -531,8 +974,17 @@ func (c *rpcShiroClient) Call(ctx context.Context, method string, configs ...typ
 	if opt.NewPhylumVersion != "" {
 		params["new_phylum_version"] = opt.NewPhylumVersion
 	}
-	if opt.DisableWritePolling {
-		params["disable_write_polling"] = opt.DisableWritePolling
+	// with a progress callback, commit is observed by the client rather
+	// than the gateway so that intermediate stages can be reported, and
+	// with a commit stats callback so that the commit latency can be
+	// measured.  The same goes for conflict retries, which need the
+	// validation code of the committed transaction.
+	clientPolling := (opt.WriteProgress != nil || opt.CommitStats != nil || opt.ConflictRetry.MaxAttempts > 1) && !opt.DisableWritePolling
+	if opt.DisableWritePolling || clientPolling {
+		params["disable_write_polling"] = true
+	}
+	if opt.IdempotencyKey != "" {
+		params["idempotency_key"] = opt.IdempotencyKey
 	}
 	params["cc_fetchurl_downgrade"] = opt.CcFetchURLDowngrade
 	if opt.CcFetchURLProxy != nil {
@@ -552,6 +1004,10 @@ func (c *rpcShiroClient) Call(ctx context.Context, method string, configs ...typ
 		req["params"].(map[string]interface{})["msp_filter"] = opt.MspFilter
 	}
 
+	if len(opt.PrivateCollections) > 0 {
+		req["params"].(map[string]interface{})["private_collections"] = opt.PrivateCollections
+	}
+
 	if opt.MinEndorsers > 0 {
 		req["params"].(map[string]interface{})["min_endorsers"] = opt.MinEndorsers
 	}
@@ -575,12 +1031,47 @@ func (c *rpcShiroClient) Call(ctx context.Context, method string, configs ...typ
 
 	switch res.errorLevel {
 	case rpc.ErrorLevelNoError:
-		resultJSON, err := json.Marshal(res.result)
+		resultJSON, err := opt.JSON.Marshal(res.result)
 		if err != nil {
 			return nil, err
 		}
 
-		res := types.NewSuccessResponse(resultJSON, res.txID, res.comBlockNum, res.simBlockNum)
+		commit := res.commit
+		progress := opt.WriteProgress
+		if progress == nil {
+			progress = func(types.WriteProgress) {}
+		}
+		progress(types.WriteProgress{Stage: types.WriteSimulated, TxID: commit.TxID, BlockNum: commit.MaxSimulatedBlock})
+		if commit.TxID != "" {
+			progress(types.WriteProgress{Stage: types.WriteSubmitted, TxID: commit.TxID})
+			if clientPolling {
+				submitted := time.Now()
+				pending := c.pendingTx(opt, commit.TxID, commit.MaxSimulatedBlock)
+				commit.CommitBlock, err = pending.Wait(ctx)
+				if err != nil {
+					return nil, &PendingWriteError{Pending: pending, Err: err}
+				}
+				if opt.CommitStats != nil {
+					opt.CommitStats(types.CommitStats{
+						PhylumMethod: method,
+						Endpoint:     res.endpoint,
+						TxID:         commit.TxID,
+						CommitBlock:  commit.CommitBlock,
+						Latency:      time.Since(submitted),
+					})
+				}
+				if commit.ValidationCode == "" {
+					commit.ValidationCode = pending.ValidationCode()
+				}
+			} else if commit.CommitBlock > 0 {
+				progress(types.WriteProgress{Stage: types.WriteCommitted, TxID: commit.TxID, BlockNum: commit.CommitBlock})
+			}
+		}
+
+		meta := res.meta
+		res := types.NewSuccessResponse(resultJSON, "", 0, 0)
+		res.SetCommit(&commit)
+		res.SetMetadata(meta)
 		if opt.ResponseReceiver != nil {
 			opt.ResponseReceiver(res)
 		}
@@ -591,7 +1082,7 @@ func (c *rpcShiroClient) Call(ctx context.Context, method string, configs ...typ
 		return nil, res.getShiroClientError()
 
 	case rpc.ErrorLevelPhylum:
-		dataJSON, err := json.Marshal(res.data)
+		dataJSON, err := opt.JSON.Marshal(res.data)
 		if err != nil {
 			return nil, err
 		}
@@ -606,7 +1097,9 @@ func (c *rpcShiroClient) Call(ctx context.Context, method string, configs ...typ
 			return nil, errors.New("ShiroClient.Call expected a string message field")
 		}
 
+		meta := res.meta
 		res := types.NewFailureResponse(int(code), message, dataJSON)
+		res.SetMetadata(meta)
 
 		if opt.ResponseReceiver != nil {
 			opt.ResponseReceiver(res)
@@ -619,6 +1112,63 @@ func (c *rpcShiroClient) Call(ctx context.Context, method string, configs ...typ
 	}
 }
 
+// callRetryConflicts calls method until its transaction is not invalidated
+// by a read conflict, up to opt.ConflictRetry.MaxAttempts times.
+// ResponseReceiver only receives the final response.
+func (c *rpcShiroClient) callRetryConflicts(ctx context.Context, method string, opt *types.RequestOptions) (types.ShiroResponse, error) {
+	attemptOpt := *opt
+	attemptOpt.ResponseReceiver = nil
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && opt.IdempotencyKey != "" {
+			// the invalidated transaction had no effect, and the gateway
+			// would return it again for the same key.
+			attemptOpt.IdempotencyKey = fmt.Sprintf("%s-conflict-%d", opt.IdempotencyKey, attempt)
+		}
+		resp, err := c.call(ctx, method, &attemptOpt)
+		if err != nil {
+			return nil, err
+		}
+		var commit *types.CommitMetadata
+		if r, ok := resp.(types.CommitResponse); ok {
+			commit = r.Commit()
+		}
+		if commit == nil || !commit.ValidationCode.Retryable() {
+			if opt.ResponseReceiver != nil {
+				opt.ResponseReceiver(resp)
+			}
+			return resp, nil
+		}
+		if attempt >= opt.ConflictRetry.MaxAttempts {
+			return nil, &ConflictError{TxID: commit.TxID, Code: commit.ValidationCode, Attempts: attempt}
+		}
+		if opt.Log != nil {
+			opt.Log.WithFields(opt.LogFields).
+				WithField("tx_id", commit.TxID).
+				WithField("validation_code", commit.ValidationCode).
+				Debug("retrying call after transaction conflict")
+		}
+		if err := backoff.Sleep(ctx, retryDelay(opt.ConflictRetry, attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ConflictError is returned when a call with conflict retries enabled was
+// invalidated by a read conflict on every attempt.
+type ConflictError struct {
+	// TxID is the ID of the last invalidated transaction.
+	TxID string
+	// Code is the validation code of the last invalidated transaction.
+	Code types.ValidationCode
+	// Attempts is the number of times the call was submitted.
+	Attempts int
+}
+
+// Error implements error.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("transaction %s invalidated with %s after %d attempts", e.TxID, e.Code, e.Attempts)
+}
+
 // QueryInfo implements the ShiroClient interface.
 func (c *rpcShiroClient) QueryInfo(ctx context.Context, configs ...types.Config) (uint64, error) {
 	ctx, span := c.tracer.Start(ctx, "sdk:QueryInfo")
@@ -803,7 +1353,24 @@ func (c *rpcShiroClient) QueryBlock(ctx context.Context, blockNumber uint64, con
 			transactions[i] = types.NewTransaction(txid, reasonsOut[i], eventsOut[i], ccidsOut[i])
 		}
 
-		return types.NewBlock(blockHash, transactions), nil
+		// block header, returned by gateways that support receipts
+
+		headerArb, ok := res["block_header"]
+		if !ok || headerArb == nil {
+			return types.NewBlock(blockHash, transactions), nil
+		}
+
+		headerJSON, err := json.Marshal(headerArb)
+		if err != nil {
+			return nil, fmt.Errorf("ShiroClient.QueryBlock invalid block_header field: %w", err)
+		}
+
+		header := &types.BlockHeader{}
+		if err := json.Unmarshal(headerJSON, header); err != nil {
+			return nil, fmt.Errorf("ShiroClient.QueryBlock invalid block_header field: %w", err)
+		}
+
+		return types.NewBlockWithHeader(blockHash, transactions, header), nil
 
 	case rpc.ErrorLevelShiroClient:
 		return nil, res.getShiroClientError()
@@ -816,10 +1383,18 @@ func (c *rpcShiroClient) QueryBlock(ctx context.Context, blockNumber uint64, con
 // NewRPC creates a new RPC ShiroClient with the given set of base
 // configs that will be applied to all commands.
 func NewRPC(clientConfigs []types.Config) types.ShiroClient {
-	return &rpcShiroClient{
-		baseConfig: clientConfigs,
+	c := &rpcShiroClient{
+		baseConfig: types.NewConfigSet(clientConfigs...),
 		defaultLog: logrus.New(),
-		httpClient: http.Client{},
+		transport:  newSharedTransport(),
 		tracer:     otel.GetTracerProvider().Tracer("shiroclient-sdk-go"),
+		lifecycle:  newLifecycle(),
+		caps:       newCapabilityCache(),
+		srv:        newSRVResolver(),
+	}
+	// invalid base configs are reported by the first request instead.
+	if opt, err := c.applyConfigs(); err == nil && opt.KeepAlive > 0 {
+		go c.keepAlive(opt)
 	}
+	return c
 }
@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := NewRPC([]types.Config{
+		types.Opt(func(r *types.RequestOptions) { r.Endpoint = srv.URL }),
+	}).(*rpcShiroClient)
+
+	callErr := make(chan error, 1)
+	go func() {
+		_, err := client.QueryInfo(context.Background())
+		callErr <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := client.Shutdown(ctx)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+	require.True(t, errors.Is(<-callErr, context.Canceled))
+
+	_, err = client.QueryInfo(context.Background())
+	require.True(t, errors.Is(err, ErrClientClosed))
+
+	require.NoError(t, client.Shutdown(context.Background()))
+	require.NoError(t, client.Close())
+}
+
+func TestShutdownDerived(t *testing.T) {
+	var mu sync.Mutex
+	closed := 0
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":"1","result":{"error_level":0,"result":3,"code":0,"message":"","data":null}}`))
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			mu.Lock()
+			closed++
+			mu.Unlock()
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	closedConns := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return closed
+	}
+
+	base := NewRPC([]types.Config{
+		types.Opt(func(r *types.RequestOptions) { r.Endpoint = srv.URL }),
+	}).(*rpcShiroClient)
+	derived := base.With()
+	ctx := context.Background()
+	_, err := derived.QueryInfo(ctx)
+	require.NoError(t, err)
+
+	// the connection shared with the base client stays open.
+	require.NoError(t, derived.(*rpcShiroClient).Close())
+	_, err = derived.QueryInfo(ctx)
+	require.True(t, errors.Is(err, ErrClientClosed))
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, 0, closedConns())
+	_, err = base.QueryInfo(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, base.Close())
+	require.Eventually(t, func() bool { return closedConns() == 1 }, time.Second, time.Millisecond)
+}
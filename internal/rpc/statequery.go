@@ -0,0 +1,74 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+)
+
+// ErrStateQueryUnsupported is returned for state queries issued to clients
+// or gateways that do not support them.
+var ErrStateQueryUnsupported = errors.New("shiroclient: state queries are not supported")
+
+// stateQuerier is an internal interface implemented by clients that can
+// read ledger state through the gateway.
+type stateQuerier interface {
+	stateQuery(ctx context.Context, method string, params map[string]interface{}, configs ...types.Config) (interface{}, error)
+}
+
+var _ stateQuerier = (*rpcShiroClient)(nil)
+
+// StateQuery sends the state query method with params through client and
+// returns the decoded result.  ErrStateQueryUnsupported is returned if
+// client is not an RPC client or its gateway does not advertise the
+// state_query capability.
+func StateQuery(ctx context.Context, client types.ShiroClient, method string, params map[string]interface{}, configs ...types.Config) (interface{}, error) {
+	q, ok := client.(stateQuerier)
+	if !ok {
+		return nil, ErrStateQueryUnsupported
+	}
+	return q.stateQuery(ctx, method, params, configs...)
+}
+
+func (c *rpcShiroClient) stateQuery(ctx context.Context, method string, params map[string]interface{}, configs ...types.Config) (interface{}, error) {
+	ctx, span := c.tracer.Start(ctx, "sdk:"+method)
+	defer span.End()
+	opt, err := c.applyConfigs(configs...)
+	if err != nil {
+		return nil, err
+	}
+
+	caps, err := c.capabilities(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	if !caps[rpc.CapabilityStateQuery] {
+		return nil, ErrStateQueryUnsupported
+	}
+
+	req := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      opt.ID,
+		"method":  method,
+		"params":  params,
+	}
+
+	res, err := c.reqres(ctx, req, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.errorLevel {
+	case rpc.ErrorLevelNoError:
+		return res.result, nil
+
+	case rpc.ErrorLevelShiroClient:
+		return nil, res.getShiroClientError()
+
+	default:
+		return nil, fmt.Errorf("ShiroClient.%s unexpected error level %d", method, res.errorLevel)
+	}
+}
@@ -1,8 +1,18 @@
 package rpc
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsTimeoutError(t *testing.T) {
@@ -17,3 +27,76 @@ func TestIsTimeoutError(t *testing.T) {
 		t.Errorf("IsTimeoutError failed to identify a wrapped timeout error")
 	}
 }
+
+// netTimeout is a net.Error reporting a timeout.
+type netTimeout struct{}
+
+func (netTimeout) Error() string   { return "i/o timeout" }
+func (netTimeout) Timeout() bool   { return true }
+func (netTimeout) Temporary() bool { return true }
+
+func TestIsTimeoutErrorWrapped(t *testing.T) {
+	for name, err := range map[string]error{
+		"deadline":       fmt.Errorf("ShiroClient.reqres: %w", context.DeadlineExceeded),
+		"net":            fmt.Errorf("ShiroClient.reqres: %w", &net.OpError{Op: "read", Net: "tcp", Err: netTimeout{}}),
+		"url":            &url.Error{Op: "Post", URL: "http://gateway", Err: netTimeout{}},
+		"gateway status": fmt.Errorf("ShiroClient.reqres: %w", &statusError{statusCode: http.StatusGatewayTimeout, err: errors.New("invalid character '<'")}),
+	} {
+		require.True(t, IsTimeoutError(err), name)
+	}
+	for name, err := range map[string]error{
+		"nil":      nil,
+		"canceled": context.Canceled,
+		"code":     &scError{message: "error", code: 2},
+		"status":   &statusError{statusCode: http.StatusBadGateway, err: errors.New("invalid character '<'")},
+		"net":      &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+	} {
+		require.False(t, IsTimeoutError(err), name)
+	}
+}
+
+func TestGatewayTimeoutStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		_, _ = w.Write([]byte("<html>504 Gateway Time-out</html>"))
+	}))
+	t.Cleanup(srv.Close)
+	client := NewRPC([]types.Config{types.Opt(func(r *types.RequestOptions) { r.Endpoint = srv.URL })})
+	_, err := client.Call(context.Background(), "ping")
+	require.Error(t, err)
+	require.True(t, IsTimeoutError(err))
+	require.Contains(t, err.Error(), "HTTP status 504")
+}
+
+func TestContextPrecedence(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	endpoint := types.Opt(func(r *types.RequestOptions) { r.Endpoint = srv.URL })
+	timeout := func(d time.Duration) types.Config {
+		return types.Opt(func(r *types.RequestOptions) { r.Timeout = d })
+	}
+
+	// the earlier of the context deadline and the timeout applies.
+	client := NewRPC([]types.Config{endpoint, timeout(time.Hour)})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.Call(ctx, "get")
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+
+	client = NewRPC([]types.Config{endpoint})
+	_, err = client.Call(context.Background(), "get", timeout(20*time.Millisecond))
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+
+	// cancelling the context aborts the request.
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err = client.Call(ctx, "get", timeout(time.Hour))
+	require.True(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+}
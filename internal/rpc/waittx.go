@@ -0,0 +1,125 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/backoff"
+)
+
+// DefaultWritePollInterval is the interval between ledger height checks
+// while waiting for a transaction to commit.
+const DefaultWritePollInterval = time.Second
+
+// PendingTx is a handle on a submitted transaction whose commit has not
+// been observed yet.
+type PendingTx struct {
+	client    types.ShiroClient
+	configs   []types.Config
+	progress  func(types.WriteProgress)
+	txID      string
+	interval  time.Duration
+	nextBlock uint64
+	code      types.ValidationCode
+}
+
+// NewPendingTx returns a handle to wait for txID to commit in a block
+// numbered fromBlock or later, using client to query the ledger with
+// configs.  A zero interval uses DefaultWritePollInterval.
+func NewPendingTx(client types.ShiroClient, txID string, fromBlock uint64, interval time.Duration, configs ...types.Config) *PendingTx {
+	if interval <= 0 {
+		interval = DefaultWritePollInterval
+	}
+	return &PendingTx{
+		client:    client,
+		configs:   configs,
+		txID:      txID,
+		interval:  interval,
+		nextBlock: fromBlock,
+	}
+}
+
+// TxID returns the ID of the pending transaction.
+func (p *PendingTx) TxID() string {
+	return p.txID
+}
+
+// ValidationCode returns the validation code of the transaction once Wait
+// has found it, or an empty code before.
+func (p *PendingTx) ValidationCode() types.ValidationCode {
+	return p.code
+}
+
+// Wait polls the ledger until the transaction is found in a block and
+// returns the block number.  If ctx is done first, the context error is
+// returned and Wait may be called again to resume polling from the last
+// block that was checked.
+func (p *PendingTx) Wait(ctx context.Context) (uint64, error) {
+	for {
+		height, err := p.client.QueryInfo(ctx, p.configs...)
+		if err != nil {
+			return 0, err
+		}
+		for ; p.nextBlock < height; p.nextBlock++ {
+			blk, err := p.client.QueryBlock(ctx, p.nextBlock, p.configs...)
+			if err != nil {
+				return 0, err
+			}
+			for _, tx := range blk.Transactions() {
+				if tx.ID() == p.txID {
+					p.code = types.ParseValidationCode(tx.Reason())
+					if p.progress != nil {
+						p.progress(types.WriteProgress{Stage: types.WriteCommitted, TxID: p.txID, BlockNum: p.nextBlock})
+					}
+					return p.nextBlock, nil
+				}
+			}
+		}
+		if err := backoff.Sleep(ctx, p.interval); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// PendingWriteError is returned when a write was submitted but its commit
+// was not observed before the request context was done.  The write may
+// still commit; use Pending to resume waiting.
+type PendingWriteError struct {
+	// Pending can be used to resume waiting for the commit.
+	Pending *PendingTx
+	// Err is the reason waiting stopped.
+	Err error
+}
+
+// Error implements error.
+func (e *PendingWriteError) Error() string {
+	return fmt.Sprintf("transaction %s submitted but commit not observed: %v", e.Pending.txID, e.Err)
+}
+
+// Unwrap implements the Wrapper interface from the errors package.
+func (e *PendingWriteError) Unwrap() error {
+	return e.Err
+}
+
+// pendingTx returns a handle that polls the gateway targeted by opt.
+func (c *rpcShiroClient) pendingTx(opt *types.RequestOptions, txID string, simBlockNum uint64) *PendingTx {
+	conn := opt.Clone()
+	p := NewPendingTx(c, txID, simBlockNum+1, opt.WritePollInterval, types.Opt(func(r *types.RequestOptions) {
+		// reuse connection settings of the original request only.
+		r.Endpoint = conn.Endpoint
+		r.EndpointRefresh = conn.EndpointRefresh
+		r.HTTPClient = conn.HTTPClient
+		r.Headers = conn.Headers
+		r.AuthToken = conn.AuthToken
+		r.AuthTokenProvider = conn.AuthTokenProvider
+		r.AuthScheme = conn.AuthScheme
+		r.APIKeyHeader = conn.APIKeyHeader
+		r.APIKey = conn.APIKey
+		r.Log = conn.Log
+		r.LogFields = conn.LogFields
+	}))
+	p.progress = opt.WriteProgress
+	return p
+}
@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteProgress(t *testing.T) {
+	var height int32 = 3
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		switch req.Method {
+		case rpc.MethodCall:
+			return "ok", rpc.ErrorLevelNoError
+		case rpc.MethodQueryInfo:
+			// the transaction commits in block 3 after the first poll.
+			return atomic.AddInt32(&height, 1) - 1, rpc.ErrorLevelNoError
+		case rpc.MethodQueryBlock:
+			if req.Params["block_number"] == float64(3) {
+				return block("other", "tx1"), rpc.ErrorLevelNoError
+			}
+			return block(), rpc.ErrorLevelNoError
+		}
+		return nil, rpc.ErrorLevelShiroClient
+	})
+	gw.envelope = func(req *gatewayRequest) map[string]interface{} {
+		if req.Method != rpc.MethodCall {
+			return nil
+		}
+		return map[string]interface{}{"$commit_tx_id": "tx1", "$sim_block_num": 1}
+	}
+
+	var stages []types.WriteProgress
+	client := gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.WriteProgress = func(p types.WriteProgress) { stages = append(stages, p) }
+		r.WritePollInterval = time.Millisecond
+	}))
+	resp, err := client.Call(context.Background(), "write")
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), resp.CommitBlockNum())
+	require.Equal(t, []types.WriteProgress{
+		{Stage: types.WriteSimulated, TxID: "tx1", BlockNum: 1},
+		{Stage: types.WriteSubmitted, TxID: "tx1"},
+		{Stage: types.WriteCommitted, TxID: "tx1", BlockNum: 3},
+	}, stages)
+	require.Equal(t, true, gw.Requests()[0].Params["disable_write_polling"])
+}
+
+func TestCommitStats(t *testing.T) {
+	var height int32 = 2
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		switch req.Method {
+		case rpc.MethodCall:
+			return "ok", rpc.ErrorLevelNoError
+		case rpc.MethodQueryInfo:
+			// the transaction commits in block 3 after two polls.
+			return atomic.AddInt32(&height, 1) - 1, rpc.ErrorLevelNoError
+		case rpc.MethodQueryBlock:
+			if req.Params["block_number"] == float64(3) {
+				return block("tx1"), rpc.ErrorLevelNoError
+			}
+			return block(), rpc.ErrorLevelNoError
+		}
+		return nil, rpc.ErrorLevelShiroClient
+	})
+	gw.envelope = func(req *gatewayRequest) map[string]interface{} {
+		if req.Method != rpc.MethodCall || req.Params["method"] == "read" {
+			return nil
+		}
+		return map[string]interface{}{"$commit_tx_id": "tx1", "$sim_block_num": 1}
+	}
+
+	var stats []types.CommitStats
+	client := gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.CommitStats = func(s types.CommitStats) { stats = append(stats, s) }
+		r.WritePollInterval = 5 * time.Millisecond
+	}))
+	_, err := client.Call(context.Background(), "read")
+	require.NoError(t, err)
+	require.Empty(t, stats)
+	resp, err := client.Call(context.Background(), "write")
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), resp.CommitBlockNum())
+	require.Len(t, stats, 1)
+	require.Equal(t, "write", stats[0].PhylumMethod)
+	require.Equal(t, gw.URL, stats[0].Endpoint)
+	require.Equal(t, "tx1", stats[0].TxID)
+	require.Equal(t, uint64(3), stats[0].CommitBlock)
+	require.GreaterOrEqual(t, stats[0].Latency, 5*time.Millisecond)
+	require.Equal(t, true, gw.Requests()[1].Params["disable_write_polling"])
+}
+
+func TestPendingWriteError(t *testing.T) {
+	gw := newTestGateway(t, func(req *gatewayRequest) (interface{}, int) {
+		switch req.Method {
+		case rpc.MethodQueryInfo:
+			return 2, rpc.ErrorLevelNoError
+		case rpc.MethodQueryBlock:
+			return block(), rpc.ErrorLevelNoError
+		}
+		return "ok", rpc.ErrorLevelNoError
+	})
+	gw.envelope = func(req *gatewayRequest) map[string]interface{} {
+		return map[string]interface{}{"$commit_tx_id": "tx1", "$sim_block_num": 1}
+	}
+	client := gw.client(types.Opt(func(r *types.RequestOptions) {
+		r.WriteProgress = func(types.WriteProgress) {}
+		r.WritePollInterval = time.Millisecond
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.Call(ctx, "write")
+	var perr *PendingWriteError
+	require.True(t, errors.As(err, &perr), "unexpected error: %v", err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Equal(t, "tx1", perr.Pending.TxID())
+}
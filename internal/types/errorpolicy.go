@@ -0,0 +1,110 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrMaskedPhylumError is returned in place of a phylum error whose detail
+// is masked by an ErrorPolicy.
+var ErrMaskedPhylumError = errors.New("unknown phylum error")
+
+// PhylumError is a phylum error returned with the detail allowed by an
+// ErrorPolicy.
+type PhylumError struct {
+	// Code categorizes the error.
+	Code int
+	// Message is the generic message of the error code.
+	Message string
+	// DataJSON is the JSON data returned by the phylum with the error, if
+	// any, e.g. the fields failing validation.  It may contain sensitive
+	// data and should not be logged.
+	DataJSON json.RawMessage
+}
+
+// Error returns the error data if it is a JSON string, as route failures
+// are, and the generic message otherwise.
+func (e *PhylumError) Error() string {
+	var msg string
+	if err := json.Unmarshal(e.DataJSON, &msg); err == nil {
+		return msg
+	}
+	return e.Message
+}
+
+// UnmarshalData decodes the error data into v.
+func (e *PhylumError) UnmarshalData(v interface{}) error {
+	if len(e.DataJSON) == 0 {
+		return errors.New("phylum error has no data")
+	}
+	return json.Unmarshal(e.DataJSON, v)
+}
+
+// ErrorPolicy determines the detail of phylum errors returned to callers,
+// which may contain PII.
+type ErrorPolicy int
+
+// Error policies, from the least to the most detailed.
+const (
+	// ErrorPolicyDefault leaves the policy to the package handling the
+	// error.
+	ErrorPolicyDefault ErrorPolicy = iota
+	// ErrorPolicyMask returns ErrMaskedPhylumError for all errors.
+	ErrorPolicyMask
+	// ErrorPolicyCodes returns a *PhylumError without its data.
+	ErrorPolicyCodes
+	// ErrorPolicyMessages returns the error data if it is a JSON string,
+	// as route failures are, and ErrMaskedPhylumError otherwise.
+	ErrorPolicyMessages
+	// ErrorPolicyFull returns a *PhylumError with its data.
+	ErrorPolicyFull
+)
+
+func (p ErrorPolicy) String() string {
+	switch p {
+	case ErrorPolicyDefault:
+		return "default"
+	case ErrorPolicyMask:
+		return "mask"
+	case ErrorPolicyCodes:
+		return "codes"
+	case ErrorPolicyMessages:
+		return "messages"
+	case ErrorPolicyFull:
+		return "full"
+	default:
+		return fmt.Sprintf("ErrorPolicy(%d)", int(p))
+	}
+}
+
+// Or returns p, or def if p is ErrorPolicyDefault.
+func (p ErrorPolicy) Or(def ErrorPolicy) ErrorPolicy {
+	if p == ErrorPolicyDefault {
+		return def
+	}
+	return p
+}
+
+// PhylumError returns the error to return to callers for e.  Unknown
+// policies, including ErrorPolicyDefault, mask the error.
+func (p ErrorPolicy) PhylumError(e Error) error {
+	switch p {
+	case ErrorPolicyCodes:
+		return &PhylumError{Code: e.Code(), Message: e.Message()}
+	case ErrorPolicyMessages:
+		var msg string
+		if err := json.Unmarshal(e.DataJSON(), &msg); err == nil {
+			return errors.New(msg)
+		}
+		return ErrMaskedPhylumError
+	case ErrorPolicyFull:
+		return &PhylumError{
+			Code:     e.Code(),
+			Message:  e.Message(),
+			DataJSON: append(json.RawMessage(nil), e.DataJSON()...),
+		}
+	default:
+		return ErrMaskedPhylumError
+	}
+}
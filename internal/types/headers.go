@@ -0,0 +1,35 @@
+package types
+
+import (
+	"context"
+)
+
+// headersKey is the context key of headers stashed by ContextWithHeaders.
+type headersKey struct{}
+
+// ContextWithHeaders returns a copy of ctx carrying headers, merged over any
+// headers already carried by ctx.
+func ContextWithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := HeadersFromContext(ctx)
+	if merged == nil {
+		merged = make(map[string]string, len(headers))
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+// HeadersFromContext returns a copy of the headers carried by ctx, or nil if
+// there are none.
+func HeadersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	if headers == nil {
+		return nil
+	}
+	out := make(map[string]string, len(headers))
+	for k, v := range headers {
+		out[k] = v
+	}
+	return out
+}
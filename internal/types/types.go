@@ -7,12 +7,24 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	//nolint:staticcheck // Deprecated package "github.com/golang/protobuf/jsonpb" used for backwards compatibility
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/google/uuid"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/runtime/protoiface"
@@ -21,6 +33,11 @@ import (
 // ShiroClient is an abstraction for a connection to a
 // blockchain-based smart contract execution engine. Currently, the
 // "phylum" code must be written in a LISP dialect known as Elps.
+//
+// The context passed to each method is the only context of a request: it
+// cancels the request and carries its deadline and trace span.  There is
+// no config carrying a context.  A timeout set with a config bounds a
+// request further, the earlier of the two deadlines applying.
 type ShiroClient interface {
 	// Seed re-opens the ShiroClient, specifying the phylum version to
 	// target.
@@ -66,13 +83,106 @@ func Opt(fn func(r *RequestOptions)) Config {
 	return &standardConfig{fn}
 }
 
+type errConfig struct {
+	fn func(*RequestOptions) error
+}
+
+// Fn implements Config.  Errors are recorded on the RequestOptions and
+// reported by ApplyConfigs.
+func (s *errConfig) Fn(r *RequestOptions) {
+	if err := s.fn(r); err != nil {
+		r.configErrs = append(r.configErrs, err)
+	}
+}
+
+// OptErr creates a configuration option whose application may fail.
+func OptErr(fn func(r *RequestOptions) error) Config {
+	return &errConfig{fn}
+}
+
 // Config is a type for a function that can mutate a types.RequestOptions
 // object.
 type Config interface {
 	Fn(*RequestOptions)
 }
 
-func ApplyConfigs(log *logrus.Logger, configs ...Config) *RequestOptions {
+// ConfigSet is an immutable sequence of configs.  Its methods never modify
+// the receiver or slices passed to them, so a ConfigSet can be shared by
+// concurrent requests and derived clients without copying.  The zero value
+// is an empty set.
+type ConfigSet struct {
+	configs []Config
+}
+
+// NewConfigSet returns a set holding a copy of configs.
+func NewConfigSet(configs ...Config) ConfigSet {
+	return ConfigSet{}.With(configs...)
+}
+
+// With returns a set holding the configs of s followed by configs.  s is
+// not modified.
+func (s ConfigSet) With(configs ...Config) ConfigSet {
+	if len(configs) == 0 {
+		return s
+	}
+	out := make([]Config, 0, len(s.configs)+len(configs))
+	out = append(out, s.configs...)
+	out = append(out, configs...)
+	return ConfigSet{configs: out}
+}
+
+// Len returns the number of configs in s.
+func (s ConfigSet) Len() int {
+	return len(s.configs)
+}
+
+// Configs returns a copy of the configs in s.
+func (s ConfigSet) Configs() []Config {
+	return append([]Config(nil), s.configs...)
+}
+
+// Apply applies the configs of s followed by configs to fresh
+// RequestOptions.  See ApplyConfigs.
+func (s ConfigSet) Apply(log *logrus.Logger, configs ...Config) (*RequestOptions, error) {
+	return ApplyConfigs(log, s.With(configs...).configs...)
+}
+
+// Apply applies configs to r.  An error is returned if any config failed to
+// apply.
+func (r *RequestOptions) Apply(configs ...Config) error {
+	for _, config := range configs {
+		config.Fn(r)
+	}
+	return errors.Join(r.configErrs...)
+}
+
+// ConfigError is returned when a Config cannot be applied or when the
+// resulting RequestOptions are invalid.
+type ConfigError struct {
+	// Field names the offending option.
+	Field string
+	// Reason describes why the option is invalid.
+	Reason string
+}
+
+// Error implements error.
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid config %s: %s", e.Field, e.Reason)
+}
+
+// ApplyConfigs applies configs in order and returns the resulting
+// RequestOptions.  An error is returned if any config failed to apply.
+func ApplyConfigs(log *logrus.Logger, configs ...Config) (*RequestOptions, error) {
+	opt, err := ApplyConfigsPartial(log, configs...)
+	if err != nil {
+		return nil, err
+	}
+	return opt, nil
+}
+
+// ApplyConfigsPartial is like ApplyConfigs but always returns the options,
+// together with the errors of the configs that failed to apply.
+func ApplyConfigsPartial(log *logrus.Logger, configs ...Config) (*RequestOptions, error) {
 	uuid, err := uuid.NewRandom()
 	if err != nil {
 		panic(fmt.Errorf("uuid: %w", err))
@@ -90,7 +200,87 @@ func ApplyConfigs(log *logrus.Logger, configs ...Config) *RequestOptions {
 		config.Fn(opt)
 	}
 
-	return opt
+	return opt, errors.Join(opt.configErrs...)
+}
+
+// Validate checks the RequestOptions for invalid combinations of settings
+// that are independent of the client implementation.
+func (r *RequestOptions) Validate() error {
+	var errs []error
+	if r.MinEndorsers < 0 {
+		errs = append(errs, &ConfigError{
+			Field:  "MinEndorsers",
+			Reason: fmt.Sprintf("must not be negative (got %d)", r.MinEndorsers),
+		})
+	}
+	errs = append(errs, r.validateTransient()...)
+	if r.Timeout < 0 {
+		errs = append(errs, &ConfigError{
+			Field:  "Timeout",
+			Reason: fmt.Sprintf("must not be negative (got %s)", r.Timeout),
+		})
+	}
+	if r.Retry.Backoff < 0 || r.Retry.MaxBackoff < 0 {
+		errs = append(errs, &ConfigError{
+			Field:  "Retry",
+			Reason: "backoff must not be negative",
+		})
+	}
+	if r.ConflictRetry.Backoff < 0 || r.ConflictRetry.MaxBackoff < 0 {
+		errs = append(errs, &ConfigError{
+			Field:  "ConflictRetry",
+			Reason: "backoff must not be negative",
+		})
+	}
+	if r.EndpointRefresh < 0 {
+		errs = append(errs, &ConfigError{
+			Field:  "EndpointRefresh",
+			Reason: fmt.Sprintf("must not be negative (got %s)", r.EndpointRefresh),
+		})
+	}
+	if strings.ContainsAny(r.AuthScheme, " \t\r\n") {
+		errs = append(errs, &ConfigError{
+			Field:  "AuthScheme",
+			Reason: fmt.Sprintf("must be a single token (got %q)", r.AuthScheme),
+		})
+	}
+	if r.APIKey != "" && strings.TrimSpace(r.APIKeyHeader) == "" {
+		errs = append(errs, &ConfigError{
+			Field:  "APIKeyHeader",
+			Reason: "a header name is required for the API key",
+		})
+	}
+	if r.APIKey != "" && http.CanonicalHeaderKey(r.APIKeyHeader) == "Authorization" &&
+		(r.AuthToken != "" || r.AuthTokenProvider != nil) {
+		errs = append(errs, &ConfigError{
+			Field:  "APIKeyHeader",
+			Reason: "the Authorization header is already used by the auth token",
+		})
+	}
+	if r.KeepAlive < 0 {
+		errs = append(errs, &ConfigError{
+			Field:  "KeepAlive",
+			Reason: fmt.Sprintf("must not be negative (got %s)", r.KeepAlive),
+		})
+	}
+	for _, name := range r.PrivateCollections {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, &ConfigError{
+				Field:  "PrivateCollections",
+				Reason: "collection names must not be empty",
+			})
+			break
+		}
+	}
+	if r.DependentBlock != "" {
+		if _, err := strconv.ParseUint(r.DependentBlock, 10, 64); err != nil {
+			errs = append(errs, &ConfigError{
+				Field:  "DependentBlock",
+				Reason: fmt.Sprintf("expected a block number (got %q)", r.DependentBlock),
+			})
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // RequestOptions are operated on by the Config functions generated by
@@ -121,6 +311,559 @@ type RequestOptions struct {
 	DisableWritePolling bool
 	CcFetchURLDowngrade bool
 	ResponseReceiver    func(ShiroResponse)
+	Timeout             time.Duration
+	Retry               RetryPolicy
+	AuthTokenProvider   func(context.Context) (string, error)
+	StrictDecoding      bool
+	JSON                JSONCodec
+	TransientLimits     TransientLimits
+	TransientEncoding   TransientEncoding
+	IdempotencyKey      string
+	WriteProgress       func(WriteProgress)
+	WritePollInterval   time.Duration
+	// SubstrateTimer, if set, receives the time spent in substrate by each
+	// mock Call.  It is not called in RPC mode.
+	SubstrateTimer func(time.Duration)
+	Stats          func(CallStats)
+	CommitStats    func(CommitStats)
+	ClientTrace    *httptrace.ClientTrace
+	ConnStats      func(ConnStats)
+	// TraceTransient injects the span context of the request context into
+	// Transient.  See InjectTraceTransient.
+	TraceTransient bool
+	// ConflictRetry resubmits calls whose transaction was invalidated by a
+	// read conflict.
+	ConflictRetry RetryPolicy
+	// PrivateCollections names the private data collections targeted by
+	// the request.
+	PrivateCollections []string
+	// EndpointRefresh is the interval after which dnssrv endpoints are
+	// resolved again.
+	EndpointRefresh time.Duration
+	// KeepAlive is the interval between keepalive pings of the gateway by
+	// an RPC client, or zero to disable them.
+	KeepAlive time.Duration
+	// KeepAliveFailure is called with the gateway URL and the error of each
+	// failed keepalive ping.
+	KeepAliveFailure func(endpoint string, err error)
+	// AuthScheme is the scheme of the Authorization header carrying the
+	// auth token, "Bearer" if empty.
+	AuthScheme string
+	// APIKeyHeader names the header carrying APIKey.
+	APIKeyHeader string
+	// APIKey authenticates the client with the gateway, independently of
+	// the auth token.
+	APIKey string
+	// EndpointPolicy restricts per-call endpoint overrides.  Only the
+	// policy set by client configs is enforced.
+	EndpointPolicy EndpointPolicy
+	// MethodPolicy restricts the phylum methods that may be called.  Only
+	// the policy set by client configs is enforced.
+	MethodPolicy MethodPolicy
+	// ForwardLogFields lists the keys of LogFields forwarded to the gateway
+	// in the X-Shiro-Context header.
+	ForwardLogFields []string
+	// SkipEncodeTx makes the private package encode private data in the
+	// transaction of a wrapped call instead of a separate encode
+	// transaction.  Clients ignore it.
+	SkipEncodeTx bool
+	// MethodConfigs holds configs applied to calls of a method, keyed by
+	// method name, after the client configs and before the per-call
+	// configs.  Only the configs set by client configs are applied.
+	MethodConfigs map[string][]Config
+	// CallRecorder, if set, is called once each Call completes, whether
+	// or not it succeeded.
+	CallRecorder func(ctx context.Context, record CallRecord)
+	// Signer, if set, signs RPC requests on behalf of a client-side
+	// identity.  It is ignored in mock mode.
+	Signer RequestSigner
+	// ResponseValidator, if set, checks the result JSON of successful
+	// calls.  See ValidateResponse.
+	ResponseValidator func(result []byte) error
+	// ErrorPolicy determines the detail of phylum errors returned by
+	// helpers, e.g. those of the private package.
+	ErrorPolicy ErrorPolicy
+
+	configErrs []error
+}
+
+// Clone returns a copy of the RequestOptions that shares no maps or slices
+// with r.  Values held by reference, like Params and HTTPClient, are not
+// copied.
+func (r *RequestOptions) Clone() RequestOptions {
+	out := *r
+	out.LogFields = make(logrus.Fields, len(r.LogFields))
+	for k, v := range r.LogFields {
+		out.LogFields[k] = v
+	}
+	out.Headers = make(map[string]string, len(r.Headers))
+	for k, v := range r.Headers {
+		out.Headers[k] = v
+	}
+	out.Transient = make(map[string][]byte, len(r.Transient))
+	for k, v := range r.Transient {
+		out.Transient[k] = append([]byte(nil), v...)
+	}
+	out.NotTargetEndpoints = append([]string(nil), r.NotTargetEndpoints...)
+	out.TargetEndpoints = append([]string(nil), r.TargetEndpoints...)
+	out.MspFilter = append([]string(nil), r.MspFilter...)
+	out.PrivateCollections = append([]string(nil), r.PrivateCollections...)
+	out.EndpointPolicy.AllowedHosts = append([]string(nil), r.EndpointPolicy.AllowedHosts...)
+	out.MethodPolicy.Allowed = append([]string(nil), r.MethodPolicy.Allowed...)
+	out.MethodPolicy.Denied = append([]string(nil), r.MethodPolicy.Denied...)
+	out.ForwardLogFields = append([]string(nil), r.ForwardLogFields...)
+	out.MethodConfigs = make(map[string][]Config, len(r.MethodConfigs))
+	for k, v := range r.MethodConfigs {
+		out.MethodConfigs[k] = append([]Config(nil), v...)
+	}
+	out.configErrs = nil
+	return out
+}
+
+// Authorization returns the value of the Authorization header carrying
+// token.
+func (r *RequestOptions) Authorization(token string) string {
+	scheme := r.AuthScheme
+	if scheme == "" {
+		scheme = "Bearer"
+	}
+	return scheme + " " + token
+}
+
+// ResolveAuthToken returns the authorization token for a request, invoking
+// AuthTokenProvider if one is configured.
+func (r *RequestOptions) ResolveAuthToken(ctx context.Context) (string, error) {
+	if r.AuthTokenProvider == nil {
+		return r.AuthToken, nil
+	}
+	token, err := r.AuthTokenProvider(ctx)
+	if err != nil {
+		return "", fmt.Errorf("auth token provider: %w", err)
+	}
+	return token, nil
+}
+
+// ErrMethodNotAllowed is returned when a client refuses to call a phylum
+// method because of its MethodPolicy.
+var ErrMethodNotAllowed = errors.New("method not allowed")
+
+// MethodPolicy restricts the phylum methods a client may call.
+type MethodPolicy struct {
+	// Restricted is set if Allowed lists the only methods that may be
+	// called, even if it is empty.
+	Restricted bool
+	// Allowed, if Restricted is set, lists the only methods that may be
+	// called.
+	Allowed []string
+	// Denied lists methods that may not be called.
+	Denied []string
+}
+
+// Allow restricts the policy to methods.  Restricting a policy that is
+// already restricted keeps only the methods allowed by both, so that the
+// policy of a client can only be tightened by further configs.
+func (p *MethodPolicy) Allow(methods ...string) {
+	if !p.Restricted {
+		p.Restricted = true
+		p.Allowed = append([]string(nil), methods...)
+		return
+	}
+	var allowed []string
+	for _, m := range p.Allowed {
+		if slices.Contains(methods, m) {
+			allowed = append(allowed, m)
+		}
+	}
+	p.Allowed = allowed
+}
+
+// Deny adds methods to the denied methods of the policy.
+func (p *MethodPolicy) Deny(methods ...string) {
+	p.Denied = append(append([]string(nil), p.Denied...), methods...)
+}
+
+// CheckMethod returns an error wrapping ErrMethodNotAllowed if the policy
+// does not allow calling method.
+func (p MethodPolicy) CheckMethod(method string) error {
+	if slices.Contains(p.Denied, method) {
+		return fmt.Errorf("%w: %s is denied", ErrMethodNotAllowed, method)
+	}
+	if !p.Restricted || slices.Contains(p.Allowed, method) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not in the allowed methods", ErrMethodNotAllowed, method)
+}
+
+// EndpointPolicy restricts the endpoints that per-call configs may select
+// in place of the endpoint configured on the client.
+type EndpointPolicy struct {
+	// Locked rejects any per-call endpoint override.
+	Locked bool
+	// AllowedHosts, if not empty, lists the hosts that per-call overrides
+	// may target.  Hosts match the host name of the endpoint, or its host
+	// and port.
+	AllowedHosts []string
+}
+
+// CheckOverride returns an error if the policy does not allow a request
+// configured with endpoint base to target endpoint instead.
+func (p EndpointPolicy) CheckOverride(base string, endpoint string) error {
+	if endpoint == base || !p.Locked && len(p.AllowedHosts) == 0 {
+		return nil
+	}
+	if p.Locked {
+		return &ConfigError{
+			Field:  "Endpoint",
+			Reason: fmt.Sprintf("the client endpoint is locked (override %q)", endpoint),
+		}
+	}
+	u, err := url.Parse(endpoint)
+	if err == nil {
+		for _, host := range p.AllowedHosts {
+			if strings.EqualFold(host, u.Hostname()) || strings.EqualFold(host, u.Host) {
+				return nil
+			}
+		}
+	}
+	return &ConfigError{
+		Field:  "Endpoint",
+		Reason: fmt.Sprintf("override %q does not target an allowed host", endpoint),
+	}
+}
+
+// Transient data keys carrying the W3C trace context of the caller.
+const (
+	TransientTraceParent = "traceparent"
+	TransientTraceState  = "tracestate"
+)
+
+// InjectTraceTransient adds the W3C trace context of the span in ctx to
+// Transient if TraceTransient is set and ctx carries a valid span context.
+func (r *RequestOptions) InjectTraceTransient(ctx context.Context) {
+	if !r.TraceTransient || !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	for _, key := range []string{TransientTraceParent, TransientTraceState} {
+		if v, ok := carrier[key]; ok && v != "" {
+			r.Transient[key] = []byte(v)
+		}
+	}
+}
+
+// RequestSigner signs gateway requests.
+type RequestSigner interface {
+	// SignRequest returns the signature of the body of a request and the
+	// DER encoded certificate of the signer, or a nil certificate for
+	// signatures with a shared key.  ctx is the context of the request;
+	// signers calling remote services must respect its cancellation.
+	SignRequest(ctx context.Context, body []byte) (signature []byte, certificate []byte, err error)
+}
+
+// CallRecord describes a completed Call.  See RequestOptions.CallRecorder.
+type CallRecord struct {
+	// Method is the phylum method.
+	Method string
+	// Options are the options of the call.  They must not be modified.
+	Options *RequestOptions
+	// Response is the response, or nil if Err is set.
+	Response ShiroResponse
+	// Err is the error that prevented a response, if any.
+	Err error
+	// Duration is the time taken by the call, including retries and
+	// waiting for the commit.
+	Duration time.Duration
+}
+
+// RecordCall passes the outcome of a Call of method started at start to
+// the CallRecorder of r, if set.
+func (r *RequestOptions) RecordCall(ctx context.Context, method string, start time.Time, resp ShiroResponse, err error) {
+	if r.CallRecorder == nil {
+		return
+	}
+	r.CallRecorder(ctx, CallRecord{
+		Method:   method,
+		Options:  r,
+		Response: resp,
+		Err:      err,
+		Duration: time.Since(start),
+	})
+}
+
+// CallStats describes a completed request.
+type CallStats struct {
+	// Method is the gateway method, e.g. "Call" or "QueryInfo".
+	Method string
+	// PhylumMethod is the phylum method of a Call, or empty.
+	PhylumMethod string
+	// Endpoint is the gateway endpoint, or empty in mock mode.
+	Endpoint string
+	// Duration is the time taken by the request, including retries.
+	Duration time.Duration
+	// RequestSize is the size in bytes of the encoded request (in mock
+	// mode, of the encoded params).
+	RequestSize int
+	// ResponseSize is the size in bytes of the response body (in mock
+	// mode, of the result or error data).
+	ResponseSize int
+	// ErrorLevel is the error level of the response, one of the
+	// rpc.ErrorLevel constants.  It is only meaningful if Err is nil.
+	ErrorLevel int
+	// Retries is the number of times the request was retried.
+	Retries int
+	// SubstrateTime is the time spent in substrate in mock mode.
+	SubstrateTime time.Duration
+	// Err is the error that prevented a response, if any.
+	Err error
+}
+
+// ResponseValidationError is returned by Call for a result rejected by the
+// ResponseValidator of the request.
+type ResponseValidationError struct {
+	// Method is the phylum method called.
+	Method string
+	// Response is the response of the call.  For a write, the transaction
+	// may have been committed.
+	Response ShiroResponse
+	// Err is the error of the validator.
+	Err error
+}
+
+func (e *ResponseValidationError) Error() string {
+	return fmt.Sprintf("invalid %s response: %v", e.Method, e.Err)
+}
+
+func (e *ResponseValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateResponse checks the result of a successful Call of method with
+// the ResponseValidator of r, if set, returning a
+// *ResponseValidationError instead of resp if it is rejected.
+func (r *RequestOptions) ValidateResponse(method string, resp ShiroResponse, err error) (ShiroResponse, error) {
+	if r.ResponseValidator == nil || err != nil || resp == nil || resp.Error() != nil {
+		return resp, err
+	}
+	if verr := r.ResponseValidator(resp.ResultJSON()); verr != nil {
+		return nil, &ResponseValidationError{Method: method, Response: resp, Err: verr}
+	}
+	return resp, nil
+}
+
+// CommitStats describes the commit of a write observed by the client.
+type CommitStats struct {
+	// PhylumMethod is the phylum method of the write.
+	PhylumMethod string
+	// Endpoint is the gateway endpoint the write was submitted to, or
+	// empty in mock mode.
+	Endpoint string
+	// TxID is the ID of the transaction.
+	TxID string
+	// CommitBlock is the block the transaction was committed in.
+	CommitBlock uint64
+	// Latency is the time from the submission of the transaction until
+	// its commit was observed.  It is zero in mock mode, where writes
+	// commit synchronously.
+	Latency time.Duration
+}
+
+// ConnStats summarizes the connection-level timing of an HTTP request.
+// Durations are zero for phases that did not occur, e.g. DNS and Connect
+// when a pooled connection is reused.
+type ConnStats struct {
+	// DNS is the time taken to resolve the gateway host.
+	DNS time.Duration
+	// Connect is the time taken to establish the TCP connection.
+	Connect time.Duration
+	// TLSHandshake is the time taken by the TLS handshake.
+	TLSHandshake time.Duration
+	// TimeToFirstByte is the time from the start of the request until
+	// the first byte of the response was received.
+	TimeToFirstByte time.Duration
+	// Total is the time from the start of the request until the response
+	// body was read.
+	Total time.Duration
+	// Reused is true if a pooled connection was used.
+	Reused bool
+	// RemoteAddr is the address of the gateway connection.
+	RemoteAddr string
+	// Attempt is the 1-based attempt number of the request.
+	Attempt int
+}
+
+// WriteStage identifies a step in the life of a write transaction.
+type WriteStage int
+
+const (
+	// WriteSimulated indicates that the transaction was simulated.
+	WriteSimulated WriteStage = iota + 1
+	// WriteSubmitted indicates that the transaction was submitted for
+	// ordering.
+	WriteSubmitted
+	// WriteCommitted indicates that the transaction was observed in a
+	// block.
+	WriteCommitted
+)
+
+// String implements fmt.Stringer.
+func (s WriteStage) String() string {
+	switch s {
+	case WriteSimulated:
+		return "simulated"
+	case WriteSubmitted:
+		return "submitted"
+	case WriteCommitted:
+		return "committed"
+	default:
+		return fmt.Sprintf("WriteStage(%d)", int(s))
+	}
+}
+
+// WriteProgress reports that a write reached a WriteStage.
+type WriteProgress struct {
+	// Stage is the stage that was reached.
+	Stage WriteStage
+	// TxID is the transaction ID, which is empty for WriteSimulated if
+	// the request did not produce a transaction.
+	TxID string
+	// BlockNum is the maximum simulated block for WriteSimulated and the
+	// commit block for WriteCommitted, if known.
+	BlockNum uint64
+}
+
+// TransientEncoding selects how transient data values are encoded in
+// gateway requests.
+type TransientEncoding int
+
+const (
+	// TransientEncodingHex hex encodes values, which all gateways support.
+	TransientEncodingHex TransientEncoding = iota
+	// TransientEncodingBase64 base64 encodes values, without checking
+	// whether the gateway supports it.
+	TransientEncodingBase64
+	// TransientEncodingAuto uses base64 if the gateway advertises support
+	// for it and hex otherwise.
+	TransientEncodingAuto
+)
+
+// TransientLimits constrain the transient data of a request.  Zero values
+// disable the corresponding check.
+type TransientLimits struct {
+	// MaxValueSize is the maximum size in bytes of a single value.
+	MaxValueSize int
+	// MaxTotalSize is the maximum combined size in bytes of all keys and
+	// values.
+	MaxTotalSize int
+	// KeyPattern, if set, must match every key.
+	KeyPattern *regexp.Regexp
+}
+
+// TransientDataError is returned when transient data violates the
+// configured TransientLimits or a key is invalid.
+type TransientDataError struct {
+	// Key is the offending key, or empty if the total size was exceeded.
+	Key string
+	// Reason describes the violation.
+	Reason string
+	// Size is the offending size in bytes, if the violation concerns size.
+	Size int
+	// Limit is the exceeded limit in bytes, if the violation concerns size.
+	Limit int
+}
+
+// Error implements error.
+func (e *TransientDataError) Error() string {
+	if e.Limit > 0 {
+		return fmt.Sprintf("transient data %q: %s (%d > %d bytes)", e.Key, e.Reason, e.Size, e.Limit)
+	}
+	return fmt.Sprintf("transient data %q: %s", e.Key, e.Reason)
+}
+
+// ValidateTransientKey checks constraints on transient keys that apply
+// regardless of the configured limits.
+func ValidateTransientKey(key string) error {
+	if key == "" {
+		return &TransientDataError{Key: key, Reason: "empty key"}
+	}
+	return nil
+}
+
+func (r *RequestOptions) validateTransient() []error {
+	limits := r.TransientLimits
+	if limits.MaxValueSize <= 0 && limits.MaxTotalSize <= 0 && limits.KeyPattern == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(r.Transient))
+	for k := range r.Transient {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var errs []error
+	total := 0
+	for _, k := range keys {
+		v := r.Transient[k]
+		total += len(k) + len(v)
+		if limits.KeyPattern != nil && !limits.KeyPattern.MatchString(k) {
+			errs = append(errs, &TransientDataError{
+				Key:    k,
+				Reason: fmt.Sprintf("key does not match %s", limits.KeyPattern),
+			})
+		}
+		if limits.MaxValueSize > 0 && len(v) > limits.MaxValueSize {
+			errs = append(errs, &TransientDataError{
+				Key:    k,
+				Reason: "value too large",
+				Size:   len(v),
+				Limit:  limits.MaxValueSize,
+			})
+		}
+	}
+	if limits.MaxTotalSize > 0 && total > limits.MaxTotalSize {
+		errs = append(errs, &TransientDataError{
+			Reason: "total size too large",
+			Size:   total,
+			Limit:  limits.MaxTotalSize,
+		})
+	}
+	return errs
+}
+
+// JSONCodec marshals and unmarshals JSON.  Nil functions fall back to
+// encoding/json.  UnmarshalFunc must decode into interface{} values using
+// the same Go types as encoding/json (map[string]interface{}, float64, ...).
+type JSONCodec struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(data []byte, v interface{}) error
+}
+
+// Marshal encodes v using the codec.
+func (c JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	if c.MarshalFunc == nil {
+		return json.Marshal(v)
+	}
+	return c.MarshalFunc(v)
+}
+
+// Unmarshal decodes data into v using the codec.
+func (c JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	if c.UnmarshalFunc == nil {
+		return json.Unmarshal(data, v)
+	}
+	return c.UnmarshalFunc(data, v)
+}
+
+// RetryPolicy controls how requests to the gateway are retried after
+// transport failures.  The zero value disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts for a single request,
+	// including the first.  Values less than 2 disable retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry.  The delay doubles on
+	// each subsequent retry.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries, if positive.
+	MaxBackoff time.Duration
 }
 
 // ShiroResponse is a wrapper for a response from a shiro
@@ -135,6 +878,105 @@ type ShiroResponse interface {
 	Error() Error
 }
 
+// StackFrame is a frame of a phylum stack trace.
+type StackFrame struct {
+	// Function is the name of the ELPS function.
+	Function string
+	// File is the source file of the call, if known.
+	File string
+	// Line is the 1-based source line of the call, or 0 if unknown.
+	Line int
+	// Column is the 1-based source column of the call, or 0 if unknown.
+	Column int
+}
+
+func (f StackFrame) String() string {
+	switch {
+	case f.File == "":
+		return f.Function
+	case f.Line == 0:
+		return fmt.Sprintf("%s (%s)", f.Function, f.File)
+	case f.Column == 0:
+		return fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line)
+	default:
+		return fmt.Sprintf("%s (%s:%d:%d)", f.Function, f.File, f.Line, f.Column)
+	}
+}
+
+// StackError is implemented by errors that carry a phylum stack trace.
+type StackError interface {
+	Error
+	Stack() []StackFrame
+}
+
+// ResponseMetadata describes the HTTP response that carried a
+// ShiroResponse in RPC mode.
+type ResponseMetadata struct {
+	// Envelope is the raw JSON-RPC response body.
+	Envelope []byte
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Header holds the HTTP response headers.
+	Header http.Header
+}
+
+// RequestID returns the gateway request ID from the response headers, or
+// an empty string if the gateway did not send one.
+func (m *ResponseMetadata) RequestID() string {
+	if m == nil {
+		return ""
+	}
+	return m.Header.Get(rpc.HeaderRequestID)
+}
+
+// CommitMetadata describes how the transaction of a response was simulated
+// and committed.  Fields the gateway or substrate did not report are left
+// empty.
+type CommitMetadata struct {
+	// TxID is the ID of the transaction, or empty for read-only calls.
+	TxID string
+	// CommitBlock is the number of the block the transaction was committed
+	// in, or 0 if the commit was not observed.
+	CommitBlock uint64
+	// MaxSimulatedBlock is the highest block number the transaction was
+	// simulated against.
+	MaxSimulatedBlock uint64
+	// Endorsers lists the peers that endorsed the transaction.
+	Endorsers []string
+	// ValidationCode is the validation code of the committed transaction,
+	// or empty if it was not reported.
+	ValidationCode ValidationCode
+}
+
+// Committed reports whether the transaction was observed in a block.
+func (m *CommitMetadata) Committed() bool {
+	return m != nil && m.CommitBlock > 0
+}
+
+// Clone returns a deep copy of m.
+func (m *CommitMetadata) Clone() *CommitMetadata {
+	if m == nil {
+		return nil
+	}
+	c := *m
+	if m.Endorsers != nil {
+		c.Endorsers = append([]string(nil), m.Endorsers...)
+	}
+	return &c
+}
+
+// CommitResponse is implemented by responses that carry commit metadata.
+type CommitResponse interface {
+	ShiroResponse
+	Commit() *CommitMetadata
+}
+
+// MetadataResponse is implemented by responses that carry HTTP metadata.
+type MetadataResponse interface {
+	ShiroResponse
+	Metadata() *ResponseMetadata
+}
+
 // Error is a generic application error.
 type Error interface {
 	error
@@ -160,12 +1002,19 @@ func NewFailureResponse(code int, message string, data []byte) *failureResponse
 	}
 }
 
-var _ ShiroResponse = (*failureResponse)(nil)
+var _ CommitResponse = (*failureResponse)(nil)
 
 type failureError struct {
 	message string
 	data    []byte
 	code    int
+	stack   []StackFrame
+}
+
+// Stack returns the phylum stack trace of the error, innermost frame
+// first, or nil if none was reported.
+func (s *failureError) Stack() []StackFrame {
+	return s.stack
 }
 
 func (s *failureError) Code() int {
@@ -183,11 +1032,32 @@ func (s *failureError) DataJSON() []byte {
 }
 
 func (s *failureError) Error() string {
-	return fmt.Sprintf("error [%d] message [%s]", s.Code(), s.Message())
+	msg := fmt.Sprintf("error [%d] message [%s]", s.Code(), s.Message())
+	for _, frame := range s.stack {
+		msg += "\n\tat " + frame.String()
+	}
+	return msg
 }
 
 type failureResponse struct {
-	err failureError
+	err  failureError
+	meta *ResponseMetadata
+}
+
+// SetStack attaches the phylum stack trace of the error to the response.
+func (s *failureResponse) SetStack(stack []StackFrame) {
+	s.err.stack = stack
+}
+
+// Metadata returns the HTTP metadata of the response, or nil if the
+// response was not received over HTTP.
+func (s *failureResponse) Metadata() *ResponseMetadata {
+	return s.meta
+}
+
+// SetMetadata attaches HTTP metadata to the response.
+func (s *failureResponse) SetMetadata(meta *ResponseMetadata) {
+	s.meta = meta
 }
 
 func (s *failureResponse) UnmarshalTo(dst interface{}) error {
@@ -202,6 +1072,11 @@ func (s *failureResponse) TransactionID() string {
 	return ""
 }
 
+// Commit returns nil; failed calls are not committed.
+func (s *failureResponse) Commit() *CommitMetadata {
+	return nil
+}
+
 func (s *failureResponse) MaxSimBlockNum() uint64 {
 	return 0
 }
@@ -214,24 +1089,49 @@ func (s *failureResponse) Error() Error {
 	return &s.err
 }
 
-var _ ShiroResponse = (*successResponse)(nil)
+var _ CommitResponse = (*successResponse)(nil)
 
 type successResponse struct {
-	txID        string
-	comBlockNum uint64
-	simBlockNum uint64
-	result      []byte
+	commit CommitMetadata
+	result []byte
+	meta   *ResponseMetadata
+}
+
+// Metadata returns the HTTP metadata of the response, or nil if the
+// response was not received over HTTP.
+func (s *successResponse) Metadata() *ResponseMetadata {
+	return s.meta
+}
+
+// SetMetadata attaches HTTP metadata to the response.
+func (s *successResponse) SetMetadata(meta *ResponseMetadata) {
+	s.meta = meta
 }
 
 func NewSuccessResponse(result []byte, txID string, comBlockNum uint64, simBlockNum uint64) *successResponse {
 	return &successResponse{
-		result:      result,
-		txID:        txID,
-		comBlockNum: comBlockNum,
-		simBlockNum: simBlockNum,
+		result: result,
+		commit: CommitMetadata{
+			TxID:              txID,
+			CommitBlock:       comBlockNum,
+			MaxSimulatedBlock: simBlockNum,
+		},
 	}
 }
 
+// SetCommit replaces the commit metadata of the response.
+func (s *successResponse) SetCommit(commit *CommitMetadata) {
+	s.commit = CommitMetadata{}
+	if commit != nil {
+		s.commit = *commit.Clone()
+	}
+}
+
+// Commit returns a copy of the commit metadata of the response.
+func (s *successResponse) Commit() *CommitMetadata {
+	return s.commit.Clone()
+}
+
 func (s *successResponse) UnmarshalTo(dst interface{}) error {
 	return UnmarshalProto(s.result, dst)
 }
@@ -243,7 +1143,7 @@ func (s *successResponse) ResultJSON() []byte {
 }
 
 func (s *successResponse) TransactionID() string {
-	return s.txID
+	return s.commit.TxID
 }
 
 func (s *successResponse) Error() Error {
@@ -251,11 +1151,11 @@ func (s *successResponse) Error() Error {
 }
 
 func (s *successResponse) MaxSimBlockNum() uint64 {
-	return s.simBlockNum
+	return s.commit.MaxSimulatedBlock
 }
 
 func (s *successResponse) CommitBlockNum() uint64 {
-	return s.comBlockNum
+	return s.commit.CommitBlock
 }
 
 // Transaction is a wrapper for summary information about a transaction.
@@ -266,7 +1166,7 @@ type Transaction interface {
 	ChaincodeID() string
 }
 
-var _ Transaction = &transaction{}
+var _ ValidatedTransaction = &transaction{}
 
 func NewTransaction(id string, reason string, event []byte, ccID string) *transaction {
 	return &transaction{id: id, reason: reason, event: event, ccID: ccID}
@@ -295,6 +1195,12 @@ func (t *transaction) ChaincodeID() string {
 	return t.ccID
 }
 
+// ValidationCode returns the validation code parsed from the transaction
+// reason.
+func (t *transaction) ValidationCode() ValidationCode {
+	return ParseValidationCode(t.reason)
+}
+
 // Block is a wrapper for summary information about a block.
 type Block interface {
 	Hash() string
@@ -307,9 +1213,48 @@ func NewBlock(hash string, txs []Transaction) *block {
 	return &block{hash: hash, transactions: txs}
 }
 
+// NewBlockWithHeader is like NewBlock for blocks whose header was returned
+// by the gateway.
+func NewBlockWithHeader(hash string, txs []Transaction, header *BlockHeader) *block {
+	return &block{hash: hash, transactions: txs, header: header}
+}
+
+// BlockHeader holds the header of a block and the orderer signatures over
+// it, which are needed to verify offline that a transaction was included
+// in the ledger.  Hashes use the encoding of Block.Hash.
+type BlockHeader struct {
+	Number       uint64            `json:"number"`
+	PreviousHash string            `json:"previous_hash"`
+	DataHash     string            `json:"data_hash"`
+	Signatures   []*BlockSignature `json:"signatures,omitempty"`
+}
+
+// BlockSignature is an orderer signature over a block header.
+type BlockSignature struct {
+	// Creator is the serialized identity of the signer.
+	Creator   []byte `json:"creator"`
+	Nonce     []byte `json:"nonce"`
+	Signature []byte `json:"signature"`
+}
+
+// HeaderBlock is implemented by blocks that may carry their header.
+type HeaderBlock interface {
+	Block
+	// Header returns the block header, or nil if the gateway did not
+	// return it.
+	Header() *BlockHeader
+}
+
+var _ HeaderBlock = &block{}
+
 type block struct {
 	hash         string
 	transactions []Transaction
+	header       *BlockHeader
+}
+
+func (b *block) Header() *BlockHeader {
+	return b.header
 }
 
 func (b *block) Hash() string {
@@ -322,15 +1267,127 @@ func (b *block) Transactions() []Transaction {
 	return out
 }
 
+// ProtoMarshalOptions returns the options used to encode messages for the
+// phylum: the inverse of UnmarshalProto, with proto field names.
+func ProtoMarshalOptions() protojson.MarshalOptions {
+	return protojson.MarshalOptions{UseProtoNames: true}
+}
+
+// MarshalProto encodes src as JSON: messages with ProtoMarshalOptions(),
+// legacy messages with jsonpb, proto field names and the AnyResolver set
+// with SetLegacyUnmarshalOptions, and other values with encoding/json.
+func MarshalProto(src interface{}) ([]byte, error) {
+	opts := ProtoMarshalOptions()
+	switch message := src.(type) {
+	case proto.Message:
+		return opts.Marshal(message)
+	case protoiface.MessageV1:
+		unmarshalMu.RLock()
+		resolver := legacyUnmarshalOpts.AnyResolver
+		unmarshalMu.RUnlock()
+		m := &jsonpb.Marshaler{
+			OrigName:     opts.UseProtoNames,
+			EnumsAsInts:  opts.UseEnumNumbers,
+			EmitDefaults: opts.EmitUnpopulated,
+			Indent:       opts.Indent,
+			AnyResolver:  resolver,
+		}
+		var b bytes.Buffer
+		//nolint:staticcheck // Deprecated Marshal used for backwards compatibility
+		if err := m.Marshal(&b, message); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	default:
+		return json.Marshal(src)
+	}
+}
+
+// ProtoParams returns positional phylum params encoding msgs with opts.
+// The result is passed to WithParams.
+func ProtoParams(opts protojson.MarshalOptions, msgs ...proto.Message) []interface{} {
+	params := make([]interface{}, len(msgs))
+	for i, msg := range msgs {
+		params[i] = &protoParam{msg: msg, opts: opts}
+	}
+	return params
+}
+
+// protoParam is a message encoded as JSON with protojson options.
+type protoParam struct {
+	msg  proto.Message
+	opts protojson.MarshalOptions
+}
+
+func (p *protoParam) MarshalJSON() ([]byte, error) {
+	return p.opts.Marshal(p.msg)
+}
+
+// LegacyUnmarshalOptions configures the unmarshaling of legacy
+// (MessageV1) messages by UnmarshalProto with the deprecated jsonpb
+// package.
+type LegacyUnmarshalOptions struct {
+	// AllowUnknownFields ignores fields unknown to the message instead of
+	// failing.
+	AllowUnknownFields bool
+	// AnyResolver resolves the type URLs of Any fields.  By default the
+	// global registry is used.
+	AnyResolver jsonpb.AnyResolver
+}
+
+// ProtoUnmarshalFunc unmarshals src into dst.
+type ProtoUnmarshalFunc func(src []byte, dst interface{}) error
+
+var (
+	unmarshalMu         sync.RWMutex
+	legacyUnmarshalOpts LegacyUnmarshalOptions
+	protoUnmarshalers   = make(map[reflect.Type]ProtoUnmarshalFunc)
+)
+
+// SetLegacyUnmarshalOptions sets the options used by UnmarshalProto for
+// legacy messages.
+func SetLegacyUnmarshalOptions(opts LegacyUnmarshalOptions) {
+	unmarshalMu.Lock()
+	defer unmarshalMu.Unlock()
+	legacyUnmarshalOpts = opts
+}
+
+// RegisterProtoUnmarshaler makes UnmarshalProto use fn for destinations
+// with the type of dst, e.g. a pointer to a message.  A nil fn removes the
+// registration.
+func RegisterProtoUnmarshaler(dst interface{}, fn ProtoUnmarshalFunc) {
+	unmarshalMu.Lock()
+	defer unmarshalMu.Unlock()
+	if fn == nil {
+		delete(protoUnmarshalers, reflect.TypeOf(dst))
+		return
+	}
+	protoUnmarshalers[reflect.TypeOf(dst)] = fn
+}
+
 // UnmarshalProto attempts to unmarshal protobuf bytes with backwards compatability.
+// Unmarshalers registered with RegisterProtoUnmarshaler take precedence,
+// and legacy messages are unmarshaled with the options set with
+// SetLegacyUnmarshalOptions.
 func UnmarshalProto(src []byte, dst interface{}) error {
+	unmarshalMu.RLock()
+	fn := protoUnmarshalers[reflect.TypeOf(dst)]
+	legacy := legacyUnmarshalOpts
+	unmarshalMu.RUnlock()
+	if fn != nil {
+		return fn(src, dst)
+	}
 	var err error
 	switch message := dst.(type) {
 	case proto.Message:
 		err = protojson.Unmarshal(src, message)
 	case protoiface.MessageV1:
+		u := &jsonpb.Unmarshaler{
+			AllowUnknownFields: legacy.AllowUnknownFields,
+			AnyResolver:        legacy.AnyResolver,
+		}
 		//nolint:staticcheck // Deprecated Unmarshal used for backwards compatibility
-		err = jsonpb.Unmarshal(bytes.NewReader(src), message)
+		err = u.Unmarshal(bytes.NewReader(src), message)
 	default:
 		err = json.Unmarshal(src, message)
 	}
@@ -0,0 +1,123 @@
+package types
+
+import (
+	"strings"
+)
+
+// ValidationCode is the result of validating a transaction when its block
+// is committed.  Values are the names of the Fabric transaction validation
+// codes.
+type ValidationCode string
+
+// Validation codes reported for transactions.
+const (
+	// ValidationUnknown is reported for validation codes that are not
+	// recognized by the SDK.
+	ValidationUnknown                    ValidationCode = "UNKNOWN"
+	ValidationValid                      ValidationCode = "VALID"
+	ValidationNilEnvelope                ValidationCode = "NIL_ENVELOPE"
+	ValidationBadPayload                 ValidationCode = "BAD_PAYLOAD"
+	ValidationBadCommonHeader            ValidationCode = "BAD_COMMON_HEADER"
+	ValidationBadCreatorSignature        ValidationCode = "BAD_CREATOR_SIGNATURE"
+	ValidationInvalidEndorserTransaction ValidationCode = "INVALID_ENDORSER_TRANSACTION"
+	ValidationInvalidConfigTransaction   ValidationCode = "INVALID_CONFIG_TRANSACTION"
+	ValidationUnsupportedTxPayload       ValidationCode = "UNSUPPORTED_TX_PAYLOAD"
+	ValidationBadProposalTxID            ValidationCode = "BAD_PROPOSAL_TXID"
+	ValidationDuplicateTxID              ValidationCode = "DUPLICATE_TXID"
+	ValidationEndorsementPolicyFailure   ValidationCode = "ENDORSEMENT_POLICY_FAILURE"
+	ValidationMVCCReadConflict           ValidationCode = "MVCC_READ_CONFLICT"
+	ValidationPhantomReadConflict        ValidationCode = "PHANTOM_READ_CONFLICT"
+	ValidationUnknownTxType              ValidationCode = "UNKNOWN_TX_TYPE"
+	ValidationTargetChainNotFound        ValidationCode = "TARGET_CHAIN_NOT_FOUND"
+	ValidationMarshalTxError             ValidationCode = "MARSHAL_TX_ERROR"
+	ValidationNilTxAction                ValidationCode = "NIL_TXACTION"
+	ValidationExpiredChaincode           ValidationCode = "EXPIRED_CHAINCODE"
+	ValidationChaincodeVersionConflict   ValidationCode = "CHAINCODE_VERSION_CONFLICT"
+	ValidationBadHeaderExtension         ValidationCode = "BAD_HEADER_EXTENSION"
+	ValidationBadChannelHeader           ValidationCode = "BAD_CHANNEL_HEADER"
+	ValidationBadResponsePayload         ValidationCode = "BAD_RESPONSE_PAYLOAD"
+	ValidationBadRWSet                   ValidationCode = "BAD_RWSET"
+	ValidationIllegalWriteSet            ValidationCode = "ILLEGAL_WRITESET"
+	ValidationInvalidWriteSet            ValidationCode = "INVALID_WRITESET"
+	ValidationInvalidChaincode           ValidationCode = "INVALID_CHAINCODE"
+	ValidationNotValidated               ValidationCode = "NOT_VALIDATED"
+	ValidationInvalidOtherReason         ValidationCode = "INVALID_OTHER_REASON"
+)
+
+var validationCodes = map[ValidationCode]bool{
+	ValidationValid:                      true,
+	ValidationNilEnvelope:                true,
+	ValidationBadPayload:                 true,
+	ValidationBadCommonHeader:            true,
+	ValidationBadCreatorSignature:        true,
+	ValidationInvalidEndorserTransaction: true,
+	ValidationInvalidConfigTransaction:   true,
+	ValidationUnsupportedTxPayload:       true,
+	ValidationBadProposalTxID:            true,
+	ValidationDuplicateTxID:              true,
+	ValidationEndorsementPolicyFailure:   true,
+	ValidationMVCCReadConflict:           true,
+	ValidationPhantomReadConflict:        true,
+	ValidationUnknownTxType:              true,
+	ValidationTargetChainNotFound:        true,
+	ValidationMarshalTxError:             true,
+	ValidationNilTxAction:                true,
+	ValidationExpiredChaincode:           true,
+	ValidationChaincodeVersionConflict:   true,
+	ValidationBadHeaderExtension:         true,
+	ValidationBadChannelHeader:           true,
+	ValidationBadResponsePayload:         true,
+	ValidationBadRWSet:                   true,
+	ValidationIllegalWriteSet:            true,
+	ValidationInvalidWriteSet:            true,
+	ValidationInvalidChaincode:           true,
+	ValidationNotValidated:               true,
+	ValidationInvalidOtherReason:         true,
+}
+
+// ParseValidationCode parses a transaction reason reported by the gateway.
+// An empty reason is reported for valid transactions.  Reasons may carry a
+// description after the code (e.g. "MVCC_READ_CONFLICT: key changed"), which
+// is ignored.  Unrecognized codes are parsed as ValidationUnknown.
+func ParseValidationCode(reason string) ValidationCode {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ValidationValid
+	}
+	end := strings.IndexFunc(reason, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_')
+	})
+	if end >= 0 {
+		reason = reason[:end]
+	}
+	code := ValidationCode(strings.ToUpper(reason))
+	if !validationCodes[code] {
+		return ValidationUnknown
+	}
+	return code
+}
+
+// Valid reports whether c is ValidationValid.
+func (c ValidationCode) Valid() bool {
+	return c == ValidationValid
+}
+
+// Retryable reports whether a transaction invalidated with c can safely be
+// simulated and submitted again.  This is the case for read conflicts, where
+// the state read by the transaction changed before it was committed and the
+// transaction had no effect.
+func (c ValidationCode) Retryable() bool {
+	switch c {
+	case ValidationMVCCReadConflict, ValidationPhantomReadConflict:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidatedTransaction is implemented by transactions that report the
+// validation code of their transaction reason.
+type ValidatedTransaction interface {
+	Transaction
+	ValidationCode() ValidationCode
+}
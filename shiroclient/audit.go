@@ -0,0 +1,166 @@
+package shiroclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+)
+
+// AuditRecord is a redacted record of a Call for audit trails.  Params and
+// transient data are not recorded; ParamsHash allows matching a record to
+// a request kept elsewhere.
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+	Method    string    `json:"method"`
+	// ParamsHash is the hex encoded SHA-256 hash of the JSON encoded
+	// params.
+	ParamsHash string `json:"params_hash"`
+	TxID       string `json:"tx_id,omitempty"`
+	// CommitBlock is the block the transaction was committed in, or zero
+	// if it is unknown or the call was read-only.
+	CommitBlock uint64        `json:"commit_block,omitempty"`
+	Latency     time.Duration `json:"latency_ns"`
+	// Creator is the MSP ID the call was made on behalf of, see
+	// WithCreator.
+	Creator string `json:"creator,omitempty"`
+	// Caller identifies the user making the call, see AuditOptions.Caller.
+	Caller string `json:"caller,omitempty"`
+	// ErrorCode is the code of a phylum error.
+	ErrorCode int `json:"error_code,omitempty"`
+	// Error is the message of a phylum error or of the error that
+	// prevented a response.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink receives audit records, e.g. to publish them to Kafka.
+// WriteAuditRecord is called synchronously after each recorded call, so
+// slow sinks should buffer records.  It may be called concurrently.
+type AuditSink interface {
+	WriteAuditRecord(rec *AuditRecord) error
+}
+
+// NewAuditWriter returns a sink writing records to w as JSON lines.  Writes
+// are serialized, so w need not be safe for concurrent use.
+func NewAuditWriter(w io.Writer) AuditSink {
+	return &auditWriter{enc: json.NewEncoder(w)}
+}
+
+type auditWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (w *auditWriter) WriteAuditRecord(rec *AuditRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(rec)
+}
+
+// AuditOptions controls the records sent to an AuditSink.
+type AuditOptions struct {
+	// SampleRate is the fraction of successful calls recorded.  Zero, or
+	// a rate of 1 or more, records all calls.  Failed calls are always
+	// recorded.
+	SampleRate float64
+	// MaxPerSecond bounds the number of records per second, including
+	// failed calls, or is zero for no bound.
+	MaxPerSecond int
+	// Caller, if set, returns the identity of the user making a call, e.g.
+	// taken from the request context by an authentication middleware.
+	Caller func(ctx context.Context) string
+	// OnError, if set, is called with the errors of the sink.
+	OnError func(err error)
+}
+
+// WithAuditSink sends a redacted record of every Call to sink for
+// regulatory audit trails.  See AuditRecord for the recorded fields.  It is
+// usually passed to NewRPC or NewMock.  Sinks configured before, e.g. for
+// another trail, still receive records.  Sink errors never fail calls.
+func WithAuditSink(sink AuditSink, opts AuditOptions) Config {
+	a := &auditor{sink: sink, opts: opts}
+	return types.Opt(func(r *types.RequestOptions) {
+		prev := r.CallRecorder
+		r.CallRecorder = func(ctx context.Context, record types.CallRecord) {
+			a.record(ctx, record)
+			if prev != nil {
+				prev(ctx, record)
+			}
+		}
+	})
+}
+
+type auditor struct {
+	sink AuditSink
+	opts AuditOptions
+
+	mu          sync.Mutex
+	windowStart time.Time
+	windowCount int
+}
+
+func (a *auditor) record(ctx context.Context, call types.CallRecord) {
+	failed := call.Err != nil || (call.Response != nil && call.Response.Error() != nil)
+	if !failed && a.opts.SampleRate > 0 && a.opts.SampleRate < 1 && rand.Float64() >= a.opts.SampleRate {
+		return
+	}
+	if !a.allow() {
+		return
+	}
+	opt := call.Options
+	rec := &AuditRecord{
+		Time:      time.Now().UTC(),
+		RequestID: opt.ID,
+		Method:    call.Method,
+		Latency:   call.Duration,
+		Creator:   opt.Creator,
+	}
+	if params, err := opt.JSON.Marshal(opt.Params); err == nil {
+		sum := sha256.Sum256(params)
+		rec.ParamsHash = hex.EncodeToString(sum[:])
+	}
+	if a.opts.Caller != nil {
+		rec.Caller = a.opts.Caller(ctx)
+	}
+	switch {
+	case call.Err != nil:
+		rec.Error = call.Err.Error()
+	case call.Response.Error() != nil:
+		rec.ErrorCode = call.Response.Error().Code()
+		rec.Error = call.Response.Error().Message()
+	default:
+		if commit := GetCommitMetadata(call.Response); commit != nil {
+			rec.TxID = commit.TxID
+			rec.CommitBlock = commit.CommitBlock
+		}
+	}
+	if err := a.sink.WriteAuditRecord(rec); err != nil && a.opts.OnError != nil {
+		a.opts.OnError(err)
+	}
+}
+
+// allow reports whether a record fits in the MaxPerSecond bound.
+func (a *auditor) allow() bool {
+	if a.opts.MaxPerSecond <= 0 {
+		return true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	if now.Sub(a.windowStart) >= time.Second {
+		a.windowStart = now
+		a.windowCount = 0
+	}
+	if a.windowCount >= a.opts.MaxPerSecond {
+		return false
+	}
+	a.windowCount++
+	return true
+}
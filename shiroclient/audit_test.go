@@ -0,0 +1,106 @@
+package shiroclient_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/mockgateway"
+)
+
+// auditClient is a ShiroClient whose calls commit in block 9, except for
+// "fail" which returns a phylum error.
+type auditClient struct {
+	shiroclient.ShiroClient
+}
+
+func (c *auditClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	if method == "fail" {
+		return types.NewFailureResponse(400, "bad request", nil), nil
+	}
+	resp := types.NewSuccessResponse([]byte(`{}`), "", 0, 0)
+	resp.SetCommit(&types.CommitMetadata{TxID: "tx1", CommitBlock: 9})
+	return resp, nil
+}
+
+type callerKey struct{}
+
+type failingSink struct{}
+
+func (failingSink) WriteAuditRecord(*shiroclient.AuditRecord) error {
+	return errors.New("sink down")
+}
+
+func TestWithAuditSink(t *testing.T) {
+	gw := mockgateway.NewServer(&auditClient{})
+	t.Cleanup(gw.Close)
+
+	var buf bytes.Buffer
+	var sinkErrs []error
+	client := shiroclient.NewRPC([]shiroclient.Config{
+		gw.Config(),
+		shiroclient.WithAuditSink(shiroclient.NewAuditWriter(&buf), shiroclient.AuditOptions{
+			Caller: func(ctx context.Context) string {
+				caller, _ := ctx.Value(callerKey{}).(string)
+				return caller
+			},
+		}),
+		shiroclient.WithAuditSink(failingSink{}, shiroclient.AuditOptions{
+			OnError: func(err error) { sinkErrs = append(sinkErrs, err) },
+		}),
+	})
+	ctx := context.WithValue(context.Background(), callerKey{}, "alice")
+	_, err := client.Call(ctx, "transfer", shiroclient.WithParams([]string{"secret"}), shiroclient.WithCreator("Org1MSP"))
+	require.NoError(t, err)
+	_, err = client.Call(ctx, "fail", shiroclient.WithParams([]string{"x"}))
+	require.NoError(t, err)
+	require.Len(t, sinkErrs, 2)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.NotContains(t, lines[0], "secret")
+	var rec shiroclient.AuditRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &rec))
+	require.Equal(t, "transfer", rec.Method)
+	require.Equal(t, "tx1", rec.TxID)
+	require.Equal(t, uint64(9), rec.CommitBlock)
+	require.Equal(t, "Org1MSP", rec.Creator)
+	require.Equal(t, "alice", rec.Caller)
+	require.Len(t, rec.ParamsHash, 64)
+	require.NotEmpty(t, rec.RequestID)
+
+	rec = shiroclient.AuditRecord{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &rec))
+	require.Equal(t, 400, rec.ErrorCode)
+	require.Equal(t, "bad request", rec.Error)
+}
+
+func TestAuditSampling(t *testing.T) {
+	gw := mockgateway.NewServer(&auditClient{})
+	t.Cleanup(gw.Close)
+
+	var buf bytes.Buffer
+	client := shiroclient.NewRPC([]shiroclient.Config{
+		gw.Config(),
+		shiroclient.WithAuditSink(shiroclient.NewAuditWriter(&buf), shiroclient.AuditOptions{SampleRate: 1e-9, MaxPerSecond: 2}),
+	})
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		_, err := client.Call(ctx, "transfer")
+		require.NoError(t, err)
+	}
+	require.Empty(t, buf.String())
+	// failures are not sampled out, but are bounded.
+	for i := 0; i < 5; i++ {
+		_, err := client.Call(ctx, "fail")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, strings.Count(buf.String(), "\n"))
+}
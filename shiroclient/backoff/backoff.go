@@ -0,0 +1,211 @@
+// Package backoff implements the exponential backoff used by the retry
+// loops of the SDK, for reuse by its consumers.
+//
+//	policy := backoff.Policy{
+//		MaxAttempts: 5,
+//		Initial:     100 * time.Millisecond,
+//		Max:         2 * time.Second,
+//		Jitter:      0.5,
+//		Budget:      backoff.NewBudget(0.1, 10),
+//	}
+//	err := backoff.Retry(ctx, policy, func(ctx context.Context) error {
+//		resp, err := client.Call(ctx, "get_balance", configs...)
+//		if err != nil && !shiroclient.IsTimeoutError(err) {
+//			return backoff.Permanent(err)
+//		}
+//		...
+//	})
+package backoff
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultMultiplier is the growth factor of delays of a Policy without a
+// Multiplier.
+const DefaultMultiplier = 2
+
+// Policy configures exponential backoff.  The delay before retry n,
+// counting from 1, is Initial multiplied by Multiplier n-1 times, capped
+// at Max if it is positive, and then reduced by a random fraction of up to
+// Jitter.
+type Policy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// Values less than 1 allow unlimited attempts.
+	MaxAttempts int
+	// Initial is the delay before the first retry.
+	Initial time.Duration
+	// Max caps the delay between attempts, if positive.
+	Max time.Duration
+	// Multiplier is the growth factor of delays.  Values less than 1
+	// select DefaultMultiplier.
+	Multiplier float64
+	// Jitter is the maximum fraction, between 0 and 1, by which delays are
+	// randomly reduced to spread out the retries of concurrent clients.  A
+	// Jitter of 1 selects delays uniformly between zero and the
+	// exponential delay.
+	Jitter float64
+	// Budget, if set, bounds retries across all the calls sharing it.
+	Budget *Budget
+	// OnRetry, if set, is called before each retry with the number of the
+	// failed attempt, counting from 1, its error and the delay before the
+	// retry.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// Delay returns the delay before retry n, counting from 1.
+func (p Policy) Delay(n int) time.Duration {
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = DefaultMultiplier
+	}
+	delay := float64(p.Initial)
+	for i := 1; i < n; i++ {
+		delay *= mult
+		if p.Max > 0 && delay >= float64(p.Max) {
+			break
+		}
+		if delay >= math.MaxInt64 {
+			break
+		}
+	}
+	if p.Max > 0 && delay > float64(p.Max) {
+		delay = float64(p.Max)
+	}
+	if jitter := math.Min(math.Max(p.Jitter, 0), 1); jitter > 0 {
+		delay -= delay * jitter * rand.Float64()
+	}
+	if delay >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(delay)
+}
+
+// Sleep waits for d or until ctx is done, whichever comes first, and
+// returns the error of ctx if it is done.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err to stop Retry, which returns err.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent returns true if err was wrapped with Permanent.
+func IsPermanent(err error) bool {
+	var perr *permanentError
+	return errors.As(err, &perr)
+}
+
+// Retry calls fn until it succeeds, returns an error wrapped with
+// Permanent, the attempts or retry budget of p are exhausted, or ctx is
+// done.  The last error of fn is returned, unwrapped from Permanent.  The
+// error of ctx is returned if it is done before the first attempt.
+func Retry(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.Budget.Calls(1)
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		var perr *permanentError
+		if errors.As(err, &perr) {
+			return perr.err
+		}
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return err
+		}
+		if !p.Budget.TryRetry() {
+			return err
+		}
+		delay := p.Delay(attempt)
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+		if Sleep(ctx, delay) != nil {
+			return err
+		}
+	}
+}
+
+// Budget bounds the retries of many calls, so that a failing dependency
+// is not overloaded by retries.  Each call deposits ratio tokens and each
+// retry takes one, so that sustained retries are at most ratio times the
+// calls; a reserve of tokens allows bursts of retries.  A nil Budget
+// allows all retries.  It is safe for concurrent use.
+type Budget struct {
+	ratio   float64
+	reserve float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// NewBudget returns a budget allowing retries of ratio times the calls,
+// with a reserve of tokens allowing bursts of reserve retries.  The
+// reserve is at least 1.
+func NewBudget(ratio float64, reserve int) *Budget {
+	if reserve < 1 {
+		reserve = 1
+	}
+	return &Budget{ratio: ratio, reserve: float64(reserve), tokens: float64(reserve)}
+}
+
+// Calls records n calls, e.g. made by a retry loop not using Retry.
+func (b *Budget) Calls(n int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.tokens+b.ratio*float64(n), b.reserve)
+}
+
+// TryRetry takes a token for a retry, returning false if the budget is
+// exhausted.
+func (b *Budget) TryRetry() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
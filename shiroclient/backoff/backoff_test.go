@@ -0,0 +1,101 @@
+package backoff_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/backoff"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	p := backoff.Policy{Initial: 10 * time.Millisecond, Max: 35 * time.Millisecond}
+	require.Equal(t, 10*time.Millisecond, p.Delay(1))
+	require.Equal(t, 20*time.Millisecond, p.Delay(2))
+	require.Equal(t, 35*time.Millisecond, p.Delay(3))
+	require.Equal(t, 35*time.Millisecond, p.Delay(100))
+
+	p = backoff.Policy{Initial: time.Second, Multiplier: 3}
+	require.Equal(t, 9*time.Second, p.Delay(3))
+	require.Greater(t, p.Delay(1000), time.Duration(0))
+
+	p = backoff.Policy{Initial: 100 * time.Millisecond, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		d := p.Delay(1)
+		require.GreaterOrEqual(t, d, 50*time.Millisecond)
+		require.LessOrEqual(t, d, 100*time.Millisecond)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	errFail := errors.New("fail")
+
+	var attempts int
+	var retries []int
+	err := backoff.Retry(ctx, backoff.Policy{
+		MaxAttempts: 3,
+		Initial:     time.Millisecond,
+		OnRetry:     func(attempt int, err error, delay time.Duration) { retries = append(retries, attempt) },
+	}, func(ctx context.Context) error {
+		attempts++
+		return errFail
+	})
+	require.ErrorIs(t, err, errFail)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, []int{1, 2}, retries)
+
+	attempts = 0
+	err = backoff.Retry(ctx, backoff.Policy{Initial: time.Millisecond}, func(ctx context.Context) error {
+		attempts++
+		if attempts == 4 {
+			return nil
+		}
+		return errFail
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, attempts)
+
+	attempts = 0
+	err = backoff.Retry(ctx, backoff.Policy{}, func(ctx context.Context) error {
+		attempts++
+		return backoff.Permanent(errFail)
+	})
+	require.Equal(t, errFail, err)
+	require.Equal(t, 1, attempts)
+
+	cctx, cancel := context.WithCancel(ctx)
+	err = backoff.Retry(cctx, backoff.Policy{Initial: time.Hour}, func(ctx context.Context) error {
+		cancel()
+		return errFail
+	})
+	require.ErrorIs(t, err, errFail)
+	require.ErrorIs(t, backoff.Retry(cctx, backoff.Policy{}, nil), context.Canceled)
+}
+
+func TestBudget(t *testing.T) {
+	ctx := context.Background()
+	errFail := errors.New("fail")
+	budget := backoff.NewBudget(0.5, 2)
+	p := backoff.Policy{MaxAttempts: 10, Budget: budget}
+
+	// the reserve allows two retries.
+	var attempts int
+	err := backoff.Retry(ctx, p, func(ctx context.Context) error {
+		attempts++
+		return errFail
+	})
+	require.ErrorIs(t, err, errFail)
+	require.Equal(t, 3, attempts)
+
+	// each call then earns half a retry.
+	require.False(t, budget.TryRetry())
+	budget.Calls(2)
+	require.True(t, budget.TryRetry())
+	require.False(t, budget.TryRetry())
+
+	require.True(t, (*backoff.Budget)(nil).TryRetry())
+}
@@ -8,6 +8,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
 	"sync"
 	"time"
 
@@ -16,8 +19,11 @@ import (
 )
 
 type options struct {
-	log       logrus.FieldLogger
-	logFields logrus.Fields
+	log         logrus.FieldLogger
+	logFields   logrus.Fields
+	compression *compression
+	onPanic     func(*Panic)
+	negotiate   bool
 }
 
 // Config is a type for a function that can mutate an options object.
@@ -54,6 +60,38 @@ func WithLogrusFields(fields logrus.Fields) Config {
 	}
 }
 
+// WithEnvelopeNegotiation passes MaxEnvelopeVersion to batch_get_requests
+// so that the phylum returns envelopes the Driver understands.  Phylums
+// that predate envelope versioning reject the extra parameter, so
+// negotiation must only be enabled for phylums supporting it.
+func WithEnvelopeNegotiation() Config {
+	return func(r *options) {
+		r.negotiate = true
+	}
+}
+
+// Panic describes a panic recovered from a batch callback.
+type Panic struct {
+	BatchName string
+	BatchID   string
+	RequestID string
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// WithPanicHandler calls handler for each panic recovered from a callback,
+// e.g. to count panics in a metric.  Panics are recovered whether or not a
+// handler is set: they are logged with their stack trace and the request
+// receives an error response.  handler is called synchronously from the
+// goroutine of the callback.
+func WithPanicHandler(handler func(p *Panic)) Config {
+	return func(r *options) {
+		r.onPanic = handler
+	}
+}
+
 const (
 	batchGetRequestsMethod     = "batch_get_requests"
 	batchProcessResponseMethod = "batch_process_response"
@@ -104,12 +142,96 @@ func (d *Driver) call(ctx context.Context, method string, params interface{}, ba
 	return res
 }
 
+// MaxEnvelopeVersion is the highest envelope version understood by the
+// Driver.  Version 0 designates the envelopes of phylums that predate
+// envelope versioning.  Requests with a higher version are not processed,
+// since their schema may have changed in ways the Driver cannot detect:
+// they are logged and answered with an error response wrapping
+// ErrUnsupportedEnvelopeVersion.
+const MaxEnvelopeVersion = EnvelopeVersionChunked
+
+// ErrUnsupportedEnvelopeVersion is returned, in an error response, for
+// requests whose envelope version is higher than MaxEnvelopeVersion.
+var ErrUnsupportedEnvelopeVersion = errors.New("batch: unsupported envelope version")
+
 // RequestEnvelope corresponds to the JSON structure used for batch
 // requests in the Elps code.
 type RequestEnvelope struct {
 	BatchID   string          `json:"batch_id"`
 	RequestID string          `json:"request_id"`
 	Message   json.RawMessage `json:"message"`
+	// Priority orders requests within a poll, highest first.  It is zero
+	// unless the request was scheduled with a priority.
+	Priority int `json:"priority,omitempty"`
+	// RequestType is the type the request was scheduled with, if any.
+	RequestType string `json:"request_type,omitempty"`
+	// Version is the envelope version of the request.  See
+	// MaxEnvelopeVersion.
+	Version int `json:"version,omitempty"`
+	// MessageType identifies the schema of Message, if set.
+	MessageType string `json:"message_type,omitempty"`
+}
+
+// FetchOptions bound and filter the requests processed by each poll of a
+// batch.  They are passed to batch_get_requests so that the phylum can
+// select the requests, and are also applied to the requests it returns, so
+// that phylums ignoring them still only have the selected requests
+// processed.  Requests that are not processed remain pending.
+type FetchOptions struct {
+	// Limit is the maximum number of requests processed by a poll, or
+	// zero for no limit.
+	Limit int `json:"limit,omitempty"`
+	// MinPriority skips requests with a lower priority.
+	MinPriority int `json:"min_priority,omitempty"`
+	// RequestTypes, if not empty, skips requests of other types.
+	RequestTypes []string `json:"request_types,omitempty"`
+}
+
+// getRequestsOptions is the optional batch_get_requests parameter.
+type getRequestsOptions struct {
+	*FetchOptions
+	EnvelopeVersion int `json:"envelope_version,omitempty"`
+}
+
+func (f *FetchOptions) isZero() bool {
+	return f.Limit == 0 && f.MinPriority == 0 && len(f.RequestTypes) == 0
+}
+
+// filterRequests returns the requests of envs selected by the filters of
+// f, highest priority first.
+func (f *FetchOptions) filterRequests(envs []RequestEnvelope) []RequestEnvelope {
+	selected := make([]RequestEnvelope, 0, len(envs))
+	for _, env := range envs {
+		if env.Priority < f.MinPriority {
+			continue
+		}
+		if len(f.RequestTypes) > 0 && !containsString(f.RequestTypes, env.RequestType) {
+			continue
+		}
+		selected = append(selected, env)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Priority > selected[j].Priority
+	})
+	return selected
+}
+
+// limitRequests returns the first requests of envs allowed by the limit of
+// f.
+func (f *FetchOptions) limitRequests(envs []RequestEnvelope) []RequestEnvelope {
+	if f.Limit > 0 && len(envs) > f.Limit {
+		return envs[:f.Limit]
+	}
+	return envs
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
 
 // ResponseEnvelope corresponds to the JSON structure used for batch
@@ -119,6 +241,15 @@ type ResponseEnvelope struct {
 	RequestID string          `json:"request_id"`
 	IsError   bool            `json:"is_error"`
 	Message   json.RawMessage `json:"message"`
+	// Version and MessageType are those of the request.
+	Version     int    `json:"version,omitempty"`
+	MessageType string `json:"message_type,omitempty"`
+	// Encoding, ChunkIndex and ChunkCount are set on the chunks of
+	// compressed responses, whose version is EnvelopeVersionChunked.  See
+	// WithResponseCompression.
+	Encoding   string `json:"encoding,omitempty"`
+	ChunkIndex int    `json:"chunk_index,omitempty"`
+	ChunkCount int    `json:"chunk_count,omitempty"`
 }
 
 type callbackFunc func(batchID string, requestID string, message json.RawMessage) (json.RawMessage, error)
@@ -129,11 +260,16 @@ type Ticker struct {
 	batchName     string
 	callback      callbackFunc
 	clientConfigs []shiroclient.Config
-	ticker        *time.Ticker
+	schedule      Schedule
 	override      chan bool
-	// rwMutex guards the enable boolean
+	stop          chan struct{}
+	stopOnce      sync.Once
+	// rwMutex guards the enable boolean, the next poll time and the
+	// fetch options
 	rwMutex *sync.RWMutex
 	enable  bool
+	next    time.Time
+	fetch   FetchOptions
 }
 
 // Tick forces an additional poll right now. This is independent of
@@ -144,7 +280,16 @@ type Ticker struct {
 func (t *Ticker) Tick(ctx context.Context) {
 	d := t.driver
 
-	res := d.call(ctx, batchGetRequestsMethod, []interface{}{t.batchName}, t.batchName, "", "", t.clientConfigs...)
+	fetch := t.FetchOptions()
+	params := []interface{}{t.batchName}
+	if !fetch.isZero() || d.opt.negotiate {
+		getOpts := &getRequestsOptions{FetchOptions: &fetch}
+		if d.opt.negotiate {
+			getOpts.EnvelopeVersion = MaxEnvelopeVersion
+		}
+		params = append(params, getOpts)
+	}
+	res := d.call(ctx, batchGetRequestsMethod, params, t.batchName, "", "", t.clientConfigs...)
 	if res == nil {
 		return
 	}
@@ -159,6 +304,36 @@ func (t *Ticker) Tick(ctx context.Context) {
 			Error("Batch::Tick: failed to unmarshal while polling")
 		return
 	}
+	// requests of unsupported versions are answered with an error before
+	// the limit is applied, so that they neither remain pending forever
+	// nor starve supported requests.
+	supported := envs[:0]
+	for _, env := range fetch.filterRequests(envs) {
+		if env.Version < 0 || env.Version > MaxEnvelopeVersion {
+			env := env
+			d.opt.log.
+				WithFields(d.opt.logFields).
+				WithField("batchName", t.batchName).
+				WithField("batchID", env.BatchID).
+				WithField("requestID", env.RequestID).
+				WithField("envelopeVersion", env.Version).
+				WithField("maxEnvelopeVersion", MaxEnvelopeVersion).
+				WithError(ErrUnsupportedEnvelopeVersion).
+				Error("Batch::Tick: rejecting request with unsupported envelope version; upgrade the SDK")
+			t.respond(ctx, &env, nil, fmt.Errorf("%w %d (max %d)", ErrUnsupportedEnvelopeVersion, env.Version, MaxEnvelopeVersion))
+			continue
+		}
+		supported = append(supported, env)
+	}
+	selected := fetch.limitRequests(supported)
+	if skipped := len(envs) - len(selected); skipped > 0 {
+		d.opt.log.
+			WithFields(d.opt.logFields).
+			WithField("batchName", t.batchName).
+			WithField("skipped", skipped).
+			Debug("Batch::Tick: skipped requests not selected by fetch options")
+	}
+	envs = selected
 
 	var wg sync.WaitGroup
 	defer wg.Wait()
@@ -177,7 +352,7 @@ func (t *Ticker) Tick(ctx context.Context) {
 		go func() {
 			defer wg.Done()
 
-			response, err := t.callback(env.BatchID, env.RequestID, env.Message)
+			response, err := t.runCallback(&env)
 			if err == nil && len(response) == 0 {
 				err = errors.New("Batch::Tick: zero-length response")
 			}
@@ -190,64 +365,121 @@ func (t *Ticker) Tick(ctx context.Context) {
 					WithError(err).
 					Error("Batch::Tick: callback failed to produce response")
 			}
+			t.respond(ctx, &env, response, err)
+		}()
+	}
+}
 
-			var isError bool
-			var message json.RawMessage
-
-			if err == nil {
-				isError = false
-				message = response
-			} else {
-				errError := err.Error()
-				isError = true
-				message, err = json.Marshal(&errError)
-				if err != nil {
-					d.opt.log.
-						WithFields(d.opt.logFields).
-						WithField("batchName", t.batchName).
-						WithField("batchID", env.BatchID).
-						WithField("requestID", env.RequestID).
-						WithError(err).
-						Error("Batch::Tick: failed to marshal error response")
-					return
-				}
-			}
+// respond sends the response to env, or an error response if err is set.
+func (t *Ticker) respond(ctx context.Context, env *RequestEnvelope, response json.RawMessage, err error) {
+	d := t.driver
 
-			params := []interface{}{
-				t.batchName,
-				&ResponseEnvelope{
-					BatchID:   env.BatchID,
-					RequestID: env.RequestID,
-					IsError:   isError,
-					Message:   message,
-				},
-			}
-			result := d.call(ctx, batchProcessResponseMethod, params, t.batchName, env.BatchID, env.RequestID, t.clientConfigs...)
-			if result == nil {
-				d.opt.log.
-					WithFields(d.opt.logFields).
-					WithField("batchName", t.batchName).
-					WithField("batchID", env.BatchID).
-					WithField("requestID", env.RequestID).
-					Error("Batch::Tick: response method failed")
-				return
-			}
+	var isError bool
+	var message json.RawMessage
+
+	if err == nil {
+		isError = false
+		message = response
+	} else {
+		errError := err.Error()
+		isError = true
+		message, err = json.Marshal(&errError)
+		if err != nil {
+			d.opt.log.
+				WithFields(d.opt.logFields).
+				WithField("batchName", t.batchName).
+				WithField("batchID", env.BatchID).
+				WithField("requestID", env.RequestID).
+				WithError(err).
+				Error("Batch::Tick: failed to marshal error response")
+			return
+		}
+	}
 
-			d.opt.log.WithFields(d.opt.logFields).
+	version := env.Version
+	if version < 0 || version > MaxEnvelopeVersion {
+		version = MaxEnvelopeVersion
+	}
+	resps, err := d.opt.compression.responseEnvelopes(env, &ResponseEnvelope{
+		BatchID:     env.BatchID,
+		RequestID:   env.RequestID,
+		IsError:     isError,
+		Message:     message,
+		Version:     version,
+		MessageType: env.MessageType,
+	})
+	if err != nil {
+		d.opt.log.
+			WithFields(d.opt.logFields).
+			WithField("batchName", t.batchName).
+			WithField("batchID", env.BatchID).
+			WithField("requestID", env.RequestID).
+			WithError(err).
+			Error("Batch::Tick: failed to compress response")
+		return
+	}
+	for _, resp := range resps {
+		params := []interface{}{t.batchName, resp}
+		result := d.call(ctx, batchProcessResponseMethod, params, t.batchName, env.BatchID, env.RequestID, t.clientConfigs...)
+		if result == nil {
+			d.opt.log.
+				WithFields(d.opt.logFields).
 				WithField("batchName", t.batchName).
 				WithField("batchID", env.BatchID).
 				WithField("requestID", env.RequestID).
-				Debug("batch processed response")
-		}()
+				Error("Batch::Tick: response method failed")
+			return
+		}
 	}
+
+	d.opt.log.WithFields(d.opt.logFields).
+		WithField("batchName", t.batchName).
+		WithField("batchID", env.BatchID).
+		WithField("requestID", env.RequestID).
+		Debug("batch processed response")
+}
+
+// runCallback calls the callback for env, converting a panic into an
+// error.
+func (t *Ticker) runCallback(env *RequestEnvelope) (response json.RawMessage, err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		p := &Panic{
+			BatchName: t.batchName,
+			BatchID:   env.BatchID,
+			RequestID: env.RequestID,
+			Value:     v,
+			Stack:     debug.Stack(),
+		}
+		d := t.driver
+		d.opt.log.
+			WithFields(d.opt.logFields).
+			WithField("batchName", t.batchName).
+			WithField("batchID", env.BatchID).
+			WithField("requestID", env.RequestID).
+			WithField("stack", string(p.Stack)).
+			Errorf("Batch::Tick: callback panicked: %v", v)
+		if d.opt.onPanic != nil {
+			d.opt.onPanic(p)
+		}
+		response, err = nil, fmt.Errorf("Batch::Tick: callback panicked: %v", v)
+	}()
+	return t.callback(env.BatchID, env.RequestID, env.Message)
 }
 
 // TickAsync forces an asynchronous poll. This is independent of the
 // Pause/Resume mechanism; the poll will happen even if regular
 // polling is paused. It should return (almost) immediately, without
-// waiting for the polling and responses to take place.
+// waiting for the polling and responses to take place. TickAsync
+// does nothing once the Ticker is stopped.
 func (t *Ticker) TickAsync() {
-	t.override <- true
+	select {
+	case t.override <- true:
+	case <-t.stop:
+	}
 }
 
 // Pause pauses regular polling.
@@ -268,7 +500,44 @@ func (t *Ticker) Resume() {
 
 // Stop permanently stops regular polling.
 func (t *Ticker) Stop() {
-	t.ticker.Stop()
+	t.stopOnce.Do(func() { close(t.stop) })
+}
+
+// SetFetchOptions sets the options bounding and filtering the requests
+// processed by subsequent polls.  The zero FetchOptions processes every
+// request returned by the phylum, as by default.
+func (t *Ticker) SetFetchOptions(opts FetchOptions) {
+	opts.RequestTypes = append([]string(nil), opts.RequestTypes...)
+
+	t.rwMutex.Lock()
+	defer t.rwMutex.Unlock()
+
+	t.fetch = opts
+}
+
+// FetchOptions returns the options set with SetFetchOptions.
+func (t *Ticker) FetchOptions() FetchOptions {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+
+	return t.fetch
+}
+
+// Next returns the time of the next regular poll, or the zero time if the
+// schedule does not fire again or polling was stopped.  The next poll is
+// skipped if polling is paused at that time.
+func (t *Ticker) Next() time.Time {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+
+	return t.next
+}
+
+func (t *Ticker) setNext(next time.Time) {
+	t.rwMutex.Lock()
+	defer t.rwMutex.Unlock()
+
+	t.next = next
 }
 
 // Register registers a callback for a specific batch name with a
@@ -281,29 +550,57 @@ func (t *Ticker) Stop() {
 // Register). Also, the callback function should return results in a
 // reasonable timeframe or return an error, not hang indefinitely.
 func (d *Driver) Register(ctx context.Context, batchName string, interval time.Duration, callback func(batchID string, requestID string, message json.RawMessage) (json.RawMessage, error), configs ...shiroclient.Config) *Ticker {
+	return d.RegisterSchedule(ctx, batchName, Every(interval), callback, configs...)
+}
+
+// RegisterSchedule is like Register but polls at the times of schedule,
+// e.g. a cron expression parsed with ParseCron, instead of at a fixed
+// interval.  Like regular polls, scheduled polls that fall while polling
+// is paused are skipped.
+func (d *Driver) RegisterSchedule(ctx context.Context, batchName string, schedule Schedule, callback func(batchID string, requestID string, message json.RawMessage) (json.RawMessage, error), configs ...shiroclient.Config) *Ticker {
 	ticker := &Ticker{
 		driver:        d,
 		batchName:     batchName,
 		callback:      callback,
 		clientConfigs: configs,
-		ticker:        time.NewTicker(interval),
+		schedule:      schedule,
 		override:      make(chan bool),
+		stop:          make(chan struct{}),
 		rwMutex:       &sync.RWMutex{},
 		enable:        true,
 	}
+	ticker.next = schedule.Next(time.Now())
 
 	poll := func() {
+		defer ticker.setNext(time.Time{})
 		for {
 			var enable bool
 
+			var fire <-chan time.Time
+			var timer *time.Timer
+			if next := ticker.Next(); !next.IsZero() {
+				timer = time.NewTimer(time.Until(next))
+				fire = timer.C
+			}
+
 			select {
-			case <-ticker.ticker.C:
-				ticker.rwMutex.RLock()
+			case <-fire:
+				ticker.rwMutex.Lock()
 				enable = ticker.enable
-				ticker.rwMutex.RUnlock()
+				ticker.next = schedule.Next(time.Now())
+				ticker.rwMutex.Unlock()
 
 			case <-ticker.override:
 				enable = true
+
+			case <-ticker.stop:
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+			if timer != nil {
+				timer.Stop()
 			}
 
 			if !enable {
@@ -5,12 +5,12 @@ import (
 	_ "embed"
 	"encoding/json"
 	"errors"
-	"sync"
 	"testing"
 	"time"
 
 	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
 	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/batch"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/mockclock"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,29 +20,10 @@ import (
 var testPhylum []byte
 
 func Test001(t *testing.T) {
-	var TS001 = "2000-01-01T00:00:00-08:00"
 	var TS002 = "2000-01-02T00:00:00-08:00"
-	var TS003 = "2000-01-03T00:00:00-08:00"
 
-	var tsMutex *sync.Mutex
-	var tsString string
-
-	tsMutex = &sync.Mutex{}
-	tsString = TS001
-
-	tsAssign := func(tsInput string) {
-		tsMutex.Lock()
-		defer tsMutex.Unlock()
-
-		tsString = tsInput
-	}
-
-	tsGenerator := func(ctx context.Context) string {
-		tsMutex.Lock()
-		defer tsMutex.Unlock()
-
-		return tsString
-	}
+	pst := time.FixedZone("", -8*3600)
+	clock := mockclock.New(time.Date(2000, 1, 1, 0, 0, 0, 0, pst))
 
 	log := logrus.New()
 
@@ -50,7 +31,7 @@ func Test001(t *testing.T) {
 
 	clientConfigs := []shiroclient.Config{
 		shiroclient.WithLog(log),
-		shiroclient.WithTimestampGenerator(tsGenerator),
+		clock.Config(),
 	}
 	client, err := shiroclient.NewMock(clientConfigs)
 	require.Nil(t, err)
@@ -146,7 +127,7 @@ func Test001(t *testing.T) {
 				require.Equal(t, "ping2", lastReceivedMessage, "Expected lastReceivedMessage to be 'ping2' before advancing time")
 
 				// Now artificially advance time
-				tsAssign(TS003)
+				clock.Set(time.Date(2000, 1, 3, 0, 0, 0, 0, pst))
 
 				// Tick (again)
 				doTick(t)
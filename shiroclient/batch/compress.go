@@ -0,0 +1,83 @@
+package batch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+)
+
+// EnvelopeVersionChunked is the lowest request envelope version of
+// phylums that reassemble compressed, chunked responses.
+const EnvelopeVersionChunked = 2
+
+// EncodingGzipBase64 is the encoding of compressed response messages: the
+// message is compressed with gzip and encoded with standard base64, and
+// each chunk holds a part of the encoded string as a JSON string.
+const EncodingGzipBase64 = "gzip+base64"
+
+// DefaultChunkSize is the size of response chunks when
+// WithResponseCompression is given no chunk size.
+const DefaultChunkSize = 256 << 10
+
+type compression struct {
+	threshold int
+	chunkSize int
+}
+
+// WithResponseCompression compresses response messages larger than
+// threshold bytes, splitting the result into chunks of at most chunkSize
+// bytes that are sent in separate calls, so that large responses fit in
+// gateway limits.  A non-positive chunkSize selects DefaultChunkSize.
+// Responses are only compressed for requests whose envelope version is at
+// least EnvelopeVersionChunked, since older phylums cannot reassemble
+// them; other responses are sent as is.
+func WithResponseCompression(threshold int, chunkSize int) Config {
+	return func(r *options) {
+		if chunkSize <= 0 {
+			chunkSize = DefaultChunkSize
+		}
+		r.compression = &compression{threshold: threshold, chunkSize: chunkSize}
+	}
+}
+
+// responseEnvelopes returns the envelopes carrying resp to the phylum, in
+// the order they must be sent.
+func (c *compression) responseEnvelopes(req *RequestEnvelope, resp *ResponseEnvelope) ([]*ResponseEnvelope, error) {
+	if c == nil || req.Version < EnvelopeVersionChunked || len(resp.Message) <= c.threshold {
+		return []*ResponseEnvelope{resp}, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(resp.Message); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+	count := (len(encoded) + c.chunkSize - 1) / c.chunkSize
+	envs := make([]*ResponseEnvelope, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * c.chunkSize
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		message, err := json.Marshal(encoded[i*c.chunkSize : end])
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, &ResponseEnvelope{
+			BatchID:     resp.BatchID,
+			RequestID:   resp.RequestID,
+			IsError:     resp.IsError,
+			Message:     message,
+			Version:     EnvelopeVersionChunked,
+			MessageType: resp.MessageType,
+			Encoding:    EncodingGzipBase64,
+			ChunkIndex:  i,
+			ChunkCount:  count,
+		})
+	}
+	return envs, nil
+}
@@ -0,0 +1,71 @@
+package batch_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/batch"
+)
+
+// reassemble decodes the chunks of a compressed response, as the phylum
+// does.
+func reassemble(t *testing.T, chunks []*batch.ResponseEnvelope) []byte {
+	var encoded strings.Builder
+	for i, chunk := range chunks {
+		require.Equal(t, batch.EncodingGzipBase64, chunk.Encoding)
+		require.Equal(t, i, chunk.ChunkIndex)
+		require.Equal(t, len(chunks), chunk.ChunkCount)
+		var part string
+		require.NoError(t, json.Unmarshal(chunk.Message, &part))
+		encoded.WriteString(part)
+	}
+	compressed, err := base64.StdEncoding.DecodeString(encoded.String())
+	require.NoError(t, err)
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	message, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return message
+}
+
+func TestResponseCompression(t *testing.T) {
+	large, err := json.Marshal(strings.Repeat("document ", 10000))
+	require.NoError(t, err)
+	client := &batchClient{requests: []batch.RequestEnvelope{
+		{BatchID: "b", RequestID: "chunked", Message: large, Version: batch.EnvelopeVersionChunked},
+		{BatchID: "b", RequestID: "old", Message: large},
+		{BatchID: "b", RequestID: "small", Message: json.RawMessage(`"ok"`), Version: batch.EnvelopeVersionChunked},
+	}}
+	driver := batch.NewDriver(client, batch.WithResponseCompression(1024, 100))
+	ticker := driver.RegisterSchedule(context.Background(), "test_batch", batch.MustParseCron("@yearly", nil), func(batchID string, requestID string, message json.RawMessage) (json.RawMessage, error) {
+		return message, nil
+	})
+	defer ticker.Stop()
+	ticker.Tick(context.Background())
+
+	byID := make(map[string][]*batch.ResponseEnvelope)
+	for _, resp := range client.responses {
+		byID[resp.RequestID] = append(byID[resp.RequestID], resp)
+	}
+	chunks := byID["chunked"]
+	require.Greater(t, len(chunks), 1)
+	for _, chunk := range chunks {
+		require.LessOrEqual(t, len(chunk.Message), 100+2)
+	}
+	require.Equal(t, []byte(large), reassemble(t, chunks))
+
+	// phylums without chunking support and small responses are sent as is.
+	require.Len(t, byID["old"], 1)
+	require.Equal(t, json.RawMessage(large), byID["old"][0].Message)
+	require.Empty(t, byID["old"][0].Encoding)
+	require.Len(t, byID["small"], 1)
+	require.Equal(t, json.RawMessage(`"ok"`), byID["small"][0].Message)
+}
@@ -0,0 +1,168 @@
+package batch_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/batch"
+)
+
+// batchClient is a ShiroClient serving fixed batch requests, ignoring the
+// fetch options like an older phylum.
+type batchClient struct {
+	shiroclient.ShiroClient
+	requests []batch.RequestEnvelope
+
+	mu        sync.Mutex
+	getParams []json.RawMessage
+	processed []string
+	responses []*batch.ResponseEnvelope
+}
+
+func (c *batchClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	params, err := json.Marshal(opt.Params)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch method {
+	case "batch_get_requests":
+		c.getParams = append(c.getParams, params)
+		result, err := json.Marshal(c.requests)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewSuccessResponse(result, "", 0, 0), nil
+	default:
+		var resp []json.RawMessage
+		if err := json.Unmarshal(params, &resp); err != nil {
+			return nil, err
+		}
+		var env batch.ResponseEnvelope
+		if err := json.Unmarshal(resp[1], &env); err != nil {
+			return nil, err
+		}
+		c.processed = append(c.processed, env.RequestID)
+		c.responses = append(c.responses, &env)
+		return types.NewSuccessResponse([]byte(`{}`), "", 0, 0), nil
+	}
+}
+
+func TestFetchOptions(t *testing.T) {
+	client := &batchClient{requests: []batch.RequestEnvelope{
+		{BatchID: "b", RequestID: "low", Message: json.RawMessage(`1`), Priority: 1, RequestType: "report"},
+		{BatchID: "b", RequestID: "high", Message: json.RawMessage(`2`), Priority: 9, RequestType: "payment"},
+		{BatchID: "b", RequestID: "mid", Message: json.RawMessage(`3`), Priority: 5, RequestType: "payment"},
+		{BatchID: "b", RequestID: "none", Message: json.RawMessage(`4`)},
+	}}
+	driver := batch.NewDriver(client)
+	ticker := driver.RegisterSchedule(context.Background(), "test_batch", batch.MustParseCron("@yearly", nil), func(batchID string, requestID string, message json.RawMessage) (json.RawMessage, error) {
+		return message, nil
+	})
+	defer ticker.Stop()
+
+	ticker.Tick(context.Background())
+	require.JSONEq(t, `["test_batch"]`, string(client.getParams[0]))
+	require.ElementsMatch(t, []string{"low", "high", "mid", "none"}, client.processed)
+
+	client.processed = nil
+	ticker.SetFetchOptions(batch.FetchOptions{Limit: 1, MinPriority: 2, RequestTypes: []string{"payment"}})
+	ticker.Tick(context.Background())
+	require.JSONEq(t, `["test_batch", {"limit": 1, "min_priority": 2, "request_types": ["payment"]}]`, string(client.getParams[1]))
+	require.Equal(t, []string{"high"}, client.processed)
+
+	client.processed = nil
+	ticker.SetFetchOptions(batch.FetchOptions{Limit: 2})
+	ticker.Tick(context.Background())
+	require.ElementsMatch(t, []string{"high", "mid"}, client.processed)
+}
+
+func TestCallbackPanic(t *testing.T) {
+	client := &batchClient{requests: []batch.RequestEnvelope{
+		{BatchID: "b", RequestID: "boom", Message: json.RawMessage(`1`)},
+		{BatchID: "b", RequestID: "ok", Message: json.RawMessage(`2`)},
+	}}
+	var panics []*batch.Panic
+	var mu sync.Mutex
+	driver := batch.NewDriver(client, batch.WithPanicHandler(func(p *batch.Panic) {
+		mu.Lock()
+		defer mu.Unlock()
+		panics = append(panics, p)
+	}))
+	ticker := driver.RegisterSchedule(context.Background(), "test_batch", batch.MustParseCron("@yearly", nil), func(batchID string, requestID string, message json.RawMessage) (json.RawMessage, error) {
+		if requestID == "boom" {
+			panic("callback bug")
+		}
+		return message, nil
+	})
+	defer ticker.Stop()
+	ticker.Tick(context.Background())
+
+	require.Len(t, panics, 1)
+	require.Equal(t, "boom", panics[0].RequestID)
+	require.Equal(t, "callback bug", panics[0].Value)
+	require.Contains(t, string(panics[0].Stack), "TestCallbackPanic")
+	require.Len(t, client.responses, 2)
+	for _, resp := range client.responses {
+		if resp.RequestID == "boom" {
+			require.True(t, resp.IsError)
+			require.Contains(t, string(resp.Message), "callback bug")
+		} else {
+			require.False(t, resp.IsError)
+		}
+	}
+}
+
+func TestEnvelopeVersions(t *testing.T) {
+	client := &batchClient{requests: []batch.RequestEnvelope{
+		{BatchID: "b", RequestID: "v1", Message: json.RawMessage(`1`), Version: 1, MessageType: "payment.v1"},
+		{BatchID: "b", RequestID: "future", Message: json.RawMessage(`2`), Version: batch.MaxEnvelopeVersion + 1},
+	}}
+	driver := batch.NewDriver(client, batch.WithEnvelopeNegotiation())
+	ticker := driver.RegisterSchedule(context.Background(), "test_batch", batch.MustParseCron("@yearly", nil), func(batchID string, requestID string, message json.RawMessage) (json.RawMessage, error) {
+		return message, nil
+	})
+	defer ticker.Stop()
+	ticker.Tick(context.Background())
+
+	require.JSONEq(t, `["test_batch", {"envelope_version": 2}]`, string(client.getParams[0]))
+	// requests with unknown versions are answered with an error without
+	// being processed.
+	require.Equal(t, []string{"future", "v1"}, client.processed)
+	require.True(t, client.responses[0].IsError)
+	require.Contains(t, string(client.responses[0].Message), batch.ErrUnsupportedEnvelopeVersion.Error())
+	require.Equal(t, batch.MaxEnvelopeVersion, client.responses[0].Version)
+	require.False(t, client.responses[1].IsError)
+	require.Equal(t, 1, client.responses[1].Version)
+	require.Equal(t, "payment.v1", client.responses[1].MessageType)
+}
+
+func TestEnvelopeVersionsLimit(t *testing.T) {
+	client := &batchClient{requests: []batch.RequestEnvelope{
+		{BatchID: "b", RequestID: "future", Message: json.RawMessage(`1`), Version: batch.MaxEnvelopeVersion + 1, Priority: 1},
+		{BatchID: "b", RequestID: "v1", Message: json.RawMessage(`2`), Version: 1},
+	}}
+	driver := batch.NewDriver(client)
+	ticker := driver.RegisterSchedule(context.Background(), "test_batch", batch.MustParseCron("@yearly", nil), func(batchID string, requestID string, message json.RawMessage) (json.RawMessage, error) {
+		return message, nil
+	})
+	defer ticker.Stop()
+	ticker.SetFetchOptions(batch.FetchOptions{Limit: 1})
+	ticker.Tick(context.Background())
+
+	// requests with unknown versions do not count against the limit.
+	require.Equal(t, []string{"future", "v1"}, client.processed)
+	require.True(t, client.responses[0].IsError)
+	require.False(t, client.responses[1].IsError)
+}
@@ -0,0 +1,108 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// Notifier signals that batch requests were scheduled, so that they are
+// processed without waiting for the next regular poll.
+type Notifier interface {
+	// Notifications returns a channel receiving the names of batches with
+	// new requests, or an empty name if the batch is unknown.  The channel
+	// is closed once ctx is done.
+	Notifications(ctx context.Context) (<-chan string, error)
+}
+
+// Subscribe polls the batch each time n signals new requests for it, in
+// addition to the regular polls, which remain as a fallback for missed
+// notifications.  Notifications stop when ctx is done.
+func (t *Ticker) Subscribe(ctx context.Context, n Notifier) error {
+	ch, err := n.Notifications(ctx)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for name := range ch {
+			if name == "" || name == t.batchName {
+				t.TickAsync()
+			}
+		}
+	}()
+	return nil
+}
+
+// BatchEvent is the chaincode event payload that BlockEvents recognizes:
+// a JSON object whose "batch" field names the batch of a new request, e.g.
+//
+//	{"name": "batch_request", "batch": "payments"}
+type BatchEvent struct {
+	Batch string `json:"batch"`
+}
+
+// BlockEvents returns a Notifier watching the ledger of client for
+// transactions emitting a BatchEvent.  The ledger height is checked every
+// interval, which can be much shorter than the polling interval since
+// QueryInfo is cheaper than fetching batch requests.  Errors querying the
+// ledger are retried at the next check.
+func BlockEvents(client shiroclient.ShiroClient, interval time.Duration, configs ...shiroclient.Config) Notifier {
+	return &blockEvents{client: client, interval: interval, configs: configs}
+}
+
+type blockEvents struct {
+	client   shiroclient.ShiroClient
+	interval time.Duration
+	configs  []shiroclient.Config
+}
+
+func (b *blockEvents) Notifications(ctx context.Context) (<-chan string, error) {
+	height, err := b.client.QueryInfo(ctx, b.configs...)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			height = b.scan(ctx, height, ch)
+		}
+	}()
+	return ch, nil
+}
+
+// scan sends the batches of the events in blocks from height up to the
+// current height, and returns the height reached.
+func (b *blockEvents) scan(ctx context.Context, height uint64, ch chan<- string) uint64 {
+	current, err := b.client.QueryInfo(ctx, b.configs...)
+	if err != nil {
+		return height
+	}
+	for ; height < current; height++ {
+		blk, err := b.client.QueryBlock(ctx, height, b.configs...)
+		if err != nil {
+			return height
+		}
+		for _, tx := range blk.Transactions() {
+			var event BatchEvent
+			if len(tx.Event()) == 0 || json.Unmarshal(tx.Event(), &event) != nil || event.Batch == "" {
+				continue
+			}
+			select {
+			case ch <- event.Batch:
+			case <-ctx.Done():
+				return height
+			}
+		}
+	}
+	return height
+}
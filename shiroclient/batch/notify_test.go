@@ -0,0 +1,68 @@
+package batch_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/batch"
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+)
+
+// eventClient is a batchClient with a ledger of blocks.
+type eventClient struct {
+	*batchClient
+	blocks []*plugin.Block
+}
+
+func (c *eventClient) QueryInfo(ctx context.Context, configs ...shiroclient.Config) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return uint64(len(c.blocks)), nil
+}
+
+func (c *eventClient) QueryBlock(ctx context.Context, n uint64, configs ...shiroclient.Config) (shiroclient.Block, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return plugin.NewShiroClientBlock(c.blocks[n]), nil
+}
+
+func (c *eventClient) emit(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blocks = append(c.blocks, &plugin.Block{Transactions: []*plugin.Transaction{{ID: "tx", Event: []byte(event)}}})
+}
+
+func (c *eventClient) processedCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.processed)
+}
+
+func TestSubscribe(t *testing.T) {
+	client := &eventClient{batchClient: &batchClient{requests: []batch.RequestEnvelope{
+		{BatchID: "b", RequestID: "r", Message: json.RawMessage(`1`)},
+	}}}
+	driver := batch.NewDriver(client)
+	ticker := driver.RegisterSchedule(context.Background(), "test_batch", batch.MustParseCron("@yearly", nil), func(batchID string, requestID string, message json.RawMessage) (json.RawMessage, error) {
+		return message, nil
+	})
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, ticker.Subscribe(ctx, batch.BlockEvents(client, time.Millisecond)))
+
+	// events of other batches and other events are ignored.
+	client.emit(`{"batch":"other_batch"}`)
+	client.emit(`"unrelated"`)
+	time.Sleep(20 * time.Millisecond)
+	require.Zero(t, client.processedCount())
+
+	client.emit(`{"name":"batch_request","batch":"test_batch"}`)
+	require.Eventually(t, func() bool { return client.processedCount() == 1 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,262 @@
+package batch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule determines when a batch is polled.
+type Schedule interface {
+	// Next returns the first poll time strictly after t, or the zero time
+	// if the schedule never fires again.
+	Next(t time.Time) time.Time
+}
+
+// Every returns a schedule firing at a fixed interval, as Register does.
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		panic("batch: non-positive interval")
+	}
+	return everySchedule(interval)
+}
+
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// cronSchedule is a parsed cron expression.  Each field is a bitset of the
+// values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are set when the field is "*", in which case
+	// the other day field alone selects days.
+	domStar, dowStar bool
+	loc              *time.Location
+}
+
+// cronField describes the range of a cron field.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	// 7 is accepted for Sunday and folded into 0.
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a standard five field cron expression
+//
+//	minute hour day-of-month month day-of-week
+//
+// evaluated in loc, or in UTC if loc is nil.  Fields accept "*", values,
+// ranges ("1-5"), lists ("1,15") and steps ("*/15", "9-17/2").  Months and
+// days of the week may be given by their three letter English names, and
+// Sunday is 0 or 7.  As in cron, when both day fields are restricted a day
+// matching either of them fires.  The macros @yearly, @monthly, @weekly,
+// @daily and @hourly are accepted.  Next returns times in loc.  For
+// example, "30 9 * * mon-fri" fires at 9:30 on weekdays.
+func ParseCron(expr string, loc *time.Location) (Schedule, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	spec := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("batch: invalid cron expression %q: expected %d fields", expr, len(cronFields))
+	}
+	s := &cronSchedule{loc: loc}
+	bits := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, f := range fields {
+		b, err := cronFields[i].parse(f)
+		if err != nil {
+			return nil, fmt.Errorf("batch: invalid cron expression %q: %w", expr, err)
+		}
+		*bits[i] = b
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return s, nil
+}
+
+// MustParseCron is like ParseCron but panics if expr is invalid.
+func MustParseCron(expr string, loc *time.Location) Schedule {
+	s, err := ParseCron(expr, loc)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (f cronField) parse(spec string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(spec, ",") {
+		rng, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepSpec)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid %s step %q", f.name, stepSpec)
+			}
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			loSpec, hiSpec, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loSpec); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiSpec); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid %s range %q", f.name, rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(spec string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(spec, name) {
+			return i + f.min, nil
+		}
+	}
+	v, err := strconv.Atoi(spec)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q", f.name, spec)
+	}
+	return v, nil
+}
+
+// cronSearchYears bounds the search for the next fire time of expressions
+// that rarely or never match, like "0 0 31 2 *".
+const cronSearchYears = 5
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = advance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc))
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc))
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.loc))
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// advance returns next, the start of the following month, day or hour of
+// t, unless a daylight saving time transition normalized it to t or
+// earlier, in which case it returns the next minute.
+func advance(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Minute)
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domStar:
+		return dow
+	case s.dowStar:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// BusinessDays returns a schedule firing at the times of s that fall on
+// weekdays, skipping Saturdays, Sundays and holidays.  Days are compared
+// in the location of the times returned by s.  Only the year, month and
+// day of holidays are used.
+func BusinessDays(s Schedule, holidays ...time.Time) Schedule {
+	days := make(map[civilDate]bool, len(holidays))
+	for _, h := range holidays {
+		days[dateOf(h)] = true
+	}
+	return &businessDaySchedule{s: s, holidays: days}
+}
+
+type civilDate struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) civilDate {
+	y, m, d := t.Date()
+	return civilDate{y, m, d}
+}
+
+type businessDaySchedule struct {
+	s        Schedule
+	holidays map[civilDate]bool
+}
+
+func (b *businessDaySchedule) Next(t time.Time) time.Time {
+	limit := t.AddDate(cronSearchYears, 0, 0)
+	for {
+		t = b.s.Next(t)
+		if t.IsZero() || t.After(limit) {
+			return time.Time{}
+		}
+		if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			continue
+		}
+		if b.holidays[dateOf(t)] {
+			continue
+		}
+		return t
+	}
+}
@@ -0,0 +1,73 @@
+package batch_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/batch"
+)
+
+func TestParseCron(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// Friday.
+	from := time.Date(2024, 3, 8, 10, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		expr string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"*/15 * * * *", nil, time.Date(2024, 3, 8, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * *", nil, time.Date(2024, 3, 9, 9, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", nil, time.Date(2024, 3, 11, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", nil, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 feb *", nil, time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", nil, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+		// either day field matches when both are restricted.
+		{"0 0 13 * fri", nil, time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", nil, time.Date(2024, 3, 8, 11, 0, 0, 0, time.UTC)},
+		// 10:00 UTC is 5:00 in New York, before daylight saving time.
+		{"0 6 * * *", ny, time.Date(2024, 3, 8, 6, 0, 0, 0, ny)},
+		// the day daylight saving time starts.
+		{"0 6 10 3 *", ny, time.Date(2024, 3, 10, 6, 0, 0, 0, ny)},
+	} {
+		s, err := batch.ParseCron(tc.expr, tc.loc)
+		require.NoError(t, err, tc.expr)
+		next := s.Next(from)
+		require.True(t, tc.want.Equal(next), "%s: got %v, want %v", tc.expr, next, tc.want)
+	}
+
+	require.True(t, batch.MustParseCron("0 0 31 2 *", nil).Next(from).IsZero())
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		_, err := batch.ParseCron(expr, nil)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestBusinessDays(t *testing.T) {
+	s := batch.BusinessDays(batch.MustParseCron("0 9 * * *", nil), time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC))
+	// Friday after 9:00; the weekend and the Monday holiday are skipped.
+	next := s.Next(time.Date(2024, 3, 8, 10, 0, 0, 0, time.UTC))
+	require.Equal(t, time.Date(2024, 3, 12, 9, 0, 0, 0, time.UTC), next)
+}
+
+func TestTickerNext(t *testing.T) {
+	driver := batch.NewDriver(nil)
+	callback := func(batchID string, requestID string, message json.RawMessage) (json.RawMessage, error) {
+		return nil, nil
+	}
+	schedule := batch.MustParseCron("0 0 1 1 *", nil)
+	ticker := driver.RegisterSchedule(context.Background(), "never_soon", schedule, callback)
+	next := ticker.Next()
+	require.True(t, next.After(time.Now()))
+	require.Equal(t, time.January, next.Month())
+	ticker.Stop()
+	require.Eventually(t, func() bool { return ticker.Next().IsZero() }, time.Second, time.Millisecond)
+	ticker.Stop()
+}
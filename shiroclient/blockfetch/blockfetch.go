@@ -0,0 +1,147 @@
+// Package blockfetch reads ranges of ledger blocks with concurrent
+// QueryBlock calls, delivering them in order with bounded memory use.
+//
+//	f := blockfetch.New(client, blockfetch.WithParallelism(8))
+//	err := f.Fetch(ctx, from, to, func(n uint64, blk shiroclient.Block) error {
+//		return reconcile(n, blk)
+//	})
+package blockfetch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// DefaultParallelism is the number of concurrent QueryBlock calls of a
+// Fetcher created without WithParallelism.
+const DefaultParallelism = 4
+
+// Option configures a Fetcher.
+type Option func(*Fetcher)
+
+// WithParallelism sets the number of concurrent QueryBlock calls.
+func WithParallelism(n int) Option {
+	return func(f *Fetcher) {
+		f.parallelism = n
+	}
+}
+
+// WithWindow bounds the number of blocks fetched ahead of the consumer.
+// At most n blocks are held in memory in addition to the one being
+// delivered, so a window smaller than the parallelism limits the
+// concurrency.  It defaults to twice the parallelism.
+func WithWindow(n int) Option {
+	return func(f *Fetcher) {
+		f.window = n
+	}
+}
+
+// WithConfigs sets the configs passed to QueryBlock.
+func WithConfigs(configs ...shiroclient.Config) Option {
+	return func(f *Fetcher) {
+		f.configs = append([]shiroclient.Config(nil), configs...)
+	}
+}
+
+// Fetcher fetches ranges of blocks.
+type Fetcher struct {
+	client      shiroclient.ShiroClient
+	parallelism int
+	window      int
+	configs     []shiroclient.Config
+}
+
+// New returns a Fetcher reading blocks from client.
+func New(client shiroclient.ShiroClient, opts ...Option) *Fetcher {
+	f := &Fetcher{client: client}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.parallelism <= 0 {
+		f.parallelism = DefaultParallelism
+	}
+	if f.window <= 0 {
+		f.window = 2 * f.parallelism
+	}
+	return f
+}
+
+type result struct {
+	n   uint64
+	blk shiroclient.Block
+	err error
+}
+
+type job struct {
+	n   uint64
+	out chan<- result
+}
+
+// Fetch fetches the blocks [from, to) and calls fn with each block, in
+// order of block number.  fn is never called concurrently.  Fetch stops
+// at the first QueryBlock error, error returned by fn or when ctx is done,
+// returning that error, and returns once all of its calls have returned.
+func (f *Fetcher) Fetch(ctx context.Context, from uint64, to uint64, fn func(n uint64, blk shiroclient.Block) error) error {
+	if from >= to {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	// pending holds the result channels of the blocks in order.  Its
+	// capacity is the window: the producer blocks until the consumer
+	// takes the oldest block.
+	pending := make(chan chan result, f.window)
+	jobs := make(chan job)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		defer close(pending)
+		for n := from; n < to; n++ {
+			out := make(chan result, 1)
+			select {
+			case pending <- out:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- job{n: n, out: out}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for i := 0; i < f.parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				blk, err := f.client.QueryBlock(ctx, j.n, f.configs...)
+				j.out <- result{n: j.n, blk: blk, err: err}
+			}
+		}()
+	}
+
+	for out := range pending {
+		var r result
+		select {
+		case r = <-out:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if r.err != nil {
+			return fmt.Errorf("blockfetch: query block %d: %w", r.n, r.err)
+		}
+		if err := fn(r.n, r.blk); err != nil {
+			return err
+		}
+	}
+	// the producer stops early only if ctx is done.
+	return ctx.Err()
+}
@@ -0,0 +1,108 @@
+package blockfetch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/blockfetch"
+)
+
+// blockClient serves blocks after a random delay, tracking the number of
+// concurrent calls and of blocks fetched but not yet delivered.
+type blockClient struct {
+	shiroclient.ShiroClient
+	// fail, if not zero, is a block that cannot be fetched.
+	fail uint64
+
+	mu          sync.Mutex
+	active      int
+	maxActive   int
+	undelivered int
+	maxHeld     int
+}
+
+func (c *blockClient) QueryBlock(ctx context.Context, n uint64, configs ...shiroclient.Config) (shiroclient.Block, error) {
+	c.mu.Lock()
+	c.active++
+	if c.active > c.maxActive {
+		c.maxActive = c.active
+	}
+	c.mu.Unlock()
+	time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active--
+	if n == c.fail && n != 0 {
+		return nil, errors.New("unavailable")
+	}
+	c.undelivered++
+	if c.undelivered > c.maxHeld {
+		c.maxHeld = c.undelivered
+	}
+	return types.NewBlock(fmt.Sprintf("hash%d", n), nil), nil
+}
+
+func (c *blockClient) deliver() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.undelivered--
+}
+
+func TestFetch(t *testing.T) {
+	client := &blockClient{}
+	f := blockfetch.New(client, blockfetch.WithParallelism(4), blockfetch.WithWindow(6))
+	var got []uint64
+	err := f.Fetch(context.Background(), 10, 110, func(n uint64, blk shiroclient.Block) error {
+		require.Equal(t, fmt.Sprintf("hash%d", n), blk.Hash())
+		got = append(got, n)
+		time.Sleep(time.Millisecond)
+		client.deliver()
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 100)
+	for i, n := range got {
+		require.Equal(t, uint64(10+i), n)
+	}
+	require.LessOrEqual(t, client.maxActive, 4)
+	require.Greater(t, client.maxActive, 1)
+	require.LessOrEqual(t, client.maxHeld, 7)
+}
+
+func TestFetchErrors(t *testing.T) {
+	ctx := context.Background()
+	client := &blockClient{fail: 20}
+	f := blockfetch.New(client)
+	var last uint64
+	err := f.Fetch(ctx, 0, 100, func(n uint64, blk shiroclient.Block) error {
+		last = n
+		return nil
+	})
+	require.ErrorContains(t, err, "query block 20")
+	require.Equal(t, uint64(19), last)
+
+	errStop := errors.New("stop")
+	err = blockfetch.New(&blockClient{}).Fetch(ctx, 0, 100, func(n uint64, blk shiroclient.Block) error {
+		if n == 5 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+
+	cctx, cancel := context.WithCancel(ctx)
+	err = blockfetch.New(&blockClient{}).Fetch(cctx, 0, 100, func(n uint64, blk shiroclient.Block) error {
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -3,9 +3,13 @@ package shiroclient
 import (
 	"context"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"regexp"
+	"time"
 
 	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/jsonschema"
 	"github.com/sirupsen/logrus"
 )
 
@@ -31,6 +35,17 @@ func WithLogField(key string, value interface{}) Config {
 	})
 }
 
+// WithForwardedLogFields forwards the log fields named by keys to the
+// gateway in the X-Shiro-Context header, as a JSON object, so that gateway
+// and substrate logs carry the same request metadata.  Only the listed
+// fields are forwarded, to avoid leaking sensitive ones.  Has no effect in
+// mock mode.
+func WithForwardedLogFields(keys ...string) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.ForwardLogFields = append([]string(nil), keys...)
+	})
+}
+
 // WithLogrusFields allows specifying multiple log fields to be
 // included.
 func WithLogrusFields(fields logrus.Fields) Config {
@@ -48,14 +63,123 @@ func WithHeader(key string, value string) Config {
 	})
 }
 
+// ContextWithHeaders returns a copy of ctx carrying HTTP headers that RPC
+// clients copy onto the gateway requests made with the context, merged over
+// headers already carried by ctx.  It lets middleware, like HTTP handlers or
+// gRPC interceptors, forward correlation headers without passing WithHeader
+// configs through every layer.  Headers set with WithHeader take precedence.
+// Has no effect in mock mode.
+func ContextWithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return types.ContextWithHeaders(ctx, headers)
+}
+
+// HeadersFromContext returns a copy of the headers stashed in ctx with
+// ContextWithHeaders, or nil if there are none.
+func HeadersFromContext(ctx context.Context) map[string]string {
+	return types.HeadersFromContext(ctx)
+}
+
 // WithEndpoint allows specifying the endpoint to target. The RPC
 // implementation will not work if an endpoint is not specified.
+//
+// Gateways can be discovered with DNS SRV records by using an endpoint like
+// "dnssrv://shiroclient-gateway.service.consul", or "dnssrv+https://..." to
+// reach them over HTTPS.  Any path in the endpoint is kept.  Requests are
+// spread over the targets with the best priority, and retries configured
+// with WithRetryPolicy fail over to the next target.  Records are resolved
+// again as configured by WithEndpointRefresh.
 func WithEndpoint(endpoint string) Config {
 	return types.Opt(func(r *types.RequestOptions) {
 		r.Endpoint = endpoint
 	})
 }
 
+// WithLockedEndpoint makes a client reject requests whose configs select
+// an endpoint other than the one configured on the client, instead of
+// silently sending them to another gateway.  It must be passed to NewRPC or
+// With; per-call configs cannot unlock the endpoint.
+func WithLockedEndpoint() Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.EndpointPolicy.Locked = true
+	})
+}
+
+// WithEndpointOverrideHosts makes a client reject requests whose configs
+// select an endpoint other than the one configured on the client, unless it
+// targets one of hosts.  Hosts match the host name of the endpoint, or its
+// host and port, e.g. "gateway.internal" or "gateway.internal:8082".  It
+// must be passed to NewRPC or With; per-call configs cannot extend the list.
+func WithEndpointOverrideHosts(hosts ...string) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.EndpointPolicy.AllowedHosts = append([]string(nil), hosts...)
+	})
+}
+
+// ErrMethodNotAllowed is returned when a client refuses to call a phylum
+// method, see WithAllowedMethods and WithDeniedMethods.
+var ErrMethodNotAllowed = types.ErrMethodNotAllowed
+
+// WithAllowedMethods makes a client refuse to Call phylum methods other
+// than methods, without contacting the gateway.  This guarantees a service
+// only calls the endpoints it was built for, even if the method name is
+// derived from untrusted input.  It must be passed to NewRPC, NewMock or
+// With; per-call configs cannot change the allowed methods.  If the
+// allowed methods are already restricted, e.g. by the client With is
+// called on, only the methods allowed by both remain allowed.  Helpers that
+// call phylum methods, like the update and private packages, are subject to
+// the restriction too.
+func WithAllowedMethods(methods ...string) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.MethodPolicy.Allow(methods...)
+	})
+}
+
+// WithDeniedMethods makes a client refuse to Call methods, e.g. destructive
+// endpoints like "private_purge", "update" and "disable", without
+// contacting the gateway.  Denied methods take precedence over allowed
+// ones.  It must be passed to NewRPC, NewMock or With; per-call configs
+// cannot change the denied methods.  Methods denied by several configs,
+// e.g. by the client With is called on, are all denied.
+func WithDeniedMethods(methods ...string) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.MethodPolicy.Deny(methods...)
+	})
+}
+
+// WithMethodConfigs registers configs applied to every Call of method,
+// after the client configs and before the per-call configs, which can
+// override them.  This replaces wrapper functions adding the same configs
+// to each call of a method, e.g.
+//
+//	client, err := shiroclient.NewRPC([]shiroclient.Config{
+//		shiroclient.WithEndpoint(endpoint),
+//		shiroclient.WithMethodConfigs("get_account", shiroclient.WithTimeout(2*time.Second)),
+//		shiroclient.WithMethodConfigs("transfer", shiroclient.WithMinEndorsers(2)),
+//	})
+//
+// It must be passed to NewRPC, NewMock or With and may be repeated, configs
+// registered for the same method accumulating.  Method configs are ignored
+// when passed to a single call.
+func WithMethodConfigs(method string, configs ...Config) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		methodConfigs := make(map[string][]Config, len(r.MethodConfigs)+1)
+		for k, v := range r.MethodConfigs {
+			methodConfigs[k] = v
+		}
+		methodConfigs[method] = append(append([]Config(nil), r.MethodConfigs[method]...), configs...)
+		r.MethodConfigs = methodConfigs
+	})
+}
+
+// WithEndpointRefresh sets the interval after which the SRV records of a
+// dnssrv endpoint are resolved again, 30 seconds by default.  If resolution
+// fails, the previously resolved gateways keep being used.
+func WithEndpointRefresh(interval time.Duration) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.EndpointRefresh = interval
+	})
+}
+
 // WithID allows specifying the request ID. If the request ID is not
 // specified, a randomly-generated UUID will be used.
 func WithID(id string) Config {
@@ -73,24 +197,54 @@ func WithParams(params interface{}) Config {
 }
 
 // WithTransientData allows specifying a single "transient data"
-// key-value pair.
+// key-value pair.  The key must not be empty.
 func WithTransientData(key string, val []byte) Config {
-	return types.Opt(func(r *types.RequestOptions) {
+	return types.OptErr(func(r *types.RequestOptions) error {
+		if err := types.ValidateTransientKey(key); err != nil {
+			return err
+		}
 		r.Transient[key] = val
+		return nil
 	})
 }
 
 // WithTransientDataMap allows specifying multiple "transient data"
-// key-value pairs.
+// key-value pairs.  Keys must not be empty.
 func WithTransientDataMap(data map[string][]byte) Config {
-	return types.Opt(func(r *types.RequestOptions) {
+	return types.OptErr(func(r *types.RequestOptions) error {
 		for key, val := range data {
+			if err := types.ValidateTransientKey(key); err != nil {
+				return err
+			}
 			r.Transient[key] = val
 		}
+		return nil
+	})
+}
+
+// WithTransientLimits allows rejecting requests whose transient data is
+// too large before they are sent.  maxValueSize bounds the size of each
+// value and maxTotalSize the combined size of all keys and values; zero
+// disables a check.  Violations are reported as *TransientDataError.
+func WithTransientLimits(maxValueSize int, maxTotalSize int) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.TransientLimits.MaxValueSize = maxValueSize
+		r.TransientLimits.MaxTotalSize = maxTotalSize
+	})
+}
+
+// WithTransientKeyPattern allows requiring every transient data key to
+// match pattern.  Note that keys added by the SDK itself (e.g. by the
+// private package) must match too.
+func WithTransientKeyPattern(pattern *regexp.Regexp) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.TransientLimits.KeyPattern = pattern
 	})
 }
 
 // WithResponse allows capturing the RPC response for futher analysis.
+// GetResponseMetadata additionally provides the raw response body and
+// HTTP headers.
 func WithResponse(target *interface{}) Config {
 	return types.Opt(func(r *types.RequestOptions) {
 		r.Target = target
@@ -98,10 +252,46 @@ func WithResponse(target *interface{}) Config {
 }
 
 // WithAuthToken passes authorization for the transaction issuer with a
-// request.
+// request.  It replaces any provider set with WithAuthTokenProvider.
 func WithAuthToken(token string) Config {
 	return types.Opt(func(r *types.RequestOptions) {
 		r.AuthToken = token
+		r.AuthTokenProvider = nil
+	})
+}
+
+// WithAuthTokenProvider allows obtaining the authorization token for each
+// request from provider, e.g. to refresh short-lived tokens.  It replaces
+// any token set with WithAuthToken.
+func WithAuthTokenProvider(provider func(context.Context) (string, error)) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.AuthToken = ""
+		r.AuthTokenProvider = provider
+	})
+}
+
+// WithAuthScheme passes credentials for the transaction issuer in the
+// Authorization header using scheme, e.g. "Token" or "Basic", instead of the
+// default "Bearer".  It replaces any token or provider set with WithAuthToken
+// or WithAuthTokenProvider.  To use a custom scheme with a provider, pass
+// WithAuthScheme(scheme, "") before WithAuthTokenProvider.
+func WithAuthScheme(scheme string, credentials string) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.AuthScheme = scheme
+		r.AuthToken = credentials
+		r.AuthTokenProvider = nil
+	})
+}
+
+// WithAPIKey authenticates the client with the gateway by sending value in
+// the headerName header.  The API key is independent of the auth token of
+// the transaction issuer, and unlike it is also sent with health checks.
+// headerName must not be Authorization when an auth token is configured.
+// Has no effect in mock mode.
+func WithAPIKey(headerName string, value string) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.APIKeyHeader = headerName
+		r.APIKey = value
 	})
 }
 
@@ -115,14 +305,31 @@ func WithTimestampGenerator(timestampGenerator func(context.Context) string) Con
 	})
 }
 
-// WithMSPFilter allows specifying the MSP filter. Has no effect in
-// mock mode.
+// WithMSPFilter allows specifying the MSP filter.  Has no effect in mock
+// mode, unless the mock client is created with mock.WithStrictConfig, in
+// which case requests using it fail with a *ConfigError.
 func WithMSPFilter(mspFilter []string) Config {
 	return types.Opt(func(r *types.RequestOptions) {
 		r.MspFilter = append([]string(nil), mspFilter...)
 	})
 }
 
+// WithPrivateCollection targets the Fabric private data collection name.
+// The collection names are passed to substrate, which routes the private
+// data written by the request, including data derived from transient data,
+// to the targeted collections.  The config may be given more than once to
+// target several collections.
+func WithPrivateCollection(name string) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		for _, c := range r.PrivateCollections {
+			if c == name {
+				return
+			}
+		}
+		r.PrivateCollections = append(r.PrivateCollections, name)
+	})
+}
+
 // WithTargetEndpoints allows specifying which exact peers will be used
 // to process the transaction. Specifcy a name or URL of the peer.
 func WithTargetEndpoints(nameOrURL []string) Config {
@@ -155,6 +362,15 @@ func WithCreator(creator string) Config {
 	})
 }
 
+// WithRequestSigner signs RPC requests with signer, in the
+// rpc.HeaderSignature and rpc.HeaderSignerCertificate headers.  It has no
+// effect in mock mode.  See WithIdentity.
+func WithRequestSigner(signer RequestSigner) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.Signer = signer
+	})
+}
+
 // WithDependentTxID allows specifying a dependency on a transaction ID. If
 // set, the client will poll for the presence of that transaction before
 // simulating the request on the peer with the transaction.
@@ -165,7 +381,8 @@ func WithDependentTxID(txID string) Config {
 }
 
 // WithDisableWritePolling allows disabling polling for full consensus after a
-// write is committed.
+// write is committed.  Passed to NewRPC, it disables polling for every call
+// of the client; see ConfirmWrite to confirm the writes later.
 func WithDisableWritePolling(disable bool) Config {
 	return types.Opt(func(r *types.RequestOptions) {
 		r.DisableWritePolling = disable
@@ -210,9 +427,231 @@ func WithPhylumVersion(phylumVersion string) Config {
 	})
 }
 
-// WithResponseReceiver allows retrieving the shiro response directly.
+// WithResponseReceiver allows retrieving the shiro response directly.  get
+// is called synchronously, at most once per request, with the response that
+// Call is about to return, including phylum error responses; it is called
+// before Call returns and from the goroutine that invoked Call.  It is not
+// called when Call returns an error, e.g. a transport failure, a
+// *PendingWriteError or a *ConflictError; with WithConflictRetry only the
+// final response is received.  In RPC mode Init also reports the gateway
+// response; in mock mode Init produces no response.
 func WithResponseReceiver(get func(resp ShiroResponse)) Config {
 	return types.Opt(func(r *types.RequestOptions) {
 		r.ResponseReceiver = get
 	})
 }
+
+// WithTimeout bounds the total time spent on a request to the gateway,
+// including any retries.  The deadline of the request context still
+// applies.  Has no effect in mock mode.
+func WithTimeout(timeout time.Duration) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.Timeout = timeout
+	})
+}
+
+// WithRetryPolicy allows retrying requests that fail before a response is
+// received from the gateway.  Requests that cannot modify the ledger are
+// retried after any transport failure, while Call and Init are only retried
+// when the connection to the gateway could not be established, unless they
+// carry an idempotency key (see WithIdempotencyKey).  The delay
+// before each retry starts at backoff and doubles after every attempt, up
+// to maxBackoff if it is positive.  Has no effect in mock mode.
+func WithRetryPolicy(maxAttempts int, backoff time.Duration, maxBackoff time.Duration) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.Retry = types.RetryPolicy{
+			MaxAttempts: maxAttempts,
+			Backoff:     backoff,
+			MaxBackoff:  maxBackoff,
+		}
+	})
+}
+
+// WithStrictDecoding enables strict validation of gateway responses.  Any
+// unexpected field, field of the wrong type, or committed transaction
+// without a transaction ID results in a *DecodeError describing the field
+// path.  By default responses are decoded leniently.  Has no effect in mock
+// mode.
+func WithStrictDecoding(strict bool) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.StrictDecoding = strict
+	})
+}
+
+// WithJSONCodec allows replacing encoding/json for marshaling requests and
+// parameters and for unmarshaling gateway responses, e.g. with a faster
+// drop-in implementation.  A nil function falls back to encoding/json.
+// When unmarshaling into an interface{}, unmarshal must produce the same Go
+// types as encoding/json.
+func WithJSONCodec(marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.JSON = types.JSONCodec{
+			MarshalFunc:   marshal,
+			UnmarshalFunc: unmarshal,
+		}
+	})
+}
+
+// TransientEncoding selects how transient data values are encoded in
+// gateway requests.  See WithTransientEncoding.
+type TransientEncoding = types.TransientEncoding
+
+const (
+	// TransientEncodingHex hex encodes transient values.  This is the
+	// default and is supported by all gateways.
+	TransientEncodingHex = types.TransientEncodingHex
+	// TransientEncodingBase64 base64 encodes transient values, which
+	// reduces request size by a third compared to hex.  Only use it with
+	// gateways known to support it.
+	TransientEncodingBase64 = types.TransientEncodingBase64
+	// TransientEncodingAuto uses base64 when the gateway advertises
+	// support for it and falls back to hex otherwise.  The gateway's
+	// capabilities are checked once per endpoint and cached.
+	TransientEncodingAuto = types.TransientEncodingAuto
+)
+
+// WithTransientEncoding allows selecting the encoding of transient data
+// values sent to the gateway.  Has no effect in mock mode.
+func WithTransientEncoding(encoding TransientEncoding) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.TransientEncoding = encoding
+	})
+}
+
+// WithIdempotencyKey allows attaching an idempotency key to a request.  The
+// key is sent to the gateway, which uses it to discard duplicate
+// submissions of the same write.  Because of this, requests with an
+// idempotency key are retried after any transport failure when a retry
+// policy is configured (see WithRetryPolicy), including ambiguous failures
+// after a write may already have been submitted.  Callers must use a
+// distinct key for each logical operation.
+func WithIdempotencyKey(key string) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.IdempotencyKey = key
+	})
+}
+
+// CallStats describes a completed request.  See WithStats.
+type CallStats = types.CallStats
+
+// WithStats allows receiving statistics about each request: the method,
+// duration, request and response sizes, error level, retry count and
+// endpoint.  stats is called synchronously once per request after it
+// completes, whether or not it succeeded, so it should return quickly.
+// Requests made by helpers (e.g. polling for a commit) are not reported
+// unless the helper is passed the config.
+func WithStats(stats func(CallStats)) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.Stats = stats
+	})
+}
+
+// CommitStats describes the commit of a write observed by the client.  See
+// WithCommitStats.
+type CommitStats = types.CommitStats
+
+// WithCommitStats allows measuring consensus latency: commitStats is called
+// synchronously with the time from the submission of each write until its
+// commit was observed, along with its method and endpoint, e.g. to feed a
+// histogram labeled by both.  In RPC mode it makes the client, rather than
+// the gateway, wait for the commit, as WithWriteProgress does, so it is not
+// called for writes made with WithDisableWritePolling.
+func WithCommitStats(commitStats func(CommitStats)) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.CommitStats = commitStats
+	})
+}
+
+// ResponseValidationError is returned by Call for a result rejected by
+// WithResponseSchema.  It unwraps to the *jsonschema.ValidationError
+// listing the violations by JSON pointer.
+type ResponseValidationError = types.ResponseValidationError
+
+// WithResponseSchema makes Call validate the result JSON of successful
+// calls against schema before returning, for phylum methods returning
+// dynamic JSON rather than a proto message.  An invalid result is
+// returned as a *ResponseValidationError holding the response, since a
+// write may have been committed.  Responses containing a phylum error are
+// not validated.
+func WithResponseSchema(schema *jsonschema.Schema) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.ResponseValidator = schema.Validate
+	})
+}
+
+// ErrorPolicy determines the detail of phylum errors returned to callers by
+// helpers converting phylum errors to Go errors, such as phylum clients and
+// the private package, so that what may reach a frontend is decided in one
+// place.  Error data may contain PII.
+type ErrorPolicy = types.ErrorPolicy
+
+// Error policies, from the least to the most detailed.
+const (
+	// ErrorPolicyDefault leaves the policy to the helper: phylum clients
+	// use ErrorPolicyMessages and the private package ErrorPolicyCodes.
+	ErrorPolicyDefault = types.ErrorPolicyDefault
+	// ErrorPolicyMask returns ErrMaskedPhylumError for all errors.
+	ErrorPolicyMask = types.ErrorPolicyMask
+	// ErrorPolicyCodes returns a *PhylumError with the code and generic
+	// message of errors, without their data.
+	ErrorPolicyCodes = types.ErrorPolicyCodes
+	// ErrorPolicyMessages returns the error data if it is a JSON string,
+	// as route failures are, and ErrMaskedPhylumError otherwise.
+	ErrorPolicyMessages = types.ErrorPolicyMessages
+	// ErrorPolicyFull returns a *PhylumError with the data of errors.
+	ErrorPolicyFull = types.ErrorPolicyFull
+)
+
+// PhylumError is a phylum error returned with the detail allowed by an
+// ErrorPolicy.
+type PhylumError = types.PhylumError
+
+// ErrMaskedPhylumError is returned in place of a phylum error whose detail
+// is masked by an ErrorPolicy.
+var ErrMaskedPhylumError = types.ErrMaskedPhylumError
+
+// WithErrorPolicy sets the policy used by helpers, e.g. those of the
+// private package, to convert phylum errors to Go errors.  It has no
+// effect on ShiroClient.Call, whose responses carry the full error.
+func WithErrorPolicy(policy ErrorPolicy) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.ErrorPolicy = policy
+	})
+}
+
+// ConnStats summarizes the connection-level timing of an HTTP request.
+// See WithConnStats.
+type ConnStats = types.ConnStats
+
+// WithClientTrace allows attaching an httptrace.ClientTrace to the HTTP
+// requests made to the gateway, for connection-level diagnostics.  Has no
+// effect in mock mode.
+func WithClientTrace(trace *httptrace.ClientTrace) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.ClientTrace = trace
+	})
+}
+
+// WithConnStats allows receiving a summary of the connection-level timing
+// (DNS, connect, TLS handshake and time to first byte) of each HTTP request
+// made to the gateway, to tell whether a slow call spent its time in
+// connection setup or waiting for the gateway.  stats is called once per
+// attempt.  Has no effect in mock mode.
+func WithConnStats(stats func(ConnStats)) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.ConnStats = stats
+	})
+}
+
+// WithKeepAlive makes an RPC client send a health check request to the
+// gateway every interval, keeping connections warm and detecting dead
+// gateways before user requests do.  Failed pings are logged and reported
+// to onFailure, if not nil, with the URL of the gateway.  It must be passed
+// to NewRPC; the pings stop when the client is shut down.  Has no effect in
+// mock mode.
+func WithKeepAlive(interval time.Duration, onFailure func(endpoint string, err error)) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.KeepAlive = interval
+		r.KeepAliveFailure = onFailure
+	})
+}
@@ -0,0 +1,214 @@
+package shiroclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/jsonschema"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/mockgateway"
+)
+
+func TestValidateConfigs(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		configs []shiroclient.Config
+		field   string
+	}{
+		{
+			name: "valid",
+			configs: []shiroclient.Config{
+				shiroclient.WithEndpoint("http://localhost:8082"),
+				shiroclient.WithMinEndorsers(2),
+				shiroclient.WithDependentBlock("10"),
+				shiroclient.WithPrivateCollection("org1Private"),
+				shiroclient.WithAPIKey("X-API-Key", "key"),
+				shiroclient.WithAuthScheme("Token", "creds"),
+			},
+		},
+		{
+			name:    "empty private collection",
+			configs: []shiroclient.Config{shiroclient.WithPrivateCollection("")},
+			field:   "PrivateCollections",
+		},
+		{
+			name:    "api key without header",
+			configs: []shiroclient.Config{shiroclient.WithAPIKey("", "key")},
+			field:   "APIKeyHeader",
+		},
+		{
+			name: "api key in bearer authorization",
+			configs: []shiroclient.Config{
+				shiroclient.WithAuthToken("token"),
+				shiroclient.WithAPIKey("authorization", "key"),
+			},
+			field: "APIKeyHeader",
+		},
+		{
+			name:    "auth scheme with space",
+			configs: []shiroclient.Config{shiroclient.WithAuthScheme("Custom Scheme", "creds")},
+			field:   "AuthScheme",
+		},
+		{
+			name:    "negative min endorsers",
+			configs: []shiroclient.Config{shiroclient.WithMinEndorsers(-1)},
+			field:   "MinEndorsers",
+		},
+		{
+			name:    "non-numeric dependent block",
+			configs: []shiroclient.Config{shiroclient.WithDependentBlock("latest")},
+			field:   "DependentBlock",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := shiroclient.ValidateConfigs(test.configs...)
+			if test.field == "" {
+				require.NoError(t, err)
+				return
+			}
+			var cerr *shiroclient.ConfigError
+			require.True(t, errors.As(err, &cerr), "expected a ConfigError: %v", err)
+			require.Equal(t, test.field, cerr.Field)
+		})
+	}
+}
+
+func TestNewRPCRequiresEndpoint(t *testing.T) {
+	client := shiroclient.NewRPC(nil)
+	_, err := client.QueryInfo(context.Background())
+	var cerr *shiroclient.ConfigError
+	require.True(t, errors.As(err, &cerr), "expected a ConfigError: %v", err)
+	require.Equal(t, "Endpoint", cerr.Field)
+}
+
+func TestWithJSONCodec(t *testing.T) {
+	var auth string
+	srv := heightServer(t, &auth)
+	var marshals, unmarshals int
+	client := shiroclient.NewRPC([]shiroclient.Config{
+		shiroclient.WithEndpoint(srv.URL),
+		shiroclient.WithJSONCodec(func(v interface{}) ([]byte, error) {
+			marshals++
+			return json.Marshal(v)
+		}, func(data []byte, v interface{}) error {
+			unmarshals++
+			return json.Unmarshal(data, v)
+		}),
+	})
+	height, err := client.QueryInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), height)
+	require.Equal(t, 1, marshals)
+	require.Equal(t, 1, unmarshals)
+}
+
+func TestTransientLimits(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		configs []shiroclient.Config
+		key     string
+	}{
+		{
+			name: "within limits",
+			configs: []shiroclient.Config{
+				shiroclient.WithTransientData("a", []byte("12345")),
+				shiroclient.WithTransientLimits(5, 6),
+			},
+		},
+		{
+			name:    "empty key",
+			configs: []shiroclient.Config{shiroclient.WithTransientDataMap(map[string][]byte{"": nil})},
+			key:     "",
+		},
+		{
+			name: "value too large",
+			configs: []shiroclient.Config{
+				shiroclient.WithTransientLimits(4, 0),
+				shiroclient.WithTransientData("a", []byte("12345")),
+			},
+			key: "a",
+		},
+		{
+			name: "total too large",
+			configs: []shiroclient.Config{
+				shiroclient.WithTransientLimits(0, 9),
+				shiroclient.WithTransientDataMap(map[string][]byte{"a": []byte("1234"), "b": []byte("1234")}),
+			},
+			key: "",
+		},
+		{
+			name: "key pattern",
+			configs: []shiroclient.Config{
+				shiroclient.WithTransientKeyPattern(regexp.MustCompile(`^[a-z_]+$`)),
+				shiroclient.WithTransientData("Bad-Key", nil),
+			},
+			key: "Bad-Key",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := shiroclient.ValidateConfigs(test.configs...)
+			if test.name == "within limits" {
+				require.NoError(t, err)
+				return
+			}
+			var terr *shiroclient.TransientDataError
+			require.True(t, errors.As(err, &terr), "expected a TransientDataError: %v", err)
+			require.Equal(t, test.key, terr.Key)
+		})
+	}
+}
+
+// resultClient returns a fixed result, except for "fail" which returns a
+// phylum error.
+type resultClient struct {
+	shiroclient.ShiroClient
+	result string
+}
+
+func (c *resultClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	if method == "fail" {
+		return types.NewFailureResponse(400, "bad request", nil), nil
+	}
+	return types.NewSuccessResponse([]byte(c.result), "tx1", 0, 0), nil
+}
+
+func TestWithResponseSchema(t *testing.T) {
+	inner := &resultClient{result: `{"balance": 3}`}
+	gw := mockgateway.NewServer(inner)
+	t.Cleanup(gw.Close)
+	client := shiroclient.NewRPC([]shiroclient.Config{gw.Config()})
+	schema := shiroclient.WithResponseSchema(jsonschema.MustCompile(`{
+		"type": "object",
+		"required": ["balance"],
+		"properties": {"balance": {"type": "integer", "minimum": 0}}
+	}`))
+	ctx := context.Background()
+
+	resp, err := client.Call(ctx, "get_balance", schema)
+	require.NoError(t, err)
+	require.Equal(t, "tx1", resp.TransactionID())
+
+	inner.result = `{"balance": -1}`
+	_, err = client.Call(ctx, "get_balance", schema)
+	var rerr *shiroclient.ResponseValidationError
+	require.True(t, errors.As(err, &rerr), "expected a ResponseValidationError: %v", err)
+	require.Equal(t, "tx1", rerr.Response.TransactionID())
+	var verr *jsonschema.ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.Equal(t, "/balance", verr.Errors[0].Path)
+	require.EqualError(t, err, "invalid get_balance response: jsonschema: /balance: must be >= 0")
+
+	resp, err = client.Call(ctx, "fail", schema)
+	require.NoError(t, err)
+	require.Error(t, resp.Error())
+
+	// the schema applies only to calls made with it.
+	_, err = client.Call(ctx, "get_balance")
+	require.NoError(t, err)
+}
@@ -0,0 +1,64 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// Client is a ShiroClient validating the payloads of calls against the
+// contracts of a Registry.
+type Client struct {
+	shiroclient.ShiroClient
+	registry *Registry
+}
+
+// Client returns a client validating the calls made with client.
+func (r *Registry) Client(client shiroclient.ShiroClient) *Client {
+	return &Client{ShiroClient: client, registry: r}
+}
+
+// Call implements shiroclient.ShiroClient.  A call whose request violates
+// its contract is not made.  If the result of a call violates its
+// contract, Call returns a *ResponseViolationError holding the response:
+// the call was made, and a write may have been committed.  Results are
+// not validated for responses containing a phylum error.
+func (c *Client) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	if _, ok := c.registry.Lookup(method); !ok {
+		return c.ShiroClient.Call(ctx, method, configs...)
+	}
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	params, err := json.Marshal(opt.Params)
+	if err != nil {
+		return nil, fmt.Errorf("contract: %s params: %w", method, err)
+	}
+	if err := c.registry.ValidateRequest(method, params); err != nil {
+		return nil, err
+	}
+	resp, err := c.ShiroClient.Call(ctx, method, configs...)
+	if err != nil || resp.Error() != nil {
+		return resp, err
+	}
+	if err := c.registry.ValidateResponse(method, resp.ResultJSON()); err != nil {
+		return nil, &ResponseViolationError{ViolationError: err.(*ViolationError), Response: resp}
+	}
+	return resp, nil
+}
+
+// ResponseViolationError is returned by Client.Call for a result violating
+// its contract.
+type ResponseViolationError struct {
+	*ViolationError
+	// Response is the response of the call.
+	Response shiroclient.ShiroResponse
+}
+
+func (e *ResponseViolationError) Unwrap() error {
+	return e.ViolationError
+}
@@ -0,0 +1,214 @@
+// Package contract declares the request and response types of phylum
+// methods, so that contract drift between a phylum and the services
+// calling it is caught at call time rather than by parsing errors in
+// production.
+//
+//	reg := contract.NewRegistry()
+//	err := reg.Register(contract.Contract{
+//		Method:      "create_account",
+//		Description: "Creates an account.",
+//		Request:     (*pb.CreateAccountRequest)(nil),
+//		Response:    (*pb.CreateAccountResponse)(nil),
+//	})
+//	...
+//	client := reg.Client(rpcClient)
+//	_, err = client.Call(ctx, "create_account", configs...)
+//	var verr *contract.ViolationError
+//	if errors.As(err, &verr) {
+//		...
+//	}
+//
+// The request of a call is its first param, following the convention of
+// phylum.Client.  Methods without a contract are not validated.  The
+// registry also describes the declared contracts with Docs, e.g. to
+// generate documentation.
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/jsonschema"
+)
+
+// Contract declares the payloads of a phylum method.  Request and Response
+// are checked by decoding payloads into new messages of their type,
+// rejecting unknown fields; schemas are checked with jsonschema.  Unset
+// fields are not checked.
+type Contract struct {
+	// Method is the phylum method.
+	Method string
+	// Description documents the method.
+	Description string
+	// Request is a message, possibly a typed nil, of the type of the
+	// request.
+	Request proto.Message
+	// Response is a message, possibly a typed nil, of the type of the
+	// result.
+	Response proto.Message
+	// RequestSchema is the JSON Schema of the request.
+	RequestSchema *jsonschema.Schema
+	// ResponseSchema is the JSON Schema of the result.
+	ResponseSchema *jsonschema.Schema
+}
+
+// Direction identifies the payload violating a contract.
+type Direction string
+
+// Payload directions.
+const (
+	DirectionRequest  Direction = "request"
+	DirectionResponse Direction = "response"
+)
+
+// ViolationError is returned for a payload violating the contract of its
+// method.
+type ViolationError struct {
+	Method    string
+	Direction Direction
+	Err       error
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("contract: %s %s: %v", e.Method, e.Direction, e.Err)
+}
+
+func (e *ViolationError) Unwrap() error {
+	return e.Err
+}
+
+// Registry holds the contracts of phylum methods.  It is safe for
+// concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	contracts map[string]*Contract
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{contracts: make(map[string]*Contract)}
+}
+
+// Register adds or replaces the contract of c.Method.
+func (r *Registry) Register(c Contract) error {
+	if c.Method == "" {
+		return errors.New("contract: method is required")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contracts[c.Method] = &c
+	return nil
+}
+
+// Lookup returns the contract of method.
+func (r *Registry) Lookup(method string) (Contract, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.contracts[method]
+	if !ok {
+		return Contract{}, false
+	}
+	return *c, true
+}
+
+// Contracts returns the registered contracts sorted by method.
+func (r *Registry) Contracts() []Contract {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	contracts := make([]Contract, 0, len(r.contracts))
+	for _, c := range r.contracts {
+		contracts = append(contracts, *c)
+	}
+	sort.Slice(contracts, func(i, j int) bool { return contracts[i].Method < contracts[j].Method })
+	return contracts
+}
+
+// Doc is the description of a contract for documentation, which encodes
+// as JSON.
+type Doc struct {
+	Method      string `json:"method"`
+	Description string `json:"description,omitempty"`
+	// RequestType and ResponseType are the full names of the proto
+	// messages of the payloads.
+	RequestType    string             `json:"request_type,omitempty"`
+	ResponseType   string             `json:"response_type,omitempty"`
+	RequestSchema  *jsonschema.Schema `json:"request_schema,omitempty"`
+	ResponseSchema *jsonschema.Schema `json:"response_schema,omitempty"`
+}
+
+func messageName(m proto.Message) string {
+	if m == nil {
+		return ""
+	}
+	return string(m.ProtoReflect().Descriptor().FullName())
+}
+
+// Docs describes the registered contracts, sorted by method.
+func (r *Registry) Docs() []Doc {
+	contracts := r.Contracts()
+	docs := make([]Doc, len(contracts))
+	for i, c := range contracts {
+		docs[i] = Doc{
+			Method:         c.Method,
+			Description:    c.Description,
+			RequestType:    messageName(c.Request),
+			ResponseType:   messageName(c.Response),
+			RequestSchema:  c.RequestSchema,
+			ResponseSchema: c.ResponseSchema,
+		}
+	}
+	return docs
+}
+
+// check checks payload against a message type and schema.
+func check(payload []byte, msg proto.Message, schema *jsonschema.Schema) error {
+	if msg != nil {
+		m := msg.ProtoReflect().New().Interface()
+		if err := protojson.Unmarshal(payload, m); err != nil {
+			return fmt.Errorf("%s: %w", messageName(msg), err)
+		}
+	}
+	if schema != nil {
+		return schema.Validate(payload)
+	}
+	return nil
+}
+
+// ValidateRequest checks the JSON params of a call to method against its
+// contract, if any.  The request is the first param.
+func (r *Registry) ValidateRequest(method string, params []byte) error {
+	c, ok := r.Lookup(method)
+	if !ok || (c.Request == nil && c.RequestSchema == nil) {
+		return nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(params, &list); err != nil {
+		return &ViolationError{Method: method, Direction: DirectionRequest, Err: fmt.Errorf("params must be an array: %w", err)}
+	}
+	if len(list) == 0 {
+		return &ViolationError{Method: method, Direction: DirectionRequest, Err: errors.New("missing request param")}
+	}
+	if err := check(list[0], c.Request, c.RequestSchema); err != nil {
+		return &ViolationError{Method: method, Direction: DirectionRequest, Err: err}
+	}
+	return nil
+}
+
+// ValidateResponse checks the JSON result of a call to method against its
+// contract, if any.
+func (r *Registry) ValidateResponse(method string, result []byte) error {
+	c, ok := r.Lookup(method)
+	if !ok {
+		return nil
+	}
+	if err := check(result, c.Response, c.ResponseSchema); err != nil {
+		return &ViolationError{Method: method, Direction: DirectionResponse, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package contract_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/contract"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/jsonschema"
+)
+
+// resultClient returns a fixed result and counts calls.
+type resultClient struct {
+	shiroclient.ShiroClient
+	result string
+	calls  int
+}
+
+func (c *resultClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	c.calls++
+	return types.NewSuccessResponse([]byte(c.result), "tx1", 0, 0), nil
+}
+
+func registry(t *testing.T) *contract.Registry {
+	reg := contract.NewRegistry()
+	require.NoError(t, reg.Register(contract.Contract{
+		Method:      "create_enum",
+		Description: "Creates an enum.",
+		Request:     (*descriptorpb.EnumValueDescriptorProto)(nil),
+		Response:    (*descriptorpb.EnumDescriptorProto)(nil),
+	}))
+	require.NoError(t, reg.Register(contract.Contract{
+		Method:         "get_count",
+		RequestSchema:  jsonschema.MustCompile(`{"type": "string"}`),
+		ResponseSchema: jsonschema.MustCompile(`{"type": "object", "required": ["count"]}`),
+	}))
+	require.Error(t, reg.Register(contract.Contract{}))
+	return reg
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	reg := registry(t)
+	inner := &resultClient{result: `{"name": "color", "value": [{"name": "RED"}]}`}
+	client := reg.Client(inner)
+
+	req := &descriptorpb.EnumValueDescriptorProto{Name: proto.String("RED"), Number: proto.Int32(1)}
+	resp, err := client.Call(ctx, "create_enum", shiroclient.WithParams(shiroclient.ProtoParams(req)))
+	require.NoError(t, err)
+	require.Equal(t, "tx1", resp.TransactionID())
+
+	// a request of the wrong type is not sent.
+	wrong := &descriptorpb.FieldDescriptorProto{JsonName: proto.String("red")}
+	_, err = client.Call(ctx, "create_enum", shiroclient.WithParams(shiroclient.ProtoParams(wrong)))
+	var verr *contract.ViolationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, contract.DirectionRequest, verr.Direction)
+	require.ErrorContains(t, err, "google.protobuf.EnumValueDescriptorProto")
+	require.Equal(t, 1, inner.calls)
+
+	// a result with an unknown field is reported with the response.
+	inner.result = `{"name": "color", "values": []}`
+	_, err = client.Call(ctx, "create_enum", shiroclient.WithParams(shiroclient.ProtoParams(req)))
+	var rerr *contract.ResponseViolationError
+	require.ErrorAs(t, err, &rerr)
+	require.Equal(t, "tx1", rerr.Response.TransactionID())
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, contract.DirectionResponse, verr.Direction)
+
+	inner.result = `{"total": 1}`
+	_, err = client.Call(ctx, "get_count", shiroclient.WithParams([]interface{}{"a"}))
+	var serr *jsonschema.ValidationError
+	require.ErrorAs(t, err, &serr)
+	require.Equal(t, `contract: get_count response: jsonschema: /: missing required property "count"`, err.Error())
+
+	_, err = client.Call(ctx, "get_count", shiroclient.WithParams([]interface{}{1}))
+	require.ErrorContains(t, err, "get_count request: jsonschema: /: expected string, got integer")
+	_, err = client.Call(ctx, "get_count")
+	require.ErrorContains(t, err, "missing request param")
+	_, err = client.Call(ctx, "get_count", shiroclient.WithParams(42))
+	require.ErrorContains(t, err, "params must be an array")
+
+	// methods without a contract are not checked.
+	_, err = client.Call(ctx, "other", shiroclient.WithParams(42))
+	require.NoError(t, err)
+}
+
+func TestDocs(t *testing.T) {
+	b, err := json.Marshal(registry(t).Docs())
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{
+			"method": "create_enum",
+			"description": "Creates an enum.",
+			"request_type": "google.protobuf.EnumValueDescriptorProto",
+			"response_type": "google.protobuf.EnumDescriptorProto"
+		},
+		{
+			"method": "get_count",
+			"request_schema": {"type": "string"},
+			"response_schema": {"type": "object", "required": ["count"]}
+		}
+	]`, string(b))
+
+	c, ok := registry(t).Lookup("get_count")
+	require.True(t, ok)
+	require.Nil(t, c.Request)
+}
@@ -0,0 +1,265 @@
+// Package dedup suppresses identical writes submitted within a short
+// window, e.g. by a double-click in a UI, returning the response of the
+// original call instead of committing a duplicate transaction.
+//
+//	client := dedup.New(rpcClient,
+//		dedup.WithWindow(5*time.Second),
+//		dedup.WithReadMethods("get_account"))
+//	resp, err := client.Call(ctx, "transfer", configs...)
+//
+// Calls are identical if they are made by the same caller and have the
+// same idempotency key (see shiroclient.WithIdempotencyKey) or, without
+// one, the same method and params.  The caller is identified by the
+// context headers of the call (see shiroclient.ContextWithHeaders), its
+// auth token, API key, headers and creator, and by WithIdentity if the
+// identity of callers is carried elsewhere, e.g. by an auth token provider
+// of the underlying client.  Calls made with a per-call auth token provider
+// are only deduplicated if they carry an idempotency key.  Transient data
+// is not compared, since helpers like private.WithSeed make it differ
+// between otherwise identical calls.
+//
+// A call made while an identical call is in flight waits for it and
+// returns its outcome, unless the context of the call in flight is done
+// first, in which case the waiting call is made.  Only successful responses are remembered for the
+// window, so a call failing with an error or a phylum error can be retried
+// immediately.
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// DefaultWindow is the default time a response is returned for identical
+// calls after the original completed.
+const DefaultWindow = 2 * time.Second
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithWindow sets the time a response is returned for identical calls
+// after the original completed.  It defaults to DefaultWindow.
+func WithWindow(window time.Duration) Option {
+	return func(c *Client) {
+		c.window = window
+	}
+}
+
+// WithReadMethods sets the phylum methods that do not write to the ledger
+// and are never deduplicated.
+func WithReadMethods(methods ...string) Option {
+	return func(c *Client) {
+		for _, m := range methods {
+			c.reads[m] = true
+		}
+	}
+}
+
+// WithOnDuplicate sets a function called with the method of each
+// suppressed call, e.g. to count them in a metric.
+func WithOnDuplicate(fn func(ctx context.Context, method string)) Option {
+	return func(c *Client) {
+		c.onDuplicate = fn
+	}
+}
+
+// WithIdentity sets a function identifying the caller of a call from its
+// context, so that identical calls of different callers are not
+// suppressed.  It is required if the identity of callers is not carried by
+// the context headers or configs of calls.
+func WithIdentity(identity func(ctx context.Context) string) Option {
+	return func(c *Client) {
+		c.identity = identity
+	}
+}
+
+// WithClock sets the function returning the current time.  It defaults to
+// time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+// Client is a ShiroClient suppressing identical write calls.
+type Client struct {
+	shiroclient.ShiroClient
+	window      time.Duration
+	reads       map[string]bool
+	onDuplicate func(ctx context.Context, method string)
+	identity    func(ctx context.Context) string
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// expiry holds the keys of completed entries in the order they
+	// expire.
+	expiry []string
+}
+
+// entry is the outcome of a call.
+type entry struct {
+	// done is closed when resp and err are set.
+	done    chan struct{}
+	resp    shiroclient.ShiroResponse
+	err     error
+	expires time.Time
+	// canceled is set if the call failed because its context was done,
+	// in which case waiting calls are made instead.
+	canceled bool
+}
+
+// New returns a client making calls with client, suppressing identical
+// write calls.
+func New(client shiroclient.ShiroClient, opts ...Option) *Client {
+	c := &Client{
+		ShiroClient: client,
+		window:      DefaultWindow,
+		reads:       make(map[string]bool),
+		now:         time.Now,
+		entries:     make(map[string]*entry),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Key returns the key identifying identical calls of method made with ctx
+// and configs, or false if calls cannot be identified safely.  The key
+// does not include the identity returned by WithIdentity.
+func Key(ctx context.Context, method string, configs ...shiroclient.Config) (string, bool, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return "", false, err
+	}
+	if opt.IdempotencyKey == "" && opt.AuthTokenProvider != nil {
+		return "", false, nil
+	}
+	caller, err := json.Marshal(struct {
+		ContextHeaders map[string]string `json:"context_headers"`
+		Headers        map[string]string `json:"headers"`
+		AuthScheme     string            `json:"auth_scheme"`
+		AuthToken      string            `json:"auth_token"`
+		APIKeyHeader   string            `json:"api_key_header"`
+		APIKey         string            `json:"api_key"`
+		Creator        string            `json:"creator"`
+	}{
+		ContextHeaders: shiroclient.HeadersFromContext(ctx),
+		Headers:        opt.Headers,
+		AuthScheme:     opt.AuthScheme,
+		AuthToken:      opt.AuthToken,
+		APIKeyHeader:   opt.APIKeyHeader,
+		APIKey:         opt.APIKey,
+		Creator:        opt.Creator,
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("dedup: %s caller: %w", method, err)
+	}
+	h := sha256.New()
+	h.Write(caller)
+	h.Write([]byte{0})
+	if opt.IdempotencyKey != "" {
+		h.Write([]byte("idempotency\x00" + opt.IdempotencyKey))
+		return hex.EncodeToString(h.Sum(nil)), true, nil
+	}
+	params, err := json.Marshal(opt.Params)
+	if err != nil {
+		return "", false, fmt.Errorf("dedup: %s params: %w", method, err)
+	}
+	h.Write([]byte("call\x00" + method + "\x00"))
+	h.Write(params)
+	return hex.EncodeToString(h.Sum(nil)), true, nil
+}
+
+// Call implements shiroclient.ShiroClient.  A write call identical to one
+// in flight, or to one that succeeded within the window, is not made and
+// returns the response of the original call.  If the context of the
+// original call is done before it returns, the calls waiting for it are
+// made instead.
+func (c *Client) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	if c.reads[method] {
+		return c.ShiroClient.Call(ctx, method, configs...)
+	}
+	key, ok, err := Key(ctx, method, configs...)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return c.ShiroClient.Call(ctx, method, configs...)
+	}
+	if c.identity != nil {
+		key = c.identity(ctx) + "\x00" + key
+	}
+	for {
+		c.mu.Lock()
+		c.expire()
+		e, ok := c.entries[key]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		if c.onDuplicate != nil {
+			c.onDuplicate(ctx, method)
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if !e.canceled {
+			return e.resp, e.err
+		}
+	}
+	e := &entry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	e.resp, e.err = c.ShiroClient.Call(ctx, method, configs...)
+
+	c.mu.Lock()
+	if e.err != nil || e.resp.Error() != nil {
+		e.canceled = e.err != nil && ctx.Err() != nil
+		delete(c.entries, key)
+	} else {
+		e.expires = c.now().Add(c.window)
+		c.expiry = append(c.expiry, key)
+	}
+	c.mu.Unlock()
+	close(e.done)
+	return e.resp, e.err
+}
+
+// Len returns the number of calls in flight or remembered for the window.
+func (c *Client) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire()
+	return len(c.entries)
+}
+
+// expire removes the completed entries whose window has passed.  c.mu must
+// be held.
+func (c *Client) expire() {
+	now := c.now()
+	n := 0
+	for _, key := range c.expiry {
+		e, ok := c.entries[key]
+		if ok && now.Before(e.expires) {
+			break
+		}
+		if ok {
+			delete(c.entries, key)
+		}
+		n++
+	}
+	c.expiry = c.expiry[n:]
+}
@@ -0,0 +1,237 @@
+package dedup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/dedup"
+)
+
+// countingClient returns a new transaction for each call, blocking calls
+// until release is closed if it is set.
+type countingClient struct {
+	shiroclient.ShiroClient
+	mu      sync.Mutex
+	calls   int
+	release chan struct{}
+	fail    error
+}
+
+func (c *countingClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	if c.release != nil {
+		select {
+		case <-c.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.fail != nil {
+		return nil, c.fail
+	}
+	if method == "reject" {
+		return types.NewFailureResponse(400, "rejected", nil), nil
+	}
+	return types.NewSuccessResponse([]byte(`{}`), fmt.Sprintf("tx%d", c.calls), 0, 0), nil
+}
+
+func TestWindow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+	inner := &countingClient{}
+	var dups []string
+	client := dedup.New(inner,
+		dedup.WithWindow(time.Second),
+		dedup.WithReadMethods("get"),
+		dedup.WithClock(func() time.Time { return now }),
+		dedup.WithOnDuplicate(func(ctx context.Context, method string) { dups = append(dups, method) }))
+	transfer := shiroclient.WithParams([]interface{}{map[string]int{"amount": 5}})
+
+	resp, err := client.Call(ctx, "transfer", transfer)
+	require.NoError(t, err)
+	require.Equal(t, "tx1", resp.TransactionID())
+	resp, err = client.Call(ctx, "transfer", transfer)
+	require.NoError(t, err)
+	require.Equal(t, "tx1", resp.TransactionID())
+	require.Equal(t, 1, inner.calls)
+	require.Equal(t, []string{"transfer"}, dups)
+
+	// different params, methods and reads are not suppressed.
+	_, err = client.Call(ctx, "transfer", shiroclient.WithParams([]interface{}{map[string]int{"amount": 6}}))
+	require.NoError(t, err)
+	_, err = client.Call(ctx, "get", transfer)
+	require.NoError(t, err)
+	_, err = client.Call(ctx, "get", transfer)
+	require.NoError(t, err)
+	require.Equal(t, 4, inner.calls)
+	require.Equal(t, 2, client.Len())
+
+	now = now.Add(time.Second)
+	require.Equal(t, 0, client.Len())
+	resp, err = client.Call(ctx, "transfer", transfer)
+	require.NoError(t, err)
+	require.Equal(t, "tx5", resp.TransactionID())
+
+	// idempotency keys identify calls regardless of params.
+	_, err = client.Call(ctx, "transfer", transfer, shiroclient.WithIdempotencyKey("k1"))
+	require.NoError(t, err)
+	resp, err = client.Call(ctx, "transfer", shiroclient.WithIdempotencyKey("k1"))
+	require.NoError(t, err)
+	require.Equal(t, "tx6", resp.TransactionID())
+	require.Equal(t, 6, inner.calls)
+}
+
+func TestFailuresNotRemembered(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingClient{}
+	client := dedup.New(inner)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Call(ctx, "reject")
+		require.NoError(t, err)
+		require.Error(t, resp.Error())
+	}
+	inner.fail = errors.New("timeout")
+	for i := 0; i < 2; i++ {
+		_, err := client.Call(ctx, "transfer")
+		require.EqualError(t, err, "timeout")
+	}
+	require.Equal(t, 4, inner.calls)
+	require.Equal(t, 0, client.Len())
+}
+
+func TestInFlight(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingClient{release: make(chan struct{})}
+	dups := make(chan struct{}, 4)
+	client := dedup.New(inner, dedup.WithOnDuplicate(func(context.Context, string) { dups <- struct{}{} }))
+
+	var wg sync.WaitGroup
+	txIDs := make([]string, 4)
+	call := func(i int) {
+		defer wg.Done()
+		resp, err := client.Call(ctx, "transfer")
+		require.NoError(t, err)
+		txIDs[i] = resp.TransactionID()
+	}
+	wg.Add(1)
+	go call(0)
+	require.Eventually(t, func() bool { return client.Len() == 1 }, time.Second, time.Millisecond)
+	for i := 1; i < 4; i++ {
+		wg.Add(1)
+		go call(i)
+		<-dups
+	}
+	close(inner.release)
+	wg.Wait()
+	require.Equal(t, []string{"tx1", "tx1", "tx1", "tx1"}, txIDs)
+	require.Equal(t, 1, inner.calls)
+
+	// a waiting call returns when its context is done.
+	inner.release = make(chan struct{})
+	defer close(inner.release)
+	go func() { _, _ = client.Call(ctx, "other") }()
+	require.Eventually(t, func() bool { return client.Len() == 2 }, time.Second, time.Millisecond)
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := client.Call(cctx, "other")
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestInFlightCanceled(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingClient{release: make(chan struct{})}
+	dups := make(chan struct{}, 2)
+	client := dedup.New(inner, dedup.WithOnDuplicate(func(context.Context, string) { dups <- struct{}{} }))
+
+	cctx, cancel := context.WithCancel(ctx)
+	errc := make(chan error, 1)
+	go func() {
+		_, err := client.Call(cctx, "transfer")
+		errc <- err
+	}()
+	require.Eventually(t, func() bool { return client.Len() == 1 }, time.Second, time.Millisecond)
+	type result struct {
+		resp shiroclient.ShiroResponse
+		err  error
+	}
+	waiter := make(chan result, 1)
+	go func() {
+		resp, err := client.Call(ctx, "transfer")
+		waiter <- result{resp, err}
+	}()
+	<-dups
+
+	// the waiting call is made once the original is canceled.
+	cancel()
+	require.ErrorIs(t, <-errc, context.Canceled)
+	close(inner.release)
+	r := <-waiter
+	require.NoError(t, r.err)
+	require.Equal(t, "tx1", r.resp.TransactionID())
+	require.Equal(t, 1, inner.calls)
+}
+
+func TestCallers(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingClient{}
+	client := dedup.New(inner)
+	transfer := shiroclient.WithParams([]interface{}{map[string]int{"amount": 5}})
+
+	// identical calls of different callers are made.
+	alice := shiroclient.ContextWithHeaders(ctx, map[string]string{"Authorization": "alice"})
+	bob := shiroclient.ContextWithHeaders(ctx, map[string]string{"Authorization": "bob"})
+	resp, err := client.Call(alice, "transfer", transfer)
+	require.NoError(t, err)
+	require.Equal(t, "tx1", resp.TransactionID())
+	resp, err = client.Call(bob, "transfer", transfer)
+	require.NoError(t, err)
+	require.Equal(t, "tx2", resp.TransactionID())
+	resp, err = client.Call(ctx, "transfer", transfer, shiroclient.WithAuthToken("carol"))
+	require.NoError(t, err)
+	require.Equal(t, "tx3", resp.TransactionID())
+	resp, err = client.Call(alice, "transfer", transfer)
+	require.NoError(t, err)
+	require.Equal(t, "tx1", resp.TransactionID())
+
+	// callers identified by a per-call provider are unknown.
+	provider := shiroclient.WithAuthTokenProvider(func(context.Context) (string, error) { return "dave", nil })
+	for i := 4; i < 6; i++ {
+		resp, err = client.Call(ctx, "transfer", transfer, provider)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("tx%d", i), resp.TransactionID())
+	}
+	resp, err = client.Call(ctx, "transfer", provider, shiroclient.WithIdempotencyKey("k1"))
+	require.NoError(t, err)
+	require.Equal(t, "tx6", resp.TransactionID())
+	resp, err = client.Call(ctx, "transfer", provider, shiroclient.WithIdempotencyKey("k1"))
+	require.NoError(t, err)
+	require.Equal(t, "tx6", resp.TransactionID())
+}
+
+type userKey struct{}
+
+func TestWithIdentity(t *testing.T) {
+	inner := &countingClient{}
+	client := dedup.New(inner, dedup.WithIdentity(func(ctx context.Context) string {
+		user, _ := ctx.Value(userKey{}).(string)
+		return user
+	}))
+	alice := context.WithValue(context.Background(), userKey{}, "alice")
+	bob := context.WithValue(context.Background(), userKey{}, "bob")
+	for _, ctx := range []context.Context{alice, bob, alice} {
+		_, err := client.Call(ctx, "transfer")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, inner.calls)
+}
@@ -0,0 +1,214 @@
+// Package degrade keeps serving reads when the ordering path of a gateway
+// is down, rejecting or queueing writes with ErrWriteUnavailable instead of
+// letting them time out.
+//
+//	monitor := degrade.NewMonitor(rpcClient)
+//	go monitor.Run(ctx)
+//	client := degrade.New(rpcClient, monitor,
+//		degrade.WithReadMethods("get_account", "list_accounts"),
+//		degrade.WithQueue(100))
+//	resp, err := client.Call(ctx, "create_account", configs...)
+//	if errors.Is(err, degrade.ErrWriteUnavailable) {
+//		// respond 503 and let the caller retry later.
+//	}
+//
+// Phylum methods are writes unless they are listed with WithReadMethods.
+// Other ShiroClient methods, like QueryBlock, are always served.
+package degrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// ErrWriteUnavailable is returned for write calls made while the ordering
+// path is down.
+var ErrWriteUnavailable = errors.New("write unavailable")
+
+// DownError is the reason the ordering path is down.
+type DownError struct {
+	// Service is the name of a service whose status is not up, if the
+	// health check succeeded.
+	Service string
+	// Status is the status of Service.
+	Status string
+	// Err is the error of the health check, if it failed.
+	Err error
+}
+
+func (e *DownError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("ordering down: health check: %v", e.Err)
+	}
+	return fmt.Sprintf("ordering down: %s is %s", e.Service, e.Status)
+}
+
+func (e *DownError) Unwrap() error {
+	return e.Err
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithReadMethods sets the phylum methods that do not write to the ledger
+// and are served while the ordering path is down.
+func WithReadMethods(methods ...string) Option {
+	return func(c *Client) {
+		for _, m := range methods {
+			c.reads[m] = true
+		}
+	}
+}
+
+// WithQueue makes write calls made while the ordering path is down wait,
+// up to size at a time, until it recovers instead of failing immediately.
+// Queued calls are made one at a time, in the order they were queued, when
+// the ordering path recovers.  Calls made while the queue is full, or
+// whose context is done while queued, fail with ErrWriteUnavailable.
+func WithQueue(size int) Option {
+	return func(c *Client) {
+		c.queueSize = size
+	}
+}
+
+// Client is a ShiroClient degrading to read-only operation when a Monitor
+// reports the ordering path down.
+type Client struct {
+	shiroclient.ShiroClient
+	monitor   *Monitor
+	reads     map[string]bool
+	queueSize int
+
+	mu       sync.Mutex
+	queue    []*waiter
+	draining bool
+}
+
+// waiter is a write call waiting for the ordering path to recover.
+type waiter struct {
+	// ready is closed when the call may be made.
+	ready chan struct{}
+	// done is closed when the call has been made.
+	done chan struct{}
+}
+
+// New returns a client making calls with client while monitor reports the
+// ordering path up.
+func New(client shiroclient.ShiroClient, monitor *Monitor, opts ...Option) *Client {
+	c := &Client{
+		ShiroClient: client,
+		monitor:     monitor,
+		reads:       make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	monitor.OnChange(func(up bool) {
+		if up {
+			c.drain()
+		}
+	})
+	return c
+}
+
+// Queued returns the number of write calls waiting for the ordering path
+// to recover.
+func (c *Client) Queued() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue)
+}
+
+// Call implements shiroclient.ShiroClient.  Write calls fail with
+// ErrWriteUnavailable, or wait if queueing is enabled, while the ordering
+// path is down or earlier queued writes have not been made.
+func (c *Client) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	if c.reads[method] {
+		return c.ShiroClient.Call(ctx, method, configs...)
+	}
+	w, err := c.enqueue(method)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return c.ShiroClient.Call(ctx, method, configs...)
+	}
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		if c.remove(w) {
+			return nil, fmt.Errorf("%w: %s: %w", ErrWriteUnavailable, method, ctx.Err())
+		}
+		// the call was released concurrently.
+		<-w.ready
+	}
+	defer close(w.done)
+	return c.ShiroClient.Call(ctx, method, configs...)
+}
+
+// enqueue returns nil if a write call may be made immediately, or a waiter
+// for its turn.
+func (c *Client) enqueue(method string) (*waiter, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.queue) == 0 && c.monitor.Up() {
+		return nil, nil
+	}
+	if len(c.queue) >= c.queueSize {
+		return nil, fmt.Errorf("%w: %s: %w", ErrWriteUnavailable, method, c.reason())
+	}
+	w := &waiter{ready: make(chan struct{}), done: make(chan struct{})}
+	c.queue = append(c.queue, w)
+	return w, nil
+}
+
+// reason returns the reason writes are unavailable.
+func (c *Client) reason() error {
+	if err := c.monitor.Err(); err != nil {
+		return err
+	}
+	return errors.New("queue full")
+}
+
+// remove removes w from the queue, returning false if it was released.
+func (c *Client) remove(w *waiter) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, q := range c.queue {
+		if q == w {
+			c.queue = append(c.queue[:i], c.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// drain releases queued calls one at a time while the ordering path is up.
+func (c *Client) drain() {
+	c.mu.Lock()
+	if c.draining {
+		c.mu.Unlock()
+		return
+	}
+	c.draining = true
+	c.mu.Unlock()
+	go func() {
+		for {
+			c.mu.Lock()
+			if len(c.queue) == 0 || !c.monitor.Up() {
+				c.draining = false
+				c.mu.Unlock()
+				return
+			}
+			w := c.queue[0]
+			c.queue = c.queue[1:]
+			c.mu.Unlock()
+			close(w.ready)
+			<-w.done
+		}
+	}()
+}
@@ -0,0 +1,162 @@
+package degrade_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/degrade"
+)
+
+// healthClient reports the orderer with a settable status and records the
+// phylum methods called.
+type healthClient struct {
+	shiroclient.ShiroClient
+
+	mu     sync.Mutex
+	status string
+	fail   bool
+	calls  []string
+}
+
+func (c *healthClient) setStatus(status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status = status
+}
+
+func (c *healthClient) called() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.calls...)
+}
+
+func (c *healthClient) QueryInfo(ctx context.Context, configs ...shiroclient.Config) (uint64, error) {
+	return 7, nil
+}
+
+func (c *healthClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if method == "healthcheck" {
+		if c.fail {
+			return nil, errors.New("unreachable")
+		}
+		b, err := json.Marshal(map[string]interface{}{
+			"reports": []map[string]string{{
+				"timestamp":       "2024-01-01T00:00:00Z",
+				"status":          c.status,
+				"service_name":    "fabric_orderer",
+				"service_version": "2.5",
+			}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return types.NewSuccessResponse(b, "", 0, 0), nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.calls = append(c.calls, method)
+	return types.NewSuccessResponse([]byte(`{}`), "tx1", 0, 0), nil
+}
+
+func TestMonitor(t *testing.T) {
+	ctx := context.Background()
+	client := &healthClient{status: degrade.HealthStatusUp}
+	monitor := degrade.NewMonitor(client)
+	var changes []bool
+	monitor.OnChange(func(up bool) { changes = append(changes, up) })
+	require.True(t, monitor.Up())
+	require.NoError(t, monitor.Check(ctx))
+
+	client.setStatus("DOWN")
+	err := monitor.Check(ctx)
+	require.EqualError(t, err, "ordering down: fabric_orderer is DOWN")
+	require.False(t, monitor.Up())
+	require.Equal(t, err, monitor.Err())
+
+	client.fail = true
+	var derr *degrade.DownError
+	require.ErrorAs(t, monitor.Check(ctx), &derr)
+	require.EqualError(t, derr.Err, "unreachable")
+
+	client.fail = false
+	client.setStatus(degrade.HealthStatusUp)
+	require.NoError(t, monitor.Check(ctx))
+	require.True(t, monitor.Up())
+	require.Equal(t, []bool{false, true}, changes)
+}
+
+func TestClientReject(t *testing.T) {
+	ctx := context.Background()
+	client := &healthClient{status: "DOWN"}
+	monitor := degrade.NewMonitor(client)
+	require.Error(t, monitor.Check(ctx))
+	c := degrade.New(client, monitor, degrade.WithReadMethods("get"))
+
+	_, err := c.Call(ctx, "get")
+	require.NoError(t, err)
+	_, err = c.Call(ctx, "put")
+	require.ErrorIs(t, err, degrade.ErrWriteUnavailable)
+	require.ErrorContains(t, err, "fabric_orderer is DOWN")
+	height, err := c.QueryInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), height)
+	require.Equal(t, []string{"get"}, client.called())
+
+	client.setStatus(degrade.HealthStatusUp)
+	require.NoError(t, monitor.Check(ctx))
+	_, err = c.Call(ctx, "put")
+	require.NoError(t, err)
+	require.Equal(t, []string{"get", "put"}, client.called())
+}
+
+func TestClientQueue(t *testing.T) {
+	ctx := context.Background()
+	client := &healthClient{status: "DOWN"}
+	monitor := degrade.NewMonitor(client)
+	require.Error(t, monitor.Check(ctx))
+	c := degrade.New(client, monitor, degrade.WithQueue(3))
+
+	// a queued call whose context is done leaves the queue.
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Call(cctx, "timeout")
+	require.ErrorIs(t, err, degrade.ErrWriteUnavailable)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Zero(t, c.Queued())
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i, method := range []string{"w0", "w1", "w2"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = c.Call(ctx, method)
+		}()
+		require.Eventually(t, func() bool { return c.Queued() == i+1 }, time.Second, time.Millisecond)
+	}
+
+	// the queue is full.
+	_, err = c.Call(ctx, "w3")
+	require.ErrorIs(t, err, degrade.ErrWriteUnavailable)
+	require.Empty(t, client.called())
+
+	client.setStatus(degrade.HealthStatusUp)
+	require.NoError(t, monitor.Check(ctx))
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"w0", "w1", "w2"}, client.called())
+	require.Zero(t, c.Queued())
+}
@@ -0,0 +1,157 @@
+package degrade
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// HealthStatusUp is the status of an operational service in a health
+// check report.
+const HealthStatusUp = "UP"
+
+// DefaultInterval is the time between health checks of a Monitor.
+const DefaultInterval = 5 * time.Second
+
+// DefaultOrderingServices are the services checked by a Monitor without
+// WithServices.
+var DefaultOrderingServices = []string{"fabric_orderer"}
+
+// MonitorOption configures a Monitor.
+type MonitorOption func(*Monitor)
+
+// WithServices sets the services on the ordering path checked by the
+// monitor.  It defaults to DefaultOrderingServices.
+func WithServices(services ...string) MonitorOption {
+	return func(m *Monitor) {
+		m.services = services
+	}
+}
+
+// WithInterval sets the time between health checks.  It defaults to
+// DefaultInterval.
+func WithInterval(interval time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		m.interval = interval
+	}
+}
+
+// WithConfigs sets configs for the health check requests.
+func WithConfigs(configs ...shiroclient.Config) MonitorOption {
+	return func(m *Monitor) {
+		m.configs = configs
+	}
+}
+
+// Monitor tracks whether the ordering path of a gateway is up, using
+// shiroclient.RemoteHealthCheck.  The ordering path is down when a health
+// check fails or reports a service whose status is not HealthStatusUp.  It
+// is considered up until a check reports otherwise.  A Monitor is safe for
+// concurrent use.
+type Monitor struct {
+	client   shiroclient.ShiroClient
+	services []string
+	interval time.Duration
+	configs  []shiroclient.Config
+
+	mu        sync.Mutex
+	down      bool
+	err       error
+	listeners []func(up bool)
+}
+
+// NewMonitor returns a monitor of the ordering path of client.  Checks are
+// made by Check, or periodically by Run.
+func NewMonitor(client shiroclient.ShiroClient, opts ...MonitorOption) *Monitor {
+	m := &Monitor{
+		client:   client,
+		services: DefaultOrderingServices,
+		interval: DefaultInterval,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Up returns true unless the last health check reported the ordering path
+// down.
+func (m *Monitor) Up() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.down
+}
+
+// Err returns the reason the ordering path is down, or nil if it is up.
+func (m *Monitor) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// OnChange registers fn to be called, from the goroutine of the check,
+// when the ordering path goes down or comes back up.
+func (m *Monitor) OnChange(fn func(up bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// Check performs a health check and returns nil if the ordering path is
+// up, or the reason it is down.
+func (m *Monitor) Check(ctx context.Context) error {
+	err := m.check(ctx)
+	m.set(err)
+	return err
+}
+
+func (m *Monitor) check(ctx context.Context) error {
+	health, err := shiroclient.RemoteHealthCheck(ctx, m.client, m.services, m.configs...)
+	if err != nil {
+		return &DownError{Err: err}
+	}
+	for _, r := range health.Reports() {
+		if r.Status() != HealthStatusUp {
+			return &DownError{Service: r.ServiceName(), Status: r.Status()}
+		}
+	}
+	return nil
+}
+
+// set records the outcome of a check and notifies listeners of a change.
+func (m *Monitor) set(err error) {
+	m.mu.Lock()
+	changed := m.down != (err != nil)
+	m.down = err != nil
+	m.err = err
+	listeners := m.listeners
+	m.mu.Unlock()
+	if !changed {
+		return
+	}
+	for _, fn := range listeners {
+		fn(err == nil)
+	}
+}
+
+// Run checks the health of the ordering path every interval until ctx is
+// done, and returns the error of ctx.
+func (m *Monitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		err := m.check(ctx)
+		if ctx.Err() != nil {
+			// the check was cut short rather than failed.
+			return ctx.Err()
+		}
+		m.set(err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
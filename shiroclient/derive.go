@@ -0,0 +1,110 @@
+package shiroclient
+
+import (
+	"context"
+	"errors"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+)
+
+// RequestOptions is the resolved form of a set of configs.  Values returned
+// by BaseOptions are copies and modifying them has no effect on a client.
+type RequestOptions = types.RequestOptions
+
+// derivable is implemented by clients that support With and BaseOptions
+// natively (clients created with NewRPC and NewMock).
+type derivable interface {
+	With(configs ...Config) ShiroClient
+	BaseOptions() (RequestOptions, error)
+}
+
+// With returns a client that applies configs after the base configs of
+// client and before any per-call configs.  The original client is not
+// modified.  Clients created with NewRPC and NewMock share their
+// connections with the derived client; other implementations are wrapped.
+// Derived RPC clients have their own lifecycle: Shutdown must be called on
+// each of them and on the original client, and the shared connections are
+// closed once all are shut down.
+func With(client ShiroClient, configs ...Config) ShiroClient {
+	if d, ok := client.(derivable); ok {
+		return d.With(configs...)
+	}
+	return &derivedClient{
+		client:  client,
+		configs: append([]Config(nil), configs...),
+	}
+}
+
+// BaseOptions returns the options resolved from the base configs of client,
+// for debugging.  Each call resolves a fresh copy, so generated values like
+// ID differ between calls.
+func BaseOptions(client ShiroClient) (RequestOptions, error) {
+	if d, ok := client.(derivable); ok {
+		return d.BaseOptions()
+	}
+	return RequestOptions{}, errors.New("client does not expose base options")
+}
+
+// derivedClient prepends configs to every request of an arbitrary client.
+type derivedClient struct {
+	client  ShiroClient
+	configs []Config
+}
+
+var _ derivable = (*derivedClient)(nil)
+
+func (d *derivedClient) join(configs []Config) []Config {
+	out := make([]Config, 0, len(d.configs)+len(configs))
+	out = append(out, d.configs...)
+	return append(out, configs...)
+}
+
+// With implements derivable.
+func (d *derivedClient) With(configs ...Config) ShiroClient {
+	return &derivedClient{client: d.client, configs: d.join(configs)}
+}
+
+// BaseOptions implements derivable.
+func (d *derivedClient) BaseOptions() (RequestOptions, error) {
+	base, err := BaseOptions(d.client)
+	if err != nil {
+		return base, err
+	}
+	opt, err := types.ApplyConfigs(base.Log, append([]Config{types.Opt(func(r *types.RequestOptions) {
+		*r = base.Clone()
+	})}, d.configs...)...)
+	if err != nil {
+		return RequestOptions{}, err
+	}
+	return opt.Clone(), nil
+}
+
+// Seed implements the ShiroClient interface.
+func (d *derivedClient) Seed(ctx context.Context, version string, configs ...Config) error {
+	return d.client.Seed(ctx, version, d.join(configs)...)
+}
+
+// ShiroPhylum implements the ShiroClient interface.
+func (d *derivedClient) ShiroPhylum(ctx context.Context, configs ...Config) (string, error) {
+	return d.client.ShiroPhylum(ctx, d.join(configs)...)
+}
+
+// Init implements the ShiroClient interface.
+func (d *derivedClient) Init(ctx context.Context, phylum string, configs ...Config) error {
+	return d.client.Init(ctx, phylum, d.join(configs)...)
+}
+
+// Call implements the ShiroClient interface.
+func (d *derivedClient) Call(ctx context.Context, method string, configs ...Config) (ShiroResponse, error) {
+	return d.client.Call(ctx, method, d.join(configs)...)
+}
+
+// QueryInfo implements the ShiroClient interface.
+func (d *derivedClient) QueryInfo(ctx context.Context, configs ...Config) (uint64, error) {
+	return d.client.QueryInfo(ctx, d.join(configs)...)
+}
+
+// QueryBlock implements the ShiroClient interface.
+func (d *derivedClient) QueryBlock(ctx context.Context, blockNumber uint64, configs ...Config) (Block, error) {
+	return d.client.QueryBlock(ctx, blockNumber, d.join(configs)...)
+}
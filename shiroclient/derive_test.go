@@ -0,0 +1,40 @@
+package shiroclient_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+func TestWith(t *testing.T) {
+	var auth string
+	srv := heightServer(t, &auth)
+	base := shiroclient.NewRPC([]shiroclient.Config{shiroclient.WithEndpoint(srv.URL)})
+	derived := shiroclient.With(base, shiroclient.WithAuthToken("derived"), shiroclient.WithHeader("X-Tenant", "a"))
+
+	ctx := context.Background()
+	_, err := derived.QueryInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer derived", auth)
+	_, err = base.QueryInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "", auth)
+
+	opt, err := shiroclient.BaseOptions(derived)
+	require.NoError(t, err)
+	require.Equal(t, srv.URL, opt.Endpoint)
+	require.Equal(t, "a", opt.Headers["X-Tenant"])
+
+	// modifying the view does not affect the client
+	opt.Headers["X-Tenant"] = "b"
+	opt, err = shiroclient.BaseOptions(derived)
+	require.NoError(t, err)
+	require.Equal(t, "a", opt.Headers["X-Tenant"])
+
+	baseOpt, err := shiroclient.BaseOptions(base)
+	require.NoError(t, err)
+	require.Empty(t, baseOpt.Headers)
+}
@@ -0,0 +1,199 @@
+// Package election runs singleton workloads, like a batch.Driver or a
+// health monitor, on exactly one replica of a highly available service.
+// Replicas campaign for a lease held in a Backend and the holder runs the
+// workload until it loses the lease or the workload returns.
+//
+//	elector := election.New(election.NewPhylumLease(client, "batch-driver"))
+//	err := elector.RunWhenLeader(ctx, func(ctx context.Context) error {
+//		ticker := driver.Register(ctx, "payments", time.Minute, handle)
+//		defer ticker.Stop()
+//		<-ctx.Done()
+//		return nil
+//	})
+//
+// Leases expire if their holder stops renewing them, so a replica that
+// loses connectivity to the backend must stop its workload before the TTL
+// elapses; RunWhenLeader cancels the workload when a renewal fails or does
+// not complete before the lease would expire.
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Backend holds a lease for a single leader.
+type Backend interface {
+	// Acquire acquires the lease for id, or renews it if id holds it, for
+	// ttl.  It returns false if another candidate holds the lease.  It must
+	// return once ctx is done.
+	Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error)
+	// Release releases the lease if id holds it.
+	Release(ctx context.Context, id string) error
+}
+
+// Default lease timings.
+const (
+	DefaultTTL   = 15 * time.Second
+	DefaultRenew = 5 * time.Second
+)
+
+// Option configures an Elector.
+type Option func(*Elector)
+
+// WithID sets the candidate ID of the replica.  It defaults to the host
+// name followed by a random suffix.
+func WithID(id string) Option {
+	return func(e *Elector) {
+		e.id = id
+	}
+}
+
+// WithTTL sets the duration of the lease, after which it expires unless
+// renewed.  It defaults to DefaultTTL.
+func WithTTL(ttl time.Duration) Option {
+	return func(e *Elector) {
+		e.ttl = ttl
+	}
+}
+
+// WithRenewInterval sets how often the leader renews the lease and other
+// candidates try to acquire it.  It must be shorter than the TTL and
+// defaults to DefaultRenew.
+func WithRenewInterval(interval time.Duration) Option {
+	return func(e *Elector) {
+		e.renew = interval
+	}
+}
+
+// Elector campaigns for the lease of a Backend on behalf of a replica.
+type Elector struct {
+	backend Backend
+	id      string
+	ttl     time.Duration
+	renew   time.Duration
+}
+
+// New returns an Elector campaigning in backend.  New panics if the renew
+// interval is not shorter than the TTL.
+func New(backend Backend, opts ...Option) *Elector {
+	e := &Elector{
+		backend: backend,
+		ttl:     DefaultTTL,
+		renew:   DefaultRenew,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.renew <= 0 || e.renew >= e.ttl {
+		panic("election: renew interval must be positive and shorter than the TTL")
+	}
+	if e.id == "" {
+		host, _ := os.Hostname()
+		e.id = fmt.Sprintf("%s-%s", host, uuid.NewString()[:8])
+	}
+	return e
+}
+
+// ID returns the candidate ID of the replica.
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// RunWhenLeader waits until the replica holds the lease, then runs fn
+// while renewing the lease.  The context of fn is canceled if a renewal
+// fails, times out or the lease is lost, after which RunWhenLeader waits for fn to
+// return and campaigns again.  When fn returns while the replica still
+// holds the lease, the lease is released and RunWhenLeader returns the
+// error of fn.  RunWhenLeader returns ctx.Err() once ctx is done.
+func (e *Elector) RunWhenLeader(ctx context.Context, fn func(ctx context.Context) error) error {
+	for {
+		held, err := e.campaign(ctx)
+		if err != nil {
+			return err
+		}
+		lost, err := e.lead(ctx, held, fn)
+		if !lost {
+			// releasing is best effort: the lease expires anyway.
+			rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), e.renew)
+			_ = e.backend.Release(rctx, e.id)
+			cancel()
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// campaign returns once the replica holds the lease, with the time the
+// lease was requested.
+func (e *Elector) campaign(ctx context.Context) (time.Time, error) {
+	for {
+		start := time.Now()
+		ok, err := e.acquire(ctx, start.Add(e.ttl-e.renew))
+		if err == nil && ok {
+			return start, nil
+		}
+		select {
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		case <-time.After(e.renew):
+		}
+	}
+}
+
+// acquire acquires or renews the lease, giving up at deadline.
+func (e *Elector) acquire(ctx context.Context, deadline time.Time) (bool, error) {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	return e.backend.Acquire(ctx, e.id, e.ttl)
+}
+
+// errLeaseLost cancels the context of fn when the lease is lost.
+var errLeaseLost = errors.New("election: lease lost")
+
+// lead runs fn while renewing the lease requested at held.  It reports
+// whether the lease was lost, or ctx done, before fn returned.  A renewal
+// gives up one renew interval before the held lease expires, so that a
+// hanging backend leaves fn time to stop before another replica can take
+// the lease.
+func (e *Elector) lead(ctx context.Context, held time.Time, fn func(ctx context.Context) error) (bool, error) {
+	fctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	done := make(chan error, 1)
+	go func() { done <- fn(fctx) }()
+
+	renew := time.NewTicker(e.renew)
+	defer renew.Stop()
+	for {
+		select {
+		case err := <-done:
+			return false, err
+		case <-ctx.Done():
+			<-done
+			return true, nil
+		case <-renew.C:
+			start := time.Now()
+			ok, err := e.acquire(ctx, held.Add(e.ttl-e.renew))
+			if err == nil && ok {
+				held = start
+				continue
+			}
+			cancel(errLeaseLost)
+			<-done
+			return true, nil
+		}
+	}
+}
+
+// IsLeaseLost reports whether the context of a function run by
+// RunWhenLeader was canceled because the lease was lost.
+func IsLeaseLost(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errLeaseLost)
+}
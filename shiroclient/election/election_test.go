@@ -0,0 +1,222 @@
+package election_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/election"
+)
+
+func newElector(backend election.Backend, id string) *election.Elector {
+	return election.New(backend, election.WithID(id), election.WithTTL(time.Second), election.WithRenewInterval(10*time.Millisecond))
+}
+
+func TestRunWhenLeader(t *testing.T) {
+	backend := election.NewMemory()
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	stop := make(chan struct{})
+	errA := make(chan error, 1)
+	go func() {
+		errA <- newElector(backend, "a").RunWhenLeader(ctx, func(ctx context.Context) error {
+			close(started)
+			<-stop
+			return errors.New("done")
+		})
+	}()
+	<-started
+	require.Equal(t, "a", backend.Holder())
+
+	var ranB atomic.Bool
+	errB := make(chan error, 1)
+	go func() {
+		errB <- newElector(backend, "b").RunWhenLeader(ctx, func(ctx context.Context) error {
+			ranB.Store(true)
+			return nil
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	require.False(t, ranB.Load(), "only one replica leads")
+
+	close(stop)
+	require.EqualError(t, <-errA, "done")
+	// a released the lease, which b acquired.
+	require.NoError(t, <-errB)
+	require.True(t, ranB.Load())
+	require.Empty(t, backend.Holder())
+}
+
+// flakyBackend fails to renew the lease while failing is set.
+type flakyBackend struct {
+	*election.Memory
+	failing atomic.Bool
+}
+
+func (f *flakyBackend) Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	if f.failing.Load() {
+		return false, errors.New("unreachable")
+	}
+	return f.Memory.Acquire(ctx, id, ttl)
+}
+
+func TestLeaseLost(t *testing.T) {
+	backend := &flakyBackend{Memory: election.NewMemory()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var terms atomic.Int32
+	lost := make(chan bool, 2)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- newElector(backend, "a").RunWhenLeader(ctx, func(ctx context.Context) error {
+			if terms.Add(1) == 1 {
+				backend.failing.Store(true)
+			} else {
+				cancel()
+			}
+			<-ctx.Done()
+			lost <- election.IsLeaseLost(ctx)
+			backend.failing.Store(false)
+			return nil
+		})
+	}()
+	require.True(t, <-lost)
+	// the replica campaigns again after losing the lease.
+	require.False(t, <-lost)
+	require.ErrorIs(t, <-errc, context.Canceled)
+	require.Equal(t, int32(2), terms.Load())
+}
+
+// blockingBackend hangs until the context of Acquire is done while
+// blocking is set.
+type blockingBackend struct {
+	*election.Memory
+	blocking atomic.Bool
+	blocked  atomic.Int32
+}
+
+func (b *blockingBackend) Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	if b.blocking.Load() {
+		b.blocked.Add(1)
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+	return b.Memory.Acquire(ctx, id, ttl)
+}
+
+func TestRenewalTimeout(t *testing.T) {
+	backend := &blockingBackend{Memory: election.NewMemory()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const ttl = 200 * time.Millisecond
+	elector := election.New(backend, election.WithID("a"), election.WithTTL(ttl), election.WithRenewInterval(50*time.Millisecond))
+	lost := make(chan bool, 1)
+	stopped := make(chan time.Duration, 1)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- elector.RunWhenLeader(ctx, func(ctx context.Context) error {
+			start := time.Now()
+			backend.blocking.Store(true)
+			<-ctx.Done()
+			lost <- election.IsLeaseLost(ctx)
+			stopped <- time.Since(start)
+			return nil
+		})
+	}()
+	require.True(t, <-lost)
+	// the workload is stopped before the lease expires.
+	require.Less(t, <-stopped, ttl)
+	// campaigning does not hang either.
+	require.Eventually(t, func() bool { return backend.blocked.Load() >= 3 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-errc, context.Canceled)
+}
+
+func TestFileLock(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "leader.lock")
+	a, b := election.NewFileLock(path), election.NewFileLock(path)
+
+	ok, err := a.Acquire(ctx, "a", time.Second)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = b.Acquire(ctx, "b", time.Second)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, a.Release(ctx, "a"))
+	ok, err = b.Acquire(ctx, "b", time.Second)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, b.Release(ctx, "b"))
+}
+
+// leaseClient is a ShiroClient implementing the lease endpoints.
+type leaseClient struct {
+	shiroclient.ShiroClient
+	holder string
+	calls  []string
+	fail   bool
+}
+
+func (c *leaseClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	if c.fail {
+		return types.NewFailureResponse(500, "storage failure", nil), nil
+	}
+	params := opt.Params.([]interface{})
+	b, _ := json.Marshal(params)
+	c.calls = append(c.calls, method+string(b))
+	id := params[1].(string)
+	switch method {
+	case election.AcquireLeaseMethod:
+		if c.holder != "" && c.holder != id {
+			return types.NewSuccessResponse([]byte(`false`), "", 0, 0), nil
+		}
+		c.holder = id
+		return types.NewSuccessResponse([]byte(`true`), "", 0, 0), nil
+	case election.ReleaseLeaseMethod:
+		if c.holder == id {
+			c.holder = ""
+		}
+		return types.NewSuccessResponse([]byte(`{}`), "", 0, 0), nil
+	}
+	return types.NewFailureResponse(404, "unknown method", nil), nil
+}
+
+func TestPhylumLease(t *testing.T) {
+	ctx := context.Background()
+	client := &leaseClient{}
+	lease := election.NewPhylumLease(client, "driver")
+
+	ok, err := lease.Acquire(ctx, "a", 1500*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = lease.Acquire(ctx, "b", time.Second)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, lease.Release(ctx, "a"))
+	require.Equal(t, []string{
+		`acquire_lease["driver","a",1500]`,
+		`acquire_lease["driver","b",1000]`,
+		`release_lease["driver","a"]`,
+	}, client.calls)
+
+	client.fail = true
+	_, err = lease.Acquire(ctx, "a", time.Second)
+	require.ErrorContains(t, err, "storage failure")
+}
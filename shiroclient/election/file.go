@@ -0,0 +1,68 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrFileLockUnsupported is returned by FileLock backends on platforms
+// without advisory file locks.
+var ErrFileLockUnsupported = errors.New("election: file locks are not supported on this platform")
+
+// FileLock is a Backend holding the lease as an exclusive advisory lock on
+// a file, for replicas on the same host or sharing a file system with
+// reliable locks.  The lock is held until released or until the process
+// exits, so the TTL is not used.
+type FileLock struct {
+	path string
+
+	mu     sync.Mutex
+	file   *os.File
+	holder string
+}
+
+var _ Backend = (*FileLock)(nil)
+
+// NewFileLock returns a Backend locking the file at path, which is
+// created if needed.
+func NewFileLock(path string) *FileLock {
+	return &FileLock{path: path}
+}
+
+// Acquire implements Backend.
+func (f *FileLock) Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file != nil {
+		return f.holder == id, nil
+	}
+	file, err := os.OpenFile(f.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return false, err
+	}
+	ok, err := tryLock(file)
+	if err != nil || !ok {
+		_ = file.Close()
+		return false, err
+	}
+	f.file = file
+	f.holder = id
+	return true, nil
+}
+
+// Release implements Backend.
+func (f *FileLock) Release(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil || f.holder != id {
+		return nil
+	}
+	// closing the file releases the lock.
+	err := f.file.Close()
+	f.file = nil
+	f.holder = ""
+	return err
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package election
+
+import "os"
+
+func tryLock(file *os.File) (bool, error) {
+	return false, ErrFileLockUnsupported
+}
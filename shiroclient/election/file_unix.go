@@ -0,0 +1,18 @@
+//go:build unix
+
+package election
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock locks file without blocking and reports whether it succeeded.
+func tryLock(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,57 @@
+package election
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is a Backend holding the lease in memory, for candidates in the
+// same process and for tests.
+type Memory struct {
+	mu      sync.Mutex
+	holder  string
+	expires time.Time
+	now     func() time.Time
+}
+
+var _ Backend = (*Memory)(nil)
+
+// NewMemory returns an in-memory Backend.
+func NewMemory() *Memory {
+	return &Memory{now: time.Now}
+}
+
+// Acquire implements Backend.
+func (m *Memory) Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if m.holder != "" && m.holder != id && now.Before(m.expires) {
+		return false, nil
+	}
+	m.holder = id
+	m.expires = now.Add(ttl)
+	return true, nil
+}
+
+// Release implements Backend.
+func (m *Memory) Release(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.holder == id {
+		m.holder = ""
+	}
+	return nil
+}
+
+// Holder returns the ID of the candidate holding the lease, or an empty
+// string if the lease is free or expired.
+func (m *Memory) Holder() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.now().Before(m.expires) {
+		return ""
+	}
+	return m.holder
+}
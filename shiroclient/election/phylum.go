@@ -0,0 +1,71 @@
+package election
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// Phylum endpoints implementing a lease, called by PhylumLease.
+const (
+	// AcquireLeaseMethod is called with the lease name, the candidate ID
+	// and the TTL in milliseconds.  It must return true if it granted or
+	// renewed the lease, or false if another candidate holds an unexpired
+	// lease.  Expiry must be computed from the transaction timestamp so
+	// that endorsers agree.
+	AcquireLeaseMethod = "acquire_lease"
+	// ReleaseLeaseMethod is called with the lease name and the candidate
+	// ID.  It must release the lease if the candidate holds it.
+	ReleaseLeaseMethod = "release_lease"
+)
+
+// PhylumLease is a Backend holding a named lease on the ledger through
+// the AcquireLeaseMethod and ReleaseLeaseMethod endpoints of the phylum, so
+// that replicas need no infrastructure besides the gateway.
+type PhylumLease struct {
+	client  shiroclient.ShiroClient
+	name    string
+	configs []shiroclient.Config
+}
+
+var _ Backend = (*PhylumLease)(nil)
+
+// NewPhylumLease returns a Backend holding the lease name through client.
+// configs are applied to each call.
+func NewPhylumLease(client shiroclient.ShiroClient, name string, configs ...shiroclient.Config) *PhylumLease {
+	return &PhylumLease{client: client, name: name, configs: configs}
+}
+
+func (p *PhylumLease) call(ctx context.Context, method string, params []interface{}) (json.RawMessage, error) {
+	configs := append([]shiroclient.Config{shiroclient.WithParams(params)}, p.configs...)
+	resp, err := p.client.Call(ctx, method, configs...)
+	if err != nil {
+		return nil, err
+	}
+	if e := resp.Error(); e != nil {
+		return nil, fmt.Errorf("election: %s: phylum error %d: %s", method, e.Code(), e.Message())
+	}
+	return resp.ResultJSON(), nil
+}
+
+// Acquire implements Backend.
+func (p *PhylumLease) Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	result, err := p.call(ctx, AcquireLeaseMethod, []interface{}{p.name, id, ttl.Milliseconds()})
+	if err != nil {
+		return false, err
+	}
+	var acquired bool
+	if err := json.Unmarshal(result, &acquired); err != nil {
+		return false, fmt.Errorf("election: %s: invalid result: %w", AcquireLeaseMethod, err)
+	}
+	return acquired, nil
+}
+
+// Release implements Backend.
+func (p *PhylumLease) Release(ctx context.Context, id string) error {
+	_, err := p.call(ctx, ReleaseLeaseMethod, []interface{}{p.name, id})
+	return err
+}
@@ -0,0 +1,34 @@
+package shiroclient
+
+import (
+	"context"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/rpc"
+)
+
+// EndorsementPolicy describes the endorsement policy of the chaincode and
+// the peers currently eligible to endorse its transactions.
+type EndorsementPolicy = rpc.EndorsementPolicy
+
+// EndorsingPeer is a peer eligible to endorse transactions.
+type EndorsingPeer = rpc.EndorsingPeer
+
+// ErrEndorsementPolicyUnsupported is returned by GetEndorsementPolicy for
+// clients that cannot inspect the endorsement policy, like those created
+// with NewMock.
+var ErrEndorsementPolicyUnsupported = rpc.ErrEndorsementPolicyUnsupported
+
+// GetEndorsementPolicy returns the endorsement policy of the chaincode and
+// the peers currently eligible to endorse, as seen by the gateway of
+// client.  Applications can use it to choose WithMSPFilter and
+// WithMinEndorsers values at runtime instead of configuring them per
+// environment:
+//
+//	policy, err := shiroclient.GetEndorsementPolicy(ctx, client)
+//	...
+//	configs = append(configs,
+//		shiroclient.WithMSPFilter(policy.MSPIDs()),
+//		shiroclient.WithMinEndorsers(policy.MinEndorsements))
+func GetEndorsementPolicy(ctx context.Context, client ShiroClient, configs ...Config) (*EndorsementPolicy, error) {
+	return rpc.GetEndorsementPolicy(ctx, client, configs...)
+}
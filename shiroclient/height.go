@@ -0,0 +1,101 @@
+package shiroclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// HeightMonitor caches the ledger height of a client, so that subsystems
+// invalidating state on new blocks and lag dashboards can share one source
+// of heights instead of each calling QueryInfo.  The cached height never
+// decreases, so a gateway lagging behind a load balanced endpoint does not
+// appear to roll back the ledger.  A HeightMonitor is safe for concurrent
+// use.
+type HeightMonitor struct {
+	client  ShiroClient
+	configs []Config
+
+	mu       sync.Mutex
+	height   uint64
+	observed time.Time
+}
+
+// NewHeightMonitor returns a monitor querying the ledger height of client
+// with configs.  No query is made until Refresh, Latest or WatchHeight is
+// called.
+func NewHeightMonitor(client ShiroClient, configs ...Config) *HeightMonitor {
+	return &HeightMonitor{client: client, configs: append([]Config(nil), configs...)}
+}
+
+// Height returns the cached ledger height and the time it was last
+// observed, or zero values if the height was never queried.
+func (m *HeightMonitor) Height() (uint64, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.height, m.observed
+}
+
+// Refresh queries the ledger height and returns the cached height, which is
+// larger than the queried one if it was observed before.
+func (m *HeightMonitor) Refresh(ctx context.Context) (uint64, error) {
+	height, err := m.client.QueryInfo(ctx, m.configs...)
+	if err != nil {
+		return 0, err
+	}
+	return m.observe(height), nil
+}
+
+// Latest returns the cached height if it was observed within maxAge, and
+// otherwise refreshes it.
+func (m *HeightMonitor) Latest(ctx context.Context, maxAge time.Duration) (uint64, error) {
+	m.mu.Lock()
+	height, observed := m.height, m.observed
+	m.mu.Unlock()
+	if !observed.IsZero() && time.Since(observed) < maxAge {
+		return height, nil
+	}
+	return m.Refresh(ctx)
+}
+
+func (m *HeightMonitor) observe(height uint64) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if height > m.height {
+		m.height = height
+	}
+	m.observed = time.Now()
+	return m.height
+}
+
+// WatchHeight refreshes the height every interval and returns a channel
+// receiving the height each time it advances, starting with the first
+// height observed.  A slow receiver only gets the latest height.  Failed
+// queries are retried at the next interval.  The channel is closed once
+// ctx is done.
+func (m *HeightMonitor) WatchHeight(ctx context.Context, interval time.Duration) <-chan uint64 {
+	ch := make(chan uint64, 1)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var sent uint64
+		for {
+			if height, err := m.Refresh(ctx); err == nil && height > sent {
+				sent = height
+				// replace an update the receiver has not taken yet.
+				select {
+				case <-ch:
+				default:
+				}
+				ch <- height
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,85 @@
+package shiroclient_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// heightClient is a ShiroClient reporting a settable ledger height.
+type heightClient struct {
+	shiroclient.ShiroClient
+
+	mu      sync.Mutex
+	height  uint64
+	err     error
+	queries int
+}
+
+func (c *heightClient) QueryInfo(ctx context.Context, configs ...shiroclient.Config) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries++
+	return c.height, c.err
+}
+
+func (c *heightClient) set(height uint64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.height, c.err = height, err
+}
+
+func TestHeightMonitor(t *testing.T) {
+	client := &heightClient{height: 5}
+	m := shiroclient.NewHeightMonitor(client)
+	ctx := context.Background()
+
+	height, observed := m.Height()
+	require.Zero(t, height)
+	require.True(t, observed.IsZero())
+
+	height, err := m.Latest(ctx, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), height)
+	client.set(6, nil)
+	height, err = m.Latest(ctx, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), height)
+	require.Equal(t, 1, client.queries)
+
+	// a lagging gateway does not lower the height.
+	client.set(4, nil)
+	height, err = m.Refresh(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), height)
+
+	client.set(0, errors.New("unavailable"))
+	_, err = m.Refresh(ctx)
+	require.Error(t, err)
+	height, observed = m.Height()
+	require.Equal(t, uint64(5), height)
+	require.False(t, observed.IsZero())
+}
+
+func TestWatchHeight(t *testing.T) {
+	client := &heightClient{height: 1}
+	m := shiroclient.NewHeightMonitor(client)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := m.WatchHeight(ctx, time.Millisecond)
+
+	require.Equal(t, uint64(1), <-ch)
+	client.set(3, nil)
+	require.Equal(t, uint64(3), <-ch)
+	height, _ := m.Height()
+	require.Equal(t, uint64(3), height)
+
+	cancel()
+	for range ch {
+	}
+}
@@ -0,0 +1,140 @@
+package shiroclient
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+)
+
+// Identity is a client-side Fabric identity: an MSP ID with the X.509
+// certificate and private key of a member.  Clients configured with
+// WithIdentity make calls as the identity's MSP and sign their requests,
+// instead of relying on keys held by the gateway.
+type Identity struct {
+	// MSPID is the ID of the MSP that issued the certificate.
+	MSPID string
+	// Certificate is the certificate of the identity.
+	Certificate *x509.Certificate
+	// Key holds the private key of the certificate.  It may be backed by
+	// an HSM or a KMS.
+	Key crypto.Signer
+}
+
+var _ types.RequestSigner = (*Identity)(nil)
+
+// NewIdentity returns the identity of mspID with the PEM encoded
+// certificate certPEM and key, which must match the public key of the
+// certificate.
+func NewIdentity(mspID string, certPEM []byte, key crypto.Signer) (*Identity, error) {
+	if mspID == "" {
+		return nil, errors.New("identity: empty MSP ID")
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("identity: no PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(cert.PublicKey) {
+		return nil, errors.New("identity: key does not match certificate")
+	}
+	return &Identity{MSPID: mspID, Certificate: cert, Key: key}, nil
+}
+
+// LoadIdentity loads the identity of mspID from a PEM encoded certificate
+// and a PEM encoded PKCS #8, SEC 1 (EC) or PKCS #1 (RSA) private key, as
+// found in the signcerts and keystore directories of a Fabric MSP.
+func LoadIdentity(mspID string, certFile string, keyFile string) (*Identity, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return NewIdentity(mspID, certPEM, key)
+}
+
+func parsePrivateKey(keyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("identity: no PEM private key")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("identity: unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// SignRequest implements types.RequestSigner.  Ed25519 keys sign body
+// itself; other keys sign its SHA-256 digest.
+func (id *Identity) SignRequest(ctx context.Context, body []byte) ([]byte, []byte, error) {
+	var sig []byte
+	var err error
+	switch id.Key.Public().(type) {
+	case ed25519.PublicKey:
+		sig, err = id.Key.Sign(rand.Reader, body, crypto.Hash(0))
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+		digest := sha256.Sum256(body)
+		sig, err = id.Key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		return nil, nil, fmt.Errorf("identity: unsupported key type %T", id.Key.Public())
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("identity: sign request: %w", err)
+	}
+	return sig, id.Certificate.Raw, nil
+}
+
+// CertificatePEM returns the PEM encoded certificate of the identity.
+func (id *Identity) CertificatePEM() []byte {
+	var b bytes.Buffer
+	_ = pem.Encode(&b, &pem.Block{Type: "CERTIFICATE", Bytes: id.Certificate.Raw})
+	return b.Bytes()
+}
+
+// WithIdentity makes requests as id: calls are made on behalf of its MSP,
+// as with WithCreator, and RPC requests are signed with its key in the
+// rpc.HeaderSignature and rpc.HeaderSignerCertificate headers.  Gateways
+// that do not verify signatures ignore them.  Signing has no effect in
+// mock mode.
+func WithIdentity(id *Identity) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.Creator = id.MSPID
+		r.Signer = id
+	})
+}
@@ -0,0 +1,97 @@
+package shiroclient_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+)
+
+// writeIdentity writes a self-signed certificate and its EC private key to
+// dir, as in a Fabric MSP, and returns the paths of the files.
+func writeIdentity(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "user1@org1.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestIdentity(t *testing.T) {
+	certFile, keyFile := writeIdentity(t, t.TempDir())
+	id, err := shiroclient.LoadIdentity("Org1MSP", certFile, keyFile)
+	require.NoError(t, err)
+	require.Equal(t, "Org1MSP", id.MSPID)
+
+	var (
+		body    []byte
+		sig     string
+		certB64 string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		sig = r.Header.Get(rpc.HeaderSignature)
+		certB64 = r.Header.Get(rpc.HeaderSignerCertificate)
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":"1","result":{"error_level":0,"result":{},"code":0,"message":"","data":null,"transaction_id":"tx1"}}`)
+	}))
+	defer srv.Close()
+
+	client := shiroclient.NewRPC([]shiroclient.Config{
+		shiroclient.WithEndpoint(srv.URL),
+		shiroclient.WithIdentity(id),
+	})
+	_, err = client.Call(context.Background(), "ping")
+	require.NoError(t, err)
+
+	var req struct {
+		Params map[string]interface{} `json:"params"`
+	}
+	require.NoError(t, json.Unmarshal(body, &req))
+	require.Equal(t, "Org1MSP", req.Params["creator_msp_id"])
+
+	certDER, err := base64.StdEncoding.DecodeString(certB64)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+	require.Equal(t, id.Certificate.Raw, cert.Raw)
+	sigDER, err := base64.StdEncoding.DecodeString(sig)
+	require.NoError(t, err)
+	digest := sha256.Sum256(body)
+	require.True(t, ecdsa.VerifyASN1(cert.PublicKey.(*ecdsa.PublicKey), digest[:], sigDER))
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = shiroclient.NewIdentity("Org1MSP", id.CertificatePEM(), other)
+	require.Error(t, err)
+}
@@ -0,0 +1,143 @@
+package integrationtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// ErrDockerUnavailable is returned when the docker CLI is not installed.
+var ErrDockerUnavailable = errors.New("integrationtest: docker is not available")
+
+// ContainerRequest describes a container to start.
+type ContainerRequest struct {
+	// Image is the container image.
+	Image string
+	// Name is the container name.  A random name is used if empty.
+	Name string
+	// Env holds environment variables of the container.
+	Env map[string]string
+	// ExposedPorts are container ports published on random host ports,
+	// e.g. "8082/tcp".
+	ExposedPorts []string
+	// Network is the docker network the container joins, if not empty.
+	Network string
+	// NetworkAliases are host names of the container on Network.
+	NetworkAliases []string
+	// Cmd overrides the image command, if not empty.
+	Cmd []string
+}
+
+// Container is a started container.
+type Container struct {
+	// ID is the docker container ID.
+	ID string
+	// Image is the container image.
+	Image string
+}
+
+// docker runs the docker CLI and returns its trimmed stdout.
+func docker(ctx context.Context, args ...string) (string, error) {
+	path, err := exec.LookPath("docker")
+	if err != nil {
+		return "", ErrDockerUnavailable
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...) // #nosec G204
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// dockerAvailable reports whether the docker daemon can be reached.
+func dockerAvailable(ctx context.Context) bool {
+	_, err := docker(ctx, "version", "--format", "{{.Server.Version}}")
+	return err == nil
+}
+
+// StartContainer starts a detached container.  The container is kept when
+// it stops, e.g. because it crashed, so that its logs remain available
+// until Terminate removes it.
+func StartContainer(ctx context.Context, req ContainerRequest) (*Container, error) {
+	if req.Image == "" {
+		return nil, errors.New("integrationtest: container image is required")
+	}
+	args := []string{"run", "--detach"}
+	if req.Name != "" {
+		args = append(args, "--name", req.Name)
+	}
+	keys := make([]string, 0, len(req.Env))
+	for k := range req.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--env", k+"="+req.Env[k])
+	}
+	for _, port := range req.ExposedPorts {
+		args = append(args, "--publish", "127.0.0.1::"+port)
+	}
+	if req.Network != "" {
+		args = append(args, "--network", req.Network)
+		for _, alias := range req.NetworkAliases {
+			args = append(args, "--network-alias", alias)
+		}
+	}
+	args = append(args, req.Image)
+	args = append(args, req.Cmd...)
+	id, err := docker(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &Container{ID: id, Image: req.Image}, nil
+}
+
+// HostPort returns the host address published for a container port, e.g.
+// "127.0.0.1:49153" for "8082/tcp".
+func (c *Container) HostPort(ctx context.Context, port string) (string, error) {
+	out, err := docker(ctx, "port", c.ID, port)
+	if err != nil {
+		return "", err
+	}
+	// docker may list one address per line (IPv4 and IPv6).
+	addr, _, _ := strings.Cut(out, "\n")
+	host, p, err := net.SplitHostPort(strings.TrimSpace(addr))
+	if err != nil {
+		return "", fmt.Errorf("integrationtest: port %s: %w", port, err)
+	}
+	if host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, p), nil
+}
+
+// Logs returns the combined output of the container.
+func (c *Container) Logs(ctx context.Context) (string, error) {
+	return docker(ctx, "logs", c.ID)
+}
+
+// Terminate stops and removes the container.
+func (c *Container) Terminate(ctx context.Context) error {
+	_, err := docker(ctx, "rm", "--force", c.ID)
+	return err
+}
+
+// createNetwork creates a docker network with the given name.
+func createNetwork(ctx context.Context, name string) error {
+	_, err := docker(ctx, "network", "create", name)
+	return err
+}
+
+// removeNetwork removes the docker network with the given name.
+func removeNetwork(ctx context.Context, name string) error {
+	_, err := docker(ctx, "network", "rm", name)
+	return err
+}
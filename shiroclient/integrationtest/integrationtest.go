@@ -0,0 +1,275 @@
+// Package integrationtest starts a shiroclient gateway, and optionally the
+// containers it depends on, for integration tests.  Containers are managed
+// with the docker CLI rather than a container library, so the package adds
+// no dependencies to the module.
+//
+//	func TestHello(t *testing.T) {
+//		gw := integrationtest.NewGateway(t, integrationtest.GatewayOptions{
+//			Dependencies: []integrationtest.ContainerRequest{{
+//				Image:          "luthersystems/substrate:latest",
+//				NetworkAliases: []string{"substrate"},
+//			}},
+//			Env: map[string]string{"SHIROCLIENT_SUBSTRATE": "substrate"},
+//		})
+//		resp, err := gw.Client.Call(context.Background(), "hello")
+//		...
+//	}
+//
+// Setting SHIROCLIENT_TEST_GATEWAY_ENDPOINT uses an already running gateway
+// instead of starting containers.
+package integrationtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// Environment variables read by NewGateway.
+const (
+	// EnvGatewayEndpoint is the endpoint of an existing gateway to use
+	// instead of starting containers.
+	EnvGatewayEndpoint = "SHIROCLIENT_TEST_GATEWAY_ENDPOINT"
+	// EnvGatewayImage is the gateway image used when GatewayOptions.Image
+	// is empty.
+	EnvGatewayImage = "SHIROCLIENT_TEST_GATEWAY_IMAGE"
+)
+
+const (
+	// DefaultGatewayPort is the container port of the gateway.
+	DefaultGatewayPort = "8082/tcp"
+	// DefaultStartupTimeout bounds the time to wait for a healthy gateway.
+	DefaultStartupTimeout = 2 * time.Minute
+	// healthPollInterval is the interval between health checks.
+	healthPollInterval = 500 * time.Millisecond
+)
+
+// GatewayOptions configures the containers started by StartGateway.
+type GatewayOptions struct {
+	// Image is the gateway image.  Defaults to $SHIROCLIENT_TEST_GATEWAY_IMAGE.
+	Image string
+	// Env holds environment variables of the gateway container.
+	Env map[string]string
+	// Port is the container port of the gateway.  Defaults to
+	// DefaultGatewayPort.
+	Port string
+	// Dependencies are containers started before the gateway, e.g. a
+	// substrate container or a Fabric dev network.  They join the same
+	// network as the gateway and are reachable by their NetworkAliases.
+	Dependencies []ContainerRequest
+	// Network is the docker network of the containers.  A network is
+	// created, and removed on termination, if Network is empty and there
+	// are dependencies.
+	Network string
+	// Services are the upstream services that must report UP before the
+	// gateway is considered ready.  See shiroclient.RemoteHealthCheck.
+	Services []string
+	// StartupTimeout bounds the time to wait for a healthy gateway.
+	// Defaults to DefaultStartupTimeout.
+	StartupTimeout time.Duration
+	// Configs are additional configs of the returned client.
+	Configs []shiroclient.Config
+}
+
+// Gateway is a running gateway and a client connected to it.
+type Gateway struct {
+	// Endpoint is the URL of the gateway.
+	Endpoint string
+	// Client is a ready client for the gateway.
+	Client shiroclient.ShiroClient
+
+	containers     []*Container
+	createdNetwork string
+}
+
+// WaitHealthy polls RemoteHealthCheck until every report has status UP, or
+// ctx is done.
+func WaitHealthy(ctx context.Context, client shiroclient.ShiroClient, services []string) error {
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		health, err := shiroclient.RemoteHealthCheck(ctx, client, services)
+		if err == nil {
+			err = checkReports(health)
+		}
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("integrationtest: gateway not healthy: %w (last error: %v)", ctx.Err(), lastErr)
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkReports(health shiroclient.HealthCheck) error {
+	reports := health.Reports()
+	if len(reports) == 0 {
+		return errors.New("no health reports")
+	}
+	var down []string
+	for _, report := range reports {
+		if report.Status() != "UP" {
+			down = append(down, report.ServiceName()+"="+report.Status())
+		}
+	}
+	if len(down) > 0 {
+		return fmt.Errorf("services not up: %s", strings.Join(down, ", "))
+	}
+	return nil
+}
+
+// StartGateway starts the dependencies and gateway described by opts and
+// waits until the gateway is healthy.  Containers are terminated if the
+// gateway does not become healthy.
+func StartGateway(ctx context.Context, opts GatewayOptions) (gw *Gateway, err error) {
+	if opts.Image == "" {
+		opts.Image = os.Getenv(EnvGatewayImage)
+	}
+	if opts.Image == "" {
+		return nil, fmt.Errorf("integrationtest: no gateway image (set %s)", EnvGatewayImage)
+	}
+	if opts.Port == "" {
+		opts.Port = DefaultGatewayPort
+	}
+	if opts.StartupTimeout <= 0 {
+		opts.StartupTimeout = DefaultStartupTimeout
+	}
+	gw = &Gateway{}
+	defer func() {
+		if err != nil {
+			_ = gw.Terminate(context.Background())
+		}
+	}()
+
+	network := opts.Network
+	if network == "" && len(opts.Dependencies) > 0 {
+		network = "shiroclient-test-" + uuid.NewString()
+		if err := createNetwork(ctx, network); err != nil {
+			return nil, err
+		}
+		gw.createdNetwork = network
+	}
+	for _, dep := range opts.Dependencies {
+		if dep.Network == "" {
+			dep.Network = network
+		}
+		c, err := StartContainer(ctx, dep)
+		if err != nil {
+			return nil, fmt.Errorf("integrationtest: start %s: %w", dep.Image, err)
+		}
+		gw.containers = append(gw.containers, c)
+	}
+	c, err := StartContainer(ctx, ContainerRequest{
+		Image:        opts.Image,
+		Env:          opts.Env,
+		ExposedPorts: []string{opts.Port},
+		Network:      network,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("integrationtest: start gateway: %w", err)
+	}
+	gw.containers = append(gw.containers, c)
+	addr, err := c.HostPort(ctx, opts.Port)
+	if err != nil {
+		return nil, err
+	}
+	gw.Endpoint = "http://" + addr
+
+	if err := gw.connect(ctx, opts); err != nil {
+		return nil, fmt.Errorf("%w\n%s", err, gw.Logs(context.Background()))
+	}
+	return gw, nil
+}
+
+// Logs returns the output of the containers started for the gateway, the
+// gateway last, to diagnose a failed test.  Containers that crashed are
+// included, since they are only removed by Terminate.
+func (gw *Gateway) Logs(ctx context.Context) string {
+	var b strings.Builder
+	for _, c := range gw.containers {
+		logs, err := c.Logs(ctx)
+		if err != nil {
+			logs = fmt.Sprintf("(failed to read logs: %v)", err)
+		}
+		fmt.Fprintf(&b, "logs of %s (%s):\n%s\n", c.Image, c.ID, logs)
+	}
+	return b.String()
+}
+
+// connect creates the client and waits for the gateway to become healthy.
+func (gw *Gateway) connect(ctx context.Context, opts GatewayOptions) error {
+	configs := append([]shiroclient.Config{shiroclient.WithEndpoint(gw.Endpoint)}, opts.Configs...)
+	gw.Client = shiroclient.NewRPC(configs)
+	ctx, cancel := context.WithTimeout(ctx, opts.StartupTimeout)
+	defer cancel()
+	return WaitHealthy(ctx, gw.Client, opts.Services)
+}
+
+// Terminate closes the client and removes the containers and network
+// started for the gateway.
+func (gw *Gateway) Terminate(ctx context.Context) error {
+	var errs []error
+	if gw.Client != nil {
+		errs = append(errs, shiroclient.Shutdown(ctx, gw.Client))
+	}
+	for i := len(gw.containers) - 1; i >= 0; i-- {
+		errs = append(errs, gw.containers[i].Terminate(ctx))
+	}
+	gw.containers = nil
+	if gw.createdNetwork != "" {
+		errs = append(errs, removeNetwork(ctx, gw.createdNetwork))
+		gw.createdNetwork = ""
+	}
+	return errors.Join(errs...)
+}
+
+// NewGateway returns a ready gateway for the test, terminated when the test
+// completes.  If $SHIROCLIENT_TEST_GATEWAY_ENDPOINT is set the gateway at
+// that endpoint is used instead of starting containers.  The test is
+// skipped if docker or a gateway image is unavailable.  If the test fails,
+// the logs of the containers are logged before they are removed.
+func NewGateway(t testing.TB, opts GatewayOptions) *Gateway {
+	t.Helper()
+	ctx := context.Background()
+	if endpoint := os.Getenv(EnvGatewayEndpoint); endpoint != "" {
+		gw := &Gateway{Endpoint: endpoint}
+		if opts.StartupTimeout <= 0 {
+			opts.StartupTimeout = DefaultStartupTimeout
+		}
+		t.Cleanup(func() { _ = gw.Terminate(ctx) })
+		if err := gw.connect(ctx, opts); err != nil {
+			t.Fatal(err)
+		}
+		return gw
+	}
+	if opts.Image == "" && os.Getenv(EnvGatewayImage) == "" {
+		t.Skipf("integrationtest: set %s or %s to run", EnvGatewayEndpoint, EnvGatewayImage)
+	}
+	if !dockerAvailable(ctx) {
+		t.Skip(ErrDockerUnavailable)
+	}
+	gw, err := StartGateway(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Log(gw.Logs(ctx))
+		}
+		if err := gw.Terminate(ctx); err != nil {
+			t.Logf("integrationtest: terminate: %v", err)
+		}
+	})
+	return gw
+}
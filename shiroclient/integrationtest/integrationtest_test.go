@@ -0,0 +1,60 @@
+package integrationtest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// healthServer reports the phylum DOWN for the first downCount checks.
+func healthServer(t *testing.T, downCount int32) *httptest.Server {
+	var checks int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/health_check", r.URL.Path)
+		status := "UP"
+		if atomic.AddInt32(&checks, 1) <= downCount {
+			status = "DOWN"
+		}
+		fmt.Fprintf(w, `{"reports":[{"timestamp":"2024-01-01T00:00:00Z","status":%q,"service_name":"phylum","service_version":"1"}]}`, status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWaitHealthy(t *testing.T) {
+	srv := healthServer(t, 2)
+	client := shiroclient.NewRPC([]shiroclient.Config{shiroclient.WithEndpoint(srv.URL)})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, WaitHealthy(ctx, client, []string{"phylum"}))
+
+	down := healthServer(t, 1000)
+	client = shiroclient.NewRPC([]shiroclient.Config{shiroclient.WithEndpoint(down.URL)})
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := WaitHealthy(ctx, client, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Contains(t, err.Error(), "phylum=DOWN")
+}
+
+func TestNewGatewayEndpoint(t *testing.T) {
+	srv := healthServer(t, 0)
+	t.Setenv(EnvGatewayEndpoint, srv.URL)
+	gw := NewGateway(t, GatewayOptions{})
+	require.Equal(t, srv.URL, gw.Endpoint)
+	require.NotNil(t, gw.Client)
+}
+
+func TestStartGatewayRequiresImage(t *testing.T) {
+	t.Setenv(EnvGatewayImage, "")
+	_, err := StartGateway(context.Background(), GatewayOptions{})
+	require.Error(t, err)
+}
@@ -0,0 +1,598 @@
+// Package jsonschema validates JSON documents against JSON Schemas, for
+// checking the payloads exchanged with a phylum.
+//
+//	schema, err := jsonschema.Compile([]byte(`{
+//		"type": "object",
+//		"required": ["id"],
+//		"properties": {"id": {"type": "string", "minLength": 1}}
+//	}`))
+//	if err != nil {
+//		return err
+//	}
+//	err = schema.Validate(resp.ResultJSON())
+//	var verr *jsonschema.ValidationError
+//	if errors.As(err, &verr) {
+//		for _, f := range verr.Errors {
+//			log.Printf("%s: %s", f.Path, f.Message)
+//		}
+//	}
+//
+// The keywords of draft 2020-12 describing the structure of values are
+// supported: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, uniqueItems, minimum,
+// maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, minLength,
+// maxLength, pattern, minProperties, maxProperties, allOf, anyOf, oneOf,
+// not and $ref to definitions in $defs or definitions of the same schema.
+// Other keywords, like format, are ignored.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError is a violation of a schema by a value.
+type FieldError struct {
+	// Path is the JSON pointer of the value, e.g. "/accounts/0/id", or an
+	// empty string for the document.
+	Path string
+	// Message describes the violation.
+	Message string
+}
+
+func (e FieldError) String() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// ValidationError is returned by Validate for a document violating a
+// schema.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, f := range e.Errors {
+		msgs[i] = f.String()
+	}
+	return "jsonschema: " + strings.Join(msgs, "; ")
+}
+
+// Schema is a compiled JSON Schema.  It is safe for concurrent use.
+type Schema struct {
+	raw  json.RawMessage
+	root *node
+}
+
+// node is a compiled schema or subschema.
+type node struct {
+	// never is set for the false schema.
+	never bool
+
+	types      []string
+	enum       []interface{}
+	hasConst   bool
+	constValue interface{}
+
+	properties    map[string]*node
+	required      []string
+	additional    *node
+	minProperties *int
+	maxProperties *int
+
+	items       *node
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+
+	ref string
+}
+
+// compiler compiles a schema document.
+type compiler struct {
+	defs map[string]*node
+	// refs are the nodes whose ref must be resolved.
+	refs []*node
+}
+
+// Compile compiles a JSON Schema.
+func Compile(schema []byte) (*Schema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		return nil, fmt.Errorf("jsonschema: %w", err)
+	}
+	c := &compiler{defs: make(map[string]*node)}
+	root, err := c.compile(doc, "")
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: %w", err)
+	}
+	if m, ok := doc.(map[string]interface{}); ok {
+		for _, key := range []string{"$defs", "definitions"} {
+			defs, ok := m[key].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for name, def := range defs {
+				n, err := c.compile(def, "/"+key+"/"+name)
+				if err != nil {
+					return nil, fmt.Errorf("jsonschema: %w", err)
+				}
+				c.defs["#/"+key+"/"+name] = n
+			}
+		}
+	}
+	for _, n := range c.refs {
+		if n.ref == "#" {
+			continue
+		}
+		if _, ok := c.defs[n.ref]; !ok {
+			return nil, fmt.Errorf("jsonschema: unresolved $ref %q", n.ref)
+		}
+	}
+	s := &Schema{raw: append(json.RawMessage(nil), schema...), root: root}
+	s.root.resolve(c.defs, root)
+	return s, nil
+}
+
+// MustCompile is like Compile but panics if the schema cannot be compiled.
+// It simplifies initialization of global schemas.
+func MustCompile(schema string) *Schema {
+	s, err := Compile([]byte(schema))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// MarshalJSON returns the source of the schema.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return s.raw, nil
+}
+
+// resolve replaces the refs of n, its subschemas and defs with an allOf
+// of their target, so that recursive schemas are compiled without
+// recursion.
+func (n *node) resolve(defs map[string]*node, root *node) {
+	seen := make(map[*node]bool)
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n == nil || seen[n] {
+			return
+		}
+		seen[n] = true
+		if n.ref != "" {
+			target := root
+			if n.ref != "#" {
+				target = defs[n.ref]
+			}
+			n.allOf = append(n.allOf, target)
+			n.ref = ""
+		}
+		for _, p := range n.properties {
+			walk(p)
+		}
+		walk(n.additional)
+		walk(n.items)
+		walk(n.not)
+		for _, list := range [][]*node{n.allOf, n.anyOf, n.oneOf} {
+			for _, s := range list {
+				walk(s)
+			}
+		}
+	}
+	walk(n)
+	for _, d := range defs {
+		walk(d)
+	}
+}
+
+func (c *compiler) compile(v interface{}, loc string) (*node, error) {
+	switch v := v.(type) {
+	case bool:
+		return &node{never: !v}, nil
+	case map[string]interface{}:
+		return c.compileObject(v, loc)
+	default:
+		return nil, fmt.Errorf("%s: schema must be an object or boolean", locString(loc))
+	}
+}
+
+func locString(loc string) string {
+	if loc == "" {
+		return "/"
+	}
+	return loc
+}
+
+func (c *compiler) compileObject(m map[string]interface{}, loc string) (*node, error) {
+	n := &node{}
+	var err error
+	fail := func(key string, msg string) error {
+		return fmt.Errorf("%s/%s: %s", loc, key, msg)
+	}
+	if ref, ok := m["$ref"]; ok {
+		s, ok := ref.(string)
+		if !ok || !(s == "#" || strings.HasPrefix(s, "#/$defs/") || strings.HasPrefix(s, "#/definitions/")) {
+			return nil, fail("$ref", "only references to $defs or definitions are supported")
+		}
+		n.ref = s
+		c.refs = append(c.refs, n)
+	}
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		n.types = []string{t}
+	case []interface{}:
+		for _, e := range t {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fail("type", "must be a string or an array of strings")
+			}
+			n.types = append(n.types, s)
+		}
+	default:
+		return nil, fail("type", "must be a string or an array of strings")
+	}
+	for _, t := range n.types {
+		switch t {
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+		default:
+			return nil, fail("type", fmt.Sprintf("unknown type %q", t))
+		}
+	}
+	if e, ok := m["enum"]; ok {
+		if n.enum, ok = e.([]interface{}); !ok {
+			return nil, fail("enum", "must be an array")
+		}
+	}
+	if cv, ok := m["const"]; ok {
+		n.hasConst, n.constValue = true, cv
+	}
+	if p, ok := m["properties"]; ok {
+		props, ok := p.(map[string]interface{})
+		if !ok {
+			return nil, fail("properties", "must be an object")
+		}
+		n.properties = make(map[string]*node, len(props))
+		for name, ps := range props {
+			if n.properties[name], err = c.compile(ps, loc+"/properties/"+escape(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if r, ok := m["required"]; ok {
+		list, ok := r.([]interface{})
+		if !ok {
+			return nil, fail("required", "must be an array of strings")
+		}
+		for _, e := range list {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fail("required", "must be an array of strings")
+			}
+			n.required = append(n.required, s)
+		}
+	}
+	if a, ok := m["additionalProperties"]; ok {
+		if n.additional, err = c.compile(a, loc+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+	if i, ok := m["items"]; ok {
+		if n.items, err = c.compile(i, loc+"/items"); err != nil {
+			return nil, err
+		}
+	}
+	if u, ok := m["uniqueItems"]; ok {
+		if n.uniqueItems, ok = u.(bool); !ok {
+			return nil, fail("uniqueItems", "must be a boolean")
+		}
+	}
+	ints := map[string]**int{
+		"minProperties": &n.minProperties,
+		"maxProperties": &n.maxProperties,
+		"minItems":      &n.minItems,
+		"maxItems":      &n.maxItems,
+		"minLength":     &n.minLength,
+		"maxLength":     &n.maxLength,
+	}
+	for key, dst := range ints {
+		v, ok := m[key]
+		if !ok {
+			continue
+		}
+		f, ok := v.(float64)
+		if !ok || f < 0 || f != math.Trunc(f) {
+			return nil, fail(key, "must be a non-negative integer")
+		}
+		i := int(f)
+		*dst = &i
+	}
+	floats := map[string]**float64{
+		"minimum":          &n.minimum,
+		"maximum":          &n.maximum,
+		"exclusiveMinimum": &n.exclusiveMinimum,
+		"exclusiveMaximum": &n.exclusiveMaximum,
+		"multipleOf":       &n.multipleOf,
+	}
+	for key, dst := range floats {
+		v, ok := m[key]
+		if !ok {
+			continue
+		}
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fail(key, "must be a number")
+		}
+		*dst = &f
+	}
+	if n.multipleOf != nil && *n.multipleOf <= 0 {
+		return nil, fail("multipleOf", "must be positive")
+	}
+	if p, ok := m["pattern"]; ok {
+		s, ok := p.(string)
+		if !ok {
+			return nil, fail("pattern", "must be a string")
+		}
+		if n.pattern, err = regexp.Compile(s); err != nil {
+			return nil, fail("pattern", err.Error())
+		}
+	}
+	lists := map[string]*[]*node{"allOf": &n.allOf, "anyOf": &n.anyOf, "oneOf": &n.oneOf}
+	for key, dst := range lists {
+		v, ok := m[key]
+		if !ok {
+			continue
+		}
+		list, ok := v.([]interface{})
+		if !ok || len(list) == 0 {
+			return nil, fail(key, "must be a non-empty array")
+		}
+		for i, s := range list {
+			sub, err := c.compile(s, loc+"/"+key+"/"+strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+			*dst = append(*dst, sub)
+		}
+	}
+	if v, ok := m["not"]; ok {
+		if n.not, err = c.compile(v, loc+"/not"); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// escape escapes a JSON pointer token.
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// Validate validates the JSON document data against s.  It returns a
+// *ValidationError listing every violation if data does not conform to s.
+func (s *Schema) Validate(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("jsonschema: invalid document: %w", err)
+	}
+	return s.ValidateValue(v)
+}
+
+// ValidateValue validates a value decoded by encoding/json into an
+// interface{} against s.
+func (s *Schema) ValidateValue(v interface{}) error {
+	var errs []FieldError
+	s.root.validate(v, "", &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// valid returns true if v conforms to n.
+func (n *node) valid(v interface{}) bool {
+	var errs []FieldError
+	n.validate(v, "", &errs)
+	return len(errs) == 0
+}
+
+func typeOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	}
+	return "unknown"
+}
+
+func (n *node) validate(v interface{}, path string, errs *[]FieldError) {
+	add := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if n.never {
+		add("no value is allowed")
+		return
+	}
+	if len(n.types) > 0 {
+		t := typeOf(v)
+		ok := false
+		for _, want := range n.types {
+			if want == t || (want == "number" && t == "integer") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			add("expected %s, got %s", strings.Join(n.types, " or "), t)
+			return
+		}
+	}
+	if n.enum != nil {
+		found := false
+		for _, e := range n.enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("value is not one of the allowed values")
+		}
+	}
+	if n.hasConst && !reflect.DeepEqual(n.constValue, v) {
+		add("value does not match the constant")
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		n.validateObject(v, path, errs, add)
+	case []interface{}:
+		if n.minItems != nil && len(v) < *n.minItems {
+			add("expected at least %d items, got %d", *n.minItems, len(v))
+		}
+		if n.maxItems != nil && len(v) > *n.maxItems {
+			add("expected at most %d items, got %d", *n.maxItems, len(v))
+		}
+		if n.uniqueItems {
+			for i := range v {
+				for j := 0; j < i; j++ {
+					if reflect.DeepEqual(v[i], v[j]) {
+						add("items %d and %d are equal", j, i)
+					}
+				}
+			}
+		}
+		if n.items != nil {
+			for i, e := range v {
+				n.items.validate(e, path+"/"+strconv.Itoa(i), errs)
+			}
+		}
+	case float64:
+		if n.minimum != nil && v < *n.minimum {
+			add("must be >= %v", *n.minimum)
+		}
+		if n.maximum != nil && v > *n.maximum {
+			add("must be <= %v", *n.maximum)
+		}
+		if n.exclusiveMinimum != nil && v <= *n.exclusiveMinimum {
+			add("must be > %v", *n.exclusiveMinimum)
+		}
+		if n.exclusiveMaximum != nil && v >= *n.exclusiveMaximum {
+			add("must be < %v", *n.exclusiveMaximum)
+		}
+		if n.multipleOf != nil {
+			if q := v / *n.multipleOf; q != math.Trunc(q) {
+				add("must be a multiple of %v", *n.multipleOf)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n.minLength != nil && length < *n.minLength {
+			add("expected at least %d characters, got %d", *n.minLength, length)
+		}
+		if n.maxLength != nil && length > *n.maxLength {
+			add("expected at most %d characters, got %d", *n.maxLength, length)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			add("does not match pattern %q", n.pattern.String())
+		}
+	}
+	for _, s := range n.allOf {
+		s.validate(v, path, errs)
+	}
+	if len(n.anyOf) > 0 {
+		ok := false
+		for _, s := range n.anyOf {
+			if s.valid(v) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			add("does not match any schema of anyOf")
+		}
+	}
+	if len(n.oneOf) > 0 {
+		var matches int
+		for _, s := range n.oneOf {
+			if s.valid(v) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			add("matches %d schemas of oneOf, expected 1", matches)
+		}
+	}
+	if n.not != nil && n.not.valid(v) {
+		add("must not match the schema of not")
+	}
+}
+
+func (n *node) validateObject(v map[string]interface{}, path string, errs *[]FieldError, add func(string, ...interface{})) {
+	for _, name := range n.required {
+		if _, ok := v[name]; !ok {
+			add("missing required property %q", name)
+		}
+	}
+	if n.minProperties != nil && len(v) < *n.minProperties {
+		add("expected at least %d properties, got %d", *n.minProperties, len(v))
+	}
+	if n.maxProperties != nil && len(v) > *n.maxProperties {
+		add("expected at most %d properties, got %d", *n.maxProperties, len(v))
+	}
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := path + "/" + escape(name)
+		if s, ok := n.properties[name]; ok {
+			s.validate(v[name], p, errs)
+		} else if n.additional != nil {
+			if n.additional.never {
+				*errs = append(*errs, FieldError{Path: p, Message: "unexpected property"})
+			} else {
+				n.additional.validate(v[name], p, errs)
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/jsonschema"
+)
+
+const accountSchema = `{
+	"type": "object",
+	"required": ["id", "balance"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "string", "pattern": "^acct-[0-9]+$"},
+		"balance": {"type": "integer", "minimum": 0},
+		"currency": {"enum": ["USD", "EUR"]},
+		"tags": {"type": "array", "items": {"type": "string", "minLength": 1}, "uniqueItems": true},
+		"owner": {"$ref": "#/$defs/person"}
+	},
+	"$defs": {
+		"person": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string"},
+				"manager": {"$ref": "#/$defs/person"}
+			}
+		}
+	}
+}`
+
+func fieldErrors(t *testing.T, err error) []string {
+	t.Helper()
+	var verr *jsonschema.ValidationError
+	require.True(t, errors.As(err, &verr), "error %v", err)
+	var msgs []string
+	for _, f := range verr.Errors {
+		msgs = append(msgs, f.String())
+	}
+	return msgs
+}
+
+func TestValidate(t *testing.T) {
+	schema := jsonschema.MustCompile(accountSchema)
+	require.NoError(t, schema.Validate([]byte(`{
+		"id": "acct-1",
+		"balance": 10,
+		"currency": "USD",
+		"tags": ["a", "b"],
+		"owner": {"name": "ann", "manager": {"name": "bob"}}
+	}`)))
+
+	err := schema.Validate([]byte(`{
+		"id": "1",
+		"balance": 1.5,
+		"currency": "GBP",
+		"tags": ["a", "a", ""],
+		"owner": {"manager": {}},
+		"extra": true
+	}`))
+	require.Equal(t, []string{
+		`/balance: expected integer, got number`,
+		`/currency: value is not one of the allowed values`,
+		`/extra: unexpected property`,
+		`/id: does not match pattern "^acct-[0-9]+$"`,
+		`/owner: missing required property "name"`,
+		`/owner/manager: missing required property "name"`,
+		`/tags: items 0 and 1 are equal`,
+		`/tags/2: expected at least 1 characters, got 0`,
+	}, fieldErrors(t, err))
+
+	require.Equal(t, []string{
+		`/: missing required property "id"`,
+		`/: missing required property "balance"`,
+	}, fieldErrors(t, schema.Validate([]byte(`{}`))))
+	require.Equal(t, []string{`/: expected object, got array`}, fieldErrors(t, schema.Validate([]byte(`[]`))))
+	require.ErrorContains(t, schema.Validate([]byte(`{`)), "invalid document")
+
+	b, err := json.Marshal(schema)
+	require.NoError(t, err)
+	require.JSONEq(t, accountSchema, string(b))
+}
+
+func TestCombinators(t *testing.T) {
+	schema := jsonschema.MustCompile(`{
+		"oneOf": [{"type": "string"}, {"type": "number", "exclusiveMaximum": 10}],
+		"not": {"const": "forbidden"}
+	}`)
+	require.NoError(t, schema.Validate([]byte(`"ok"`)))
+	require.NoError(t, schema.Validate([]byte(`3`)))
+	require.Equal(t, []string{`/: matches 0 schemas of oneOf, expected 1`}, fieldErrors(t, schema.Validate([]byte(`10`))))
+	require.Equal(t, []string{`/: must not match the schema of not`}, fieldErrors(t, schema.Validate([]byte(`"forbidden"`))))
+
+	schema = jsonschema.MustCompile(`{"anyOf": [{"type": "null"}, {"type": "integer", "multipleOf": 5}]}`)
+	require.NoError(t, schema.Validate([]byte(`null`)))
+	require.NoError(t, schema.Validate([]byte(`15`)))
+	require.Error(t, schema.Validate([]byte(`7`)))
+}
+
+func TestCompileErrors(t *testing.T) {
+	for schema, msg := range map[string]string{
+		`{"type": "decimal"}`:                        `unknown type "decimal"`,
+		`{"properties": {"a": 1}}`:                   "/properties/a: schema must be an object",
+		`{"$ref": "#/$defs/missing"}`:                "unresolved $ref",
+		`{"$ref": "http://example.com/schema"}`:      "only references to $defs",
+		`{"pattern": "("}`:                           "/pattern:",
+		`{"minLength": -1}`:                          "must be a non-negative integer",
+		`{"allOf": []}`:                              "must be a non-empty array",
+		`[]`:                                         "schema must be an object or boolean",
+		`{"items": {"required": "a"}}`:               "/items/required: must be an array",
+		`{"$defs": {"a": {"type": 1}}, "type": "a"}`: "unknown type",
+	} {
+		_, err := jsonschema.Compile([]byte(schema))
+		require.ErrorContains(t, err, msg, "schema %s", schema)
+	}
+}
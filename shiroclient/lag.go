@@ -0,0 +1,108 @@
+package shiroclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// EndpointHeight is the ledger height reported by one gateway replica.
+type EndpointHeight struct {
+	Endpoint string
+	// Height is zero if the query failed.
+	Height uint64
+	Err    error
+	// Lag is the number of blocks between Height and the highest height
+	// reported by any replica.
+	Lag uint64
+	// Behind is set if Lag exceeds the threshold of the check or the
+	// query failed.
+	Behind bool
+}
+
+// LedgerLag reports the ledger heights of gateway replicas.
+type LedgerLag struct {
+	// Endpoints holds the heights in the order of the checked endpoints.
+	Endpoints []*EndpointHeight
+	// Max and Min are the highest and lowest heights reported, ignoring
+	// failed queries.
+	Max, Min uint64
+}
+
+// Spread returns the number of blocks between the highest and the lowest
+// height reported.
+func (l *LedgerLag) Spread() uint64 {
+	return l.Max - l.Min
+}
+
+// Behind returns the replicas behind the threshold of the check, including
+// those that could not be queried.
+func (l *LedgerLag) Behind() []*EndpointHeight {
+	var behind []*EndpointHeight
+	for _, e := range l.Endpoints {
+		if e.Behind {
+			behind = append(behind, e)
+		}
+	}
+	return behind
+}
+
+// CheckLedgerLag queries the ledger height of each of endpoints
+// concurrently, using client with configs and WithEndpoint, and flags the
+// replicas more than threshold blocks behind the highest one.  Replicas
+// behind a load balancer answering reads from a lagging peer are a common
+// cause of stale reads.  Failed queries are reported per endpoint, and an
+// error is only returned if no endpoint could be queried.  The endpoint
+// policy of client applies, so a client made with WithLockedEndpoint can
+// only check its own endpoint.
+func CheckLedgerLag(ctx context.Context, client ShiroClient, endpoints []string, threshold uint64, configs ...Config) (*LedgerLag, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("shiroclient: no endpoints to check")
+	}
+	heights := make([]*EndpointHeight, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			call := append(append([]Config(nil), configs...), WithEndpoint(endpoint))
+			height, err := client.QueryInfo(ctx, call...)
+			if err != nil {
+				err = fmt.Errorf("query height of %s: %w", endpoint, err)
+			}
+			heights[i] = &EndpointHeight{Endpoint: endpoint, Height: height, Err: err}
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	lag := &LedgerLag{Endpoints: heights}
+	var errs []error
+	ok := false
+	for _, e := range heights {
+		if e.Err != nil {
+			e.Height = 0
+			errs = append(errs, e.Err)
+			continue
+		}
+		if !ok || e.Height > lag.Max {
+			lag.Max = e.Height
+		}
+		if !ok || e.Height < lag.Min {
+			lag.Min = e.Height
+		}
+		ok = true
+	}
+	if !ok {
+		return nil, errors.Join(errs...)
+	}
+	for _, e := range heights {
+		if e.Err != nil {
+			e.Behind = true
+			continue
+		}
+		e.Lag = lag.Max - e.Height
+		e.Behind = e.Lag > threshold
+	}
+	return lag, nil
+}
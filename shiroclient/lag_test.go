@@ -0,0 +1,51 @@
+package shiroclient_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// fixedHeightServer is a gateway reporting a fixed ledger height.
+func fixedHeightServer(t *testing.T, height uint64) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":"1","result":{"error_level":0,"result":%d,"code":0,"message":"","data":null}}`, height)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestCheckLedgerLag(t *testing.T) {
+	a := fixedHeightServer(t, 100)
+	b := fixedHeightServer(t, 98)
+	c := fixedHeightServer(t, 90)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	client := shiroclient.NewRPC([]shiroclient.Config{shiroclient.WithEndpoint(a)})
+	ctx := context.Background()
+	lag, err := shiroclient.CheckLedgerLag(ctx, client, []string{a, b, c, down.URL}, 5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), lag.Max)
+	require.Equal(t, uint64(90), lag.Min)
+	require.Equal(t, uint64(10), lag.Spread())
+	require.Len(t, lag.Endpoints, 4)
+	require.Equal(t, uint64(2), lag.Endpoints[1].Lag)
+	require.False(t, lag.Endpoints[1].Behind)
+
+	behind := lag.Behind()
+	require.Len(t, behind, 2)
+	require.Equal(t, c, behind[0].Endpoint)
+	require.Equal(t, uint64(10), behind[0].Lag)
+	require.Equal(t, down.URL, behind[1].Endpoint)
+	require.Error(t, behind[1].Err)
+
+	_, err = shiroclient.CheckLedgerLag(ctx, client, []string{down.URL}, 5)
+	require.Error(t, err)
+}
@@ -0,0 +1,247 @@
+package shiroclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by ConfigFromEnv.
+const (
+	EnvEndpoint              = "SHIROCLIENT_ENDPOINT"
+	EnvAuthToken             = "SHIROCLIENT_AUTH_TOKEN"
+	EnvTLSCAFile             = "SHIROCLIENT_TLS_CA_FILE"
+	EnvTLSCertFile           = "SHIROCLIENT_TLS_CERT_FILE"
+	EnvTLSKeyFile            = "SHIROCLIENT_TLS_KEY_FILE"
+	EnvTLSInsecureSkipVerify = "SHIROCLIENT_TLS_INSECURE_SKIP_VERIFY"
+	EnvTimeout               = "SHIROCLIENT_TIMEOUT"
+	EnvRetryMaxAttempts      = "SHIROCLIENT_RETRY_MAX_ATTEMPTS"
+	EnvRetryBackoff          = "SHIROCLIENT_RETRY_BACKOFF"
+	EnvRetryMaxBackoff       = "SHIROCLIENT_RETRY_MAX_BACKOFF"
+	EnvLogLevel              = "SHIROCLIENT_LOG_LEVEL"
+)
+
+// tlsSettings is the TLS section of a client configuration.
+type tlsSettings struct {
+	CAFile             string `json:"ca_file" yaml:"ca_file"`
+	CertFile           string `json:"cert_file" yaml:"cert_file"`
+	KeyFile            string `json:"key_file" yaml:"key_file"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+// retrySettings is the retry section of a client configuration.
+type retrySettings struct {
+	MaxAttempts int    `json:"max_attempts" yaml:"max_attempts"`
+	Backoff     string `json:"backoff" yaml:"backoff"`
+	MaxBackoff  string `json:"max_backoff" yaml:"max_backoff"`
+}
+
+// clientSettings is the common representation of client configuration
+// loaded from a file or the environment.  Unset fields produce no Config.
+type clientSettings struct {
+	Endpoint  string         `json:"endpoint" yaml:"endpoint"`
+	AuthToken string         `json:"auth_token" yaml:"auth_token"`
+	TLS       *tlsSettings   `json:"tls" yaml:"tls"`
+	Timeout   string         `json:"timeout" yaml:"timeout"`
+	Retry     *retrySettings `json:"retry" yaml:"retry"`
+	LogLevel  string         `json:"log_level" yaml:"log_level"`
+}
+
+// ConfigFromEnv returns configs built from SHIROCLIENT_* environment
+// variables.  Variables that are unset or empty produce no config.
+//
+//	SHIROCLIENT_ENDPOINT                  gateway URL (WithEndpoint)
+//	SHIROCLIENT_AUTH_TOKEN                bearer token (WithAuthToken)
+//	SHIROCLIENT_TLS_CA_FILE               PEM file of trusted CAs
+//	SHIROCLIENT_TLS_CERT_FILE             PEM client certificate
+//	SHIROCLIENT_TLS_KEY_FILE              PEM client key
+//	SHIROCLIENT_TLS_INSECURE_SKIP_VERIFY  "true" to skip verification
+//	SHIROCLIENT_TIMEOUT                   duration, e.g. "30s" (WithTimeout)
+//	SHIROCLIENT_RETRY_MAX_ATTEMPTS        integer (WithRetryPolicy)
+//	SHIROCLIENT_RETRY_BACKOFF             duration (WithRetryPolicy)
+//	SHIROCLIENT_RETRY_MAX_BACKOFF         duration (WithRetryPolicy)
+//	SHIROCLIENT_LOG_LEVEL                 logrus level name (WithLog)
+//
+// Any TLS variable causes an HTTP client to be configured with
+// WithHTTPClient.
+//
+// Configs are applied in order, so later configs take precedence.  To let
+// the environment override a configuration file, and explicit configs
+// override both, pass them to NewRPC in that order:
+//
+//	fileConfigs, err := shiroclient.ConfigFromFile(path)
+//	envConfigs, err := shiroclient.ConfigFromEnv()
+//	configs := append(append(fileConfigs, envConfigs...), explicit...)
+//
+// Note that TLS settings are not merged between sources: TLS settings from
+// the environment replace the HTTP client configured by the file.
+func ConfigFromEnv() ([]Config, error) {
+	s := &clientSettings{
+		Endpoint:  os.Getenv(EnvEndpoint),
+		AuthToken: os.Getenv(EnvAuthToken),
+		Timeout:   os.Getenv(EnvTimeout),
+		LogLevel:  os.Getenv(EnvLogLevel),
+	}
+	tlsCfg := &tlsSettings{
+		CAFile:   os.Getenv(EnvTLSCAFile),
+		CertFile: os.Getenv(EnvTLSCertFile),
+		KeyFile:  os.Getenv(EnvTLSKeyFile),
+	}
+	if v := os.Getenv(EnvTLSInsecureSkipVerify); v != "" {
+		skip, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvTLSInsecureSkipVerify, err)
+		}
+		tlsCfg.InsecureSkipVerify = skip
+	}
+	if *tlsCfg != (tlsSettings{}) {
+		s.TLS = tlsCfg
+	}
+	retry := &retrySettings{
+		Backoff:    os.Getenv(EnvRetryBackoff),
+		MaxBackoff: os.Getenv(EnvRetryMaxBackoff),
+	}
+	if v := os.Getenv(EnvRetryMaxAttempts); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvRetryMaxAttempts, err)
+		}
+		retry.MaxAttempts = n
+	}
+	if *retry != (retrySettings{}) {
+		s.Retry = retry
+	}
+	return s.configs()
+}
+
+// ConfigFromFile returns configs built from a YAML or JSON file.  Files
+// with a .yaml or .yml extension are parsed as YAML, all others as JSON.
+// Keys that are absent produce no config.
+//
+//	endpoint: https://gateway:8082
+//	auth_token: secret
+//	tls:
+//	  ca_file: /etc/shiro/ca.pem
+//	  cert_file: /etc/shiro/client.pem
+//	  key_file: /etc/shiro/client-key.pem
+//	  insecure_skip_verify: false
+//	timeout: 30s
+//	retry:
+//	  max_attempts: 3
+//	  backoff: 100ms
+//	  max_backoff: 2s
+//	log_level: info
+//
+// See ConfigFromEnv for the precedence of configs from multiple sources.
+func ConfigFromFile(path string) ([]Config, error) {
+	b, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("read client config: %w", err)
+	}
+	s := &clientSettings{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, s)
+	default:
+		err = json.Unmarshal(b, s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse client config %s: %w", path, err)
+	}
+	return s.configs()
+}
+
+func parseDuration(name string, v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return d, nil
+}
+
+func (s *clientSettings) configs() ([]Config, error) {
+	var configs []Config
+	if s.Endpoint != "" {
+		configs = append(configs, WithEndpoint(s.Endpoint))
+	}
+	if s.AuthToken != "" {
+		configs = append(configs, WithAuthToken(s.AuthToken))
+	}
+	if s.TLS != nil {
+		client, err := s.TLS.httpClient()
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, WithHTTPClient(client))
+	}
+	timeout, err := parseDuration("timeout", s.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		configs = append(configs, WithTimeout(timeout))
+	}
+	if s.Retry != nil {
+		backoff, err := parseDuration("retry backoff", s.Retry.Backoff)
+		if err != nil {
+			return nil, err
+		}
+		maxBackoff, err := parseDuration("retry max backoff", s.Retry.MaxBackoff)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, WithRetryPolicy(s.Retry.MaxAttempts, backoff, maxBackoff))
+	}
+	if s.LogLevel != "" {
+		level, err := logrus.ParseLevel(s.LogLevel)
+		if err != nil {
+			return nil, fmt.Errorf("log level: %w", err)
+		}
+		log := logrus.New()
+		log.SetLevel(level)
+		configs = append(configs, WithLog(log))
+	}
+	return configs, nil
+}
+
+func (t *tlsSettings) httpClient() (*http.Client, error) {
+	// #nosec G402 -- InsecureSkipVerify is an explicit operator choice.
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls ca file %s: no certificates found", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,68 @@
+package shiroclient_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+func TestConfigFromFile(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"client.yaml": `
+endpoint: http://gateway:8082
+auth_token: secret
+timeout: 30s
+retry:
+  max_attempts: 3
+  backoff: 100ms
+log_level: debug
+`,
+		"client.json": `{
+	"endpoint": "http://gateway:8082",
+	"auth_token": "secret",
+	"timeout": "30s",
+	"retry": {"max_attempts": 3, "backoff": "100ms"},
+	"log_level": "debug"
+}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+			configs, err := shiroclient.ConfigFromFile(path)
+			require.NoError(t, err)
+			opt, err := types.ApplyConfigs(nil, configs...)
+			require.NoError(t, err)
+			require.Equal(t, "http://gateway:8082", opt.Endpoint)
+			require.Equal(t, "secret", opt.AuthToken)
+			require.Equal(t, 30*time.Second, opt.Timeout)
+			require.Equal(t, 3, opt.Retry.MaxAttempts)
+			require.Equal(t, 100*time.Millisecond, opt.Retry.Backoff)
+			require.Equal(t, logrus.DebugLevel, opt.Log.GetLevel())
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(shiroclient.EnvEndpoint, "http://env:8082")
+	t.Setenv(shiroclient.EnvTimeout, "5s")
+	configs, err := shiroclient.ConfigFromEnv()
+	require.NoError(t, err)
+	opt, err := types.ApplyConfigs(nil, configs...)
+	require.NoError(t, err)
+	require.Equal(t, "http://env:8082", opt.Endpoint)
+	require.Equal(t, 5*time.Second, opt.Timeout)
+	require.Nil(t, opt.HTTPClient)
+	require.Zero(t, opt.Retry)
+
+	t.Setenv(shiroclient.EnvTimeout, "soon")
+	_, err = shiroclient.ConfigFromEnv()
+	require.Error(t, err)
+}
@@ -4,6 +4,7 @@ package mock
 
 import (
 	"io"
+	"time"
 
 	"github.com/luthersystems/shiroclient-sdk-go/internal/mockint"
 )
@@ -55,3 +56,87 @@ func WithSnapshotReader(r io.Reader) Option {
 		config.SnapshotReader = r
 	}
 }
+
+// WithSnapshotKey encrypts the output of Snapshot with AES-GCM using key,
+// which must be 16, 24 or 32 bytes long, and decrypts the snapshot read
+// with WithSnapshotReader.  Plaintext snapshots are rejected, so that
+// snapshots holding sensitive fixtures are never read or written in the
+// clear.
+func WithSnapshotKey(key []byte) Option {
+	return func(config *mockint.Config) {
+		config.SnapshotKey = key
+	}
+}
+
+// WithSnapshotPassphrase is like WithSnapshotKey but derives the key from
+// passphrase, with a random salt stored in each snapshot.  It cannot be
+// combined with WithSnapshotKey.
+func WithSnapshotPassphrase(passphrase string) Option {
+	return func(config *mockint.Config) {
+		config.SnapshotPassphrase = passphrase
+	}
+}
+
+// WithErrorStacks includes the ELPS stack trace of the phylum in errors
+// returned by Call.  Use shiroclient.ErrorStack to obtain the trace.  The
+// plugin must support stack traces; older plugins return errors without
+// one.
+func WithErrorStacks(enable bool) Option {
+	return func(config *mockint.Config) {
+		config.ErrorStacks = enable
+	}
+}
+
+// WithRandSeed makes the random values used by the phylum reproducible.
+// Each Init and Call passes the substrate a seed drawn from a generator
+// seeded with seed, so the same sequence of requests on a fresh mock yields
+// the same random IDs.  Clients derived with With share the generator.  The
+// plugin must support seeding; older plugins ignore it.  See
+// shirotest.RandSeed to capture and replay the seed of a failing test.
+func WithRandSeed(seed int64) Option {
+	return func(config *mockint.Config) {
+		config.RandSeed = &seed
+	}
+}
+
+// WithDependencyChecks makes Call honor WithDependentTxID and
+// WithDependentBlock like a gateway does: a call whose dependency is not on
+// the mock ledger waits up to wait for another client to commit it, then
+// fails with an error wrapping shiroclient.ErrDependencyNotFound.  A zero
+// wait fails immediately.  Without this option dependencies are ignored in
+// mock mode.
+func WithDependencyChecks(wait time.Duration) Option {
+	return func(config *mockint.Config) {
+		config.DependencyWait = &wait
+	}
+}
+
+// WithCoverage collects ELPS code coverage of the phylum executed by each
+// mock client.  Use MockShiroClient.CoverageReport to write the per-function
+// and per-line hit counts once the test has run.  The plugin must support
+// coverage; otherwise creating a client fails.
+func WithCoverage(enable bool) Option {
+	return func(config *mockint.Config) {
+		config.Coverage = enable
+	}
+}
+
+// WithStrictConfig makes requests using configs that are not supported in
+// mock mode, like shiroclient.WithMSPFilter, fail with a
+// *shiroclient.ConfigError.  By default those configs are ignored, so that
+// the same configs can be shared by gateway and mock clients.
+func WithStrictConfig(enable bool) Option {
+	return func(config *mockint.Config) {
+		config.StrictConfig = enable
+	}
+}
+
+// WithPhylumOutput writes output printed by the phylum (e.g. with ELPS print
+// and debug functions) during Call to w, instead of interleaving it with the
+// plugin's log output.  Each mock client can use its own writer, so tests can
+// include the phylum output relevant to a failure.  Writes are serialized.
+func WithPhylumOutput(w io.Writer) Option {
+	return func(config *mockint.Config) {
+		config.PhylumOutput = w
+	}
+}
@@ -0,0 +1,98 @@
+// Package mockclock provides a controllable clock for tests using mock
+// clients.  A Clock plugs into shiroclient.WithTimestampGenerator so the
+// substrate "now" timestamp of each Init and Call is deterministic:
+//
+//	clock := mockclock.New(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+//	client, err := shiroclient.NewMock([]shiroclient.Config{clock.Config()})
+//	...
+//	clock.Advance(24 * time.Hour)
+//
+// A Clock is safe for concurrent use.
+package mockclock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// Clock is a clock that only moves when told to.
+type Clock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// New returns a Clock set to start.
+func New(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set sets the clock to t.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// AutoStep makes the clock move forward by d after each timestamp it
+// generates, so consecutive requests observe increasing times.  A zero d
+// disables stepping.
+func (c *Clock) AutoStep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.step = d
+}
+
+// Timestamp returns the current time of the clock formatted as RFC3339,
+// like the default mock timestamp, then applies the AutoStep.  It has the
+// signature expected by shiroclient.WithTimestampGenerator.
+func (c *Clock) Timestamp(context.Context) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ts := c.now.Format(time.RFC3339)
+	c.now = c.now.Add(c.step)
+	return ts
+}
+
+// Config returns a config that timestamps requests using the clock.
+func (c *Clock) Config() shiroclient.Config {
+	return shiroclient.WithTimestampGenerator(c.Timestamp)
+}
+
+// AdvanceBlocks advances the clock and the block height together.  It
+// calls method n times with client, advancing the clock by d before each
+// call, so that each block is committed d after the previous one.  method
+// must be a phylum endpoint whose calls commit a transaction.  An error is
+// returned if a call fails or returns a phylum error.
+func (c *Clock) AdvanceBlocks(ctx context.Context, client shiroclient.ShiroClient, n int, d time.Duration, method string, configs ...shiroclient.Config) error {
+	configs = append(append([]shiroclient.Config(nil), configs...), c.Config())
+	for i := 0; i < n; i++ {
+		c.Advance(d)
+		resp, err := client.Call(ctx, method, configs...)
+		if err != nil {
+			return fmt.Errorf("mockclock: advance block %d: %w", i+1, err)
+		}
+		if perr := resp.Error(); perr != nil {
+			return fmt.Errorf("mockclock: advance block %d: %s", i+1, perr.Message())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package mockclock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/mockclock"
+)
+
+// fakeClient is a ShiroClient whose Call records the timestamp of each call.
+type fakeClient struct {
+	shiroclient.ShiroClient
+	timestamps []string
+}
+
+func (f *fakeClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	f.timestamps = append(f.timestamps, opt.TimestampGenerator(ctx))
+	if method == "fail" {
+		return types.NewFailureResponse(1, "failed", nil), nil
+	}
+	return types.NewSuccessResponse([]byte(`true`), "", 0, 0), nil
+}
+
+func TestClock(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.FixedZone("", -8*3600))
+	clock := mockclock.New(start)
+	require.Equal(t, "2000-01-01T00:00:00-08:00", clock.Timestamp(ctx))
+
+	clock.Advance(24 * time.Hour)
+	require.Equal(t, "2000-01-02T00:00:00-08:00", clock.Timestamp(ctx))
+
+	clock.Set(start)
+	clock.AutoStep(time.Minute)
+	require.Equal(t, "2000-01-01T00:00:00-08:00", clock.Timestamp(ctx))
+	require.Equal(t, "2000-01-01T00:01:00-08:00", clock.Timestamp(ctx))
+	require.Equal(t, start.Add(2*time.Minute), clock.Now())
+}
+
+func TestAdvanceBlocks(t *testing.T) {
+	ctx := context.Background()
+	clock := mockclock.New(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := &fakeClient{}
+	require.NoError(t, clock.AdvanceBlocks(ctx, client, 2, time.Hour, "tick"))
+	require.Equal(t, []string{"2000-01-01T01:00:00Z", "2000-01-01T02:00:00Z"}, client.timestamps)
+
+	require.ErrorContains(t, clock.AdvanceBlocks(ctx, client, 1, time.Hour, "fail"), "failed")
+}
@@ -0,0 +1,396 @@
+// Package mockgateway serves the shiroclient JSON-RPC gateway protocol from
+// an in-process ShiroClient, typically one created with shiroclient.NewMock.
+//
+// Pointing a client created with shiroclient.NewRPC at a mock gateway
+// exercises the RPC code path end to end -- headers, authentication,
+// transient data encoding and error level mapping -- which calling the mock
+// client directly bypasses.
+//
+//	mockClient, _ := shiroclient.NewMock(nil)
+//	gw := mockgateway.NewServer(mockClient)
+//	defer gw.Close()
+//	client := shiroclient.NewRPC([]shiroclient.Config{gw.Config()})
+package mockgateway
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+)
+
+// transientTimestamp is the transient data key the RPC client uses to send
+// the output of a timestamp generator.
+const transientTimestamp = "timestamp_override"
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithAuthToken requires JSON-RPC requests to carry token as a bearer
+// token.  Requests without it are rejected with HTTP status 401.  By default
+// requests are not authenticated, and any bearer token is forwarded to the
+// backing client.
+func WithAuthToken(token string) Option {
+	return func(h *Handler) {
+		h.authToken = token
+	}
+}
+
+// WithCapabilities sets the capabilities reported to clients.  By default
+// all capabilities known to the SDK are reported.
+func WithCapabilities(capabilities ...string) Option {
+	return func(h *Handler) {
+		h.capabilities = append([]string(nil), capabilities...)
+	}
+}
+
+// Handler is an http.Handler translating gateway requests into calls on a
+// ShiroClient.
+type Handler struct {
+	client       shiroclient.ShiroClient
+	authToken    string
+	capabilities []string
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler returns a Handler serving requests with client.
+func NewHandler(client shiroclient.ShiroClient, opts ...Option) *Handler {
+	h := &Handler{
+		client:       client,
+		capabilities: []string{rpc.CapabilityTransientBase64},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Server is a mock gateway listening on a local port.
+type Server struct {
+	*httptest.Server
+}
+
+// NewServer starts a mock gateway serving requests with client.  Close the
+// server when done; closing it does not close client.
+func NewServer(client shiroclient.ShiroClient, opts ...Option) *Server {
+	return &Server{Server: httptest.NewServer(NewHandler(client, opts...))}
+}
+
+// Config returns a config pointing RPC clients at the server.
+func (s *Server) Config() shiroclient.Config {
+	return shiroclient.WithEndpoint(s.URL)
+}
+
+// request is a JSON-RPC request sent by the RPC client.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// callParams are the parameters of a Call request.
+type callParams struct {
+	Method              string            `json:"method"`
+	Params              json.RawMessage   `json:"params"`
+	Transient           map[string]string `json:"transient"`
+	TransientEncoding   string            `json:"transient_encoding"`
+	CreatorMSPID        string            `json:"creator_msp_id"`
+	DependentTxID       string            `json:"dependent_txid"`
+	DependentBlock      string            `json:"dependent_block"`
+	PhylumVersion       string            `json:"phylum_version"`
+	NewPhylumVersion    string            `json:"new_phylum_version"`
+	DisableWritePolling bool              `json:"disable_write_polling"`
+	IdempotencyKey      string            `json:"idempotency_key"`
+	MinEndorsers        int               `json:"min_endorsers"`
+	MSPFilter           []string          `json:"msp_filter"`
+	PrivateCollections  []string          `json:"private_collections"`
+}
+
+// result is the result of a request, before it is wrapped in a JSON-RPC
+// envelope.
+type result struct {
+	errorLevel int
+	result     interface{}
+	code       int
+	message    string
+	data       json.RawMessage
+	commit     *shiroclient.CommitMetadata
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// like the gateway, the health check endpoint is not authenticated.
+	if strings.HasSuffix(r.URL.Path, "/health_check") {
+		h.serveHealthCheck(w, r)
+		return
+	}
+	if h.authToken != "" && r.Header.Get("Authorization") != "Bearer "+h.authToken {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.JSONRPC != "2.0" {
+		http.Error(w, "invalid request: expected jsonrpc version 2.0", http.StatusBadRequest)
+		return
+	}
+
+	configs := h.requestConfigs(r)
+	res, err := h.dispatch(r.Context(), &req, configs)
+	if err != nil {
+		res = clientError(err)
+	}
+	h.writeResponse(w, &req, res)
+}
+
+// requestConfigs returns the configs derived from the HTTP request itself.
+func (h *Handler) requestConfigs(r *http.Request) []shiroclient.Config {
+	var configs []shiroclient.Config
+	if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); token != "" && h.authToken == "" {
+		configs = append(configs, shiroclient.WithAuthToken(token))
+	}
+	if key := r.Header.Get(rpc.HeaderIdempotencyKey); key != "" {
+		configs = append(configs, shiroclient.WithIdempotencyKey(key))
+	}
+	return configs
+}
+
+func (h *Handler) dispatch(ctx context.Context, req *request, configs []shiroclient.Config) (*result, error) {
+	switch req.Method {
+	case rpc.MethodCall:
+		var params callParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid Call params: %w", err)
+		}
+		callConfigs, err := params.configs()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := h.client.Call(ctx, params.Method, append(configs, callConfigs...)...)
+		if err != nil {
+			return nil, err
+		}
+		if perr := resp.Error(); perr != nil {
+			return &result{
+				errorLevel: rpc.ErrorLevelPhylum,
+				code:       perr.Code(),
+				message:    perr.Message(),
+				data:       perr.DataJSON(),
+			}, nil
+		}
+		return &result{
+			result: json.RawMessage(resp.ResultJSON()),
+			commit: shiroclient.GetCommitMetadata(resp),
+		}, nil
+	case rpc.MethodQueryInfo:
+		height, err := h.client.QueryInfo(ctx, configs...)
+		if err != nil {
+			return nil, err
+		}
+		return &result{result: height}, nil
+	case rpc.MethodQueryBlock:
+		var params struct {
+			BlockNumber uint64 `json:"block_number"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid QueryBlock params: %w", err)
+		}
+		block, err := h.client.QueryBlock(ctx, params.BlockNumber, configs...)
+		if err != nil {
+			return nil, err
+		}
+		return &result{result: encodeBlock(block)}, nil
+	case rpc.MethodInit:
+		var params struct {
+			Phylum string `json:"phylum"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid Init params: %w", err)
+		}
+		if err := h.client.Init(ctx, params.Phylum, configs...); err != nil {
+			return nil, err
+		}
+		return &result{result: true}, nil
+	case rpc.MethodSeed:
+		var params struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid Seed params: %w", err)
+		}
+		if err := h.client.Seed(ctx, params.Version, configs...); err != nil {
+			return nil, err
+		}
+		return &result{result: true}, nil
+	case rpc.MethodShiroPhylum:
+		phylum, err := h.client.ShiroPhylum(ctx, configs...)
+		if err != nil {
+			return nil, err
+		}
+		return &result{result: phylum}, nil
+	case rpc.MethodCapabilities:
+		return &result{result: h.capabilities}, nil
+	default:
+		return nil, fmt.Errorf("unknown method %q", req.Method)
+	}
+}
+
+// configs returns the configs reproducing the Call parameters.
+func (p *callParams) configs() ([]shiroclient.Config, error) {
+	var decode func(string) ([]byte, error)
+	switch p.TransientEncoding {
+	case "", rpc.TransientEncodingHex:
+		decode = hex.DecodeString
+	case rpc.TransientEncodingBase64:
+		decode = base64.StdEncoding.DecodeString
+	default:
+		return nil, fmt.Errorf("unsupported transient_encoding %q", p.TransientEncoding)
+	}
+	transient := make(map[string][]byte, len(p.Transient))
+	for k, v := range p.Transient {
+		b, err := decode(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transient data %q: %w", k, err)
+		}
+		transient[k] = b
+	}
+	timestamp, hasTimestamp := transient[transientTimestamp]
+	delete(transient, transientTimestamp)
+
+	configs := []shiroclient.Config{
+		shiroclient.WithParams(p.Params),
+		shiroclient.WithTransientDataMap(transient),
+		types.Opt(func(r *types.RequestOptions) {
+			r.Creator = p.CreatorMSPID
+			r.DependentTxID = p.DependentTxID
+			r.DependentBlock = p.DependentBlock
+			r.PhylumVersion = p.PhylumVersion
+			r.NewPhylumVersion = p.NewPhylumVersion
+			r.DisableWritePolling = p.DisableWritePolling
+			r.MinEndorsers = p.MinEndorsers
+			r.MspFilter = p.MSPFilter
+			r.PrivateCollections = p.PrivateCollections
+		}),
+	}
+	if p.IdempotencyKey != "" {
+		configs = append(configs, shiroclient.WithIdempotencyKey(p.IdempotencyKey))
+	}
+	if hasTimestamp {
+		configs = append(configs, shiroclient.WithTimestampGenerator(func(context.Context) string {
+			return string(timestamp)
+		}))
+	}
+	return configs, nil
+}
+
+// clientError maps an error returned by the backing client to a
+// ShiroClient level error.
+func clientError(err error) *result {
+	code := rpc.ErrorCodeShiroClientNone
+	if shiroclient.IsTimeoutError(err) {
+		code = rpc.ErrorCodeShiroClientTimeout
+	}
+	return &result{
+		errorLevel: rpc.ErrorLevelShiroClient,
+		code:       code,
+		message:    err.Error(),
+	}
+}
+
+// encodeBlock returns the QueryBlock result for block.
+func encodeBlock(block shiroclient.Block) map[string]interface{} {
+	txs := block.Transactions()
+	ids := make([]string, len(txs))
+	reasons := make([]string, len(txs))
+	events := make([]string, len(txs))
+	ccIDs := make([]string, len(txs))
+	for i, tx := range txs {
+		ids[i] = tx.ID()
+		reasons[i] = tx.Reason()
+		events[i] = base64.StdEncoding.EncodeToString(tx.Event())
+		ccIDs[i] = tx.ChaincodeID()
+	}
+	res := map[string]interface{}{
+		"block_hash":          block.Hash(),
+		"transaction_ids":     ids,
+		"transaction_reasons": reasons,
+		"transaction_events":  events,
+		"chaincode_ids":       ccIDs,
+	}
+	if header := shiroclient.GetBlockHeader(block); header != nil {
+		res["block_header"] = header
+	}
+	return res
+}
+
+func (h *Handler) writeResponse(w http.ResponseWriter, req *request, res *result) {
+	data := res.data
+	if len(data) == 0 {
+		data = json.RawMessage("null")
+	}
+	if raw, ok := res.result.(json.RawMessage); ok && len(raw) == 0 {
+		res.result = nil
+	}
+	envelope := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"result": map[string]interface{}{
+			"error_level": res.errorLevel,
+			"result":      res.result,
+			"code":        res.code,
+			"message":     res.message,
+			"data":        data,
+		},
+	}
+	if c := res.commit; c != nil {
+		if c.TxID != "" {
+			envelope["$commit_tx_id"] = c.TxID
+		}
+		if c.CommitBlock > 0 {
+			envelope["$com_block_num"] = c.CommitBlock
+		}
+		if c.MaxSimulatedBlock > 0 {
+			envelope["$sim_block_num"] = c.MaxSimulatedBlock
+		}
+		if len(c.Endorsers) > 0 {
+			envelope["$endorsers"] = c.Endorsers
+		}
+		if c.ValidationCode != "" {
+			envelope["$validation_code"] = string(c.ValidationCode)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(envelope)
+}
+
+// serveHealthCheck reports the health of the backing client by calling the
+// phylum healthcheck endpoint, as RemoteHealthCheck does for mock clients.
+func (h *Handler) serveHealthCheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	resp, err := h.client.Call(r.Context(), "healthcheck", h.requestConfigs(r)...)
+	if err == nil && resp.Error() != nil {
+		err = fmt.Errorf("phylum error [%d]: %s", resp.Error().Code(), resp.Error().Message())
+	}
+	if err != nil {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"exception": err.Error()})
+		return
+	}
+	_, _ = w.Write(resp.ResultJSON())
+}
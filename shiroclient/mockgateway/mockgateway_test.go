@@ -0,0 +1,177 @@
+package mockgateway_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/mockgateway"
+)
+
+// fakeClient is a ShiroClient whose Call echoes the options it received.
+type fakeClient struct {
+	shiroclient.ShiroClient
+	last *types.RequestOptions
+}
+
+func (f *fakeClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	f.last = opt
+	switch method {
+	case "fail":
+		return types.NewFailureResponse(42, "phylum failure", []byte(`{"why":"testing"}`)), nil
+	case "broken":
+		return nil, errors.New("substrate unavailable")
+	case "healthcheck":
+		return types.NewSuccessResponse([]byte(`{"reports":[{"timestamp":"now","status":"UP","service_name":"phylum","service_version":"v1"}]}`), "", 0, 0), nil
+	}
+	resp := types.NewSuccessResponse(opt.Params.(json.RawMessage), "tx1", 0, 0)
+	resp.SetCommit(&types.CommitMetadata{TxID: "tx1", CommitBlock: 7, MaxSimulatedBlock: 6, Endorsers: []string{"peer0"}})
+	return resp, nil
+}
+
+func (f *fakeClient) QueryInfo(ctx context.Context, configs ...shiroclient.Config) (uint64, error) {
+	return 7, nil
+}
+
+func (f *fakeClient) QueryBlock(ctx context.Context, blockNumber uint64, configs ...shiroclient.Config) (shiroclient.Block, error) {
+	return types.NewBlockWithHeader("hash", []types.Transaction{
+		types.NewTransaction("tx1", "", []byte("event"), "cc"),
+	}, &types.BlockHeader{
+		Number:       blockNumber,
+		PreviousHash: "prev",
+		DataHash:     "data",
+		Signatures:   []*types.BlockSignature{{Creator: []byte("orderer"), Nonce: []byte{1}, Signature: []byte{2, 3}}},
+	}), nil
+}
+
+func TestGateway(t *testing.T) {
+	fake := &fakeClient{}
+	gw := mockgateway.NewServer(fake, mockgateway.WithAuthToken("secret"))
+	t.Cleanup(gw.Close)
+	ctx := context.Background()
+
+	client := shiroclient.NewRPC([]shiroclient.Config{gw.Config(), shiroclient.WithAuthToken("secret")})
+	for _, encoding := range []shiroclient.TransientEncoding{shiroclient.TransientEncodingHex, shiroclient.TransientEncodingBase64} {
+		resp, err := client.Call(ctx, "echo",
+			shiroclient.WithParams([]string{"a", "b"}),
+			shiroclient.WithTransientData("key", []byte{0, 1, 2}),
+			shiroclient.WithTransientEncoding(encoding),
+			shiroclient.WithCreator("Org1MSP"),
+			shiroclient.WithTimestampGenerator(func(context.Context) string { return "2024-01-01T00:00:00Z" }),
+		)
+		require.NoError(t, err)
+		require.JSONEq(t, `["a","b"]`, string(resp.ResultJSON()))
+		require.Equal(t, &shiroclient.CommitMetadata{
+			TxID:              "tx1",
+			CommitBlock:       7,
+			MaxSimulatedBlock: 6,
+			Endorsers:         []string{"peer0"},
+		}, shiroclient.GetCommitMetadata(resp))
+		require.Equal(t, map[string][]byte{"key": {0, 1, 2}}, fake.last.Transient)
+		require.Equal(t, "Org1MSP", fake.last.Creator)
+		require.Equal(t, "2024-01-01T00:00:00Z", fake.last.TimestampGenerator(ctx))
+	}
+
+	resp, err := client.Call(ctx, "fail")
+	require.NoError(t, err)
+	require.Equal(t, 42, resp.Error().Code())
+	require.Equal(t, "phylum failure", resp.Error().Message())
+	require.JSONEq(t, `{"why":"testing"}`, string(resp.Error().DataJSON()))
+
+	_, err = client.Call(ctx, "broken")
+	require.ErrorContains(t, err, "substrate unavailable")
+
+	height, err := client.QueryInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), height)
+
+	block, err := client.QueryBlock(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, "hash", block.Hash())
+	require.Equal(t, []byte("event"), block.Transactions()[0].Event())
+	header := shiroclient.GetBlockHeader(block)
+	require.NotNil(t, header)
+	require.Equal(t, uint64(7), header.Number)
+	require.Equal(t, "prev", header.PreviousHash)
+	require.Equal(t, []byte{2, 3}, header.Signatures[0].Signature)
+
+	health, err := shiroclient.RemoteHealthCheck(ctx, client, nil)
+	require.NoError(t, err)
+	require.Equal(t, "UP", health.Reports()[0].Status())
+
+	unauthorized := shiroclient.NewRPC([]shiroclient.Config{gw.Config()})
+	_, err = unauthorized.QueryInfo(ctx)
+	require.Error(t, err)
+}
+
+func TestMethodPolicy(t *testing.T) {
+	gw := mockgateway.NewServer(&fakeClient{})
+	t.Cleanup(gw.Close)
+	ctx := context.Background()
+
+	client := shiroclient.NewRPC([]shiroclient.Config{gw.Config(), shiroclient.WithDeniedMethods("private_purge", "update")})
+	_, err := client.Call(ctx, "echo", shiroclient.WithParams([]string{"a"}))
+	require.NoError(t, err)
+	_, err = client.Call(ctx, "update", shiroclient.WithDeniedMethods())
+	require.ErrorIs(t, err, shiroclient.ErrMethodNotAllowed)
+
+	client = shiroclient.NewRPC([]shiroclient.Config{gw.Config(), shiroclient.WithAllowedMethods("echo")})
+	_, err = client.Call(ctx, "echo", shiroclient.WithParams([]string{"a"}))
+	require.NoError(t, err)
+	_, err = client.Call(ctx, "private_purge", shiroclient.WithAllowedMethods("private_purge"))
+	require.ErrorIs(t, err, shiroclient.ErrMethodNotAllowed)
+
+	// derived clients can only tighten the policy.
+	derived := shiroclient.With(client, shiroclient.WithAllowedMethods("echo", "private_purge"))
+	_, err = derived.Call(ctx, "echo", shiroclient.WithParams([]string{"a"}))
+	require.NoError(t, err)
+	_, err = derived.Call(ctx, "private_purge")
+	require.ErrorIs(t, err, shiroclient.ErrMethodNotAllowed)
+
+	derived = shiroclient.With(client, shiroclient.WithDeniedMethods("update"))
+	_, err = derived.Call(ctx, "update")
+	require.ErrorIs(t, err, shiroclient.ErrMethodNotAllowed)
+
+	client = shiroclient.NewRPC([]shiroclient.Config{gw.Config(), shiroclient.WithDeniedMethods("private_purge")})
+	derived = shiroclient.With(client, shiroclient.WithDeniedMethods("update"))
+	_, err = derived.Call(ctx, "private_purge")
+	require.ErrorIs(t, err, shiroclient.ErrMethodNotAllowed)
+	_, err = derived.Call(ctx, "update")
+	require.ErrorIs(t, err, shiroclient.ErrMethodNotAllowed)
+	_, err = derived.Call(ctx, "echo", shiroclient.WithParams([]string{"a"}))
+	require.NoError(t, err)
+}
+
+func TestMethodConfigs(t *testing.T) {
+	fake := &fakeClient{}
+	gw := mockgateway.NewServer(fake)
+	t.Cleanup(gw.Close)
+	ctx := context.Background()
+
+	client := shiroclient.NewRPC([]shiroclient.Config{
+		gw.Config(),
+		shiroclient.WithMethodConfigs("echo", shiroclient.WithCreator("Org1MSP")),
+		shiroclient.WithMethodConfigs("echo", shiroclient.WithParams([]string{"default"})),
+	})
+	resp, err := client.Call(ctx, "echo")
+	require.NoError(t, err)
+	require.JSONEq(t, `["default"]`, string(resp.ResultJSON()))
+	require.Equal(t, "Org1MSP", fake.last.Creator)
+
+	resp, err = client.Call(ctx, "echo", shiroclient.WithParams([]string{"a"}))
+	require.NoError(t, err)
+	require.JSONEq(t, `["a"]`, string(resp.ResultJSON()))
+
+	_, err = client.Call(ctx, "other", shiroclient.WithParams([]string{"a"}))
+	require.NoError(t, err)
+	require.Empty(t, fake.last.Creator)
+}
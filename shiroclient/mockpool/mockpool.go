@@ -0,0 +1,204 @@
+// Package mockpool runs isolated mock clients on a shared plugin process,
+// for tests that run in parallel.  Each client has its own mock ledger.
+// Plugin startup is serialized and the plugin path is resolved once, so
+// parallel tests do not race on them.
+//
+//	func TestAPI(t *testing.T) {
+//		pool := mockpool.ForTest(t)
+//		for _, tc := range cases {
+//			tc := tc
+//			t.Run(tc.name, func(t *testing.T) {
+//				t.Parallel()
+//				client := pool.Client(t, nil)
+//				...
+//			})
+//		}
+//	}
+//
+// Clients are closed before the pool, in the reverse order of their
+// creation.
+package mockpool
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	imock "github.com/luthersystems/shiroclient-sdk-go/internal/mock"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/mock"
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+)
+
+// ErrClosed is returned when creating a client from a closed Pool.
+var ErrClosed = errors.New("mockpool: pool closed")
+
+// Pool creates mock clients sharing one plugin process.  A Pool is safe for
+// concurrent use.
+type Pool struct {
+	conn *plugin.SubstrateConnection
+	opts []mock.Option
+
+	mu      sync.Mutex
+	closed  bool
+	nextID  int
+	clients []*pooled
+}
+
+type pooled struct {
+	id     int
+	client shiroclient.MockShiroClient
+}
+
+// New starts the plugin configured by opts, as for shiroclient.NewMock.
+// Options that configure mock ledgers, like mock.WithErrorStacks, apply to
+// every client of the pool.
+func New(opts ...mock.Option) (*Pool, error) {
+	conn, err := imock.Connect(imock.NewConfig(opts...))
+	if err != nil {
+		return nil, err
+	}
+	return newPool(conn, opts), nil
+}
+
+func newPool(conn *plugin.SubstrateConnection, opts []mock.Option) *Pool {
+	return &Pool{conn: conn, opts: append([]mock.Option(nil), opts...)}
+}
+
+// ForTest returns a new Pool that is closed when t and its subtests
+// complete.  The test fails if the plugin cannot be started.
+func ForTest(t testing.TB, opts ...mock.Option) *Pool {
+	t.Helper()
+	p, err := New(opts...)
+	if err != nil {
+		t.Fatalf("mockpool: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := p.Close(); err != nil {
+			t.Errorf("mockpool: %v", err)
+		}
+	})
+	return p
+}
+
+// New creates a client with a new mock ledger.  opts are applied after the
+// options of the pool; plugin options, like mock.WithPluginPath, have no
+// effect.  Closing the client closes its ledger only.
+func (p *Pool) New(clientConfigs []shiroclient.Config, opts ...mock.Option) (shiroclient.MockShiroClient, error) {
+	config := imock.NewConfig(append(append([]mock.Option(nil), p.opts...), opts...)...)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrClosed
+	}
+	p.nextID++
+	entry := &pooled{id: p.nextID}
+	client, err := imock.NewShared(p.conn, config, clientConfigs, func() { p.remove(entry.id) })
+	if err != nil {
+		return nil, err
+	}
+	entry.client = client
+	p.clients = append(p.clients, entry)
+	return client, nil
+}
+
+// Client creates a client like New that is closed when t completes.  The
+// test fails if the client cannot be created.
+func (p *Pool) Client(t testing.TB, clientConfigs []shiroclient.Config, opts ...mock.Option) shiroclient.MockShiroClient {
+	t.Helper()
+	client, err := p.New(clientConfigs, opts...)
+	if err != nil {
+		t.Fatalf("mockpool: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := client.Close(); err != nil {
+			t.Errorf("mockpool: %v", err)
+		}
+	})
+	return client
+}
+
+// Clients creates n clients with Client.
+func (p *Pool) Clients(t testing.TB, n int, clientConfigs []shiroclient.Config, opts ...mock.Option) []shiroclient.MockShiroClient {
+	t.Helper()
+	clients := make([]shiroclient.MockShiroClient, n)
+	for i := range clients {
+		clients[i] = p.Client(t, clientConfigs, opts...)
+	}
+	return clients
+}
+
+func (p *Pool) remove(id int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, entry := range p.clients {
+		if entry.id == id {
+			p.clients = append(p.clients[:i], p.clients[i+1:]...)
+			return
+		}
+	}
+}
+
+// Mocks lists the mocks open in the plugin of the pool.  Mocks outliving
+// the tests that created them indicate clients that were not closed.
+func (p *Pool) Mocks() ([]*plugin.MockInfo, error) {
+	inspector, ok := p.conn.GetSubstrate().(plugin.MockInspector)
+	if !ok {
+		return nil, plugin.ErrUnsupported
+	}
+	return inspector.ListMocks()
+}
+
+// CloseAll closes the clients of the pool that are still open, then any
+// other mock left open in the plugin, and returns the number of mocks
+// closed.  Unlike Close, the pool remains usable.  Mocks are only closed
+// in the plugin when it supports plugin.MockInspector.
+func (p *Pool) CloseAll() (int, error) {
+	p.mu.Lock()
+	clients := append([]*pooled(nil), p.clients...)
+	p.mu.Unlock()
+
+	var errs []error
+	closed := 0
+	for i := len(clients) - 1; i >= 0; i-- {
+		if err := clients[i].client.Close(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		closed++
+	}
+	if inspector, ok := p.conn.GetSubstrate().(plugin.MockInspector); ok {
+		n, err := inspector.CloseAllMocks()
+		if err != nil && !errors.Is(err, plugin.ErrUnsupported) {
+			errs = append(errs, err)
+		}
+		closed += n
+	}
+	return closed, errors.Join(errs...)
+}
+
+// Close closes the clients that are still open, in the reverse order of
+// their creation, then stops the plugin.  Closing a closed pool has no
+// effect.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	clients := append([]*pooled(nil), p.clients...)
+	p.mu.Unlock()
+
+	var errs []error
+	for i := len(clients) - 1; i >= 0; i-- {
+		if err := clients[i].client.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := p.conn.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close plugin: %w", err))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,149 @@
+package mockpool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+)
+
+// fakeSubstrate is an in-process plugin.Substrate recording the mock
+// ledgers it creates and closes.
+type fakeSubstrate struct {
+	plugin.Substrate
+	mu     sync.Mutex
+	next   int
+	open   map[string]bool
+	closed []string
+}
+
+func (f *fakeSubstrate) NewMockFrom(name string, version string, snapshot []byte) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next++
+	tag := fmt.Sprintf("mock%d", f.next)
+	f.open[tag] = true
+	return tag, nil
+}
+
+func (f *fakeSubstrate) CloseMock(tag string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.open[tag] {
+		return fmt.Errorf("unknown mock %s", tag)
+	}
+	delete(f.open, tag)
+	f.closed = append(f.closed, tag)
+	return nil
+}
+
+func (f *fakeSubstrate) QueryInfo(tag string, opts *plugin.ConcreteRequestOptions) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.open[tag] {
+		return 0, fmt.Errorf("unknown mock %s", tag)
+	}
+	return 1, nil
+}
+
+func TestPool(t *testing.T) {
+	fake := &fakeSubstrate{open: make(map[string]bool)}
+	pool := newPool(plugin.NewLocalConnection(fake), nil)
+
+	t.Run("clients", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			t.Run(fmt.Sprint(i), func(t *testing.T) {
+				t.Parallel()
+				for _, client := range pool.Clients(t, 2, nil) {
+					_, err := client.QueryInfo(context.Background())
+					require.NoError(t, err)
+				}
+			})
+		}
+	})
+	require.Empty(t, fake.open)
+	require.Len(t, fake.closed, 8)
+
+	// open clients are closed in reverse order with the pool.
+	for i := 0; i < 2; i++ {
+		_, err := pool.New(nil)
+		require.NoError(t, err)
+	}
+	require.NoError(t, pool.Close())
+	require.Equal(t, []string{"mock10", "mock9"}, fake.closed[8:])
+	require.NoError(t, pool.Close())
+
+	_, err := pool.New(nil)
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+// inspectingSubstrate lists and closes the mock ledgers of fakeSubstrate.
+type inspectingSubstrate struct {
+	*fakeSubstrate
+}
+
+func (f inspectingSubstrate) ListMocks() ([]*plugin.MockInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var mocks []*plugin.MockInfo
+	for tag := range f.open {
+		mocks = append(mocks, &plugin.MockInfo{Tag: tag})
+	}
+	return mocks, nil
+}
+
+func (f inspectingSubstrate) CloseAllMocks() (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := len(f.open)
+	for tag := range f.open {
+		delete(f.open, tag)
+		f.closed = append(f.closed, tag)
+	}
+	return n, nil
+}
+
+func TestCloseAll(t *testing.T) {
+	fake := &fakeSubstrate{open: make(map[string]bool)}
+	pool := newPool(plugin.NewLocalConnection(inspectingSubstrate{fake}), nil)
+	defer func() { require.NoError(t, pool.Close()) }()
+
+	client, err := pool.New(nil)
+	require.NoError(t, err)
+	// a mock leaked outside of the pool.
+	_, err = fake.NewMockFrom("", "", nil)
+	require.NoError(t, err)
+
+	mocks, err := pool.Mocks()
+	require.NoError(t, err)
+	require.Len(t, mocks, 2)
+	clientMocks, err := client.Mocks()
+	require.NoError(t, err)
+	require.ElementsMatch(t, mocks, clientMocks)
+
+	n, err := pool.CloseAll()
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Empty(t, fake.open)
+
+	// the pool remains usable.
+	_, err = pool.New(nil)
+	require.NoError(t, err)
+}
+
+func TestMocksUnsupported(t *testing.T) {
+	fake := &fakeSubstrate{open: make(map[string]bool)}
+	pool := newPool(plugin.NewLocalConnection(fake), nil)
+	defer func() { require.NoError(t, pool.Close()) }()
+	_, err := pool.Mocks()
+	require.ErrorIs(t, err, plugin.ErrUnsupported)
+	_, err = pool.New(nil)
+	require.NoError(t, err)
+	n, err := pool.CloseAll()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
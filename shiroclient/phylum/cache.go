@@ -0,0 +1,227 @@
+package phylum
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// DefaultCacheHeightInterval is the default interval between the ledger
+// height checks of a response cache.
+const DefaultCacheHeightInterval = time.Second
+
+// DefaultCacheMaxEntries is the default maximum number of responses held
+// by a response cache.
+const DefaultCacheMaxEntries = 1024
+
+// CacheConfig configures the response cache of a client.  See
+// Client.EnableResponseCache.
+type CacheConfig struct {
+	// Methods are the read-only phylum methods whose responses are cached.
+	Methods []string
+	// TTL is the maximum age of a cached response.  Zero means responses
+	// are only invalidated by new blocks.
+	TTL time.Duration
+	// HeightInterval is the minimum interval between the ledger height
+	// checks invalidating the cache.  It defaults to
+	// DefaultCacheHeightInterval, so a response may be served for that long
+	// after a block changing it was committed.
+	HeightInterval time.Duration
+	// Heights, if set, provides the ledger height, e.g. a monitor shared
+	// with other subsystems and kept current by WatchHeight, so that the
+	// cache does not query the height itself while the monitor is fresh.
+	Heights *shiroclient.HeightMonitor
+	// MaxEntries is the maximum number of responses held by the cache,
+	// evicting the least recently used.  It defaults to
+	// DefaultCacheMaxEntries.
+	MaxEntries int
+	// Observe, if set, is called with the outcome of each cache lookup,
+	// e.g. to export the hit rate as a metric.
+	Observe func(method string, hit bool)
+}
+
+// CacheStats counts the lookups of a response cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Invalidations counts the times the cache was cleared because the
+	// ledger height advanced.
+	Invalidations uint64
+	// Evictions counts the responses evicted to respect MaxEntries.
+	Evictions uint64
+}
+
+// HitRate returns the fraction of lookups that were hits, or 0 if there
+// were none.
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type cacheEntry struct {
+	key     string
+	result  []byte
+	created time.Time
+}
+
+// responseCache caches the results of read-only phylum methods until the
+// ledger height advances.
+type responseCache struct {
+	config  CacheConfig
+	methods map[string]bool
+	heights *shiroclient.HeightMonitor
+
+	mu sync.Mutex
+	// entries indexes lru, whose elements are *cacheEntry ordered from the
+	// most to the least recently used.
+	entries map[string]*list.Element
+	lru     *list.List
+	height  uint64
+	stats   CacheStats
+}
+
+// EnableResponseCache caches the responses of the read-only methods of
+// config, which UI backends often call many times with the same request.
+// Responses are invalidated when the ledger height advances, as checked
+// with QueryInfo at most every config.HeightInterval or provided by
+// config.Heights, and after config.TTL.  Only calls without per-call
+// configs are cached, since configs like the creator may change the
+// response, and responses are only served to calls carrying the same
+// context headers (see shiroclient.ContextWithHeaders), which may identify
+// the caller.  Calls depending on a transaction recorded with txctx bypass
+// the cache so that they observe it.  Errors are never cached.  Calling
+// EnableResponseCache again replaces the cache.
+func (s *Client) EnableResponseCache(config CacheConfig) {
+	if config.HeightInterval <= 0 {
+		config.HeightInterval = DefaultCacheHeightInterval
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultCacheMaxEntries
+	}
+	c := &responseCache{
+		config:  config,
+		methods: make(map[string]bool, len(config.Methods)),
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		heights: config.Heights,
+	}
+	if c.heights == nil {
+		c.heights = shiroclient.NewHeightMonitor(s.rpc)
+	}
+	for _, m := range config.Methods {
+		c.methods[m] = true
+	}
+	s.cache.Store(c)
+}
+
+// CacheStats returns the lookup counts of the response cache, or zero
+// stats if it is not enabled.
+func (s *Client) CacheStats() CacheStats {
+	c := s.cache.Load()
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// key returns the key of a call, or false if the call is not cacheable.
+// The key includes the context headers of the call, which may identify the
+// caller.
+func (c *responseCache) key(ctx context.Context, cmd string, params interface{}, configs []Config) (string, bool) {
+	if c == nil || !c.methods[cmd] || len(configs) > 0 {
+		return "", false
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return "", false
+	}
+	// maps are encoded with sorted keys.
+	headers, err := json.Marshal(shiroclient.HeadersFromContext(ctx))
+	if err != nil {
+		return "", false
+	}
+	return cmd + "\x00" + string(b) + "\x00" + string(headers), true
+}
+
+// get returns the cached result of a call.
+func (c *responseCache) get(ctx context.Context, s *Client, cmd string, key string) ([]byte, bool) {
+	c.checkHeight(ctx, s)
+	c.mu.Lock()
+	var e *cacheEntry
+	elem, ok := c.entries[key]
+	if ok {
+		e = elem.Value.(*cacheEntry)
+		if c.config.TTL > 0 && time.Since(e.created) > c.config.TTL {
+			c.remove(elem)
+			ok = false
+		}
+	}
+	if ok {
+		c.lru.MoveToFront(elem)
+		c.stats.Hits++
+	} else {
+		c.stats.Misses++
+	}
+	c.mu.Unlock()
+	if c.config.Observe != nil {
+		c.config.Observe(cmd, ok)
+	}
+	if !ok {
+		return nil, false
+	}
+	return e.result, true
+}
+
+// put caches the result of a call, evicting the least recently used
+// results beyond the maximum number of entries.
+func (c *responseCache) put(key string, result []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:     key,
+		result:  append([]byte(nil), result...),
+		created: time.Now(),
+	})
+	for c.lru.Len() > c.config.MaxEntries {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// remove removes an entry.  c.mu must be held.
+func (c *responseCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
+
+// checkHeight clears the cache if the ledger height advanced since the
+// last check.  Failed checks are logged and leave the cache unchanged.
+func (c *responseCache) checkHeight(ctx context.Context, s *Client) {
+	height, err := c.heights.Latest(ctx, c.config.HeightInterval)
+	if err != nil {
+		s.logEntry(ctx).WithError(err).Warn("response cache: query ledger height")
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if height > c.height {
+		if c.height > 0 && len(c.entries) > 0 {
+			c.stats.Invalidations++
+		}
+		c.entries = make(map[string]*list.Element)
+		c.lru.Init()
+		c.height = height
+	}
+}
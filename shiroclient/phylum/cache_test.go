@@ -0,0 +1,183 @@
+package phylum
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/txctx"
+)
+
+// fakeRPC echoes the first param of calls with a call counter, and
+// reports a settable ledger height.
+type fakeRPC struct {
+	shiroclient.ShiroClient
+	mu     sync.Mutex
+	calls  map[string]int
+	height uint64
+}
+
+func (f *fakeRPC) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	params, err := json.Marshal(opt.Params)
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[method]++
+	result, err := json.Marshal(args[0] + "-" + strconv.Itoa(f.calls[method]))
+	if err != nil {
+		return nil, err
+	}
+	return types.NewSuccessResponse(result, "", 0, 0), nil
+}
+
+func (f *fakeRPC) QueryInfo(ctx context.Context, configs ...shiroclient.Config) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.height, nil
+}
+
+func (f *fakeRPC) setHeight(h uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.height = h
+}
+
+func newFakeClient() (*Client, *fakeRPC) {
+	rpc := &fakeRPC{calls: make(map[string]int), height: 1}
+	return &Client{log: logrus.NewEntry(logrus.New()), rpc: rpc}, rpc
+}
+
+func call(t *testing.T, client *Client, method string, arg string, configs ...Config) string {
+	resp, err := Call(client, context.Background(), method, wrapperspb.String(arg), &wrapperspb.StringValue{}, configs...)
+	require.NoError(t, err)
+	return resp.GetValue()
+}
+
+func TestResponseCache(t *testing.T) {
+	client, rpc := newFakeClient()
+	var observed []bool
+	client.EnableResponseCache(CacheConfig{
+		Methods:        []string{"get"},
+		HeightInterval: time.Nanosecond,
+		Observe:        func(method string, hit bool) { observed = append(observed, hit) },
+	})
+
+	require.Equal(t, "a-1", call(t, client, "get", "a"))
+	require.Equal(t, "a-1", call(t, client, "get", "a"))
+	require.Equal(t, "b-2", call(t, client, "get", "b"))
+	// other methods and calls with configs are not cached.
+	require.Equal(t, "a-1", call(t, client, "put", "a"))
+	require.Equal(t, "a-2", call(t, client, "put", "a"))
+	require.Equal(t, "a-3", call(t, client, "get", "a", shiroclient.WithCreator("u")))
+
+	rpc.setHeight(2)
+	require.Equal(t, "a-4", call(t, client, "get", "a"))
+	require.Equal(t, "a-4", call(t, client, "get", "a"))
+
+	stats := client.CacheStats()
+	require.Equal(t, CacheStats{Hits: 2, Misses: 3, Invalidations: 1}, stats)
+	require.InDelta(t, 0.4, stats.HitRate(), 1e-9)
+	require.Equal(t, []bool{false, true, false, false, true}, observed)
+}
+
+func TestResponseCacheTTL(t *testing.T) {
+	client, _ := newFakeClient()
+	client.EnableResponseCache(CacheConfig{Methods: []string{"get"}, TTL: 10 * time.Millisecond})
+	require.Equal(t, "a-1", call(t, client, "get", "a"))
+	require.Equal(t, "a-1", call(t, client, "get", "a"))
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, "a-2", call(t, client, "get", "a"))
+}
+
+func TestResponseCacheHeights(t *testing.T) {
+	client, rpc := newFakeClient()
+	heights := shiroclient.NewHeightMonitor(rpc)
+	client.EnableResponseCache(CacheConfig{Methods: []string{"get"}, Heights: heights, HeightInterval: time.Hour})
+	require.Equal(t, "a-1", call(t, client, "get", "a"))
+
+	// the cache sees heights observed by the shared monitor.
+	rpc.setHeight(2)
+	require.Equal(t, "a-1", call(t, client, "get", "a"))
+	_, err := heights.Refresh(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "a-2", call(t, client, "get", "a"))
+}
+
+func TestResponseCacheTxContext(t *testing.T) {
+	client, _ := newFakeClient()
+	client.EnableResponseCache(CacheConfig{Methods: []string{"get"}, HeightInterval: time.Hour})
+	require.Equal(t, "a-1", call(t, client, "get", "a"))
+
+	// calls depending on a write of their request bypass the cache.
+	ctx := txctx.ContextWithID(context.Background(), "tx1")
+	resp, err := Call(client, ctx, "get", wrapperspb.String("a"), &wrapperspb.StringValue{})
+	require.NoError(t, err)
+	require.Equal(t, "a-2", resp.GetValue())
+	require.Equal(t, "a-1", call(t, client, "get", "a"))
+}
+
+func TestResponseCacheIdentity(t *testing.T) {
+	client, _ := newFakeClient()
+	client.EnableResponseCache(CacheConfig{Methods: []string{"get"}, HeightInterval: time.Hour})
+	get := func(ctx context.Context) string {
+		resp, err := Call(client, ctx, "get", wrapperspb.String("a"), &wrapperspb.StringValue{})
+		require.NoError(t, err)
+		return resp.GetValue()
+	}
+	alice := shiroclient.ContextWithHeaders(context.Background(), map[string]string{"Authorization": "alice"})
+	bob := shiroclient.ContextWithHeaders(context.Background(), map[string]string{"Authorization": "bob"})
+	require.Equal(t, "a-1", get(alice))
+	require.Equal(t, "a-2", get(bob))
+	require.Equal(t, "a-1", get(alice))
+	require.Equal(t, "a-3", get(context.Background()))
+}
+
+func TestResponseCacheMaxEntries(t *testing.T) {
+	client, _ := newFakeClient()
+	client.EnableResponseCache(CacheConfig{Methods: []string{"get"}, HeightInterval: time.Hour, MaxEntries: 2})
+	require.Equal(t, "a-1", call(t, client, "get", "a"))
+	require.Equal(t, "b-2", call(t, client, "get", "b"))
+	require.Equal(t, "a-1", call(t, client, "get", "a"))
+	// b is the least recently used.
+	require.Equal(t, "c-3", call(t, client, "get", "c"))
+	require.Equal(t, "a-1", call(t, client, "get", "a"))
+	require.Equal(t, "b-4", call(t, client, "get", "b"))
+	require.Equal(t, uint64(2), client.CacheStats().Evictions)
+}
+
+func TestEnableResponseCacheConcurrent(t *testing.T) {
+	client, _ := newFakeClient()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client.EnableResponseCache(CacheConfig{Methods: []string{"get"}})
+		}()
+		go func() {
+			defer wg.Done()
+			call(t, client, "get", "a")
+			client.CacheStats()
+		}()
+	}
+	wg.Wait()
+}
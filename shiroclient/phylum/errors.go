@@ -0,0 +1,18 @@
+package phylum
+
+import (
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// PhylumError is a phylum error returned with its data.  Clients only
+// return it when their ErrorPolicy is shiroclient.ErrorPolicyCodes or
+// shiroclient.ErrorPolicyFull.
+type PhylumError = shiroclient.PhylumError
+
+// errorPolicy returns the policy of the client's phylum errors.
+func (s *Client) errorPolicy() shiroclient.ErrorPolicy {
+	if s.ExposeErrorData {
+		return s.ErrorPolicy.Or(shiroclient.ErrorPolicyFull)
+	}
+	return s.ErrorPolicy.Or(shiroclient.ErrorPolicyMessages)
+}
@@ -0,0 +1,80 @@
+package phylum
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// errorRPC fails calls with fixed error data.
+type errorRPC struct {
+	shiroclient.ShiroClient
+	data string
+}
+
+func (e *errorRPC) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	return types.NewFailureResponse(400, "validation failed", []byte(e.data)), nil
+}
+
+func TestErrorData(t *testing.T) {
+	rpc := &errorRPC{data: `{"fields": ["email"]}`}
+	client := &Client{log: logrus.NewEntry(logrus.New()), rpc: rpc}
+	call := func() error {
+		_, err := Call(client, context.Background(), "create", wrapperspb.String("x"), &wrapperspb.StringValue{})
+		return err
+	}
+	require.EqualError(t, call(), "unknown phylum error")
+
+	client.ExposeErrorData = true
+	err := call()
+	var perr *PhylumError
+	require.True(t, errors.As(err, &perr))
+	require.Equal(t, 400, perr.Code)
+	require.EqualError(t, err, "validation failed")
+	var data struct {
+		Fields []string `json:"fields"`
+	}
+	require.NoError(t, perr.UnmarshalData(&data))
+	require.Equal(t, []string{"email"}, data.Fields)
+
+	// string data remains the error message.
+	rpc.data = `"account exists"`
+	require.EqualError(t, call(), "account exists")
+
+	// the policy takes precedence over ExposeErrorData.
+	client.ErrorPolicy = shiroclient.ErrorPolicyMask
+	require.ErrorIs(t, call(), shiroclient.ErrMaskedPhylumError)
+	client.ErrorPolicy = shiroclient.ErrorPolicyCodes
+	require.True(t, errors.As(call(), &perr))
+	require.Empty(t, perr.DataJSON)
+	require.EqualError(t, perr, "validation failed")
+}
+
+func TestWrapCallErrorPolicy(t *testing.T) {
+	rpc := &errorRPC{data: `"account exists"`}
+	client := &Client{log: logrus.NewEntry(logrus.New()), rpc: rpc}
+	call := WrapCall(client, "create")
+	_, err := call(context.Background(), map[string]string{}, &map[string]string{})
+	require.EqualError(t, err, "wrap call response error: account exists")
+
+	client.ExposeErrorData = true
+	_, err = call(context.Background(), map[string]string{}, &map[string]string{})
+	var perr *PhylumError
+	require.ErrorAs(t, err, &perr)
+	require.JSONEq(t, `"account exists"`, string(perr.DataJSON))
+
+	client.ErrorPolicy = shiroclient.ErrorPolicyCodes
+	_, err = call(context.Background(), map[string]string{}, &map[string]string{})
+	require.EqualError(t, err, "wrap call response error: validation failed")
+
+	// configs passed to the call take precedence.
+	_, err = call(context.Background(), map[string]string{}, &map[string]string{}, shiroclient.WithErrorPolicy(shiroclient.ErrorPolicyMask))
+	require.ErrorIs(t, err, shiroclient.ErrMaskedPhylumError)
+}
@@ -0,0 +1,57 @@
+package phylum
+
+import (
+	"context"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// HealthStatusUp is the status of an operational service.
+const HealthStatusUp = "UP"
+
+// Health is the result of a health check.
+type Health struct {
+	Reports []*HealthReport
+}
+
+// HealthReport is the health of a service.
+type HealthReport struct {
+	// Timestamp is the time the report was generated (RFC 3339).
+	Timestamp string
+	// Status is the status of the service, HealthStatusUp if it is
+	// operational.
+	Status         string
+	ServiceName    string
+	ServiceVersion string
+}
+
+// Healthy returns true if every reported service is up.
+func (h *Health) Healthy() bool {
+	for _, r := range h.Reports {
+		if r.Status != HealthStatusUp {
+			return false
+		}
+	}
+	return true
+}
+
+// Health performs a health check of the phylum and the upstream services.
+// See shiroclient.RemoteHealthCheck.  The healthpb package converts the
+// result to the healthcheck proto.
+func (s *Client) Health(ctx context.Context, services []string, config ...Config) (*Health, error) {
+	resp, err := shiroclient.RemoteHealthCheck(ctx, s.rpc, services, config...)
+	if err != nil {
+		return nil, err
+	}
+	reports := resp.Reports()
+	health := &Health{Reports: make([]*HealthReport, len(reports))}
+	for i, report := range reports {
+		health.Reports[i] = &HealthReport{
+			Timestamp:      report.Timestamp(),
+			Status:         report.Status(),
+			ServiceName:    report.ServiceName(),
+			ServiceVersion: report.ServiceVersion(),
+		}
+	}
+	return health, nil
+}
@@ -0,0 +1,41 @@
+package phylum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// healthRPC serves a fixed phylum health check.
+type healthRPC struct {
+	shiroclient.ShiroClient
+	result string
+}
+
+func (h *healthRPC) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	return types.NewSuccessResponse([]byte(h.result), "", 0, 0), nil
+}
+
+func TestHealth(t *testing.T) {
+	rpc := &healthRPC{result: `{"reports": [
+		{"timestamp": "2026-01-01T00:00:00Z", "status": "UP", "service_name": "phylum", "service_version": "v1"}
+	]}`}
+	client := &Client{log: logrus.NewEntry(logrus.New()), rpc: rpc}
+	health, err := client.Health(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, &Health{Reports: []*HealthReport{{
+		Timestamp:      "2026-01-01T00:00:00Z",
+		Status:         HealthStatusUp,
+		ServiceName:    "phylum",
+		ServiceVersion: "v1",
+	}}}, health)
+	require.True(t, health.Healthy())
+
+	health.Reports[0].Status = "DOWN"
+	require.False(t, health.Healthy())
+}
@@ -0,0 +1,37 @@
+// Package healthpb converts phylum health checks to the healthcheck proto.
+// It is separate from the phylum package so that clients that do not serve
+// the proto do not depend on its module.
+package healthpb
+
+import (
+	"context"
+
+	healthcheck "buf.build/gen/go/luthersystems/protos/protocolbuffers/go/healthcheck/v1"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/phylum"
+)
+
+// FromHealth converts a health check to its proto.
+func FromHealth(health *phylum.Health) *healthcheck.GetHealthCheckResponse {
+	resp := &healthcheck.GetHealthCheckResponse{
+		Reports: make([]*healthcheck.HealthCheckReport, len(health.Reports)),
+	}
+	for i, report := range health.Reports {
+		resp.Reports[i] = &healthcheck.HealthCheckReport{
+			Timestamp:      report.Timestamp,
+			Status:         report.Status,
+			ServiceName:    report.ServiceName,
+			ServiceVersion: report.ServiceVersion,
+		}
+	}
+	return resp
+}
+
+// GetHealthCheck performs a health check with client and returns it as a
+// proto.  See phylum.Client.Health.
+func GetHealthCheck(ctx context.Context, client *phylum.Client, services []string, config ...phylum.Config) (*healthcheck.GetHealthCheckResponse, error) {
+	health, err := client.Health(ctx, services, config...)
+	if err != nil {
+		return nil, err
+	}
+	return FromHealth(health), nil
+}
@@ -0,0 +1,23 @@
+package healthpb_test
+
+import (
+	"testing"
+
+	healthcheck "buf.build/gen/go/luthersystems/protos/protocolbuffers/go/healthcheck/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/phylum"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/phylum/healthpb"
+)
+
+func TestFromHealth(t *testing.T) {
+	resp := healthpb.FromHealth(&phylum.Health{Reports: []*phylum.HealthReport{
+		{Timestamp: "2026-01-01T00:00:00Z", Status: "UP", ServiceName: "phylum", ServiceVersion: "v1"},
+	}})
+	require.True(t, proto.Equal(&healthcheck.GetHealthCheckResponse{
+		Reports: []*healthcheck.HealthCheckReport{
+			{Timestamp: "2026-01-01T00:00:00Z", Status: "UP", ServiceName: "phylum", ServiceVersion: "v1"},
+		},
+	}, resp))
+}
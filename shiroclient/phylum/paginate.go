@@ -0,0 +1,120 @@
+package phylum
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Pager describes the pagination of a phylum list method.
+type Pager[K proto.Message, R proto.Message, T any] struct {
+	// NewResponse returns an empty response message.
+	NewResponse func() R
+	// SetPageToken sets the page token of a request.  It is called on a
+	// copy of the request passed to Paginate.
+	SetPageToken func(req K, token string)
+	// Extract returns the items of a response and the token of the next
+	// page, or an empty token after the last page.
+	Extract func(resp R) (items []T, next string)
+	// PageConfigs, if set, returns configs for the call fetching page n,
+	// counting from 0, applied after the configs passed to Paginate.
+	PageConfigs func(n int) []Config
+}
+
+// Iterator iterates over the items of a paginated phylum list method.
+// Pages are fetched as items are consumed.
+//
+//	it := phylum.Paginate(client, ctx, "list_accounts", req, pager)
+//	for it.Next() {
+//		account := it.Item()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	fetch func(n int, token string) ([]T, string, error)
+	page  int
+	token string
+	done  bool
+	items []T
+	item  T
+	err   error
+}
+
+// Paginate returns an iterator over the items of method, calling it with
+// req and the page token of each successive page until a page has no next
+// token.  configs are applied to every call.  Iteration stops with an
+// error if a call fails, ctx is done, or a page repeats the token it was
+// fetched with.
+func Paginate[K proto.Message, R proto.Message, T any](s *Client, ctx context.Context, methodName string, req K, pager Pager[K, R, T], config ...Config) *Iterator[T] {
+	return &Iterator[T]{
+		fetch: func(n int, token string) ([]T, string, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, "", err
+			}
+			pageReq := proto.Clone(req).(K)
+			pager.SetPageToken(pageReq, token)
+			configs := config
+			if pager.PageConfigs != nil {
+				configs = append(append([]Config(nil), config...), pager.PageConfigs(n)...)
+			}
+			resp, err := Call(s, ctx, methodName, pageReq, pager.NewResponse(), configs...)
+			if err != nil {
+				return nil, "", fmt.Errorf("page %d: %w", n, err)
+			}
+			items, next := pager.Extract(resp)
+			if next != "" && next == token {
+				return nil, "", fmt.Errorf("page %d: next page token %q repeats the page token", n, next)
+			}
+			return items, next, nil
+		},
+	}
+}
+
+// Next advances to the next item, fetching the next page if needed.  It
+// returns false when the items are exhausted or an error occurred.
+func (it *Iterator[T]) Next() bool {
+	for len(it.items) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		items, next, err := it.fetch(it.page, it.token)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page++
+		it.items = items
+		it.token = next
+		it.done = next == ""
+	}
+	it.item = it.items[0]
+	it.items = it.items[1:]
+	return true
+}
+
+// Item returns the current item.
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Pages returns the number of pages fetched so far.
+func (it *Iterator[T]) Pages() int {
+	return it.page
+}
+
+// All consumes the iterator and returns the remaining items.
+func (it *Iterator[T]) All() ([]T, error) {
+	var items []T
+	for it.Next() {
+		items = append(items, it.Item())
+	}
+	return items, it.Err()
+}
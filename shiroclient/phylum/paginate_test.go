@@ -0,0 +1,95 @@
+package phylum
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// pageRPC serves pages of items keyed by page token.
+type pageRPC struct {
+	shiroclient.ShiroClient
+	pages   map[string]string
+	tokens  []string
+	creator []string
+}
+
+func (p *pageRPC) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	params, err := json.Marshal(opt.Params)
+	if err != nil {
+		return nil, err
+	}
+	var reqs []struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(params, &reqs); err != nil {
+		return nil, err
+	}
+	p.tokens = append(p.tokens, reqs[0].Token)
+	p.creator = append(p.creator, opt.Creator)
+	return types.NewSuccessResponse([]byte(p.pages[reqs[0].Token]), "", 0, 0), nil
+}
+
+var stringPager = Pager[*structpb.Struct, *structpb.Struct, string]{
+	NewResponse: func() *structpb.Struct { return &structpb.Struct{} },
+	SetPageToken: func(req *structpb.Struct, token string) {
+		req.Fields["token"] = structpb.NewStringValue(token)
+	},
+	Extract: func(resp *structpb.Struct) ([]string, string) {
+		var items []string
+		for _, v := range resp.Fields["items"].GetListValue().GetValues() {
+			items = append(items, v.GetStringValue())
+		}
+		return items, resp.Fields["next"].GetStringValue()
+	},
+}
+
+func TestPaginate(t *testing.T) {
+	rpc := &pageRPC{pages: map[string]string{
+		"":   `{"items": ["a", "b"], "next": "t1"}`,
+		"t1": `{"items": [], "next": "t2"}`,
+		"t2": `{"items": ["c"]}`,
+	}}
+	client := &Client{log: logrus.NewEntry(logrus.New()), rpc: rpc}
+	req, err := structpb.NewStruct(map[string]interface{}{"filter": "x"})
+	require.NoError(t, err)
+
+	pager := stringPager
+	pager.PageConfigs = func(n int) []Config {
+		if n == 2 {
+			return []Config{shiroclient.WithCreator("last")}
+		}
+		return nil
+	}
+	it := Paginate(client, context.Background(), "list", req, pager)
+	items, err := it.All()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, items)
+	require.Equal(t, 3, it.Pages())
+	require.Equal(t, []string{"", "t1", "t2"}, rpc.tokens)
+	require.Equal(t, []string{"", "", "last"}, rpc.creator)
+	// the request passed to Paginate is not modified.
+	require.NotContains(t, req.Fields, "token")
+
+	// a page repeating its token is an error rather than an endless loop.
+	rpc.pages["t2"] = `{"items": ["c"], "next": "t2"}`
+	items, err = Paginate(client, context.Background(), "list", req, stringPager).All()
+	require.ErrorContains(t, err, "repeats")
+	require.Equal(t, []string{"a", "b"}, items)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Paginate(client, ctx, "list", req, stringPager).All()
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -5,15 +5,17 @@ package phylum
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	healthcheck "buf.build/gen/go/luthersystems/protos/protocolbuffers/go/healthcheck/v1"
 	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
 	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/mock"
 	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/txctx"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -45,31 +47,7 @@ func joinConfig(base []func() (Config, error), add []Config) (conf []Config, err
 
 // cmdParams is a helper to construct positional arguments to pass to a shiro cmd.
 func cmdParams(params ...proto.Message) []interface{} {
-	if len(params) == 0 {
-		return []interface{}{}
-	}
-	m := &protojson.MarshalOptions{UseProtoNames: true}
-	jsparams := make([]interface{}, len(params))
-	for i, p := range params {
-		jsparams[i] = &jsProtoMessage{
-			Message: p,
-			m:       m,
-		}
-	}
-	return jsparams
-}
-
-type jsProtoMessage struct {
-	proto.Message
-	m *protojson.MarshalOptions
-}
-
-func (msg *jsProtoMessage) MarshalJSON() ([]byte, error) {
-	b, err := msg.m.Marshal(msg.Message)
-	if err != nil {
-		return nil, err
-	}
-	return b, nil
+	return shiroclient.ProtoParams(params...)
 }
 
 // Client is a phylum client.
@@ -77,7 +55,30 @@ type Client struct {
 	log            *logrus.Entry
 	rpc            shiroclient.ShiroClient
 	GetLogMetadata func(context.Context) logrus.Fields
-	closeFunc      func() error
+	// OnPanic, if set, is called with panics recovered while decoding
+	// phylum results, e.g. to count them in a metric.  The panic is also
+	// logged and returned to the caller as an error.
+	OnPanic func(ctx context.Context, cmd string, value interface{}, stack []byte)
+	// PhylumVersionTTL is the time PhylumVersion caches the phylum
+	// version.  It defaults to DefaultPhylumVersionTTL.
+	PhylumVersionTTL time.Duration
+	// ErrorPolicy determines the detail of the phylum errors returned by
+	// calls, including those made with WrapCall.  By default
+	// (shiroclient.ErrorPolicyMessages) error data that is not a JSON
+	// string is masked to avoid leaking sensitive objects.
+	ErrorPolicy shiroclient.ErrorPolicy
+	// ExposeErrorData makes calls, including those made with WrapCall,
+	// return phylum errors as a *PhylumError carrying the error data, e.g.
+	// structured validation errors for a frontend, if ErrorPolicy is not
+	// set.
+	//
+	// Deprecated: Set ErrorPolicy to shiroclient.ErrorPolicyFull.
+	ExposeErrorData bool
+	closeFunc       func() error
+	cache           atomic.Pointer[responseCache]
+	retry           *unavailableRetry
+	versionMu       sync.Mutex
+	version         versionCache
 }
 
 // New returns a new phylum client.
@@ -126,14 +127,25 @@ func NewMockFrom(phylumPath string, log *logrus.Entry, r io.Reader) (*Client, er
 
 // shiroCall is a helper to make RPC calls.
 func (s *Client) sdkCall(ctx context.Context, cmd string, params interface{}, rep proto.Message, clientConfigs []Config) error {
+	cache := s.cache.Load()
+	cacheKey, cacheable := cache.key(ctx, cmd, params, clientConfigs)
+	// a call depending on an earlier write of its request must observe the
+	// write, which a cached response may predate.
+	cacheable = cacheable && txctx.ID(ctx) == ""
+	if cacheable {
+		if result, ok := cache.get(ctx, s, cmd, cacheKey); ok {
+			return s.decodeResult(ctx, cmd, result, rep)
+		}
+	}
 	clientConfigs, err := joinConfig(defaultConfigs, clientConfigs)
 	if err != nil {
 		return err
 	}
 	configs := make([]Config, 0, len(clientConfigs)+2)
 	configs = append(configs, shiroclient.WithParams(params))
+	configs = append(configs, txctx.Configs(ctx)...)
 	configs = append(configs, clientConfigs...)
-	resp, err := s.rpc.Call(ctx, cmd, configs...)
+	resp, err := s.call(ctx, cmd, configs)
 	if err != nil {
 		if shiroclient.IsTimeoutError(err) {
 			s.logEntry(ctx).WithError(err).Errorf("shiroclient timeout")
@@ -143,34 +155,31 @@ func (s *Client) sdkCall(ctx context.Context, cmd string, params interface{}, re
 	}
 	if e := resp.Error(); e != nil {
 		// json-rpc protocol error
+		policy := s.errorPolicy()
 		s.logEntry(ctx).WithFields(logrus.Fields{
 			"cmd":          cmd,
 			"jsonrpc_code": e.Code(),
 			// IMPORTANT: we cannot log this since it may contain PII.
 			//"jsonrpc_data":    string(jsonResp),
 			"jsonrpc_message": e.Message(),
+			"error_policy":    policy.String(),
 		}).Errorf("json-rpc error received from phylum")
-		// Attempt to extract an error message string in the JSON
-		// response, and bubble up an error that can be displayed on the
-		// frontend. This allows `route-failure` string responses to be
-		// displayed on the frontend.
-		if ejs := e.DataJSON(); ejs != nil {
-			var errMsg string
-			err := json.Unmarshal(ejs, &errMsg)
-			if err == nil {
-				return errors.New(errMsg)
-			}
-		}
-		// The error data wasn't a JSON string message, revert to a masked
-		// error to avoid potentially leaking senstive/confusing objects to the
-		// frontend.
-		return fmt.Errorf("unknown phylum error")
+		return policy.PhylumError(e)
+	}
+	txctx.SetID(ctx, resp.TransactionID())
+	if cacheable {
+		cache.put(cacheKey, resp.ResultJSON())
 	}
-	if rep == nil || len(resp.ResultJSON()) == 0 || string(resp.ResultJSON()) == "null" {
+	return s.decodeResult(ctx, cmd, resp.ResultJSON(), rep)
+}
+
+// decodeResult decodes a phylum result into rep, if there is one.
+func (s *Client) decodeResult(ctx context.Context, cmd string, result []byte, rep proto.Message) error {
+	if rep == nil || len(result) == 0 || string(result) == "null" {
 		// nothing to unmarshal
 		return nil
 	}
-	err = protojson.Unmarshal(resp.ResultJSON(), rep)
+	err := s.unmarshalResult(ctx, cmd, result, rep)
 	if err != nil {
 		s.logEntry(ctx).
 			// IMPORTANT: we cannot log this since it may contain PII.
@@ -181,6 +190,27 @@ func (s *Client) sdkCall(ctx context.Context, cmd string, params interface{}, re
 	return nil
 }
 
+// unmarshalResult decodes a phylum result into rep, converting a panic
+// (e.g. from an unusable message type) into an error.
+func (s *Client) unmarshalResult(ctx context.Context, cmd string, result []byte, rep proto.Message) (err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		stack := debug.Stack()
+		s.logEntry(ctx).
+			WithField("cmd", cmd).
+			WithField("stack", string(stack)).
+			Errorf("panic decoding phylum result: %v", v)
+		if s.OnPanic != nil {
+			s.OnPanic(ctx, cmd, v, stack)
+		}
+		err = status.Errorf(codes.Internal, "panic decoding phylum result: %v", v)
+	}()
+	return protojson.Unmarshal(result, rep)
+}
+
 // MockSnapshot copies the current state of the mock backend out to the supplied
 // io.Writer.
 func (s *Client) MockSnapshot(w io.Writer) error {
@@ -210,35 +240,6 @@ func (s *Client) logEntry(ctx context.Context) *logrus.Entry {
 	return s.log.WithFields(s.logFields(ctx))
 }
 
-// HealthCheck performs health check on phylum.
-func (s *Client) GetHealthCheck(ctx context.Context, services []string, config ...Config) (*healthcheck.GetHealthCheckResponse, error) {
-	resp, err := shiroclient.RemoteHealthCheck(ctx, s.rpc, services, config...)
-	if err != nil {
-		return nil, err
-	}
-	return convertHealthResponse(resp), nil
-}
-
-func convertHealthResponse(health shiroclient.HealthCheck) *healthcheck.GetHealthCheckResponse {
-	reports := health.Reports()
-	healthpb := &healthcheck.GetHealthCheckResponse{
-		Reports: make([]*healthcheck.HealthCheckReport, len(reports)),
-	}
-	for i, report := range reports {
-		healthpb.Reports[i] = convertHealthReport(report)
-	}
-	return healthpb
-}
-
-func convertHealthReport(report shiroclient.HealthCheckReport) *healthcheck.HealthCheckReport {
-	return &healthcheck.HealthCheckReport{
-		Timestamp:      report.Timestamp(),
-		Status:         report.Status(),
-		ServiceName:    report.ServiceName(),
-		ServiceVersion: report.ServiceVersion(),
-	}
-}
-
 // Call sends requests to the phlyum, and returns a response.
 func Call[K proto.Message, R proto.Message](s *Client, ctx context.Context, methodName string, req K, resp R, config ...Config) (R, error) {
 	err := s.sdkCall(ctx, methodName, cmdParams(req), resp, config)
@@ -248,3 +249,19 @@ func Call[K proto.Message, R proto.Message](s *Client, ctx context.Context, meth
 	}
 	return resp, nil
 }
+
+// WrapCall returns a private.CallFunc for a phylum endpoint whose request
+// and response are encoded with transforms.  The client's default configs
+// and error policy (see Client.ErrorPolicy) are applied before the configs
+// passed to the returned function.  See private.WrapCall.
+func WrapCall(s *Client, methodName string, transforms ...*private.Transform) private.CallFunc {
+	wrapped := private.WrapCall(s.rpc, methodName, transforms...)
+	return func(ctx context.Context, message interface{}, output interface{}, config ...Config) (*private.CallResult, error) {
+		config = append([]Config{shiroclient.WithErrorPolicy(s.errorPolicy())}, config...)
+		configs, err := joinConfig(defaultConfigs, config)
+		if err != nil {
+			return nil, err
+		}
+		return wrapped(ctx, message, output, configs...)
+	}
+}
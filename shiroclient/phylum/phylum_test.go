@@ -0,0 +1,62 @@
+package phylum
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/txctx"
+)
+
+// txRPC records the dependencies of calls; calls to "write" produce a
+// transaction.
+type txRPC struct {
+	shiroclient.ShiroClient
+	txs  int
+	deps []string
+}
+
+func (r *txRPC) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	r.deps = append(r.deps, opt.DependentTxID)
+	var txID string
+	if method == "write" {
+		r.txs++
+		txID = fmt.Sprintf("tx%d", r.txs)
+	}
+	return types.NewSuccessResponse([]byte(`"ok"`), txID, 0, 0), nil
+}
+
+func TestTxContext(t *testing.T) {
+	rpc := &txRPC{}
+	client := &Client{log: logrus.NewEntry(logrus.New()), rpc: rpc}
+	call := func(ctx context.Context, method string, configs ...Config) {
+		_, err := Call(client, ctx, method, wrapperspb.String("x"), &wrapperspb.StringValue{}, configs...)
+		require.NoError(t, err)
+	}
+
+	ctx := txctx.ContextWithID(context.Background(), "")
+	call(ctx, "read")
+	call(ctx, "write")
+	call(ctx, "read")
+	call(ctx, "read", shiroclient.WithDependentTxID("explicit"))
+	call(ctx, "write")
+	call(ctx, "read")
+	require.Equal(t, []string{"", "", "tx1", "explicit", "tx1", "tx2"}, rpc.deps)
+	require.Equal(t, "tx2", txctx.ID(ctx))
+
+	// unprepared contexts are unaffected.
+	rpc.deps = nil
+	call(context.Background(), "write")
+	call(context.Background(), "read")
+	require.Equal(t, []string{"", ""}, rpc.deps)
+}
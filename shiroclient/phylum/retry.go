@@ -0,0 +1,91 @@
+package phylum
+
+import (
+	"context"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/backoff"
+)
+
+// DefaultUnavailableBackoff is the delay before the first retry of an
+// UnavailableRetry without a Backoff.
+const DefaultUnavailableBackoff = 100 * time.Millisecond
+
+// UnavailableRetry configures the retry of calls that timed out in the
+// blockchain network.  See Client.EnableUnavailableRetry.
+type UnavailableRetry struct {
+	// Methods are the idempotent phylum methods that are retried.  Calls
+	// with an idempotency key (see shiroclient.WithIdempotencyKey) are
+	// retried whatever their method.
+	Methods []string
+	// MaxAttempts is the maximum number of attempts of a call, including
+	// the first.
+	MaxAttempts int
+	// Backoff is the delay before the first retry.  It doubles after every
+	// retry, up to MaxBackoff if it is positive.  It defaults to
+	// DefaultUnavailableBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// OnRetry, if set, is called before each retry with the number of the
+	// failed attempt, counting from 1, e.g. to count retries in a metric.
+	OnRetry func(ctx context.Context, method string, attempt int, err error)
+}
+
+// EnableUnavailableRetry retries idempotent calls that time out in the
+// blockchain network, which are otherwise returned as codes.Unavailable
+// errors.  The last timeout is returned if every attempt times out.  It
+// must not be called concurrently with calls.
+func (s *Client) EnableUnavailableRetry(retry UnavailableRetry) {
+	if retry.Backoff <= 0 {
+		retry.Backoff = DefaultUnavailableBackoff
+	}
+	methods := make(map[string]bool, len(retry.Methods))
+	for _, m := range retry.Methods {
+		methods[m] = true
+	}
+	s.retry = &unavailableRetry{UnavailableRetry: retry, methods: methods}
+}
+
+type unavailableRetry struct {
+	UnavailableRetry
+	methods map[string]bool
+}
+
+// attempts returns the number of attempts allowed for a call.
+func (r *unavailableRetry) attempts(cmd string, configs []Config) int {
+	if r == nil || r.MaxAttempts <= 1 {
+		return 1
+	}
+	if r.methods[cmd] {
+		return r.MaxAttempts
+	}
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err == nil && opt.IdempotencyKey != "" {
+		return r.MaxAttempts
+	}
+	return 1
+}
+
+// call calls cmd, retrying timeouts if the call is retryable.
+func (s *Client) call(ctx context.Context, cmd string, configs []Config) (shiroclient.ShiroResponse, error) {
+	attempts := s.retry.attempts(cmd, configs)
+	for attempt := 1; ; attempt++ {
+		resp, err := s.rpc.Call(ctx, cmd, configs...)
+		if err == nil || attempt >= attempts || !shiroclient.IsTimeoutError(err) || ctx.Err() != nil {
+			return resp, err
+		}
+		s.logEntry(ctx).WithError(err).
+			WithField("cmd", cmd).
+			WithField("attempt", attempt).
+			Warn("shiroclient timeout, retrying")
+		if s.retry.OnRetry != nil {
+			s.retry.OnRetry(ctx, cmd, attempt, err)
+		}
+		policy := backoff.Policy{Initial: s.retry.Backoff, Max: s.retry.MaxBackoff}
+		if backoff.Sleep(ctx, policy.Delay(attempt)) != nil {
+			return nil, err
+		}
+	}
+}
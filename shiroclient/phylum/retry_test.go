@@ -0,0 +1,83 @@
+package phylum
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/mockgateway"
+)
+
+// timeoutRPC times out a number of calls before succeeding.
+type timeoutRPC struct {
+	shiroclient.ShiroClient
+	mu       sync.Mutex
+	timeouts int
+	calls    int
+}
+
+func (f *timeoutRPC) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.calls <= f.timeouts {
+		return nil, context.DeadlineExceeded
+	}
+	return types.NewSuccessResponse([]byte(`"ok"`), "", 0, 0), nil
+}
+
+func (f *timeoutRPC) reset(timeouts int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.timeouts = timeouts
+	f.calls = 0
+}
+
+func TestUnavailableRetry(t *testing.T) {
+	backend := &timeoutRPC{}
+	gw := mockgateway.NewServer(backend)
+	t.Cleanup(gw.Close)
+	client := &Client{
+		log: logrus.NewEntry(logrus.New()),
+		rpc: shiroclient.NewRPC([]shiroclient.Config{gw.Config()}),
+	}
+	var retries []int
+	client.EnableUnavailableRetry(UnavailableRetry{
+		Methods:     []string{"get"},
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		OnRetry:     func(ctx context.Context, method string, attempt int, err error) { retries = append(retries, attempt) },
+	})
+	call := func(method string, configs ...Config) error {
+		_, err := Call(client, context.Background(), method, wrapperspb.String("x"), &wrapperspb.StringValue{}, configs...)
+		return err
+	}
+
+	backend.reset(2)
+	require.NoError(t, call("get"))
+	require.Equal(t, []int{1, 2}, retries)
+
+	// attempts are bounded.
+	retries = nil
+	backend.reset(3)
+	require.Equal(t, codes.Unavailable, status.Code(call("get")))
+	require.Equal(t, []int{1, 2}, retries)
+
+	// other methods are not retried unless they carry an idempotency key.
+	retries = nil
+	backend.reset(1)
+	require.Equal(t, codes.Unavailable, status.Code(call("put")))
+	require.Empty(t, retries)
+	backend.reset(1)
+	require.NoError(t, call("put", shiroclient.WithIdempotencyKey("k")))
+	require.Equal(t, []int{1}, retries)
+}
@@ -0,0 +1,93 @@
+package phylum
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultPhylumVersionTTL is the time a phylum version is cached when
+// Client.PhylumVersionTTL is not set.
+const DefaultPhylumVersionTTL = time.Minute
+
+// versionCache caches the phylum version of a client.
+type versionCache struct {
+	version string
+	fetched time.Time
+	// fetch is the fetch in flight, if any.
+	fetch *versionFetch
+}
+
+// versionFetch is a fetch of the phylum version shared by concurrent
+// callers.
+type versionFetch struct {
+	// done is closed when version and err are set.
+	done    chan struct{}
+	version string
+	err     error
+	// canceled is set if the fetch failed because its context was done,
+	// in which case waiting callers fetch again.
+	canceled bool
+}
+
+// PhylumVersion returns the version of the phylum, as returned by
+// ShiroPhylum.  The version is cached for PhylumVersionTTL, so services
+// stamping responses with it do not call the gateway on every request.
+// Call InvalidatePhylumVersion after installing a new phylum version, e.g.
+// with update.Install, to see it before the cached version expires.
+// Concurrent callers wait for a single fetch and share its result, or
+// return early once their own ctx is done.
+func (s *Client) PhylumVersion(ctx context.Context, config ...Config) (string, error) {
+	ttl := s.PhylumVersionTTL
+	if ttl <= 0 {
+		ttl = DefaultPhylumVersionTTL
+	}
+	for {
+		s.versionMu.Lock()
+		if !s.version.fetched.IsZero() && time.Since(s.version.fetched) < ttl {
+			version := s.version.version
+			s.versionMu.Unlock()
+			return version, nil
+		}
+		f := s.version.fetch
+		if f == nil {
+			f = &versionFetch{done: make(chan struct{})}
+			s.version.fetch = f
+			s.versionMu.Unlock()
+			return s.fetchPhylumVersion(ctx, f, config)
+		}
+		s.versionMu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		if !f.canceled {
+			return f.version, f.err
+		}
+	}
+}
+
+// fetchPhylumVersion makes the fetch f and caches its result, unless the
+// cache was invalidated in the meantime.
+func (s *Client) fetchPhylumVersion(ctx context.Context, f *versionFetch, config []Config) (string, error) {
+	f.version, f.err = s.rpc.ShiroPhylum(ctx, config...)
+	f.canceled = f.err != nil && ctx.Err() != nil
+	s.versionMu.Lock()
+	if s.version.fetch == f {
+		s.version.fetch = nil
+		if f.err == nil {
+			s.version = versionCache{version: f.version, fetched: time.Now()}
+		}
+	}
+	s.versionMu.Unlock()
+	close(f.done)
+	return f.version, f.err
+}
+
+// InvalidatePhylumVersion discards the cached phylum version, so the next
+// call to PhylumVersion fetches it.
+func (s *Client) InvalidatePhylumVersion() {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	s.version = versionCache{}
+}
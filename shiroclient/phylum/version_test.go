@@ -0,0 +1,121 @@
+package phylum
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// versionRPC reports a settable phylum version and counts the queries,
+// blocking them until release is closed if it is set.
+type versionRPC struct {
+	shiroclient.ShiroClient
+	version string
+	err     error
+	release chan struct{}
+
+	mu      sync.Mutex
+	queries int
+}
+
+func (v *versionRPC) ShiroPhylum(ctx context.Context, configs ...shiroclient.Config) (string, error) {
+	v.mu.Lock()
+	v.queries++
+	v.mu.Unlock()
+	if v.release != nil {
+		select {
+		case <-v.release:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return v.version, v.err
+}
+
+func (v *versionRPC) count() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.queries
+}
+
+func TestPhylumVersion(t *testing.T) {
+	rpc := &versionRPC{version: "v1"}
+	client := &Client{log: logrus.NewEntry(logrus.New()), rpc: rpc, PhylumVersionTTL: 20 * time.Millisecond}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		version, err := client.PhylumVersion(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "v1", version)
+	}
+	require.Equal(t, 1, rpc.count())
+
+	rpc.version = "v2"
+	client.InvalidatePhylumVersion()
+	version, err := client.PhylumVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "v2", version)
+	require.Equal(t, 2, rpc.count())
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = client.PhylumVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, rpc.count())
+}
+
+func TestPhylumVersionConcurrent(t *testing.T) {
+	rpc := &versionRPC{version: "v1", err: errors.New("unavailable"), release: make(chan struct{})}
+	client := &Client{log: logrus.NewEntry(logrus.New()), rpc: rpc}
+	ctx := context.Background()
+
+	// waiters share a failed fetch.
+	const callers = 4
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			_, err := client.PhylumVersion(ctx)
+			errs <- err
+		}()
+	}
+	require.Eventually(t, func() bool { return rpc.count() == 1 }, time.Second, time.Millisecond)
+
+	// a waiter returns when its own context is done.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := client.PhylumVersion(cctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	close(rpc.release)
+	for i := 0; i < callers; i++ {
+		require.EqualError(t, <-errs, "unavailable")
+	}
+	require.Equal(t, 1, rpc.count())
+
+	// waiters fetch again when the fetch they wait for is canceled.
+	rpc.err = nil
+	rpc.release = make(chan struct{})
+	cctx, cancel = context.WithCancel(ctx)
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.PhylumVersion(cctx)
+		first <- err
+	}()
+	require.Eventually(t, func() bool { return rpc.count() == 2 }, time.Second, time.Millisecond)
+	versions := make(chan string, 1)
+	go func() {
+		version, _ := client.PhylumVersion(ctx)
+		versions <- version
+	}()
+	cancel()
+	require.ErrorIs(t, <-first, context.Canceled)
+	require.Eventually(t, func() bool { return rpc.count() == 3 }, time.Second, time.Millisecond)
+	close(rpc.release)
+	require.Equal(t, "v1", <-versions)
+}
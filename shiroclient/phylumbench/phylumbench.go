@@ -0,0 +1,264 @@
+// Package phylumbench measures the performance of phylum methods, typically
+// against a mock client, so that regressions are caught before deployment.
+//
+//	client, _ := shiroclient.NewMock(nil)
+//	_ = client.Init(ctx, shiroclient.EncodePhylumBytes(phylum))
+//	res, err := phylumbench.Run(ctx, client, phylumbench.Options{
+//		Method:      "create_account",
+//		Concurrency: 8,
+//		Requests:    1000,
+//		Params: func(worker, i int) interface{} {
+//			return []interface{}{map[string]interface{}{"id": fmt.Sprint(worker, "-", i)}}
+//		},
+//	})
+//	fmt.Println(res)
+package phylumbench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// Options configures a benchmark run.
+type Options struct {
+	// Method is the phylum method to call.
+	Method string
+	// Concurrency is the number of concurrent callers.  Defaults to 1.
+	Concurrency int
+	// Requests is the total number of calls.  If zero, calls are made
+	// until Duration elapses.
+	Requests int
+	// Duration bounds the run when Requests is zero.
+	Duration time.Duration
+	// Params generates the params of call i of a worker, if not nil.
+	Params func(worker, i int) interface{}
+	// Transient generates the transient data of call i of a worker, if not
+	// nil.
+	Transient func(worker, i int) map[string][]byte
+	// Configs are additional configs for each call.
+	Configs []shiroclient.Config
+}
+
+// Percentiles summarizes a distribution of durations.
+type Percentiles struct {
+	Min  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+func (p Percentiles) String() string {
+	return fmt.Sprintf("min=%v mean=%v p50=%v p90=%v p99=%v max=%v",
+		p.Min, p.Mean, p.P50, p.P90, p.P99, p.Max)
+}
+
+// Summarize returns the percentiles of samples, sorting them in place.
+func Summarize(samples []time.Duration) Percentiles {
+	if len(samples) == 0 {
+		return Percentiles{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	var total time.Duration
+	for _, d := range samples {
+		total += d
+	}
+	at := func(q float64) time.Duration {
+		return samples[int(q*float64(len(samples)-1))]
+	}
+	return Percentiles{
+		Min:  samples[0],
+		Mean: total / time.Duration(len(samples)),
+		P50:  at(0.50),
+		P90:  at(0.90),
+		P99:  at(0.99),
+		Max:  samples[len(samples)-1],
+	}
+}
+
+// Result reports the outcome of a benchmark run.
+type Result struct {
+	// Requests is the number of calls made.
+	Requests int
+	// Errors is the number of calls that returned an error.
+	Errors int
+	// PhylumErrors is the number of calls whose response contained a
+	// phylum error.
+	PhylumErrors int
+	// Elapsed is the wall time of the run.
+	Elapsed time.Duration
+	// Latency is the distribution of call latency seen by the caller.
+	Latency Percentiles
+	// SubstrateTime is the distribution of time spent in substrate per
+	// call.  It is only measured for mock clients.
+	SubstrateTime Percentiles
+	// FirstError is the first error returned by a call, if any.
+	FirstError error
+}
+
+// Throughput returns the number of calls per second.
+func (r *Result) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+func (r *Result) String() string {
+	s := fmt.Sprintf("requests=%d errors=%d phylum_errors=%d elapsed=%v throughput=%.1f/s\nlatency: %v",
+		r.Requests, r.Errors, r.PhylumErrors, r.Elapsed, r.Throughput(), r.Latency)
+	if r.SubstrateTime != (Percentiles{}) {
+		s += "\nsubstrate: " + r.SubstrateTime.String()
+	}
+	return s
+}
+
+// sample is the measurement of a single call.
+type sample struct {
+	latency   time.Duration
+	substrate time.Duration
+	err       error
+	phylumErr bool
+}
+
+// withSubstrateTimer records the substrate time of a mock call.
+func withSubstrateTimer(d *time.Duration) shiroclient.Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.SubstrateTimer = func(t time.Duration) { *d = t }
+	})
+}
+
+func call(ctx context.Context, client shiroclient.ShiroClient, opts *Options, worker, i int) sample {
+	var s sample
+	configs := make([]shiroclient.Config, 0, len(opts.Configs)+3)
+	if opts.Params != nil {
+		configs = append(configs, shiroclient.WithParams(opts.Params(worker, i)))
+	}
+	if opts.Transient != nil {
+		configs = append(configs, shiroclient.WithTransientDataMap(opts.Transient(worker, i)))
+	}
+	configs = append(configs, opts.Configs...)
+	configs = append(configs, withSubstrateTimer(&s.substrate))
+	start := time.Now()
+	resp, err := client.Call(ctx, opts.Method, configs...)
+	s.latency = time.Since(start)
+	s.err = err
+	s.phylumErr = err == nil && resp.Error() != nil
+	return s
+}
+
+// Run calls opts.Method on client with opts.Concurrency workers until
+// opts.Requests calls are made or opts.Duration elapses.  Call errors are
+// counted in the result rather than stopping the run; Run only returns an
+// error for invalid options.
+func Run(ctx context.Context, client shiroclient.ShiroClient, opts Options) (*Result, error) {
+	if opts.Method == "" {
+		return nil, errors.New("phylumbench: method is required")
+	}
+	if opts.Requests <= 0 && opts.Duration <= 0 {
+		return nil, errors.New("phylumbench: requests or duration is required")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Requests <= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	var (
+		mu      sync.Mutex
+		samples []sample
+		next    int
+		wg      sync.WaitGroup
+	)
+	// claim reserves the next call, reporting false when the run is over.
+	claim := func() bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if opts.Requests > 0 && next >= opts.Requests {
+			return false
+		}
+		next++
+		return true
+	}
+	start := time.Now()
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; claim(); i++ {
+				s := call(ctx, client, &opts, worker, i)
+				if opts.Requests <= 0 && ctx.Err() != nil {
+					// the call was cut short by the end of the run.
+					return
+				}
+				mu.Lock()
+				samples = append(samples, s)
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	res := &Result{Elapsed: time.Since(start), Requests: len(samples)}
+	latency := make([]time.Duration, 0, len(samples))
+	var substrate []time.Duration
+	for _, s := range samples {
+		latency = append(latency, s.latency)
+		if s.substrate > 0 {
+			substrate = append(substrate, s.substrate)
+		}
+		if s.err != nil {
+			res.Errors++
+			if res.FirstError == nil {
+				res.FirstError = s.err
+			}
+		} else if s.phylumErr {
+			res.PhylumErrors++
+		}
+	}
+	res.Latency = Summarize(latency)
+	res.SubstrateTime = Summarize(substrate)
+	return res, nil
+}
+
+// Bench runs b.N calls as a Go benchmark and reports latency percentiles
+// and substrate time as custom metrics.  The benchmark fails if any call
+// returns an error.
+//
+//	func BenchmarkCreateAccount(b *testing.B) {
+//		phylumbench.Bench(b, client, phylumbench.Options{Method: "create_account"})
+//	}
+func Bench(b *testing.B, client shiroclient.ShiroClient, opts Options) *Result {
+	b.Helper()
+	opts.Requests = b.N
+	b.ResetTimer()
+	res, err := Run(context.Background(), client, opts)
+	b.StopTimer()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if res.Errors > 0 {
+		b.Fatalf("phylumbench: %d of %d calls failed: %v", res.Errors, res.Requests, res.FirstError)
+	}
+	b.ReportMetric(float64(res.Latency.P50.Nanoseconds()), "p50-ns/op")
+	b.ReportMetric(float64(res.Latency.P99.Nanoseconds()), "p99-ns/op")
+	if res.SubstrateTime != (Percentiles{}) {
+		b.ReportMetric(float64(res.SubstrateTime.Mean.Nanoseconds()), "substrate-ns/op")
+	}
+	return res
+}
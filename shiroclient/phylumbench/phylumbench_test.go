@@ -0,0 +1,99 @@
+package phylumbench_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/phylumbench"
+)
+
+// gateway counts calls and returns a phylum error for odd params.
+func gateway(t testing.TB, calls *int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params struct {
+				Params []int `json:"params"`
+			} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		atomic.AddInt32(calls, 1)
+		level := 0
+		if req.Params.Params[0]%2 == 1 {
+			level = 2
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      "1",
+			"result": map[string]interface{}{
+				"error_level": level,
+				"result":      "ok",
+				"code":        0,
+				"message":     "",
+				"data":        nil,
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRun(t *testing.T) {
+	var calls int32
+	srv := gateway(t, &calls)
+	client := shiroclient.NewRPC([]shiroclient.Config{shiroclient.WithEndpoint(srv.URL)})
+	res, err := phylumbench.Run(context.Background(), client, phylumbench.Options{
+		Method:      "bench",
+		Concurrency: 4,
+		Requests:    40,
+		Params: func(worker, i int) interface{} {
+			return []int{i}
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 40, res.Requests)
+	require.Equal(t, int32(40), atomic.LoadInt32(&calls))
+	require.Equal(t, 0, res.Errors)
+	require.Greater(t, res.PhylumErrors, 0)
+	require.Less(t, res.PhylumErrors, 40)
+	require.LessOrEqual(t, res.Latency.Min, res.Latency.P50)
+	require.LessOrEqual(t, res.Latency.P50, res.Latency.P99)
+	require.LessOrEqual(t, res.Latency.P99, res.Latency.Max)
+	require.Equal(t, phylumbench.Percentiles{}, res.SubstrateTime)
+	require.Contains(t, res.String(), "requests=40")
+}
+
+func TestRunDuration(t *testing.T) {
+	var calls int32
+	srv := gateway(t, &calls)
+	client := shiroclient.NewRPC([]shiroclient.Config{shiroclient.WithEndpoint(srv.URL)})
+	res, err := phylumbench.Run(context.Background(), client, phylumbench.Options{
+		Method:   "bench",
+		Duration: 50 * time.Millisecond,
+		Params:   func(worker, i int) interface{} { return []int{0} },
+	})
+	require.NoError(t, err)
+	require.Greater(t, res.Requests, 0)
+	require.Equal(t, 0, res.Errors)
+
+	_, err = phylumbench.Run(context.Background(), client, phylumbench.Options{Method: "bench"})
+	require.Error(t, err)
+}
+
+func BenchmarkGateway(b *testing.B) {
+	var calls int32
+	srv := gateway(b, &calls)
+	client := shiroclient.NewRPC([]shiroclient.Config{shiroclient.WithEndpoint(srv.URL)})
+	phylumbench.Bench(b, client, phylumbench.Options{
+		Method:      "bench",
+		Concurrency: 2,
+		Params:      func(worker, i int) interface{} { return []int{0} },
+	})
+}
@@ -0,0 +1,107 @@
+package private
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// DefaultDecodeParallelism is the number of concurrent decode calls of
+// DecodeAll when DecodeAllOptions.Parallelism is not set.
+const DefaultDecodeParallelism = 8
+
+// DecodeAllOptions configures DecodeAll.
+type DecodeAllOptions struct {
+	// Parallelism bounds the number of concurrent decode calls.
+	Parallelism int
+	// DependentTxID is the transaction the decode calls depend on, typically
+	// the one that returned the encoded messages.  It is set on every
+	// decode call, so that they all simulate against a ledger including it.
+	DependentTxID string
+	// Configs are applied to every decode call.
+	Configs []shiroclient.Config
+}
+
+// DecodeAllError is returned by DecodeAll when a message fails to decode.
+type DecodeAllError struct {
+	// Index is the index of the message.
+	Index int
+	Err   error
+}
+
+// Error implements error.
+func (e *DecodeAllError) Error() string {
+	return fmt.Sprintf("decode message %d: %s", e.Index, e.Err)
+}
+
+// Unwrap returns the decode error.
+func (e *DecodeAllError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeAll decodes encoded[i] into dsts[i] for each message, like Decode,
+// with bounded parallelism.  Messages encoded without transforms are
+// decoded without calling substrate.  DecodeAll stops at the first error,
+// which is returned as a *DecodeAllError; dsts of other messages may or may
+// not have been decoded.  opts may be nil.
+func DecodeAll(ctx context.Context, client shiroclient.ShiroClient, encoded []*EncodedResponse, dsts []interface{}, opts *DecodeAllOptions) error {
+	if len(encoded) != len(dsts) {
+		return fmt.Errorf("%d encoded messages but %d destinations", len(encoded), len(dsts))
+	}
+	if opts == nil {
+		opts = &DecodeAllOptions{}
+	}
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = DefaultDecodeParallelism
+	}
+	configs := opts.Configs
+	if opts.DependentTxID != "" {
+		configs = appendConfigs(configs, shiroclient.WithDependentTxID(opts.DependentTxID))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(i int, err error) {
+		errOnce.Do(func() {
+			firstErr = &DecodeAllError{Index: i, Err: err}
+			cancel()
+		})
+	}
+	sem := make(chan struct{}, parallelism)
+	for i, enc := range encoded {
+		if enc != nil && enc.encodedMessage == nil {
+			// fast path, no call needed.
+			if err := Decode(ctx, client, enc, dsts[i], configs...); err != nil {
+				fail(i, err)
+				break
+			}
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			fail(i, ctx.Err())
+			break
+		}
+		wg.Add(1)
+		go func(i int, enc *EncodedResponse) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := Decode(ctx, client, enc, dsts[i], configs...); err != nil {
+				fail(i, err)
+			}
+		}(i, enc)
+	}
+	wg.Wait()
+	return firstErr
+}
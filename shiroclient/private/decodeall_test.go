@@ -0,0 +1,98 @@
+package private_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private/privatetest"
+)
+
+// concurrencyClient records the peak number of concurrent decode calls
+// and their dependent transactions.
+type concurrencyClient struct {
+	*privatetest.Client
+	mu         sync.Mutex
+	active     int
+	peak       int
+	dependents map[string]int
+}
+
+func (c *concurrencyClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	if method != private.ShiroEndpointDecode {
+		return c.Client.Call(ctx, method, configs...)
+	}
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.active++
+	if c.active > c.peak {
+		c.peak = c.active
+	}
+	c.dependents[opt.DependentTxID]++
+	c.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	defer func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.active--
+	}()
+	return c.Client.Call(ctx, method, configs...)
+}
+
+func TestDecodeAll(t *testing.T) {
+	ctx := context.Background()
+	client := &concurrencyClient{Client: privatetest.New(), dependents: make(map[string]int)}
+	transforms := []*private.Transform{{
+		ContextPath: ".",
+		Header: &private.TransformHeader{
+			ProfilePaths: []string{".id"},
+			PrivatePaths: []string{"."},
+			Encryptor:    private.EncryptorAES256,
+			Compressor:   private.CompressorZlib,
+		},
+	}}
+	var encoded []*private.EncodedResponse
+	var dsts []interface{}
+	for i := 0; i < 20; i++ {
+		transforms := transforms
+		if i%5 == 0 {
+			// unencoded messages take the fast path.
+			transforms = nil
+		}
+		enc, err := private.Encode(ctx, client, map[string]int{"id": i}, transforms)
+		require.NoError(t, err)
+		encoded = append(encoded, enc)
+		dsts = append(dsts, &map[string]int{})
+	}
+
+	err := private.DecodeAll(ctx, client, encoded, dsts, &private.DecodeAllOptions{Parallelism: 3, DependentTxID: "tx1"})
+	require.NoError(t, err)
+	for i, dst := range dsts {
+		require.Equal(t, map[string]int{"id": i}, *dst.(*map[string]int))
+	}
+	require.LessOrEqual(t, client.peak, 3)
+	require.Equal(t, map[string]int{"tx1": 16}, client.dependents)
+
+	require.Error(t, private.DecodeAll(ctx, client, encoded, dsts[1:], nil))
+
+	// the index of the failed message is reported.
+	bad := &private.EncodedResponse{}
+	require.NoError(t, json.Unmarshal([]byte(`{"mxf": "unknown", "message": {}, "transforms": []}`), bad))
+	encoded[7] = bad
+	err = private.DecodeAll(ctx, client, encoded, dsts, nil)
+	var derr *private.DecodeAllError
+	require.True(t, errors.As(err, &derr), fmt.Sprint(err))
+	require.Equal(t, 7, derr.Index)
+}
@@ -0,0 +1,47 @@
+package private_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+)
+
+// failingClient fails calls with fixed error data.
+type failingClient struct {
+	shiroclient.ShiroClient
+}
+
+func (c *failingClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	return types.NewFailureResponse(400, "bad request", []byte(`{"email": "a@example.com"}`)), nil
+}
+
+func TestErrorPolicy(t *testing.T) {
+	ctx := context.Background()
+	client := &failingClient{}
+
+	// codes are exposed by default.
+	_, err := private.Export(ctx, client, "123")
+	var perr *shiroclient.PhylumError
+	require.True(t, errors.As(err, &perr))
+	require.Equal(t, 400, perr.Code)
+	require.Empty(t, perr.DataJSON)
+	require.EqualError(t, err, "bad request")
+
+	err = private.Purge(ctx, client, "123", shiroclient.WithErrorPolicy(shiroclient.ErrorPolicyMask))
+	require.ErrorIs(t, err, shiroclient.ErrMaskedPhylumError)
+
+	_, err = private.ProfileToDSID(ctx, client, nil, shiroclient.WithErrorPolicy(shiroclient.ErrorPolicyFull))
+	require.True(t, errors.As(err, &perr))
+	require.JSONEq(t, `{"email": "a@example.com"}`, string(perr.DataJSON))
+
+	call := private.WrapCall(client, "create")
+	_, err = call(ctx, map[string]string{}, &map[string]string{}, shiroclient.WithErrorPolicy(shiroclient.ErrorPolicyMask))
+	require.ErrorIs(t, err, shiroclient.ErrMaskedPhylumError)
+	require.EqualError(t, err, "wrap call response error: unknown phylum error")
+}
@@ -0,0 +1,256 @@
+package private
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// ExportFormatVersion is the version of the export archive format written
+// by ExportArchive.
+const ExportFormatVersion = 1
+
+// ExportManifestPath is the path of the manifest in an export archive.
+const ExportManifestPath = "manifest.json"
+
+// ErrUnsupportedExportVersion is returned when reading an export archive
+// of a format version newer than ExportFormatVersion.
+var ErrUnsupportedExportVersion = errors.New("unsupported export format version")
+
+// ExportManifest describes the contents of an export archive.
+type ExportManifest struct {
+	FormatVersion int  `json:"format_version"`
+	DSID          DSID `json:"dsid"`
+	// Namespace is the DSID namespace of the export, if any.
+	Namespace string    `json:"namespace,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Entries are the exported keys, sorted by key.
+	Entries []*ExportEntry `json:"entries"`
+}
+
+// ExportEntry describes the file holding the exported data of a key.
+type ExportEntry struct {
+	Key  string `json:"key"`
+	Path string `json:"path"`
+	// SHA256 is the hex encoded SHA-256 digest of the file.
+	SHA256 string `json:"sha256"`
+}
+
+// ExportArchive exports the data of the data subject with ID "dsid", like
+// Export, and writes it to w as a ZIP archive suitable for delivering a
+// data subject access request.  The archive holds a manifest, at
+// ExportManifestPath, and the data of each exported key as a JSON file
+// under "data/".  The manifest is returned.
+func ExportArchive(ctx context.Context, client shiroclient.ShiroClient, dsid DSID, w io.Writer, configs ...shiroclient.Config) (*ExportManifest, error) {
+	exported, err := Export(ctx, client, dsid, configs...)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &ExportManifest{
+		FormatVersion: ExportFormatVersion,
+		DSID:          dsid,
+		Namespace:     namespaceOf(callOptions(configs)),
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+	keys := make([]string, 0, len(exported))
+	for key := range exported {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	zw := zip.NewWriter(w)
+	create := func(path string, data []byte) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     path,
+			Method:   zip.Deflate,
+			Modified: manifest.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+	for _, key := range keys {
+		data, err := json.MarshalIndent(exported[key], "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("export key %s: %w", key, err)
+		}
+		entry := &ExportEntry{
+			Key:    key,
+			Path:   "data/" + url.PathEscape(key) + ".json",
+			SHA256: digest(data),
+		}
+		if err := create(entry.Path, data); err != nil {
+			return nil, err
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := create(ExportManifestPath, data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ExportedData is the verified contents of an export archive.
+type ExportedData struct {
+	Manifest *ExportManifest
+	// Data maps the exported keys to their data.
+	Data map[string]json.RawMessage
+}
+
+// ReadExportArchive reads and verifies an export archive written by
+// ExportArchive.  It fails if the format version is unsupported, if a
+// file listed in the manifest is missing or does not match its digest, or
+// if the archive holds files not listed in the manifest.
+func ReadExportArchive(r io.ReaderAt, size int64) (*ExportedData, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	read := func(path string) ([]byte, error) {
+		f, ok := files[path]
+		if !ok {
+			return nil, fmt.Errorf("export archive: missing %s", path)
+		}
+		delete(files, path)
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	data, err := read(ExportManifestPath)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &ExportManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("export archive: invalid manifest: %w", err)
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > ExportFormatVersion {
+		return nil, fmt.Errorf("export archive: %w %d", ErrUnsupportedExportVersion, manifest.FormatVersion)
+	}
+	exported := &ExportedData{
+		Manifest: manifest,
+		Data:     make(map[string]json.RawMessage, len(manifest.Entries)),
+	}
+	for _, entry := range manifest.Entries {
+		if _, ok := exported.Data[entry.Key]; ok {
+			return nil, fmt.Errorf("export archive: duplicate key %s", entry.Key)
+		}
+		data, err := read(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		if digest(data) != entry.SHA256 {
+			return nil, fmt.Errorf("export archive: digest mismatch for %s", entry.Path)
+		}
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("export archive: invalid JSON in %s", entry.Path)
+		}
+		exported.Data[entry.Key] = data
+	}
+	if len(files) > 0 {
+		extra := make([]string, 0, len(files))
+		for path := range files {
+			extra = append(extra, path)
+		}
+		sort.Strings(extra)
+		return nil, fmt.Errorf("export archive: unexpected files %v", extra)
+	}
+	return exported, nil
+}
+
+// ExportChangeKind is the kind of an ExportChange.
+type ExportChangeKind string
+
+// Kinds of export changes.
+const (
+	ExportKeyAdded   ExportChangeKind = "added"
+	ExportKeyRemoved ExportChangeKind = "removed"
+	ExportKeyChanged ExportChangeKind = "changed"
+)
+
+// ExportChange is a difference between the data of a key in two exports.
+type ExportChange struct {
+	Key  string
+	Kind ExportChangeKind
+}
+
+// DiffExports returns the keys whose data differs between the exports old
+// and new, sorted by key.  Data is compared as JSON values, ignoring
+// formatting and object key order.
+func DiffExports(old, new *ExportedData) ([]*ExportChange, error) {
+	var changes []*ExportChange
+	for key, oldData := range old.Data {
+		newData, ok := new.Data[key]
+		if !ok {
+			changes = append(changes, &ExportChange{Key: key, Kind: ExportKeyRemoved})
+			continue
+		}
+		equal, err := jsonEqual(oldData, newData)
+		if err != nil {
+			return nil, fmt.Errorf("diff key %s: %w", key, err)
+		}
+		if !equal {
+			changes = append(changes, &ExportChange{Key: key, Kind: ExportKeyChanged})
+		}
+	}
+	for key := range new.Data {
+		if _, ok := old.Data[key]; !ok {
+			changes = append(changes, &ExportChange{Key: key, Kind: ExportKeyAdded})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
+
+func jsonEqual(a, b []byte) (bool, error) {
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return false, err
+	}
+	ca, err := json.Marshal(va)
+	if err != nil {
+		return false, err
+	}
+	cb, err := json.Marshal(vb)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ca, cb), nil
+}
@@ -0,0 +1,112 @@
+package private_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+)
+
+// exportClient serves a fixed export.
+type exportClient struct {
+	shiroclient.ShiroClient
+	export string
+}
+
+func (c *exportClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	return types.NewSuccessResponse([]byte(c.export), "", 0, 0), nil
+}
+
+func exportArchive(t *testing.T, export string) []byte {
+	var buf bytes.Buffer
+	manifest, err := private.ExportArchive(context.Background(), &exportClient{export: export}, "ds1", &buf)
+	require.NoError(t, err)
+	require.Equal(t, private.ExportFormatVersion, manifest.FormatVersion)
+	require.Equal(t, private.DSID("ds1"), manifest.DSID)
+	return buf.Bytes()
+}
+
+func TestExportArchive(t *testing.T) {
+	archive := exportArchive(t, `{"profile": {"name": "Ada", "age": 36}, "accounts/1": ["a", "b"]}`)
+	exported, err := private.ReadExportArchive(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	require.Len(t, exported.Manifest.Entries, 2)
+	require.Equal(t, "accounts/1", exported.Manifest.Entries[0].Key)
+	require.Equal(t, "data/accounts%2F1.json", exported.Manifest.Entries[0].Path)
+	require.JSONEq(t, `{"name": "Ada", "age": 36}`, string(exported.Data["profile"]))
+
+	updated := exportArchive(t, `{"profile": {"age": 36, "name": "Ada"}, "accounts/1": ["a"], "consent": true}`)
+	next, err := private.ReadExportArchive(bytes.NewReader(updated), int64(len(updated)))
+	require.NoError(t, err)
+	changes, err := private.DiffExports(exported, next)
+	require.NoError(t, err)
+	require.Equal(t, []*private.ExportChange{
+		{Key: "accounts/1", Kind: private.ExportKeyChanged},
+		{Key: "consent", Kind: private.ExportKeyAdded},
+	}, changes)
+	changes, err = private.DiffExports(next, exported)
+	require.NoError(t, err)
+	require.Equal(t, private.ExportKeyRemoved, changes[1].Kind)
+}
+
+func TestReadExportArchiveInvalid(t *testing.T) {
+	write := func(files map[string]string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, data := range files {
+			f, err := zw.Create(name)
+			require.NoError(t, err)
+			_, err = f.Write([]byte(data))
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+		return buf.Bytes()
+	}
+	for name, files := range map[string]map[string]string{
+		"no manifest": {"data/k.json": `1`},
+		"future version": {
+			"manifest.json": `{"format_version": 2, "entries": []}`,
+		},
+		"digest mismatch": {
+			"manifest.json": `{"format_version": 1, "entries": [{"key": "k", "path": "data/k.json", "sha256": "00"}]}`,
+			"data/k.json":   `1`,
+		},
+		"missing file": {
+			"manifest.json": `{"format_version": 1, "entries": [{"key": "k", "path": "data/k.json", "sha256": "00"}]}`,
+		},
+		"unlisted file": {
+			"manifest.json": `{"format_version": 1, "entries": []}`,
+			"data/k.json":   `1`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			archive := write(files)
+			_, err := private.ReadExportArchive(bytes.NewReader(archive), int64(len(archive)))
+			require.Error(t, err)
+		})
+	}
+
+	// tampering with the data is detected.
+	archive := exportArchive(t, `{"k": 1}`)
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		var b bytes.Buffer
+		_, err = b.ReadFrom(rc)
+		require.NoError(t, err)
+		files[f.Name] = b.String()
+	}
+	files["data/k.json"] = "2"
+	tampered := write(files)
+	_, err = private.ReadExportArchive(bytes.NewReader(tampered), int64(len(tampered)))
+	require.ErrorContains(t, err, "digest mismatch")
+}
@@ -0,0 +1,81 @@
+package private
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// TransientDSIDNamespace is the transient data key carrying the DSID
+// namespace of a request.  See WithDSIDNamespace.
+const TransientDSIDNamespace = "private_dsid_namespace"
+
+// DSIDNamespaceSeparator separates the namespace of a DSID from the rest
+// of the DSID.
+const DSIDNamespaceSeparator = ":"
+
+// WithDSIDNamespace scopes private data operations to the data subjects of
+// a namespace, typically a tenant, so that one phylum can segregate the
+// data subjects of several tenants.  The namespace is passed to the phylum
+// as transient data and set on the header of transforms.  The phylum must
+// issue DSIDs in the namespace, prefixed with the namespace and
+// DSIDNamespaceSeparator.
+//
+// The helpers of this package refuse DSIDs outside of the namespace before
+// calling the phylum: ProfileToDSID fails if the phylum returns one, and
+// Export, Purge and Decode fail if given one.
+func WithDSIDNamespace(namespace string) shiroclient.Config {
+	return shiroclient.WithTransientData(TransientDSIDNamespace, []byte(namespace))
+}
+
+// namespaceOf returns the DSID namespace of a call, or an empty string if
+// there is none.
+func namespaceOf(opt *types.RequestOptions) string {
+	return string(opt.Transient[TransientDSIDNamespace])
+}
+
+// Namespace returns the namespace of the DSID, or an empty string if it
+// has none.
+func (d DSID) Namespace() string {
+	namespace, _, ok := strings.Cut(string(d), DSIDNamespaceSeparator)
+	if !ok {
+		return ""
+	}
+	return namespace
+}
+
+// checkNamespace returns an error if namespace is not empty and dsid is
+// not in it.
+func checkNamespace(namespace string, dsid DSID) error {
+	if namespace == "" || dsid.Namespace() == namespace {
+		return nil
+	}
+	return fmt.Errorf("DSID %s is not in namespace %s", dsid, namespace)
+}
+
+// namespaceTransforms returns transforms with their headers set to
+// namespace.  transforms are not modified.  An error is returned if a
+// transform header already names another namespace.
+func namespaceTransforms(namespace string, transforms []*Transform) ([]*Transform, error) {
+	if namespace == "" {
+		return transforms, nil
+	}
+	out := make([]*Transform, len(transforms))
+	for i, t := range transforms {
+		if t == nil || t.Header == nil {
+			out[i] = t
+			continue
+		}
+		if t.Header.Namespace != "" && t.Header.Namespace != namespace {
+			return nil, fmt.Errorf("transform %d: namespace %s conflicts with %s", i, t.Header.Namespace, namespace)
+		}
+		header := *t.Header
+		header.Namespace = namespace
+		transform := *t
+		transform.Header = &header
+		out[i] = &transform
+	}
+	return out, nil
+}
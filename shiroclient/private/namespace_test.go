@@ -0,0 +1,94 @@
+package private_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+)
+
+// namespaceClient echoes its params and records the options of each call.
+type namespaceClient struct {
+	shiroclient.ShiroClient
+	dsid  private.DSID
+	calls []*types.RequestOptions
+}
+
+func (c *namespaceClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	c.calls = append(c.calls, opt)
+	var result []byte
+	switch method {
+	case private.ShiroEndpointProfileToDSID:
+		result, err = json.Marshal(c.dsid)
+	case private.ShiroEndpointEncode:
+		result = []byte(`{"mxf": "v1", "message": {}, "transforms": []}`)
+	default:
+		result = []byte(`{}`)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return types.NewSuccessResponse(result, "", 0, 0), nil
+}
+
+func TestDSIDNamespace(t *testing.T) {
+	ctx := context.Background()
+	acme := private.WithDSIDNamespace("acme")
+	require.Equal(t, "acme", private.DSID("acme:123").Namespace())
+	require.Empty(t, private.DSID("123").Namespace())
+
+	client := &namespaceClient{dsid: "acme:123"}
+	dsid, err := private.ProfileToDSID(ctx, client, map[string]string{"email": "a@example.com"}, acme)
+	require.NoError(t, err)
+	require.Equal(t, private.DSID("acme:123"), dsid)
+	require.Equal(t, []byte("acme"), client.calls[0].Transient[private.TransientDSIDNamespace])
+
+	_, err = private.ProfileToDSID(ctx, client, nil, private.WithDSIDNamespace("globex"))
+	require.Error(t, err)
+
+	// DSIDs of other namespaces are refused without calling the phylum.
+	client.calls = nil
+	_, err = private.Export(ctx, client, "globex:456", acme)
+	require.ErrorContains(t, err, "not in namespace acme")
+	require.Error(t, private.Purge(ctx, client, "456", acme))
+	require.Empty(t, client.calls)
+	_, err = private.Export(ctx, client, "acme:123", acme)
+	require.NoError(t, err)
+
+	// the namespace is set on transform headers, leaving the caller's
+	// transforms unchanged.
+	transforms := []*private.Transform{{
+		ContextPath: ".",
+		Header: &private.TransformHeader{
+			ProfilePaths: []string{".id"},
+			PrivatePaths: []string{".ssn"},
+			Encryptor:    private.EncryptorAES256,
+			Compressor:   private.CompressorZlib,
+		},
+	}}
+	client.calls = nil
+	_, err = private.Encode(ctx, client, map[string]string{"ssn": "1"}, transforms, acme)
+	require.NoError(t, err)
+	require.Empty(t, transforms[0].Header.Namespace)
+	var req private.EncodeRequest
+	require.NoError(t, json.Unmarshal(client.calls[0].Transient["mxf"], &req))
+	require.Equal(t, "acme", req.Transforms[0].Header.Namespace)
+
+	transforms[0].Header.Namespace = "globex"
+	_, err = private.Encode(ctx, client, map[string]string{"ssn": "1"}, transforms, acme)
+	require.ErrorContains(t, err, "conflicts")
+
+	var encoded private.EncodedResponse
+	require.NoError(t, json.Unmarshal([]byte(`{"mxf": "v1", "message": {}, "transforms": [{"body": {"dsid": "globex:456"}}]}`), &encoded))
+	var decoded map[string]interface{}
+	require.ErrorContains(t, private.Decode(ctx, client, &encoded, &decoded, acme), "not in namespace acme")
+}
@@ -9,8 +9,12 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
 	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/txctx"
 )
 
 const (
@@ -74,6 +78,9 @@ type TransformHeader struct {
 	Encryptor Encryptor `json:"encryptor"`
 	// Compressor selects the compression algorithm.
 	Compressor Compressor `json:"compressor"`
+	// Namespace is the DSID namespace of the data subject.  See
+	// WithDSIDNamespace.
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // TransformBody is the body portion of a transformation. This is populated
@@ -160,22 +167,30 @@ func (r *EncodedResponse) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-var skipEncodeConfig = shiroclient.WithSingleton()
-
 // WithSkipEncodeTx skips the encode transaction and instead encodes the
 // private data in the same transaction as the wrapped Call transaction. This
 // is an optimization to reduce the number of transactions.
 func WithSkipEncodeTx() shiroclient.Config {
-	return skipEncodeConfig
+	return types.Opt(func(r *types.RequestOptions) {
+		r.SkipEncodeTx = true
+	})
 }
 
-func doSkipEncodeTx(configs []shiroclient.Config) bool {
-	for _, config := range configs {
-		if config == skipEncodeConfig {
-			return true
-		}
+// callOptions returns the options set by configs.  Options of configs that
+// fail to apply are ignored here; the error is reported by the call.
+func callOptions(configs []shiroclient.Config) *types.RequestOptions {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return &types.RequestOptions{}
 	}
-	return false
+	return opt
+}
+
+// phylumError returns the error of a phylum error response with the detail
+// allowed by the error policy of configs, which defaults to
+// ErrorPolicyCodes.
+func phylumError(configs []shiroclient.Config, e types.Error) error {
+	return callOptions(configs).ErrorPolicy.Or(types.ErrorPolicyCodes).PhylumError(e)
 }
 
 // withParam returns a shiroclient config that passes a single parameter
@@ -193,6 +208,15 @@ func WithSeed() (shiroclient.Config, error) {
 	return shiroclient.WithTransientData("csprng_seed_private", seed), nil
 }
 
+// appendConfigs returns a new slice holding configs followed by more.
+// Unlike append it never writes to the backing array of configs, which
+// callers may share between concurrent calls.
+func appendConfigs(configs []shiroclient.Config, more ...shiroclient.Config) []shiroclient.Config {
+	out := make([]shiroclient.Config, 0, len(configs)+len(more))
+	out = append(out, configs...)
+	return append(out, more...)
+}
+
 // WithTransientMXF adds transient data used by MXF to encode and encrypt data.
 // This config is not compatible with `WithTransientIVs`.
 func WithTransientMXF(req *EncodeRequest) ([]shiroclient.Config, error) {
@@ -213,11 +237,27 @@ func WithTransientMXF(req *EncodeRequest) ([]shiroclient.Config, error) {
 	return configs, nil
 }
 
+// encodeHelper encodes message with transforms, which are checked with
+// ValidateTransforms first.
 func encodeHelper(ctx context.Context, client shiroclient.ShiroClient, message interface{}, transforms []*Transform, configs ...shiroclient.Config) (*EncodedResponse, []shiroclient.Config, error) {
+	if err := ValidateTransforms(transforms); err != nil {
+		return nil, nil, err
+	}
+	return encodeValidated(ctx, client, message, transforms, configs...)
+}
+
+// encodeValidated is encodeHelper for transforms that passed
+// ValidateTransforms.
+func encodeValidated(ctx context.Context, client shiroclient.ShiroClient, message interface{}, transforms []*Transform, configs ...shiroclient.Config) (*EncodedResponse, []shiroclient.Config, error) {
 	if message == nil {
 		return nil, nil, nil
 	}
 	var newConfigs []shiroclient.Config
+	opt := callOptions(configs)
+	transforms, err := namespaceTransforms(namespaceOf(opt), transforms)
+	if err != nil {
+		return nil, nil, err
+	}
 	if len(transforms) == 0 {
 		// fast path, nothing to do.
 		rawBytes, err := json.Marshal(message)
@@ -243,14 +283,14 @@ func encodeHelper(ctx context.Context, client shiroclient.ShiroClient, message i
 	}
 
 	enc := &EncodedResponse{}
-	if doSkipEncodeTx(configs) {
+	if opt.SkipEncodeTx {
 		newConfigs = append(newConfigs, transientConfigs...)
 		// for this optimization, pass a hard coded "magic" request that tells
 		// `substrate` to look for the to-be encoded message in transient data.
 		newConfigs = append(newConfigs, withParam(skipEncodeRequest))
 	} else {
 
-		configs = append(configs, transientConfigs...)
+		configs = appendConfigs(configs, transientConfigs...)
 
 		resp, err := client.Call(ctx, ShiroEndpointEncode, configs...)
 		if err != nil {
@@ -258,7 +298,7 @@ func encodeHelper(ctx context.Context, client shiroclient.ShiroClient, message i
 		}
 
 		if resp.Error() != nil {
-			return nil, nil, fmt.Errorf(resp.Error().Message())
+			return nil, nil, phylumError(configs, resp.Error())
 		}
 		err = resp.UnmarshalTo(enc)
 		if err != nil {
@@ -300,13 +340,23 @@ func Decode(ctx context.Context, client shiroclient.ShiroClient, encoded *Encode
 		}
 		return shiroclient.UnmarshalProto(rawBytes, decoded)
 	}
-	configs = append(configs, withParam(encoded.encodedMessage))
+	if namespace := namespaceOf(callOptions(configs)); namespace != "" {
+		for _, t := range encoded.encodedMessage.Transforms {
+			if t == nil || t.Body == nil {
+				continue
+			}
+			if err := checkNamespace(namespace, t.Body.DSID); err != nil {
+				return err
+			}
+		}
+	}
+	configs = appendConfigs(configs, withParam(encoded.encodedMessage))
 	resp, err := client.Call(ctx, ShiroEndpointDecode, configs...)
 	if err != nil {
 		return err
 	}
 	if resp.Error() != nil {
-		return fmt.Errorf(resp.Error().Message())
+		return phylumError(configs, resp.Error())
 	}
 	err = resp.UnmarshalTo(decoded)
 	if err != nil {
@@ -321,13 +371,16 @@ func Export(ctx context.Context, client shiroclient.ShiroClient, dsid DSID, conf
 	if dsid == "" {
 		return nil, fmt.Errorf("invalid empty DSID")
 	}
-	configs = append(configs, withParam(dsid))
+	if err := checkNamespace(namespaceOf(callOptions(configs)), dsid); err != nil {
+		return nil, err
+	}
+	configs = appendConfigs(configs, withParam(dsid))
 	resp, err := client.Call(ctx, ShiroEndpointExport, configs...)
 	if err != nil {
 		return nil, err
 	}
 	if resp.Error() != nil {
-		return nil, fmt.Errorf(resp.Error().Message())
+		return nil, phylumError(configs, resp.Error())
 	}
 	var exported map[string]interface{}
 	err = resp.UnmarshalTo(&exported)
@@ -343,7 +396,10 @@ func Purge(ctx context.Context, client shiroclient.ShiroClient, dsid DSID, confi
 	if dsid == "" {
 		return fmt.Errorf("invalid empty DSID")
 	}
-	configs = append(configs, withParam(dsid))
+	if err := checkNamespace(namespaceOf(callOptions(configs)), dsid); err != nil {
+		return err
+	}
+	configs = appendConfigs(configs, withParam(dsid))
 	seedConfig, err := WithSeed()
 	if err != nil {
 		return err
@@ -354,7 +410,7 @@ func Purge(ctx context.Context, client shiroclient.ShiroClient, dsid DSID, confi
 		return err
 	}
 	if resp.Error() != nil {
-		return fmt.Errorf(resp.Error().Message())
+		return phylumError(configs, resp.Error())
 	}
 	var gotDSID DSID
 	err = resp.UnmarshalTo(&gotDSID)
@@ -369,46 +425,113 @@ func Purge(ctx context.Context, client shiroclient.ShiroClient, dsid DSID, confi
 
 // ProfileToDSID returns a DSID for a data subject profile.
 func ProfileToDSID(ctx context.Context, client shiroclient.ShiroClient, profile interface{}, configs ...shiroclient.Config) (DSID, error) {
-	configs = append(configs, withParam(profile))
+	configs = appendConfigs(configs, withParam(profile))
 	resp, err := client.Call(ctx, ShiroEndpointProfileToDSID, configs...)
 	if err != nil {
 		return "", err
 	}
 	if resp.Error() != nil {
-		return "", fmt.Errorf(resp.Error().Message())
+		return "", phylumError(configs, resp.Error())
 	}
 	var gotDSID DSID
 	err = resp.UnmarshalTo(&gotDSID)
 	if err != nil {
 		return "", err
 	}
+	if err := checkNamespace(namespaceOf(callOptions(configs)), gotDSID); err != nil {
+		return "", fmt.Errorf("unexpected response from get DSID: %w", err)
+	}
 	return gotDSID, nil
 }
 
 // CallResult is returned from wrapped calls and contains additional data
 // relating to the response.
 type CallResult struct {
-	TransactionID  string
-	maxSimBlockNum uint64
-	commitBlockNum uint64
+	TransactionID string
+	// RequestID is the ID of the wrapped call request, as set with
+	// shiroclient.WithID or generated by the client.
+	RequestID string
+	// GatewayRequestID is the request ID reported by the gateway, or empty
+	// if the call was not made over RPC.
+	GatewayRequestID string
+	// Endpoint is the gateway endpoint of the wrapped call, or empty in mock
+	// mode.
+	Endpoint string
+	// Retries is the number of times the wrapped call was retried.
+	Retries int
+	// Timing is the duration of the steps of the wrapped call.
+	Timing CallTiming
+	commit *shiroclient.CommitMetadata
+}
+
+// CallTiming is the duration of the steps of a wrapped call.
+type CallTiming struct {
+	// Encode is the time taken to encode the message, including the encode
+	// transaction, if any.
+	Encode time.Duration
+	// Call is the time taken by the wrapped call, including retries.
+	Call time.Duration
+	// Decode is the time taken to decode the response.
+	Decode time.Duration
+}
+
+// Total returns the total duration of the steps.
+func (t CallTiming) Total() time.Duration {
+	return t.Encode + t.Call + t.Decode
+}
+
+// callObserver records the request ID and stats of a call.
+type callObserver struct {
+	mu        sync.Mutex
+	requestID string
+	stats     *shiroclient.CallStats
+}
+
+// config returns a config recording the request ID and stats of the call
+// it is applied to.  Stats hooks already configured, e.g. on the client,
+// are still called.
+func (o *callObserver) config() shiroclient.Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		o.mu.Lock()
+		o.requestID = r.ID
+		o.mu.Unlock()
+		prev := r.Stats
+		r.Stats = func(stats types.CallStats) {
+			o.mu.Lock()
+			o.stats = &stats
+			o.mu.Unlock()
+			if prev != nil {
+				prev(stats)
+			}
+		}
+	})
 }
 
 // MaxSimBlockNum returns the maximum block number used to simulate the tx
 // for the wrapped Call function.
 func (s *CallResult) MaxSimBlockNum() uint64 {
-	if s == nil {
+	if s == nil || s.commit == nil {
 		return 0
 	}
-	return s.maxSimBlockNum
+	return s.commit.MaxSimulatedBlock
 }
 
 // CommitBlockNum returns the block number used to commit the tx, or
-// empty string if not available.
+// 0 if not available.
 func (s *CallResult) CommitBlockNum() uint64 {
-	if s == nil {
+	if s == nil || s.commit == nil {
 		return 0
 	}
-	return s.commitBlockNum
+	return s.commit.CommitBlock
+}
+
+// Commit returns a copy of the commit metadata of the wrapped call, or nil
+// if none was reported.
+func (s *CallResult) Commit() *shiroclient.CommitMetadata {
+	if s == nil {
+		return nil
+	}
+	return s.commit.Clone()
 }
 
 // CallFunc is the function signature returned for wrapped calls
@@ -436,19 +559,34 @@ var skipEncodeRequest = &EncodedResponse{
 // favor of the `message`.
 // IMPORTANT: The wrapper assumes the wrapped endpoint only takes a single
 // argument!
+// The transforms are checked once with ValidateTransforms; if they are
+// invalid every call fails with the *TransformValidationError, without
+// contacting substrate.
 func WrapCall(client shiroclient.ShiroClient, method string, encTransforms ...*Transform) CallFunc {
+	invalid := ValidateTransforms(encTransforms)
 	return func(ctx context.Context, message interface{}, output interface{}, configs ...shiroclient.Config) (*CallResult, error) {
-		_, newConfigs, err := encodeHelper(ctx, client, message, encTransforms, configs...)
+		if invalid != nil {
+			return nil, fmt.Errorf("wrap encode error: %w", invalid)
+		}
+		var timing CallTiming
+		start := time.Now()
+		configs = append(txctx.Configs(ctx), configs...)
+		_, newConfigs, err := encodeValidated(ctx, client, message, encTransforms, configs...)
 		if err != nil {
 			return nil, fmt.Errorf("wrap encode error: %w", err)
 		}
-		callConfigs := append(configs, newConfigs...)
+		timing.Encode = time.Since(start)
+		observer := &callObserver{}
+		callConfigs := appendConfigs(configs, newConfigs...)
+		callConfigs = append(callConfigs, observer.config())
+		start = time.Now()
 		resp, err := client.Call(ctx, method, callConfigs...)
+		timing.Call = time.Since(start)
 		if err != nil {
 			return nil, fmt.Errorf("wrap call error: %w", err)
 		}
 		if resp.Error() != nil {
-			return nil, fmt.Errorf("wrap call response error: %s", resp.Error().Message())
+			return nil, fmt.Errorf("wrap call response error: %w", phylumError(configs, resp.Error()))
 		}
 		encResp := &EncodedResponse{}
 		err = resp.UnmarshalTo(encResp)
@@ -456,16 +594,36 @@ func WrapCall(client shiroclient.ShiroClient, method string, encTransforms ...*T
 			return nil, err
 		}
 		if resp.TransactionID() != "" {
-			configs = append(configs, shiroclient.WithDependentTxID(resp.TransactionID()))
+			configs = appendConfigs(configs, shiroclient.WithDependentTxID(resp.TransactionID()))
+			txctx.SetID(ctx, resp.TransactionID())
 		}
+		start = time.Now()
 		err = Decode(ctx, client, encResp, output, configs...)
 		if err != nil {
 			return nil, fmt.Errorf("wrap decode error: %w", err)
 		}
-		return &CallResult{
-			TransactionID:  resp.TransactionID(),
-			maxSimBlockNum: resp.MaxSimBlockNum(),
-			commitBlockNum: resp.CommitBlockNum(),
-		}, nil
+		timing.Decode = time.Since(start)
+		commit := shiroclient.GetCommitMetadata(resp)
+		if commit == nil {
+			commit = &shiroclient.CommitMetadata{
+				TxID:              resp.TransactionID(),
+				CommitBlock:       resp.CommitBlockNum(),
+				MaxSimulatedBlock: resp.MaxSimBlockNum(),
+			}
+		}
+		result := &CallResult{
+			TransactionID:    commit.TxID,
+			GatewayRequestID: shiroclient.GetResponseMetadata(resp).RequestID(),
+			Timing:           timing,
+			commit:           commit,
+		}
+		observer.mu.Lock()
+		defer observer.mu.Unlock()
+		result.RequestID = observer.requestID
+		if observer.stats != nil {
+			result.Endpoint = observer.stats.Endpoint
+			result.Retries = observer.stats.Retries
+		}
+		return result, nil
 	}
 }
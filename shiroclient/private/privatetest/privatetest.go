@@ -0,0 +1,442 @@
+// Package privatetest provides an in-memory fake of the private data
+// endpoints of a phylum, for unit tests of code using the private package.
+//
+// A Client implements the endpoints called by private.Encode, Decode,
+// WrapCall, ProfileToDSID, Export and Purge without a plugin or a phylum
+// with MXF support.  Business logic endpoints wrapped with WrapCall are
+// implemented by handlers, which receive the decoded message:
+//
+//	client := privatetest.New()
+//	client.Handle("create_account", func(ctx context.Context, msg json.RawMessage) (interface{}, error) {
+//		return map[string]string{"status": "ok"}, nil
+//	})
+//	call := private.WrapCall(client, "create_account", transforms...)
+//
+// The fake encrypts whole messages with keys derived deterministically from
+// a seed and the DSID, so encoded messages are reproducible across runs.
+// It does not implement partial encryption of the private paths of a
+// transform, and must never be used outside of tests.
+package privatetest
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+)
+
+// MXF is the MXF version of messages encoded by the fake.
+const MXF = "privatetest"
+
+// ErrPurged is reported when decoding data of a purged data subject.
+var ErrPurged = errors.New("data subject purged")
+
+// Handler implements an endpoint wrapped with private.WrapCall.  message
+// is the decoded message passed to the call.  The returned value is
+// marshaled to JSON and returned unencoded.
+type Handler func(ctx context.Context, message json.RawMessage) (interface{}, error)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithSeed sets the seed the encryption keys of data subjects are derived
+// from.
+func WithSeed(seed []byte) Option {
+	return func(c *Client) {
+		c.seed = append([]byte(nil), seed...)
+	}
+}
+
+// WithFallback forwards calls to endpoints without a handler to client.
+// By default such calls fail.
+func WithFallback(client shiroclient.ShiroClient) Option {
+	return func(c *Client) {
+		c.ShiroClient = client
+	}
+}
+
+// Client is a ShiroClient faking the private data endpoints.  Methods
+// other than Call are forwarded to the fallback client, and panic if there
+// is none.
+type Client struct {
+	shiroclient.ShiroClient
+	seed []byte
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	txs      int
+	// data holds the messages encoded for each data subject, by
+	// transaction ID.
+	data   map[private.DSID]map[string]json.RawMessage
+	purged map[private.DSID]bool
+}
+
+// New returns a fake client.
+func New(opts ...Option) *Client {
+	c := &Client{
+		seed:     []byte("privatetest"),
+		handlers: make(map[string]Handler),
+		data:     make(map[private.DSID]map[string]json.RawMessage),
+		purged:   make(map[private.DSID]bool),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Handle implements method with h.
+func (c *Client) Handle(method string, h Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[method] = h
+}
+
+// DSIDs returns the data subjects with encoded data that was not purged,
+// sorted.
+func (c *Client) DSIDs() []private.DSID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dsids := make([]private.DSID, 0, len(c.data))
+	for dsid := range c.data {
+		dsids = append(dsids, dsid)
+	}
+	sort.Slice(dsids, func(i, j int) bool { return dsids[i] < dsids[j] })
+	return dsids
+}
+
+// call is a call being served.
+type call struct {
+	params    []json.RawMessage
+	transient map[string][]byte
+	txID      string
+}
+
+// Call implements shiroclient.ShiroClient.
+func (c *Client) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(opt.Params)
+	if err != nil {
+		return nil, err
+	}
+	cl := &call{transient: opt.Transient}
+	if err := json.Unmarshal(b, &cl.params); err != nil {
+		return nil, fmt.Errorf("privatetest: params of %s are not an array: %w", method, err)
+	}
+
+	c.mu.Lock()
+	handler, ok := c.handlers[method]
+	c.txs++
+	cl.txID = fmt.Sprintf("privatetest-tx%d", c.txs)
+	c.mu.Unlock()
+
+	var result interface{}
+	switch method {
+	case private.ShiroEndpointEncode:
+		result, err = c.encode(cl)
+	case private.ShiroEndpointDecode:
+		result, err = c.decode(cl, cl.param(0))
+	case private.ShiroEndpointProfileToDSID:
+		result, err = c.dsid(cl, cl.param(0))
+	case private.ShiroEndpointExport:
+		result, err = c.export(cl)
+	case private.ShiroEndpointPurge:
+		result, err = c.purge(cl)
+	default:
+		if !ok {
+			if c.ShiroClient != nil {
+				return c.ShiroClient.Call(ctx, method, configs...)
+			}
+			return nil, fmt.Errorf("privatetest: no handler for %s", method)
+		}
+		var message json.RawMessage
+		message, err = c.decode(cl, cl.param(0))
+		if err == nil {
+			result, err = handler(ctx, message)
+		}
+	}
+	if err != nil {
+		return types.NewFailureResponse(1, err.Error(), nil), nil
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewSuccessResponse(resultJSON, cl.txID, 0, 0), nil
+}
+
+func (cl *call) param(i int) json.RawMessage {
+	if i >= len(cl.params) {
+		return nil
+	}
+	return cl.params[i]
+}
+
+// encode encodes the request in the "mxf" transient data.
+func (c *Client) encode(cl *call) (*private.EncodedMessage, error) {
+	var req private.EncodeRequest
+	if err := json.Unmarshal(cl.transient["mxf"], &req); err != nil {
+		return nil, fmt.Errorf("invalid mxf transient data: %w", err)
+	}
+	message, err := json.Marshal(req.Message)
+	if err != nil {
+		return nil, err
+	}
+	enc := &private.EncodedMessage{MXF: MXF, Message: json.RawMessage(message)}
+	if len(req.Transforms) == 0 {
+		return enc, nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(message, &doc); err != nil {
+		return nil, err
+	}
+	for i, t := range req.Transforms {
+		if t == nil || t.Header == nil {
+			return nil, fmt.Errorf("transform %d: missing header", i)
+		}
+		profile, err := selectProfile(doc, t)
+		if err != nil {
+			return nil, fmt.Errorf("transform %d: %w", i, err)
+		}
+		dsid, err := c.dsid(cl, profile)
+		if err != nil {
+			return nil, err
+		}
+		body := &private.TransformBody{DSID: dsid}
+		if i == 0 {
+			if body.EncryptedBase64, err = c.seal(dsid, message); err != nil {
+				return nil, err
+			}
+		}
+		header := *t.Header
+		enc.Transforms = append(enc.Transforms, &private.Transform{
+			ContextPath: t.ContextPath,
+			Header:      &header,
+			Body:        body,
+		})
+	}
+	enc.Message = nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range enc.Transforms {
+		dsid := t.Body.DSID
+		if c.data[dsid] == nil {
+			c.data[dsid] = make(map[string]json.RawMessage)
+		}
+		c.data[dsid][cl.txID] = message
+		delete(c.purged, dsid)
+	}
+	return enc, nil
+}
+
+// decode returns the message encoded in param.  Unencoded messages are
+// returned as is.
+func (c *Client) decode(cl *call, param json.RawMessage) (json.RawMessage, error) {
+	var enc private.EncodedMessage
+	if err := json.Unmarshal(param, &enc); err != nil || enc.MXF == "" {
+		return param, nil
+	}
+	switch {
+	case enc.MXF == "transient":
+		// the message is encoded in the same transaction, see
+		// private.WithSkipEncodeTx.
+		encoded, err := c.encode(cl)
+		if err != nil {
+			return nil, err
+		}
+		return c.decodeMessage(encoded)
+	case enc.MXF != MXF:
+		return nil, fmt.Errorf("unsupported mxf version %q", enc.MXF)
+	}
+	return c.decodeMessage(&enc)
+}
+
+func (c *Client) decodeMessage(enc *private.EncodedMessage) (json.RawMessage, error) {
+	if len(enc.Transforms) == 0 {
+		return json.Marshal(enc.Message)
+	}
+	body := enc.Transforms[0].Body
+	if body == nil {
+		return nil, errors.New("missing transform body")
+	}
+	c.mu.Lock()
+	purged := c.purged[body.DSID]
+	c.mu.Unlock()
+	if purged {
+		return nil, fmt.Errorf("%w: %s", ErrPurged, body.DSID)
+	}
+	return c.open(body.DSID, body.EncryptedBase64)
+}
+
+// dsid returns the DSID of a profile, in the namespace of the call.
+func (c *Client) dsid(cl *call, profile interface{}) (private.DSID, error) {
+	b, err := json.Marshal(profile)
+	if err != nil {
+		return "", err
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return "", fmt.Errorf("invalid profile: %w", err)
+	}
+	// re-marshal to sort object keys.
+	b, _ = json.Marshal(v)
+	sum := sha256.Sum256(append(append([]byte(nil), c.seed...), b...))
+	dsid := "dsid-" + hex.EncodeToString(sum[:16])
+	if namespace := string(cl.transient[private.TransientDSIDNamespace]); namespace != "" {
+		dsid = namespace + private.DSIDNamespaceSeparator + dsid
+	}
+	return private.DSID(dsid), nil
+}
+
+func (c *Client) dsidParam(cl *call) (private.DSID, error) {
+	var dsid private.DSID
+	if err := json.Unmarshal(cl.param(0), &dsid); err != nil || dsid == "" {
+		return "", errors.New("invalid DSID")
+	}
+	return dsid, nil
+}
+
+func (c *Client) export(cl *call) (map[string]json.RawMessage, error) {
+	dsid, err := c.dsidParam(cl)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	exported := make(map[string]json.RawMessage, len(c.data[dsid]))
+	for txID, message := range c.data[dsid] {
+		exported[txID] = message
+	}
+	return exported, nil
+}
+
+func (c *Client) purge(cl *call) (private.DSID, error) {
+	dsid, err := c.dsidParam(cl)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, dsid)
+	c.purged[dsid] = true
+	return dsid, nil
+}
+
+// aead returns the cipher of a data subject.
+func (c *Client) aead(dsid private.DSID) (cipher.AEAD, error) {
+	key := sha256.Sum256(append(append([]byte(nil), c.seed...), dsid...))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts message.  The nonce is derived from the message so that
+// encoding is deterministic.
+func (c *Client) seal(dsid private.DSID, message []byte) (string, error) {
+	aead, err := c.aead(dsid)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(message)
+	nonce := sum[:aead.NonceSize()]
+	sealed := aead.Seal(append([]byte(nil), nonce...), nonce, message, []byte(dsid))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *Client) open(dsid private.DSID, encrypted string) (json.RawMessage, error) {
+	aead, err := c.aead(dsid)
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(b) < aead.NonceSize() {
+		return nil, errors.New("invalid encrypted data")
+	}
+	message, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], []byte(dsid))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return message, nil
+}
+
+// selectProfile returns the data subject profile selected by the profile
+// paths of t within doc: the single value selected, or the list of values
+// if several are.
+func selectProfile(doc interface{}, t *private.Transform) (interface{}, error) {
+	contexts := selectPath(doc, t.ContextPath)
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("context path %q selects no data", t.ContextPath)
+	}
+	var values []interface{}
+	for _, p := range t.Header.ProfilePaths {
+		for _, ctx := range contexts {
+			values = append(values, selectPath(ctx, p)...)
+		}
+	}
+	switch len(values) {
+	case 0:
+		return nil, errors.New("profile paths select no data")
+	case 1:
+		return values[0], nil
+	default:
+		return values, nil
+	}
+}
+
+// selectPath returns the values selected by an elpspath, see
+// private.ValidateTransforms.
+func selectPath(doc interface{}, path string) []interface{} {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	values := []interface{}{doc}
+	for _, seg := range strings.Split(path, ".") {
+		if seg == "" {
+			continue
+		}
+		var next []interface{}
+		for _, v := range values {
+			switch v := v.(type) {
+			case map[string]interface{}:
+				if seg == "*" {
+					keys := make([]string, 0, len(v))
+					for k := range v {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, v[k])
+					}
+				} else if x, ok := v[seg]; ok {
+					next = append(next, x)
+				}
+			case []interface{}:
+				if seg == "*" {
+					next = append(next, v...)
+					continue
+				}
+				var i int
+				if _, err := fmt.Sscanf(seg, "%d", &i); err == nil && i >= 0 && i < len(v) {
+					next = append(next, v[i])
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
@@ -0,0 +1,86 @@
+package privatetest_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private/privatetest"
+)
+
+type account struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+var transforms = []*private.Transform{{
+	ContextPath: ".",
+	Header: &private.TransformHeader{
+		ProfilePaths: []string{".email"},
+		PrivatePaths: []string{"."},
+		Encryptor:    private.EncryptorAES256,
+		Compressor:   private.CompressorZlib,
+	},
+}}
+
+func TestWrapCall(t *testing.T) {
+	ctx := context.Background()
+	client := privatetest.New()
+	var received []account
+	client.Handle("create_account", func(ctx context.Context, message json.RawMessage) (interface{}, error) {
+		var acct account
+		if err := json.Unmarshal(message, &acct); err != nil {
+			return nil, err
+		}
+		received = append(received, acct)
+		return map[string]string{"greeting": "hello " + acct.Name}, nil
+	})
+	call := private.WrapCall(client, "create_account", transforms...)
+
+	ada := account{Email: "ada@example.com", Name: "Ada"}
+	var out map[string]string
+	_, err := call(ctx, ada, &out)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"greeting": "hello Ada"}, out)
+	_, err = call(ctx, ada, &out, private.WithSkipEncodeTx())
+	require.NoError(t, err)
+	require.Equal(t, []account{ada, ada}, received)
+
+	dsid, err := private.ProfileToDSID(ctx, client, ada.Email)
+	require.NoError(t, err)
+	require.Equal(t, []private.DSID{dsid}, client.DSIDs())
+
+	// encoding is deterministic and decodes until the subject is purged.
+	enc, err := private.Encode(ctx, client, ada, transforms)
+	require.NoError(t, err)
+	again, err := private.Encode(ctx, client, ada, transforms)
+	require.NoError(t, err)
+	require.Equal(t, enc, again)
+	var decoded account
+	require.NoError(t, private.Decode(ctx, client, enc, &decoded))
+	require.Equal(t, ada, decoded)
+
+	exported, err := private.Export(ctx, client, dsid)
+	require.NoError(t, err)
+	require.NotEmpty(t, exported)
+	require.NoError(t, private.Purge(ctx, client, dsid))
+	require.Empty(t, client.DSIDs())
+	require.ErrorContains(t, private.Decode(ctx, client, enc, &decoded), "purged")
+
+	_, err = private.WrapCall(client, "unknown")(ctx, ada, &out)
+	require.ErrorContains(t, err, "no handler for unknown")
+}
+
+func TestNamespace(t *testing.T) {
+	ctx := context.Background()
+	client := privatetest.New(privatetest.WithSeed([]byte("seed")))
+	dsid, err := private.ProfileToDSID(ctx, client, "ada@example.com", private.WithDSIDNamespace("acme"))
+	require.NoError(t, err)
+	require.Equal(t, "acme", dsid.Namespace())
+	other, err := private.ProfileToDSID(ctx, privatetest.New(), "ada@example.com", private.WithDSIDNamespace("acme"))
+	require.NoError(t, err)
+	require.NotEqual(t, dsid, other)
+}
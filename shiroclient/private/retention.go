@@ -0,0 +1,225 @@
+package private
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/batch"
+)
+
+// ShiroEndpointRetentionScan is used to find the data subjects whose data
+// is past retention.  It is called with a RetentionScanRequest and returns
+// a list of ExpiredSubject.
+const ShiroEndpointRetentionScan = "private_retention_scan"
+
+// ErrNoRetentionPolicy is the error of the PurgeRecord of a subject
+// returned by the phylum for a profile type without a retention policy.
+// Its data is not purged.
+var ErrNoRetentionPolicy = errors.New("no retention policy for profile")
+
+// RetentionPolicy declares how long the data of the subjects of a profile
+// type is retained after their last activity.
+type RetentionPolicy struct {
+	// Profile is the profile type the policy applies to, as known to the
+	// phylum.
+	Profile string
+	// Retention is the retention duration.
+	Retention time.Duration
+}
+
+// RetentionScanRequest is the parameter of ShiroEndpointRetentionScan.
+type RetentionScanRequest struct {
+	Policies []RetentionScanPolicy `json:"policies"`
+	// AsOf is the time retention is evaluated at, in RFC 3339 format.  It
+	// is passed so that every endorser evaluates the same cutoff.
+	AsOf string `json:"as_of"`
+}
+
+// RetentionScanPolicy is a RetentionPolicy as sent to the phylum.
+type RetentionScanPolicy struct {
+	Profile          string `json:"profile"`
+	RetentionSeconds int64  `json:"retention_seconds"`
+}
+
+// ExpiredSubject is a data subject whose data is past retention.
+type ExpiredSubject struct {
+	DSID    DSID   `json:"dsid"`
+	Profile string `json:"profile"`
+	// LastActivity is the time of the last activity of the subject, in
+	// RFC 3339 format.
+	LastActivity string `json:"last_activity"`
+}
+
+// PurgeRecord is the audit record of the purge of an expired subject.
+type PurgeRecord struct {
+	DSID         DSID
+	Profile      string
+	LastActivity string
+	// Retention is the retention of the policy of the subject.
+	Retention time.Duration
+	// PurgedAt is the time of the purge attempt.
+	PurgedAt time.Time
+	// Err is the error of the purge, or nil if the data was purged.  It
+	// wraps ErrNoRetentionPolicy if the data was not purged because the
+	// profile has no retention policy.
+	Err error
+}
+
+// RetentionOption configures a Retention.
+type RetentionOption func(*Retention)
+
+// WithRetentionAudit calls audit with the record of each purge attempt.  A
+// sweep stops at the first audit error, so that no purge goes unrecorded
+// for longer than a failed sweep.
+func WithRetentionAudit(audit func(ctx context.Context, record *PurgeRecord) error) RetentionOption {
+	return func(r *Retention) {
+		r.audit = audit
+	}
+}
+
+// WithRetentionConfigs applies configs to the scan and purge calls.
+func WithRetentionConfigs(configs ...shiroclient.Config) RetentionOption {
+	return func(r *Retention) {
+		r.configs = append(r.configs, configs...)
+	}
+}
+
+// WithRetentionClock sets the clock retention is evaluated with.  It
+// defaults to time.Now.
+func WithRetentionClock(now func() time.Time) RetentionOption {
+	return func(r *Retention) {
+		r.now = now
+	}
+}
+
+// Retention purges the private data of subjects past the retention of
+// their profile type, to comply with storage limitation requirements.
+type Retention struct {
+	client   shiroclient.ShiroClient
+	policies []RetentionScanPolicy
+	// retention maps profile types to their retention.
+	retention map[string]time.Duration
+	audit     func(ctx context.Context, record *PurgeRecord) error
+	configs   []shiroclient.Config
+	now       func() time.Time
+}
+
+// NewRetention returns a Retention enforcing policies through client.
+// Each profile type must have a single retention of at least a second.
+func NewRetention(client shiroclient.ShiroClient, policies []RetentionPolicy, opts ...RetentionOption) (*Retention, error) {
+	if len(policies) == 0 {
+		return nil, errors.New("no retention policies")
+	}
+	r := &Retention{
+		client:    client,
+		retention: make(map[string]time.Duration, len(policies)),
+		now:       time.Now,
+	}
+	for _, p := range policies {
+		if p.Profile == "" {
+			return nil, errors.New("retention policy without profile")
+		}
+		if p.Retention < time.Second {
+			return nil, fmt.Errorf("invalid retention %v for profile %s", p.Retention, p.Profile)
+		}
+		if _, ok := r.retention[p.Profile]; ok {
+			return nil, fmt.Errorf("duplicate retention policy for profile %s", p.Profile)
+		}
+		r.retention[p.Profile] = p.Retention
+		r.policies = append(r.policies, RetentionScanPolicy{
+			Profile:          p.Profile,
+			RetentionSeconds: int64(p.Retention / time.Second),
+		})
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+// Scan returns the subjects whose data is past retention.
+func (r *Retention) Scan(ctx context.Context) ([]*ExpiredSubject, error) {
+	req := &RetentionScanRequest{
+		Policies: r.policies,
+		AsOf:     r.now().UTC().Format(time.RFC3339),
+	}
+	configs := appendConfigs(r.configs, withParam(req))
+	resp, err := r.client.Call(ctx, ShiroEndpointRetentionScan, configs...)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error() != nil {
+		return nil, phylumError(r.configs, resp.Error())
+	}
+	var expired []*ExpiredSubject
+	if err := resp.UnmarshalTo(&expired); err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
+// Sweep purges the data of the subjects returned by Scan and returns the
+// records of the purges.  A failed purge is recorded and does not stop the
+// sweep; the subject is purged again by the next sweep.  Subjects whose
+// profile has no retention policy, e.g. because of a phylum bug, are not
+// purged and are recorded with an error wrapping ErrNoRetentionPolicy.
+func (r *Retention) Sweep(ctx context.Context) ([]*PurgeRecord, error) {
+	expired, err := r.Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("retention scan: %w", err)
+	}
+	records := make([]*PurgeRecord, 0, len(expired))
+	for _, subject := range expired {
+		retention, ok := r.retention[subject.Profile]
+		record := &PurgeRecord{
+			DSID:         subject.DSID,
+			Profile:      subject.Profile,
+			LastActivity: subject.LastActivity,
+			Retention:    retention,
+			PurgedAt:     r.now(),
+		}
+		if ok {
+			record.Err = Purge(ctx, r.client, subject.DSID, r.configs...)
+		} else {
+			record.Err = fmt.Errorf("%w %q", ErrNoRetentionPolicy, subject.Profile)
+		}
+		records = append(records, record)
+		if r.audit != nil {
+			if err := r.audit(ctx, record); err != nil {
+				return records, fmt.Errorf("retention audit: %w", err)
+			}
+		}
+		if ctx.Err() != nil {
+			return records, ctx.Err()
+		}
+	}
+	return records, nil
+}
+
+// Run sweeps at the times of schedule until ctx is done, e.g. daily with
+// batch.MustParseCron("@daily", nil).  Sweep errors are passed to onError,
+// if not nil, and the next sweep proceeds as scheduled.  Run returns
+// ctx.Err().
+func (r *Retention) Run(ctx context.Context, schedule batch.Schedule, onError func(error)) error {
+	for {
+		now := r.now()
+		next := schedule.Next(now)
+		if next.IsZero() {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if _, err := r.Sweep(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package private_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/batch"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+)
+
+// retentionClient serves a fixed retention scan and records purges.
+type retentionClient struct {
+	shiroclient.ShiroClient
+	expired []*private.ExpiredSubject
+	fail    map[private.DSID]bool
+
+	mu         sync.Mutex
+	scanParams []json.RawMessage
+	purged     []private.DSID
+}
+
+func (c *retentionClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	params, err := json.Marshal(opt.Params)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch method {
+	case private.ShiroEndpointRetentionScan:
+		c.scanParams = append(c.scanParams, params)
+		result, err := json.Marshal(c.expired)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewSuccessResponse(result, "", 0, 0), nil
+	case private.ShiroEndpointPurge:
+		var dsids []private.DSID
+		if err := json.Unmarshal(params, &dsids); err != nil {
+			return nil, err
+		}
+		if c.fail[dsids[0]] {
+			return types.NewFailureResponse(1, "purge failed", nil), nil
+		}
+		c.purged = append(c.purged, dsids[0])
+		return types.NewSuccessResponse(params[1:len(params)-1], "", 0, 0), nil
+	}
+	return nil, errors.New("unexpected method " + method)
+}
+
+func TestRetention(t *testing.T) {
+	_, err := private.NewRetention(nil, nil)
+	require.Error(t, err)
+	_, err = private.NewRetention(nil, []private.RetentionPolicy{{Profile: "customer"}})
+	require.Error(t, err)
+	_, err = private.NewRetention(nil, []private.RetentionPolicy{
+		{Profile: "customer", Retention: time.Hour},
+		{Profile: "customer", Retention: 2 * time.Hour},
+	})
+	require.Error(t, err)
+
+	client := &retentionClient{
+		expired: []*private.ExpiredSubject{
+			{DSID: "ds1", Profile: "customer", LastActivity: "2019-01-01T00:00:00Z"},
+			{DSID: "ds2", Profile: "employee", LastActivity: "2015-01-01T00:00:00Z"},
+			{DSID: "ds3", Profile: "customer", LastActivity: "2018-06-01T00:00:00Z"},
+			{DSID: "ds4", Profile: "vendor", LastActivity: "2010-01-01T00:00:00Z"},
+		},
+		fail: map[private.DSID]bool{"ds2": true},
+	}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var audited []*private.PurgeRecord
+	r, err := private.NewRetention(client, []private.RetentionPolicy{
+		{Profile: "customer", Retention: 5 * 365 * 24 * time.Hour},
+		{Profile: "employee", Retention: 10 * 365 * 24 * time.Hour},
+	},
+		private.WithRetentionClock(func() time.Time { return now }),
+		private.WithRetentionAudit(func(ctx context.Context, record *private.PurgeRecord) error {
+			audited = append(audited, record)
+			return nil
+		}))
+	require.NoError(t, err)
+
+	records, err := r.Sweep(context.Background())
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"policies": [
+		{"profile": "customer", "retention_seconds": 157680000},
+		{"profile": "employee", "retention_seconds": 315360000}
+	], "as_of": "2026-01-02T03:04:05Z"}]`, string(client.scanParams[0]))
+	require.Equal(t, []private.DSID{"ds1", "ds3"}, client.purged)
+	require.Equal(t, records, audited)
+	require.Len(t, records, 4)
+	require.NoError(t, records[0].Err)
+	require.Equal(t, 5*365*24*time.Hour, records[0].Retention)
+	require.Equal(t, now, records[0].PurgedAt)
+	require.EqualError(t, records[1].Err, "purge failed")
+	require.Equal(t, "employee", records[1].Profile)
+	// subjects of profiles without a policy are reported, not purged.
+	require.ErrorIs(t, records[3].Err, private.ErrNoRetentionPolicy)
+	require.Equal(t, "vendor", records[3].Profile)
+
+	// audit failures stop the sweep.
+	client.purged = nil
+	r, err = private.NewRetention(client, []private.RetentionPolicy{{Profile: "customer", Retention: time.Hour}},
+		private.WithRetentionAudit(func(ctx context.Context, record *private.PurgeRecord) error {
+			return errors.New("audit unavailable")
+		}))
+	require.NoError(t, err)
+	records, err = r.Sweep(context.Background())
+	require.ErrorContains(t, err, "audit unavailable")
+	require.Len(t, records, 1)
+	require.Equal(t, []private.DSID{"ds1"}, client.purged)
+}
+
+func TestRetentionRun(t *testing.T) {
+	client := &retentionClient{expired: []*private.ExpiredSubject{{DSID: "ds1", Profile: "customer"}}}
+	r, err := private.NewRetention(client, []private.RetentionPolicy{{Profile: "customer", Retention: time.Hour}})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx, batch.Every(time.Millisecond), nil) }()
+	require.Eventually(t, func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return len(client.purged) >= 2
+	}, time.Second, time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+// clockSchedule fires every millisecond, recording the times it is
+// evaluated at.
+type clockSchedule struct {
+	mu    sync.Mutex
+	times []time.Time
+}
+
+func (s *clockSchedule) Next(t time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.times = append(s.times, t)
+	return t.Add(time.Millisecond)
+}
+
+func TestRetentionRunClock(t *testing.T) {
+	client := &retentionClient{}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, err := private.NewRetention(client, []private.RetentionPolicy{{Profile: "customer", Retention: time.Hour}},
+		private.WithRetentionClock(func() time.Time { return now }))
+	require.NoError(t, err)
+	schedule := &clockSchedule{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx, schedule, nil) }()
+	require.Eventually(t, func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return len(client.scanParams) >= 1
+	}, time.Second, time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	schedule.mu.Lock()
+	defer schedule.mu.Unlock()
+	require.Equal(t, now, schedule.times[0])
+}
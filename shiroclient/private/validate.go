@@ -0,0 +1,224 @@
+package private
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TransformDiagnostic is a problem found in a transform by
+// ValidateTransforms.
+type TransformDiagnostic struct {
+	// Transform is the index of the transform.
+	Transform int
+	// Field is the JSON path of the offending field within the transform,
+	// e.g. "header.private_paths[1]".
+	Field string
+	// Offset is the byte offset of the problem within the field value, or
+	// -1 if it applies to the whole field.
+	Offset int
+	// Message describes the problem.
+	Message string
+}
+
+// String returns the diagnostic as "transforms[i].field:offset: message".
+func (d *TransformDiagnostic) String() string {
+	pos := fmt.Sprintf("transforms[%d]", d.Transform)
+	if d.Field != "" {
+		pos += "." + d.Field
+	}
+	if d.Offset >= 0 {
+		pos += fmt.Sprintf(":%d", d.Offset)
+	}
+	return pos + ": " + d.Message
+}
+
+// TransformValidationError is returned by ValidateTransforms.
+type TransformValidationError struct {
+	Diagnostics []*TransformDiagnostic
+}
+
+// Error implements error.
+func (e *TransformValidationError) Error() string {
+	lines := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		lines[i] = d.String()
+	}
+	return "invalid transforms: " + strings.Join(lines, "; ")
+}
+
+// ValidateTransforms checks transforms before they are sent to substrate,
+// which reports invalid transforms with little context.  It checks that
+//
+//   - every transform has a header, a context path, profile paths and
+//     private paths,
+//   - paths are valid elpspaths,
+//   - encryptors and compressors are supported, and
+//   - no private data is selected by more than one private path.
+//
+// Paths are relative to the root of the message ("."), and are made of
+// fields (".name"), array indexes ("[0]") and wildcards (".*" or "[*]").
+// If problems are found a *TransformValidationError listing all of them is
+// returned.
+func ValidateTransforms(transforms []*Transform) error {
+	v := &transformValidator{}
+	type privatePath struct {
+		transform int
+		field     string
+		segments  []string
+	}
+	var private []privatePath
+	for i, t := range transforms {
+		if t == nil {
+			v.add(i, "", "missing transform")
+			continue
+		}
+		context, ok := v.path(i, "context_path", t.ContextPath)
+		if t.Header == nil {
+			v.add(i, "header", "missing header")
+			continue
+		}
+		h := t.Header
+		if len(h.ProfilePaths) == 0 {
+			v.add(i, "header.profile_paths", "no profile paths")
+		}
+		for j, p := range h.ProfilePaths {
+			v.path(i, fmt.Sprintf("header.profile_paths[%d]", j), p)
+		}
+		if len(h.PrivatePaths) == 0 {
+			v.add(i, "header.private_paths", "no private paths")
+		}
+		for j, p := range h.PrivatePaths {
+			field := fmt.Sprintf("header.private_paths[%d]", j)
+			segments, pathOK := v.path(i, field, p)
+			if ok && pathOK {
+				private = append(private, privatePath{
+					transform: i,
+					field:     field,
+					segments:  append(append([]string(nil), context...), segments...),
+				})
+			}
+		}
+		switch h.Encryptor {
+		case EncryptorNone, EncryptorAES256:
+		default:
+			v.add(i, "header.encryptor", fmt.Sprintf("unsupported encryptor %q", h.Encryptor))
+		}
+		switch h.Compressor {
+		case CompressorNone, CompressorZlib:
+		default:
+			v.add(i, "header.compressor", fmt.Sprintf("unsupported compressor %q", h.Compressor))
+		}
+	}
+	for a := range private {
+		for b := a + 1; b < len(private); b++ {
+			pa, pb := private[a], private[b]
+			if pathsOverlap(pa.segments, pb.segments) {
+				v.add(pb.transform, pb.field, fmt.Sprintf("overlaps transforms[%d].%s", pa.transform, pa.field))
+			}
+		}
+	}
+	if len(v.diags) > 0 {
+		return &TransformValidationError{Diagnostics: v.diags}
+	}
+	return nil
+}
+
+type transformValidator struct {
+	diags []*TransformDiagnostic
+}
+
+func (v *transformValidator) add(transform int, field string, msg string) {
+	v.addAt(transform, field, -1, msg)
+}
+
+func (v *transformValidator) addAt(transform int, field string, offset int, msg string) {
+	v.diags = append(v.diags, &TransformDiagnostic{
+		Transform: transform,
+		Field:     field,
+		Offset:    offset,
+		Message:   msg,
+	})
+}
+
+// path parses an elpspath and reports its syntax errors.
+func (v *transformValidator) path(transform int, field string, path string) ([]string, bool) {
+	segments, offset, err := parsePath(path)
+	if err != nil {
+		v.addAt(transform, field, offset, err.Error())
+		return nil, false
+	}
+	return segments, true
+}
+
+// parsePath returns the segments of an elpspath: field names, array
+// indexes and "*" for wildcards.  On error it returns the offset of the
+// problem.
+func parsePath(path string) ([]string, int, error) {
+	if path == "" {
+		return nil, -1, fmt.Errorf("empty path")
+	}
+	if path[0] != '.' {
+		return nil, 0, fmt.Errorf("path must start with '.'")
+	}
+	if path == "." {
+		return nil, 0, nil
+	}
+	var segments []string
+	i := 0
+	for i < len(path) {
+		switch path[i] {
+		case '.':
+			start := i + 1
+			end := start
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				if !isPathNameByte(path[end]) {
+					return nil, end, fmt.Errorf("invalid character %q in field name", path[end])
+				}
+				end++
+			}
+			name := path[start:end]
+			if name == "" {
+				return nil, start, fmt.Errorf("empty field name")
+			}
+			if name != "*" && strings.Contains(name, "*") {
+				return nil, start, fmt.Errorf("wildcard must be a whole field name")
+			}
+			segments = append(segments, name)
+			i = end
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, i, fmt.Errorf("unterminated '['")
+			}
+			index := path[i+1 : i+end]
+			if index != "*" && (index == "" || strings.Trim(index, "0123456789") != "") {
+				return nil, i + 1, fmt.Errorf("invalid array index %q", index)
+			}
+			segments = append(segments, index)
+			i += end + 1
+		default:
+			return nil, i, fmt.Errorf("unexpected character %q", path[i])
+		}
+	}
+	return segments, 0, nil
+}
+
+func isPathNameByte(c byte) bool {
+	return c == '_' || c == '-' || c == '*' ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// pathsOverlap returns true if one path selects data within, or equal to,
+// the data selected by the other.
+func pathsOverlap(a, b []string) bool {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] && a[i] != "*" && b[i] != "*" {
+			return false
+		}
+	}
+	return true
+}
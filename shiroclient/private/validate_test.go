@@ -0,0 +1,96 @@
+package private_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+)
+
+func newTransform(context string, profile []string, privatePaths ...string) *private.Transform {
+	return &private.Transform{
+		ContextPath: context,
+		Header: &private.TransformHeader{
+			ProfilePaths: profile,
+			PrivatePaths: privatePaths,
+			Encryptor:    private.EncryptorAES256,
+			Compressor:   private.CompressorZlib,
+		},
+	}
+}
+
+func TestValidateTransforms(t *testing.T) {
+	require.NoError(t, private.ValidateTransforms(nil))
+	require.NoError(t, private.ValidateTransforms([]*private.Transform{
+		newTransform(".", []string{".fnord"}, "."),
+	}))
+	require.NoError(t, private.ValidateTransforms([]*private.Transform{
+		newTransform(".customers[*]", []string{".email"}, ".name", ".address"),
+		newTransform(".employees.*", []string{".id"}, ".salary"),
+	}))
+
+	unsupported := newTransform(".", []string{".id"}, ".ssn")
+	unsupported.Header.Encryptor = "rot13"
+	unsupported.Header.Compressor = ""
+
+	for _, test := range []struct {
+		name       string
+		transforms []*private.Transform
+		want       []string
+	}{
+		{
+			name:       "missing",
+			transforms: []*private.Transform{nil, {ContextPath: "."}},
+			want:       []string{"transforms[0]: missing transform", "transforms[1].header: missing header"},
+		},
+		{
+			name:       "syntax",
+			transforms: []*private.Transform{newTransform("", []string{"id"}, ".a..b", ".a[x]", ".a[1", ".a$b", ".a*")},
+			want: []string{
+				"transforms[0].context_path: empty path",
+				"transforms[0].header.profile_paths[0]:0: path must start with '.'",
+				"transforms[0].header.private_paths[0]:3: empty field name",
+				"transforms[0].header.private_paths[1]:3: invalid array index \"x\"",
+				"transforms[0].header.private_paths[2]:2: unterminated '['",
+				"transforms[0].header.private_paths[3]:2: invalid character '$' in field name",
+				"transforms[0].header.private_paths[4]:1: wildcard must be a whole field name",
+			},
+		},
+		{
+			name:       "empty paths",
+			transforms: []*private.Transform{newTransform(".", nil)},
+			want:       []string{"transforms[0].header.profile_paths: no profile paths", "transforms[0].header.private_paths: no private paths"},
+		},
+		{
+			name:       "unsupported",
+			transforms: []*private.Transform{unsupported},
+			want:       []string{`transforms[0].header.encryptor: unsupported encryptor "rot13"`, `transforms[0].header.compressor: unsupported compressor ""`},
+		},
+		{
+			name: "overlap",
+			transforms: []*private.Transform{
+				newTransform(".customers", []string{".id"}, ".list"),
+				newTransform(".", []string{".id"}, ".customers.list[3]"),
+				newTransform(".customers.*", []string{".id"}, "."),
+			},
+			want: []string{
+				"transforms[1].header.private_paths[0]: overlaps transforms[0].header.private_paths[0]",
+				"transforms[2].header.private_paths[0]: overlaps transforms[0].header.private_paths[0]",
+				"transforms[2].header.private_paths[0]: overlaps transforms[1].header.private_paths[0]",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := private.ValidateTransforms(test.transforms)
+			var verr *private.TransformValidationError
+			require.True(t, errors.As(err, &verr))
+			var got []string
+			for _, d := range verr.Diagnostics {
+				got = append(got, d.String())
+			}
+			require.Equal(t, test.want, got)
+		})
+	}
+}
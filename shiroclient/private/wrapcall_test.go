@@ -0,0 +1,163 @@
+package private_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private/privatetest"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/txctx"
+)
+
+// statsClient reports stats for calls, like the RPC client.
+type statsClient struct {
+	*privatetest.Client
+}
+
+func (c *statsClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.Call(ctx, method, configs...)
+	if opt.Stats != nil {
+		opt.Stats(types.CallStats{PhylumMethod: method, Endpoint: "http://gateway", Retries: 2})
+	}
+	return resp, err
+}
+
+func TestWrapCallResult(t *testing.T) {
+	fake := privatetest.New()
+	fake.Handle("hello", func(ctx context.Context, message json.RawMessage) (interface{}, error) {
+		time.Sleep(time.Millisecond)
+		return message, nil
+	})
+	client := &statsClient{Client: fake}
+	transforms := []*private.Transform{{
+		ContextPath: ".",
+		Header: &private.TransformHeader{
+			ProfilePaths: []string{".id"},
+			PrivatePaths: []string{"."},
+			Encryptor:    private.EncryptorAES256,
+			Compressor:   private.CompressorZlib,
+		},
+	}}
+	var stats []shiroclient.CallStats
+	var out map[string]string
+	result, err := private.WrapCall(client, "hello", transforms...)(context.Background(), map[string]string{"id": "1"}, &out,
+		shiroclient.WithID("req-1"),
+		shiroclient.WithStats(func(s shiroclient.CallStats) { stats = append(stats, s) }))
+	require.NoError(t, err)
+	require.Equal(t, "req-1", result.RequestID)
+	require.Empty(t, result.GatewayRequestID)
+	require.Equal(t, "http://gateway", result.Endpoint)
+	require.Equal(t, 2, result.Retries)
+	require.NotEmpty(t, result.TransactionID)
+	require.GreaterOrEqual(t, result.Timing.Call, time.Millisecond)
+	require.Equal(t, result.Timing.Encode+result.Timing.Call+result.Timing.Decode, result.Timing.Total())
+
+	// the caller's stats hook still sees every request.  The response is
+	// not encoded, so it is decoded without a request.
+	var methods []string
+	for _, s := range stats {
+		methods = append(methods, s.PhylumMethod)
+	}
+	require.Equal(t, []string{private.ShiroEndpointEncode, "hello"}, methods)
+}
+
+// wrappedConfig wraps a config, as middleware of other packages may.
+type wrappedConfig struct {
+	shiroclient.Config
+}
+
+func TestWrapCallSkipEncodeTx(t *testing.T) {
+	fake := privatetest.New()
+	fake.Handle("hello", func(ctx context.Context, message json.RawMessage) (interface{}, error) {
+		return message, nil
+	})
+	client := &statsClient{Client: fake}
+	transforms := []*private.Transform{{
+		ContextPath: ".",
+		Header: &private.TransformHeader{
+			ProfilePaths: []string{".id"},
+			PrivatePaths: []string{"."},
+			Encryptor:    private.EncryptorAES256,
+			Compressor:   private.CompressorZlib,
+		},
+	}}
+	for name, config := range map[string]shiroclient.Config{
+		"plain":   private.WithSkipEncodeTx(),
+		"wrapped": wrappedConfig{private.WithSkipEncodeTx()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var methods []string
+			var out map[string]string
+			_, err := private.WrapCall(client, "hello", transforms...)(context.Background(), map[string]string{"id": "1"}, &out,
+				config,
+				shiroclient.WithStats(func(s shiroclient.CallStats) { methods = append(methods, s.PhylumMethod) }))
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"id": "1"}, out)
+			require.Equal(t, []string{"hello"}, methods)
+		})
+	}
+}
+
+// depsClient records the dependency of each call.
+type depsClient struct {
+	*privatetest.Client
+	deps []string
+}
+
+func (c *depsClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	c.deps = append(c.deps, method+":"+opt.DependentTxID)
+	return c.Client.Call(ctx, method, configs...)
+}
+
+func TestWrapCallTxContext(t *testing.T) {
+	fake := privatetest.New()
+	fake.Handle("hello", func(ctx context.Context, message json.RawMessage) (interface{}, error) {
+		return message, nil
+	})
+	client := &depsClient{Client: fake}
+	transforms := []*private.Transform{{
+		ContextPath: ".",
+		Header: &private.TransformHeader{
+			ProfilePaths: []string{".id"},
+			PrivatePaths: []string{"."},
+			Encryptor:    private.EncryptorAES256,
+			Compressor:   private.CompressorZlib,
+		},
+	}}
+	ctx := txctx.ContextWithID(context.Background(), "tx0")
+	var out map[string]string
+	result, err := private.WrapCall(client, "hello", transforms...)(ctx, map[string]string{"id": "1"}, &out)
+	require.NoError(t, err)
+	require.Equal(t, result.TransactionID, txctx.ID(ctx))
+	require.Equal(t, []string{private.ShiroEndpointEncode + ":tx0", "hello:privatetest-tx1"}, client.deps)
+}
+
+func TestWrapCallInvalidTransforms(t *testing.T) {
+	fake := privatetest.New()
+	client := &depsClient{Client: fake}
+	transforms := []*private.Transform{{
+		ContextPath: ".",
+		Header:      &private.TransformHeader{PrivatePaths: []string{"."}},
+	}}
+	_, err := private.WrapCall(client, "hello", transforms...)(context.Background(), map[string]string{"id": "1"}, nil)
+	var verr *private.TransformValidationError
+	require.ErrorAs(t, err, &verr)
+	_, err = private.Encode(context.Background(), client, map[string]string{"id": "1"}, transforms)
+	require.ErrorAs(t, err, &verr)
+	// invalid transforms are rejected without calling the phylum.
+	require.Empty(t, client.deps)
+}
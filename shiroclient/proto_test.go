@@ -0,0 +1,84 @@
+package shiroclient_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// legacyMessage is a MessageV1 without reflection support, like messages
+// generated by old versions of protoc-gen-go.
+type legacyMessage struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (m *legacyMessage) Reset()         { *m = legacyMessage{} }
+func (m *legacyMessage) String() string { return m.Name }
+func (*legacyMessage) ProtoMessage()    {}
+
+func TestUnmarshalProtoLegacy(t *testing.T) {
+	t.Cleanup(func() { shiroclient.SetLegacyUnmarshalOptions(shiroclient.LegacyUnmarshalOptions{}) })
+	src := []byte(`{"name":"a","extra":1}`)
+
+	msg := &legacyMessage{}
+	require.Error(t, shiroclient.UnmarshalProto(src, msg))
+
+	shiroclient.SetLegacyUnmarshalOptions(shiroclient.LegacyUnmarshalOptions{AllowUnknownFields: true})
+	require.NoError(t, shiroclient.UnmarshalProto(src, msg))
+	require.Equal(t, "a", msg.Name)
+}
+
+func TestRegisterProtoUnmarshaler(t *testing.T) {
+	errCustom := errors.New("custom")
+	shiroclient.RegisterProtoUnmarshaler((*legacyMessage)(nil), func(src []byte, dst interface{}) error {
+		dst.(*legacyMessage).Name = string(src)
+		return nil
+	})
+	t.Cleanup(func() { shiroclient.RegisterProtoUnmarshaler((*legacyMessage)(nil), nil) })
+
+	msg := &legacyMessage{}
+	require.NoError(t, shiroclient.UnmarshalProto([]byte("raw"), msg))
+	require.Equal(t, "raw", msg.Name)
+
+	// other types are unaffected.
+	shiroclient.RegisterProtoUnmarshaler((*healthcheck)(nil), func(src []byte, dst interface{}) error {
+		return errCustom
+	})
+	t.Cleanup(func() { shiroclient.RegisterProtoUnmarshaler((*healthcheck)(nil), nil) })
+	require.ErrorIs(t, shiroclient.UnmarshalProto([]byte(`{}`), &healthcheck{}), errCustom)
+	var m map[string]interface{}
+	require.NoError(t, shiroclient.UnmarshalProto([]byte(`{}`), &m))
+
+	resp := types.NewSuccessResponse([]byte("raw2"), "tx1", 0, 0)
+	require.NoError(t, resp.UnmarshalTo(msg))
+	require.Equal(t, "raw2", msg.Name)
+}
+
+func TestProtoParams(t *testing.T) {
+	msg := &descriptorpb.FieldDescriptorProto{TypeName: proto.String(".pkg.Msg")}
+	b, err := json.Marshal(shiroclient.ProtoParams(msg))
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"type_name":".pkg.Msg"}]`, string(b))
+
+	b, err = json.Marshal(shiroclient.ProtoParamsWithOptions(protojson.MarshalOptions{}, msg))
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"typeName":".pkg.Msg"}]`, string(b))
+
+	b, err = shiroclient.MarshalProto(msg)
+	require.NoError(t, err)
+	out := &descriptorpb.FieldDescriptorProto{}
+	require.NoError(t, shiroclient.UnmarshalProto(b, out))
+	require.True(t, proto.Equal(msg, out))
+
+	b, err = shiroclient.MarshalProto(&legacyMessage{Name: "a"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":"a"}`, string(b))
+}
@@ -0,0 +1,67 @@
+package shiroclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrTxNotFound is returned by GetReceipt when the transaction is not in
+// the ledger.
+var ErrTxNotFound = errors.New("transaction not found")
+
+// Receipt is evidence that a transaction was committed, suitable for
+// external audit.
+type Receipt struct {
+	TxID           string         `json:"tx_id"`
+	BlockNumber    uint64         `json:"block_number"`
+	BlockHash      string         `json:"block_hash"`
+	ValidationCode ValidationCode `json:"validation_code"`
+	ChaincodeID    string         `json:"chaincode_id"`
+	// EventHash is the hex encoded SHA-256 hash of the chaincode event of
+	// the transaction, or empty if it emitted none.
+	EventHash string `json:"event_hash,omitempty"`
+	// Header is the signed header of the block, needed to verify the
+	// inclusion of the transaction offline.  It is nil unless the gateway
+	// returns block headers.
+	Header *BlockHeader `json:"header,omitempty"`
+}
+
+// GetReceipt returns the receipt of the transaction txID, searching the
+// blocks from fromBlock up to the current height.  Pass the commit block
+// of the transaction, as reported by GetCommitMetadata, to fetch a single
+// block.  An error wrapping ErrTxNotFound is returned if the transaction
+// was not found.
+func GetReceipt(ctx context.Context, client ShiroClient, txID string, fromBlock uint64, configs ...Config) (*Receipt, error) {
+	height, err := client.QueryInfo(ctx, configs...)
+	if err != nil {
+		return nil, err
+	}
+	for n := fromBlock; n < height; n++ {
+		blk, err := client.QueryBlock(ctx, n, configs...)
+		if err != nil {
+			return nil, fmt.Errorf("query block %d: %w", n, err)
+		}
+		for _, tx := range blk.Transactions() {
+			if tx.ID() != txID {
+				continue
+			}
+			r := &Receipt{
+				TxID:           txID,
+				BlockNumber:    n,
+				BlockHash:      blk.Hash(),
+				ValidationCode: TxValidationCode(tx),
+				ChaincodeID:    tx.ChaincodeID(),
+				Header:         GetBlockHeader(blk),
+			}
+			if event := tx.Event(); len(event) > 0 {
+				sum := sha256.Sum256(event)
+				r.EventHash = hex.EncodeToString(sum[:])
+			}
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s at or after block %d", ErrTxNotFound, txID, fromBlock)
+}
@@ -0,0 +1,64 @@
+package shiroclient_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// ledgerClient is a ShiroClient serving a fixed ledger.
+type ledgerClient struct {
+	shiroclient.ShiroClient
+	blocks []shiroclient.Block
+}
+
+func (c *ledgerClient) QueryInfo(ctx context.Context, configs ...shiroclient.Config) (uint64, error) {
+	return uint64(len(c.blocks)), nil
+}
+
+func (c *ledgerClient) QueryBlock(ctx context.Context, blockNumber uint64, configs ...shiroclient.Config) (shiroclient.Block, error) {
+	return c.blocks[blockNumber], nil
+}
+
+func TestGetReceipt(t *testing.T) {
+	header := &shiroclient.BlockHeader{Number: 1, PreviousHash: "h0", DataHash: "d1"}
+	client := &ledgerClient{blocks: []shiroclient.Block{
+		types.NewBlock("h0", []types.Transaction{types.NewTransaction("tx0", "", nil, "cc")}),
+		types.NewBlockWithHeader("h1", []types.Transaction{
+			types.NewTransaction("tx1", "MVCC_READ_CONFLICT", nil, "cc"),
+			types.NewTransaction("tx2", "", []byte(`{"name":"created"}`), "cc"),
+		}, header),
+	}}
+	ctx := context.Background()
+
+	r, err := shiroclient.GetReceipt(ctx, client, "tx2", 0)
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte(`{"name":"created"}`))
+	require.Equal(t, &shiroclient.Receipt{
+		TxID:           "tx2",
+		BlockNumber:    1,
+		BlockHash:      "h1",
+		ValidationCode: shiroclient.ValidationValid,
+		ChaincodeID:    "cc",
+		EventHash:      hex.EncodeToString(sum[:]),
+		Header:         header,
+	}, r)
+
+	r, err = shiroclient.GetReceipt(ctx, client, "tx1", 1)
+	require.NoError(t, err)
+	require.Equal(t, shiroclient.ValidationMVCCReadConflict, r.ValidationCode)
+	require.Empty(t, r.EventHash)
+
+	r, err = shiroclient.GetReceipt(ctx, client, "tx0", 0)
+	require.NoError(t, err)
+	require.Nil(t, r.Header)
+
+	_, err = shiroclient.GetReceipt(ctx, client, "tx0", 1)
+	require.ErrorIs(t, err, shiroclient.ErrTxNotFound)
+}
@@ -0,0 +1,170 @@
+package shiroclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Profile is a named set of base configs held by a ClientRegistry, e.g. for
+// a tenant or an environment.  The Endpoint, AuthTokenProvider and
+// PhylumVersion fields are convenience overrides that are applied after
+// the registry's base configs and the profile's Configs.
+type Profile struct {
+	// Configs are base configs specific to the profile.
+	Configs []Config
+	// Endpoint overrides the gateway endpoint, if not empty.
+	Endpoint string
+	// AuthTokenProvider overrides the authorization token, if not nil.
+	AuthTokenProvider func(context.Context) (string, error)
+	// PhylumVersion overrides the phylum version, if not empty.
+	PhylumVersion string
+}
+
+func (p *Profile) configs() []Config {
+	configs := make([]Config, 0, len(p.Configs)+3)
+	configs = append(configs, p.Configs...)
+	if p.Endpoint != "" {
+		configs = append(configs, WithEndpoint(p.Endpoint))
+	}
+	if p.AuthTokenProvider != nil {
+		configs = append(configs, WithAuthTokenProvider(p.AuthTokenProvider))
+	}
+	if p.PhylumVersion != "" {
+		configs = append(configs, WithPhylumVersion(p.PhylumVersion))
+	}
+	return configs
+}
+
+// ClientRegistry holds named profiles and returns RPC clients derived from
+// them.  All clients returned by a registry share a single HTTP transport,
+// so connections are pooled across profiles.  The registry owns its
+// clients: they are shut down when their profile is replaced or removed,
+// and by Shutdown and Close.  A ClientRegistry is safe for concurrent use.
+type ClientRegistry struct {
+	httpClient *http.Client
+	transport  *http.Transport
+	baseConfig []Config
+
+	mu       sync.Mutex
+	profiles map[string]*Profile
+	clients  map[string]ShiroClient
+	closed   bool
+}
+
+// NewClientRegistry creates a registry whose clients are configured with
+// baseConfigs followed by the configs of their profile.  A WithHTTPClient
+// config in baseConfigs or a profile replaces the shared HTTP client.
+func NewClientRegistry(baseConfigs []Config) *ClientRegistry {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	return &ClientRegistry{
+		httpClient: &http.Client{Transport: transport},
+		transport:  transport,
+		baseConfig: append([]Config(nil), baseConfigs...),
+		profiles:   make(map[string]*Profile),
+		clients:    make(map[string]ShiroClient),
+	}
+}
+
+// Register adds or replaces the profile with the given name.  A client
+// previously returned for the name is shut down in the background: its
+// outstanding requests complete and new requests fail with an error.
+func (r *ClientRegistry) Register(name string, profile Profile) {
+	profile.Configs = append([]Config(nil), profile.Configs...)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profiles[name] = &profile
+	r.dropClient(name)
+}
+
+// Unregister removes the profile with the given name, shutting down its
+// client as Register does.
+func (r *ClientRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.profiles, name)
+	r.dropClient(name)
+}
+
+// dropClient removes the client of the named profile and shuts it down.
+// r.mu must be held.
+func (r *ClientRegistry) dropClient(name string) {
+	client, ok := r.clients[name]
+	if !ok {
+		return
+	}
+	delete(r.clients, name)
+	go func() { _ = Shutdown(context.Background(), client) }()
+}
+
+// Names returns the sorted names of registered profiles.
+func (r *ClientRegistry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.profiles))
+	for name := range r.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Client returns the client for the named profile.  The same client is
+// returned for a name until its profile is replaced.
+func (r *ClientRegistry) Client(name string) (ShiroClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, errors.New("client registry: closed")
+	}
+	if client, ok := r.clients[name]; ok {
+		return client, nil
+	}
+	profile, ok := r.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("client registry: unknown profile %q", name)
+	}
+	profileConfigs := profile.configs()
+	configs := make([]Config, 0, 1+len(r.baseConfig)+len(profileConfigs))
+	configs = append(configs, WithHTTPClient(r.httpClient))
+	configs = append(configs, r.baseConfig...)
+	configs = append(configs, profileConfigs...)
+	client := NewRPC(configs)
+	r.clients[name] = client
+	return client, nil
+}
+
+// Shutdown shuts down the clients obtained from the registry, waiting for
+// their outstanding requests until ctx is done, and closes the idle
+// connections of the shared transport.  Client fails once the registry is
+// shut down.
+func (r *ClientRegistry) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	clients := r.clients
+	r.clients = make(map[string]ShiroClient)
+	r.closed = true
+	r.mu.Unlock()
+	var errs []error
+	for name, client := range clients {
+		if err := Shutdown(ctx, client); err != nil {
+			errs = append(errs, fmt.Errorf("client registry: %s: %w", name, err))
+		}
+	}
+	r.transport.CloseIdleConnections()
+	return errors.Join(errs...)
+}
+
+// Close shuts down the registry as Shutdown does, canceling outstanding
+// requests.
+func (r *ClientRegistry) Close() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := r.Shutdown(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
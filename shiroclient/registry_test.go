@@ -0,0 +1,82 @@
+package shiroclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+func heightServer(t *testing.T, auth *string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*auth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":"1","result":{"error_level":0,"result":3,"code":0,"message":"","data":null}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientRegistry(t *testing.T) {
+	var authA, authB string
+	srvA := heightServer(t, &authA)
+	srvB := heightServer(t, &authB)
+
+	reg := shiroclient.NewClientRegistry([]shiroclient.Config{shiroclient.WithAuthToken("base")})
+	t.Cleanup(func() { require.NoError(t, reg.Close()) })
+	reg.Register("a", shiroclient.Profile{Endpoint: srvA.URL})
+	reg.Register("b", shiroclient.Profile{
+		Endpoint: srvB.URL,
+		AuthTokenProvider: func(context.Context) (string, error) {
+			return "tenant-b", nil
+		},
+	})
+	require.Equal(t, []string{"a", "b"}, reg.Names())
+
+	ctx := context.Background()
+	clientA, err := reg.Client("a")
+	require.NoError(t, err)
+	_, err = clientA.QueryInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer base", authA)
+
+	clientB, err := reg.Client("b")
+	require.NoError(t, err)
+	_, err = clientB.QueryInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer tenant-b", authB)
+
+	again, err := reg.Client("a")
+	require.NoError(t, err)
+	require.True(t, clientA == again, "expected the cached client")
+
+	// replaced and removed clients are shut down.
+	reg.Register("b", shiroclient.Profile{Endpoint: srvB.URL})
+	require.Eventually(t, func() bool {
+		_, err := clientB.QueryInfo(ctx)
+		return errors.Is(err, shiroclient.ErrClientClosed)
+	}, time.Second, time.Millisecond)
+	clientB, err = reg.Client("b")
+	require.NoError(t, err)
+	_, err = clientB.QueryInfo(ctx)
+	require.NoError(t, err)
+
+	reg.Unregister("a")
+	_, err = reg.Client("a")
+	require.Error(t, err)
+	require.Eventually(t, func() bool {
+		_, err := clientA.QueryInfo(ctx)
+		return errors.Is(err, shiroclient.ErrClientClosed)
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, reg.Close())
+	_, err = clientB.QueryInfo(ctx)
+	require.ErrorIs(t, err, shiroclient.ErrClientClosed)
+	_, err = reg.Client("b")
+	require.Error(t, err)
+}
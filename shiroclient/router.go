@@ -0,0 +1,158 @@
+package shiroclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RouteFunc selects the name of the route of a request to method, or of a
+// request other than Call if method is empty.
+type RouteFunc func(ctx context.Context, method string) (string, error)
+
+// RouteByMethodPrefix routes calls to the route of the longest prefix of
+// their method in prefixes, and other requests to fallback.
+//
+//	route := shiroclient.RouteByMethodPrefix(map[string]string{
+//		"payments_": "payments",
+//		"kyc_":      "kyc",
+//	}, "core")
+func RouteByMethodPrefix(prefixes map[string]string, fallback string) RouteFunc {
+	return func(ctx context.Context, method string) (string, error) {
+		route := fallback
+		var longest int
+		var found bool
+		for prefix, name := range prefixes {
+			if strings.HasPrefix(method, prefix) && (!found || len(prefix) > longest) {
+				route, longest, found = name, len(prefix), true
+			}
+		}
+		return route, nil
+	}
+}
+
+type routeKey struct{}
+
+// ContextWithRoute returns a copy of ctx whose requests are routed to the
+// named route by routers using RouteByContext, e.g. the route of the tenant
+// of a request.
+func ContextWithRoute(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, routeKey{}, name)
+}
+
+// RouteFromContext returns the route set by ContextWithRoute, or an empty
+// string if there is none.
+func RouteFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(routeKey{}).(string)
+	return name
+}
+
+// RouteByContext routes requests to the route set by ContextWithRoute, and
+// requests without one with next.
+func RouteByContext(next RouteFunc) RouteFunc {
+	return func(ctx context.Context, method string) (string, error) {
+		if name := RouteFromContext(ctx); name != "" {
+			return name, nil
+		}
+		return next(ctx, method)
+	}
+}
+
+// Routes returns a route lookup for NewRouter from a map of clients.
+func Routes(clients map[string]ShiroClient) func(name string) (ShiroClient, error) {
+	return func(name string) (ShiroClient, error) {
+		client, ok := clients[name]
+		if !ok {
+			return nil, fmt.Errorf("router: unknown route %q", name)
+		}
+		return client, nil
+	}
+}
+
+// Router is a ShiroClient dispatching each request to one of several
+// clients, e.g. for different phylum versions or channels, so that a
+// service spanning several phyla uses a single client.
+//
+//	registry := shiroclient.NewClientRegistry(baseConfigs)
+//	registry.Register("payments", shiroclient.Profile{PhylumVersion: "v2"})
+//	registry.Register("core", shiroclient.Profile{Endpoint: coreURL})
+//	client := shiroclient.NewRouter(
+//		shiroclient.RouteByContext(shiroclient.RouteByMethodPrefix(
+//			map[string]string{"payments_": "payments"}, "core")),
+//		registry.Client)
+type Router struct {
+	route  RouteFunc
+	lookup func(name string) (ShiroClient, error)
+}
+
+var _ ShiroClient = (*Router)(nil)
+
+// NewRouter returns a client routing requests with route to the clients
+// returned by lookup, such as ClientRegistry.Client or Routes.
+func NewRouter(route RouteFunc, lookup func(name string) (ShiroClient, error)) *Router {
+	return &Router{route: route, lookup: lookup}
+}
+
+// Client returns the client of requests to method made with ctx, or of
+// requests other than Call if method is empty.
+func (r *Router) Client(ctx context.Context, method string) (ShiroClient, error) {
+	name, err := r.route(ctx, method)
+	if err != nil {
+		return nil, fmt.Errorf("router: %w", err)
+	}
+	return r.lookup(name)
+}
+
+// Seed implements ShiroClient.
+func (r *Router) Seed(ctx context.Context, version string, configs ...Config) error {
+	client, err := r.Client(ctx, "")
+	if err != nil {
+		return err
+	}
+	return client.Seed(ctx, version, configs...)
+}
+
+// ShiroPhylum implements ShiroClient.
+func (r *Router) ShiroPhylum(ctx context.Context, configs ...Config) (string, error) {
+	client, err := r.Client(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	return client.ShiroPhylum(ctx, configs...)
+}
+
+// Init implements ShiroClient.
+func (r *Router) Init(ctx context.Context, phylum string, configs ...Config) error {
+	client, err := r.Client(ctx, "")
+	if err != nil {
+		return err
+	}
+	return client.Init(ctx, phylum, configs...)
+}
+
+// Call implements ShiroClient.
+func (r *Router) Call(ctx context.Context, method string, configs ...Config) (ShiroResponse, error) {
+	client, err := r.Client(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	return client.Call(ctx, method, configs...)
+}
+
+// QueryInfo implements ShiroClient.
+func (r *Router) QueryInfo(ctx context.Context, configs ...Config) (uint64, error) {
+	client, err := r.Client(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	return client.QueryInfo(ctx, configs...)
+}
+
+// QueryBlock implements ShiroClient.
+func (r *Router) QueryBlock(ctx context.Context, blockNumber uint64, configs ...Config) (Block, error) {
+	client, err := r.Client(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return client.QueryBlock(ctx, blockNumber, configs...)
+}
@@ -0,0 +1,71 @@
+package shiroclient_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// routeClient answers calls and height queries with its name.
+type routeClient struct {
+	shiroclient.ShiroClient
+	name   string
+	height uint64
+}
+
+func (c *routeClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	return types.NewSuccessResponse([]byte(`"`+c.name+`"`), "", 0, 0), nil
+}
+
+func (c *routeClient) QueryInfo(ctx context.Context, configs ...shiroclient.Config) (uint64, error) {
+	return c.height, nil
+}
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	router := shiroclient.NewRouter(
+		shiroclient.RouteByContext(shiroclient.RouteByMethodPrefix(map[string]string{
+			"pay_":     "payments",
+			"pay_kyc_": "kyc",
+		}, "core")),
+		shiroclient.Routes(map[string]shiroclient.ShiroClient{
+			"core":     &routeClient{name: "core", height: 1},
+			"payments": &routeClient{name: "payments", height: 2},
+			"kyc":      &routeClient{name: "kyc", height: 3},
+			"tenant1":  &routeClient{name: "tenant1", height: 4},
+		}))
+
+	route := func(ctx context.Context, method string) string {
+		resp, err := router.Call(ctx, method)
+		require.NoError(t, err)
+		return string(resp.ResultJSON())
+	}
+	require.Equal(t, `"core"`, route(ctx, "get_account"))
+	require.Equal(t, `"payments"`, route(ctx, "pay_invoice"))
+	require.Equal(t, `"kyc"`, route(ctx, "pay_kyc_check"))
+
+	tenantCtx := shiroclient.ContextWithRoute(ctx, "tenant1")
+	require.Equal(t, "tenant1", shiroclient.RouteFromContext(tenantCtx))
+	require.Equal(t, `"tenant1"`, route(tenantCtx, "pay_invoice"))
+
+	height, err := router.QueryInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), height)
+	height, err = router.QueryInfo(tenantCtx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), height)
+
+	_, err = router.Call(shiroclient.ContextWithRoute(ctx, "missing"), "get_account")
+	require.EqualError(t, err, `router: unknown route "missing"`)
+
+	failing := shiroclient.NewRouter(func(ctx context.Context, method string) (string, error) {
+		return "", errors.New("no tenant")
+	}, nil)
+	_, err = failing.Call(ctx, "get_account")
+	require.EqualError(t, err, "router: no tenant")
+}
@@ -0,0 +1,191 @@
+// Package secrets obtains the credentials of a client from a secret store,
+// like HashiCorp Vault or a cloud KMS, instead of environment variables.
+// Secrets are fetched when they are used, so rotating them in the store
+// takes effect without restarting the process.
+//
+//	vault, err := secrets.NewVault(secrets.VaultConfig{
+//		Address:   "https://vault:8200",
+//		TokenFile: "/vault/token",
+//	})
+//	store := secrets.NewCache(vault, 5*time.Minute)
+//	client := shiroclient.NewRPC([]shiroclient.Config{
+//		shiroclient.WithEndpoint(endpoint),
+//		secrets.WithAuthToken(store, "shiro/gateway#token"),
+//		shiroclient.WithHTTPClient(&http.Client{Transport: &http.Transport{
+//			TLSClientConfig: secrets.TLSConfig(store, "shiro/tls#cert", "shiro/tls#key"),
+//		}}),
+//	})
+//	private.SeedGen = secrets.SeedGen(vault)
+package secrets
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// Provider returns secrets by name.  The format of names depends on the
+// provider.  Implementations must be safe for concurrent use.
+type Provider interface {
+	GetSecret(ctx context.Context, name string) ([]byte, error)
+}
+
+// ProviderFunc is a function implementing Provider.
+type ProviderFunc func(ctx context.Context, name string) ([]byte, error)
+
+// GetSecret implements Provider.
+func (f ProviderFunc) GetSecret(ctx context.Context, name string) ([]byte, error) {
+	return f(ctx, name)
+}
+
+// EntropySource returns random bytes generated outside of the process, e.g.
+// by an HSM.
+type EntropySource interface {
+	Random(ctx context.Context, n int) ([]byte, error)
+}
+
+// NewCache returns a provider caching the secrets of p for ttl, so that
+// secrets are not fetched for each request.  Rotated secrets are used
+// within ttl of their rotation.
+func NewCache(p Provider, ttl time.Duration) Provider {
+	return &cache{p: p, ttl: ttl, entries: make(map[string]*cacheEntry)}
+}
+
+type cache struct {
+	p   Provider
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func (c *cache) GetSecret(ctx context.Context, name string) ([]byte, error) {
+	now := time.Now()
+	c.mu.Lock()
+	e, ok := c.entries[name]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.value, nil
+	}
+	value, err := c.p.GetSecret(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[name] = &cacheEntry{value: value, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// WithAuthToken obtains the authorization token of each request from the
+// secret name of p, see shiroclient.WithAuthTokenProvider.  Surrounding
+// whitespace is removed from the secret.
+func WithAuthToken(p Provider, name string) shiroclient.Config {
+	return shiroclient.WithAuthTokenProvider(func(ctx context.Context) (string, error) {
+		token, err := p.GetSecret(ctx, name)
+		if err != nil {
+			return "", fmt.Errorf("secrets: auth token: %w", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	})
+}
+
+// TLSConfig returns a TLS client configuration presenting the PEM encoded
+// certificate and key held in the secrets certName and keyName of p.  They
+// are fetched for each handshake, so pass a cached provider.  Trusted CAs
+// may be set in the RootCAs field of the result.
+func TLSConfig(p Provider, certName string, keyName string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			ctx := info.Context()
+			certPEM, err := p.GetSecret(ctx, certName)
+			if err != nil {
+				return nil, fmt.Errorf("secrets: tls certificate: %w", err)
+			}
+			keyPEM, err := p.GetSecret(ctx, keyName)
+			if err != nil {
+				return nil, fmt.Errorf("secrets: tls key: %w", err)
+			}
+			cert, err := tls.X509KeyPair(certPEM, keyPEM)
+			if err != nil {
+				return nil, fmt.Errorf("secrets: tls: %w", err)
+			}
+			return &cert, nil
+		},
+	}
+}
+
+// HMACSigner signs requests with an HMAC-SHA256 of their body, keyed by a
+// secret shared with the gateway.
+type HMACSigner struct {
+	p    Provider
+	name string
+}
+
+var _ shiroclient.RequestSigner = (*HMACSigner)(nil)
+
+// NewHMACSigner returns a signer keyed by the secret name of p.  Pass it to
+// shiroclient.WithRequestSigner.
+func NewHMACSigner(p Provider, name string) *HMACSigner {
+	return &HMACSigner{p: p, name: name}
+}
+
+// SignRequest implements shiroclient.RequestSigner.  No certificate is
+// returned.
+func (s *HMACSigner) SignRequest(ctx context.Context, body []byte) ([]byte, []byte, error) {
+	key, err := s.p.GetSecret(ctx, s.name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("secrets: hmac key: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return mac.Sum(nil), nil, nil
+}
+
+// seedSize is the size of the keys generated by private.SeedGen.
+const seedSize = 32
+
+// SeedGenTimeout bounds the time the generator returned by SeedGen waits
+// for entropy, since private.SeedGen is not given the context of the
+// request.
+const SeedGenTimeout = 10 * time.Second
+
+// SeedGen returns a key generator for private.SeedGen taking entropy from
+// src.  The entropy is mixed with the output of crypto/rand, so keys are no
+// weaker than those of the default generator even if src is compromised.
+// Keys fail to be generated if src does not respond within SeedGenTimeout.
+func SeedGen(src EntropySource) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), SeedGenTimeout)
+		defer cancel()
+		ext, err := src.Random(ctx, seedSize)
+		if err != nil {
+			return nil, fmt.Errorf("secrets: entropy: %w", err)
+		}
+		if len(ext) < seedSize {
+			return nil, fmt.Errorf("secrets: entropy: got %d bytes, want %d", len(ext), seedSize)
+		}
+		key := make([]byte, seedSize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		for i := range key {
+			key[i] ^= ext[i]
+		}
+		return key, nil
+	}
+}
@@ -0,0 +1,163 @@
+package secrets_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/secrets"
+	"github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+)
+
+// fakeVault serves the KV version 2 secrets in kv, keyed by path, to
+// requests with the token "root", and random bytes.
+func fakeVault(t *testing.T, kv map[string]map[string]string) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, `{"errors":["permission denied"]}`)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/v1/sys/tools/random/") {
+			_, _ = io.WriteString(w, `{"data":{"random_bytes":"`+base64.StdEncoding.EncodeToString(make([]byte, 32))+`"}}`)
+			return
+		}
+		data, ok := kv[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"errors":[]}`)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestVault(t *testing.T) {
+	kv := map[string]map[string]string{
+		"shiro/gateway": {"token": "t1", "value": "v"},
+	}
+	addr := fakeVault(t, kv)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("stale\n"), 0o600))
+	vault, err := secrets.NewVault(secrets.VaultConfig{Address: addr, TokenFile: tokenFile})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = vault.GetSecret(ctx, "shiro/gateway#token")
+	require.ErrorContains(t, err, "permission denied")
+
+	// the token file is read for each request.
+	require.NoError(t, os.WriteFile(tokenFile, []byte("root\n"), 0o600))
+	token, err := vault.GetSecret(ctx, "shiro/gateway#token")
+	require.NoError(t, err)
+	require.Equal(t, "t1", string(token))
+	value, err := vault.GetSecret(ctx, "shiro/gateway")
+	require.NoError(t, err)
+	require.Equal(t, "v", string(value))
+	_, err = vault.GetSecret(ctx, "shiro/gateway#missing")
+	require.Error(t, err)
+	_, err = vault.GetSecret(ctx, "shiro/missing")
+	require.Error(t, err)
+
+	random, err := vault.Random(ctx, 32)
+	require.NoError(t, err)
+	require.Len(t, random, 32)
+	key, err := secrets.SeedGen(vault)()
+	require.NoError(t, err)
+	require.Len(t, key, 32)
+}
+
+func TestCache(t *testing.T) {
+	var fetches int32
+	p := secrets.ProviderFunc(func(ctx context.Context, name string) ([]byte, error) {
+		return []byte{byte(atomic.AddInt32(&fetches, 1))}, nil
+	})
+	cache := secrets.NewCache(p, 50*time.Millisecond)
+	ctx := context.Background()
+	v, err := cache.GetSecret(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, v)
+	v, err = cache.GetSecret(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, v)
+	time.Sleep(60 * time.Millisecond)
+	v, err = cache.GetSecret(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte{2}, v)
+}
+
+func TestClientSecrets(t *testing.T) {
+	var token atomic.Value
+	token.Store("t1")
+	p := secrets.ProviderFunc(func(ctx context.Context, name string) ([]byte, error) {
+		switch name {
+		case "token":
+			return []byte(token.Load().(string) + "\n"), nil
+		default:
+			return []byte("hmac-key"), nil
+		}
+	})
+
+	var auth, sig, cert string
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		auth = r.Header.Get("Authorization")
+		sig = r.Header.Get(rpc.HeaderSignature)
+		cert = r.Header.Get(rpc.HeaderSignerCertificate)
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":"1","result":{"error_level":0,"result":{},"code":0,"message":"","data":null,"transaction_id":"tx1"}}`)
+	}))
+	defer srv.Close()
+
+	client := shiroclient.NewRPC([]shiroclient.Config{
+		shiroclient.WithEndpoint(srv.URL),
+		secrets.WithAuthToken(p, "token"),
+		shiroclient.WithRequestSigner(secrets.NewHMACSigner(p, "hmac")),
+	})
+	ctx := context.Background()
+	_, err := client.Call(ctx, "ping")
+	require.NoError(t, err)
+	require.Equal(t, "Bearer t1", auth)
+	require.Empty(t, cert)
+	mac := hmac.New(sha256.New, []byte("hmac-key"))
+	mac.Write(body)
+	require.Equal(t, base64.StdEncoding.EncodeToString(mac.Sum(nil)), sig)
+
+	// rotated tokens are used without reconfiguring the client.
+	token.Store("t2")
+	_, err = client.Call(ctx, "ping")
+	require.NoError(t, err)
+	require.Equal(t, "Bearer t2", auth)
+}
+
+func TestHMACSignerContext(t *testing.T) {
+	// a hung secret store does not outlive the request.
+	hung := secrets.ProviderFunc(func(ctx context.Context, name string) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	client := shiroclient.NewRPC([]shiroclient.Config{
+		shiroclient.WithEndpoint("http://127.0.0.1:1"),
+		shiroclient.WithRequestSigner(secrets.NewHMACSigner(hung, "hmac")),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.Call(ctx, "ping")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,170 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// VaultConfig configures a Vault provider.
+type VaultConfig struct {
+	// Address is the URL of the Vault server, e.g. "https://vault:8200".
+	Address string
+	// Token is the Vault token.  It is ignored if TokenFile is set.
+	Token string
+	// TokenFile is the path of a file holding the Vault token, e.g. the
+	// sink of a Vault Agent.  It is read for each request, so renewed
+	// tokens are picked up.
+	TokenFile string
+	// Namespace is the Vault Enterprise namespace, if any.
+	Namespace string
+	// Mount is the mount path of the KV version 2 secrets engine.  It
+	// defaults to "secret".
+	Mount string
+	// HTTPClient is used for requests to Vault.  It defaults to a client
+	// timing out after DefaultVaultTimeout.
+	HTTPClient *http.Client
+}
+
+// DefaultVaultTimeout is the timeout of requests to Vault made with the
+// default HTTP client, so that an unresponsive Vault does not block the
+// requests needing secrets forever.
+const DefaultVaultTimeout = 10 * time.Second
+
+// Vault is a Provider reading secrets from the KV version 2 secrets engine
+// of HashiCorp Vault, and an EntropySource using its random byte generator.
+//
+// Secret names have the form "path#field", and name the field of the
+// latest version of the secret at path.  The field defaults to "value".
+// Field values must be strings; they are returned as is.
+type Vault struct {
+	cfg VaultConfig
+}
+
+var (
+	_ Provider      = (*Vault)(nil)
+	_ EntropySource = (*Vault)(nil)
+)
+
+// NewVault returns a Vault provider.
+func NewVault(cfg VaultConfig) (*Vault, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("secrets: vault: missing address")
+	}
+	if cfg.Token == "" && cfg.TokenFile == "" {
+		return nil, errors.New("secrets: vault: missing token")
+	}
+	cfg.Address = strings.TrimRight(cfg.Address, "/")
+	if cfg.Mount == "" {
+		cfg.Mount = "secret"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: DefaultVaultTimeout}
+	}
+	return &Vault{cfg: cfg}, nil
+}
+
+// GetSecret implements Provider.
+func (v *Vault) GetSecret(ctx context.Context, name string) ([]byte, error) {
+	path, field, ok := strings.Cut(name, "#")
+	if !ok {
+		field = "value"
+	}
+	var out struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	url := fmt.Sprintf("%s/v1/%s/data/%s", v.cfg.Address, v.cfg.Mount, strings.Trim(path, "/"))
+	if err := v.do(ctx, http.MethodGet, url, nil, &out); err != nil {
+		return nil, fmt.Errorf("secrets: vault: %s: %w", path, err)
+	}
+	value, ok := out.Data.Data[field]
+	if !ok {
+		return nil, fmt.Errorf("secrets: vault: %s: no field %q", path, field)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("secrets: vault: %s: field %q is not a string", path, field)
+	}
+	return []byte(s), nil
+}
+
+// Random implements EntropySource.
+func (v *Vault) Random(ctx context.Context, n int) ([]byte, error) {
+	var out struct {
+		Data struct {
+			RandomBytes string `json:"random_bytes"`
+		} `json:"data"`
+	}
+	url := fmt.Sprintf("%s/v1/sys/tools/random/%d", v.cfg.Address, n)
+	if err := v.do(ctx, http.MethodPost, url, map[string]string{"format": "base64"}, &out); err != nil {
+		return nil, fmt.Errorf("secrets: vault: random: %w", err)
+	}
+	b, err := base64.StdEncoding.DecodeString(out.Data.RandomBytes)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: vault: random: %w", err)
+	}
+	return b, nil
+}
+
+func (v *Vault) token() (string, error) {
+	if v.cfg.TokenFile == "" {
+		return v.cfg.Token, nil
+	}
+	b, err := os.ReadFile(v.cfg.TokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (v *Vault) do(ctx context.Context, method string, url string, in interface{}, out interface{}) error {
+	token, err := v.token()
+	if err != nil {
+		return err
+	}
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := v.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		if len(e.Errors) > 0 {
+			return fmt.Errorf("status %d: %s", resp.StatusCode, strings.Join(e.Errors, "; "))
+		}
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
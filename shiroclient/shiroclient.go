@@ -5,11 +5,15 @@ package shiroclient
 import (
 	"context"
 	"encoding/base64"
+	"io"
 
 	imock "github.com/luthersystems/shiroclient-sdk-go/internal/mock"
 	"github.com/luthersystems/shiroclient-sdk-go/internal/rpc"
 	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
 	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/mock"
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 // ShiroClient interfaces with blockchain-based smart contract execution engine.
@@ -20,24 +24,148 @@ type ShiroClient = types.ShiroClient
 // by an in-process lightweight ledger. This uses the hashicorp plugin.
 type MockShiroClient = imock.MockShiroClient
 
+// ChaincodeStub is a canned response to an invocation of another chaincode
+// by the phylum of a mock client.  See MockShiroClient.StubChaincode.
+type ChaincodeStub = plugin.ChaincodeStub
+
+// MockInfo describes a mock open in a plugin, with its ledger size and age.
+// See MockShiroClient.Mocks.
+type MockInfo = plugin.MockInfo
+
 // Config is a type for a function that can mutate a types.RequestOptions
 // object.
 type Config = types.Config
 
+// ConfigError is returned when a Config cannot be applied or when a
+// combination of configs is invalid.
+type ConfigError = types.ConfigError
+
+// TransientDataError is returned when transient data is invalid or exceeds
+// the limits set with WithTransientLimits.
+type TransientDataError = types.TransientDataError
+
+// DecodeError describes a gateway response that failed strict validation.
+// See WithStrictDecoding.
+type DecodeError = rpc.DecodeError
+
 // ShiroResponse is a wrapper for a response from a shiro
 // chaincode. Even if the chaincode was invoked successfully, it may
 // have signaled an error.
 type ShiroResponse = types.ShiroResponse
 
+// ResponseMetadata describes the HTTP response that carried a
+// ShiroResponse in RPC mode: the raw JSON-RPC envelope, the HTTP status
+// and the response headers.
+type ResponseMetadata = types.ResponseMetadata
+
+// GetResponseMetadata returns the HTTP metadata of resp, or nil if resp was
+// not received over HTTP (e.g. from a mock client).  The gateway request ID
+// returned by ResponseMetadata.RequestID is useful when filing gateway
+// support tickets.
+func GetResponseMetadata(resp ShiroResponse) *ResponseMetadata {
+	if r, ok := resp.(types.MetadataResponse); ok {
+		return r.Metadata()
+	}
+	return nil
+}
+
+// CommitMetadata describes how the transaction of a response was simulated
+// and committed: its ID, commit block, highest simulated block, endorsers
+// and validation code.  Fields that were not reported are left empty.
+type CommitMetadata = types.CommitMetadata
+
+// GetCommitMetadata returns the commit metadata of resp, or nil if resp is
+// a phylum error or carries no commit metadata.  The returned value is a
+// copy and may be modified.
+func GetCommitMetadata(resp ShiroResponse) *CommitMetadata {
+	if r, ok := resp.(types.CommitResponse); ok {
+		return r.Commit()
+	}
+	return nil
+}
+
 // Error is a generic application error.
 type Error types.Error
 
+// StackFrame is a frame of a phylum stack trace.
+type StackFrame = types.StackFrame
+
+// ErrorStack returns the phylum stack trace of the error in resp, innermost
+// frame first.  It returns nil if resp has no error or the error carries no
+// stack trace.  Stack traces are only reported by mock clients created with
+// mock.WithErrorStacks.
+func ErrorStack(resp ShiroResponse) []StackFrame {
+	if e, ok := resp.Error().(types.StackError); ok {
+		return e.Stack()
+	}
+	return nil
+}
+
 // Transaction has summary information about a transaction.
 type Transaction types.Transaction
 
+// ValidationCode is the result of validating a transaction when its block
+// is committed, named after the Fabric transaction validation codes.
+type ValidationCode = types.ValidationCode
+
+// Validation codes reported for transactions.  Codes not listed here are
+// reported as ValidationUnknown.
+const (
+	ValidationUnknown                  = types.ValidationUnknown
+	ValidationValid                    = types.ValidationValid
+	ValidationDuplicateTxID            = types.ValidationDuplicateTxID
+	ValidationEndorsementPolicyFailure = types.ValidationEndorsementPolicyFailure
+	ValidationMVCCReadConflict         = types.ValidationMVCCReadConflict
+	ValidationPhantomReadConflict      = types.ValidationPhantomReadConflict
+	ValidationExpiredChaincode         = types.ValidationExpiredChaincode
+	ValidationInvalidOtherReason       = types.ValidationInvalidOtherReason
+)
+
+// ParseValidationCode parses a transaction reason reported by QueryBlock.
+// Empty reasons are parsed as ValidationValid and unrecognized reasons as
+// ValidationUnknown.
+func ParseValidationCode(reason string) ValidationCode {
+	return types.ParseValidationCode(reason)
+}
+
+// TxValidationCode returns the validation code of tx, parsed from its
+// reason.
+func TxValidationCode(tx Transaction) ValidationCode {
+	if t, ok := tx.(types.ValidatedTransaction); ok {
+		return t.ValidationCode()
+	}
+	return ParseValidationCode(tx.Reason())
+}
+
+// IsRetryableTx reports whether tx was invalidated in a way that makes it
+// safe to simulate and submit the same call again, like an MVCC read
+// conflict.
+func IsRetryableTx(tx Transaction) bool {
+	return TxValidationCode(tx).Retryable()
+}
+
+// RequestSigner signs RPC requests, see WithRequestSigner.
+type RequestSigner = types.RequestSigner
+
 // Block has summary information about a block.
 type Block = types.Block
 
+// BlockHeader holds the header of a block and the orderer signatures over
+// it, see GetBlockHeader.
+type BlockHeader = types.BlockHeader
+
+// BlockSignature is an orderer signature over a block header.
+type BlockSignature = types.BlockSignature
+
+// GetBlockHeader returns the header of block, or nil if the gateway did not
+// return it.  Only gateways supporting receipts return block headers.
+func GetBlockHeader(block Block) *BlockHeader {
+	if b, ok := block.(types.HeaderBlock); ok {
+		return b.Header()
+	}
+	return nil
+}
+
 // HealthCheck is a collection of reports detailing connectivity and health of
 // system components (e.g. phylum, RPC gateway, etc).  See RemoteHealthCheck.
 type HealthCheck = rpc.HealthCheck
@@ -55,11 +183,61 @@ type HealthCheck = rpc.HealthCheck
 type HealthCheckReport = rpc.HealthCheckReport
 
 // IsTimeoutError inspects an error returned from shiroclient and returns true
-// if it's a timeout.
+// if it's a timeout: a gateway timeout, an exceeded context deadline, a
+// network timeout or an HTTP 504 response.
 func IsTimeoutError(err error) bool {
 	return rpc.IsTimeoutError(err)
 }
 
+// ValidateConfigs applies configs and reports any error encountered while
+// applying them or any invalid combination of the resulting options.  The
+// same validation is run by clients before each request, so calling
+// ValidateConfigs is only necessary to detect configuration problems early
+// (e.g. at service start).  Checks that depend on the client mode, like the
+// RPC endpoint requirement, are not performed.
+func ValidateConfigs(configs ...Config) error {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return err
+	}
+	return opt.Validate()
+}
+
+// ErrClientClosed is returned for requests issued to an RPC client after it
+// was shut down.
+var ErrClientClosed = rpc.ErrClientClosed
+
+// ErrDependencyNotFound is returned by mock calls whose WithDependentTxID or
+// WithDependentBlock dependency is not on the ledger, when dependency checks
+// are enabled with mock.WithDependencyChecks.
+var ErrDependencyNotFound = imock.ErrDependencyNotFound
+
+// ErrCoverageDisabled is returned by MockShiroClient.CoverageReport for
+// clients created without mock.WithCoverage.
+var ErrCoverageDisabled = imock.ErrCoverageDisabled
+
+// ErrSnapshotNotEncrypted is returned by NewMock when the snapshot of
+// mock.WithSnapshotReader is not encrypted but mock.WithSnapshotKey or
+// mock.WithSnapshotPassphrase is set.
+var ErrSnapshotNotEncrypted = imock.ErrSnapshotNotEncrypted
+
+// Shutdown releases the resources held by client.  Clients created with
+// NewRPC stop accepting requests, wait for outstanding requests until ctx is
+// done (canceling them afterwards), and close idle connections once the
+// clients derived from them with With are also shut down.  Clients
+// that only implement io.Closer, like those created with NewMock, are
+// closed.  Shutdown is safe to call more than once on RPC clients.
+func Shutdown(ctx context.Context, client ShiroClient) error {
+	switch c := client.(type) {
+	case interface{ Shutdown(context.Context) error }:
+		return c.Shutdown(ctx)
+	case io.Closer:
+		return c.Close()
+	default:
+		return nil
+	}
+}
+
 // NewRPC creates a new RPC ShiroClient with the given set of base
 // configs that will be applied to all commands.
 func NewRPC(clientConfigs []Config) ShiroClient {
@@ -79,10 +257,57 @@ func EncodePhylumBytes(decoded []byte) string {
 }
 
 // UnmarshalProto attempts to unmarshal protobuf bytes with backwards compatability.
+// See SetLegacyUnmarshalOptions and RegisterProtoUnmarshaler to customize
+// it.
 func UnmarshalProto(src []byte, dst interface{}) error {
 	return types.UnmarshalProto(src, dst)
 }
 
+// MarshalProto encodes a message as JSON with proto field names, the
+// inverse of UnmarshalProto.  Values that are not messages are encoded with
+// encoding/json.
+func MarshalProto(src interface{}) ([]byte, error) {
+	return types.MarshalProto(src)
+}
+
+// ProtoParams returns positional phylum params encoding msgs as JSON with
+// proto field names, like the phylum package does:
+//
+//	resp, err := client.Call(ctx, "create_account", shiroclient.WithParams(shiroclient.ProtoParams(req)))
+//	err = resp.UnmarshalTo(out)
+func ProtoParams(msgs ...proto.Message) []interface{} {
+	return types.ProtoParams(types.ProtoMarshalOptions(), msgs...)
+}
+
+// ProtoParamsWithOptions is like ProtoParams but encodes msgs with opts,
+// e.g. to emit unpopulated fields.
+func ProtoParamsWithOptions(opts protojson.MarshalOptions, msgs ...proto.Message) []interface{} {
+	return types.ProtoParams(opts, msgs...)
+}
+
+// LegacyUnmarshalOptions configures the unmarshaling of legacy (MessageV1)
+// messages with the deprecated jsonpb package.
+type LegacyUnmarshalOptions = types.LegacyUnmarshalOptions
+
+// SetLegacyUnmarshalOptions sets the options used by UnmarshalProto, and
+// ShiroResponse.UnmarshalTo, for legacy messages, e.g. an AnyResolver for
+// vendored messages that are not in the global registry.  It should be
+// called during initialization.
+func SetLegacyUnmarshalOptions(opts LegacyUnmarshalOptions) {
+	types.SetLegacyUnmarshalOptions(opts)
+}
+
+// ProtoUnmarshalFunc unmarshals src into dst.
+type ProtoUnmarshalFunc = types.ProtoUnmarshalFunc
+
+// RegisterProtoUnmarshaler makes UnmarshalProto, and
+// ShiroResponse.UnmarshalTo, use fn for destinations with the type of dst,
+// e.g. (*pb.Message)(nil).  A nil fn removes the registration.  It should
+// be called during initialization.
+func RegisterProtoUnmarshaler(dst interface{}, fn ProtoUnmarshalFunc) {
+	types.RegisterProtoUnmarshaler(dst, fn)
+}
+
 // RemoteHealthCheck checks connectivity between the SDK client (e.g. oracle
 // service) and upstream services including the phylum itself.  If the list of
 // upstream services is empty the behavior of RemoteHealthCheck depends on
@@ -107,3 +332,12 @@ func UnmarshalProto(src []byte, dst interface{}) error {
 func RemoteHealthCheck(ctx context.Context, client ShiroClient, services []string, configs ...Config) (HealthCheck, error) {
 	return rpc.RemoteHealthCheck(ctx, client, services, configs...)
 }
+
+// WarmUp opens connections to the gateways of client ahead of the first
+// request, so that it does not pay for DNS resolution, connection setup and
+// TLS handshakes.  It is meant to be called at service start.  Every gateway
+// of a dnssrv endpoint is contacted.  Clients created with NewMock do nothing
+// and return nil.
+func WarmUp(ctx context.Context, client ShiroClient, configs ...Config) error {
+	return rpc.WarmUp(ctx, client, configs...)
+}
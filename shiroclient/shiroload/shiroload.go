@@ -0,0 +1,495 @@
+// Package shiroload replays a workload against a ShiroClient at a target
+// request rate and reports latency percentiles and error rates, for
+// reproducible capacity tests of a gateway and phylum.
+//
+//	w, err := shiroload.LoadWorkload("workload.yaml")
+//	if err != nil {
+//		return err
+//	}
+//	report, err := shiroload.Run(ctx, shiroclient.NewRPC(configs), w)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(report)
+//
+// Calls are started on schedule regardless of the latency of earlier calls
+// (an open workload), so that a slow gateway shows up as increased latency
+// rather than a reduced request rate.  Calls that cannot start because
+// MaxInFlight calls are already outstanding are dropped and counted.
+package shiroload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/backoff"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/phylumbench"
+)
+
+// DefaultMaxInFlight is the limit of outstanding calls of a Workload
+// without a MaxInFlight.
+const DefaultMaxInFlight = 256
+
+// maxTick bounds the time between checks of the schedule, so that rate
+// changes during a ramp take effect promptly.
+const maxTick = 10 * time.Millisecond
+
+// Method is a phylum method of a workload.
+type Method struct {
+	// Name is the phylum method to call.
+	Name string
+	// Weight is the relative frequency of the method in the workload.
+	// Values less than 1 select 1.
+	Weight int
+	// Params, if not empty, is a text/template producing the JSON params
+	// of a call from a TemplateData.
+	Params string
+	// Transient maps transient data keys to text/templates producing their
+	// values from a TemplateData.
+	Transient map[string]string
+	// Configs are additional configs for each call of the method.
+	Configs []shiroclient.Config
+}
+
+// Stage is a step of the ramp profile of a workload.  The request rate
+// changes linearly over the stage from the rate at the end of the previous
+// stage, or the StartRPS of the workload, to RPS.
+type Stage struct {
+	Duration time.Duration
+	RPS      float64
+}
+
+// Workload defines the calls made by Run.
+type Workload struct {
+	// Methods is the mix of methods called.
+	Methods []Method
+	// StartRPS is the request rate at the start of the first stage.
+	StartRPS float64
+	// Stages is the ramp profile of the workload.  A constant rate is
+	// defined by a StartRPS equal to the RPS of a single stage.
+	Stages []Stage
+	// MaxInFlight limits the number of outstanding calls.  Values less
+	// than 1 select DefaultMaxInFlight.
+	MaxInFlight int
+	// Seed seeds the selection of methods and the random values of
+	// templates, so that runs with the same seed make the same calls.
+	Seed int64
+}
+
+// TemplateData is the data of the params and transient templates of a call.
+type TemplateData struct {
+	// Seq is the sequence number of the call in the run, from 0.
+	Seq int
+	// Rand is a random non-negative number derived from the seed of the
+	// workload.
+	Rand int64
+	// Method is the phylum method called.
+	Method string
+}
+
+// Duration returns the total duration of the stages of w.
+func (w *Workload) Duration() time.Duration {
+	var total time.Duration
+	for _, s := range w.Stages {
+		total += s.Duration
+	}
+	return total
+}
+
+// arrivals returns the number of calls scheduled in the first elapsed
+// time of w, the integral of its request rate.
+func (w *Workload) arrivals(elapsed time.Duration) float64 {
+	var n float64
+	from := w.StartRPS
+	for _, s := range w.Stages {
+		d := s.Duration.Seconds()
+		if elapsed < s.Duration {
+			x := elapsed.Seconds()
+			return n + from*x + (s.RPS-from)*x*x/(2*d)
+		}
+		n += (from + s.RPS) / 2 * d
+		elapsed -= s.Duration
+		from = s.RPS
+	}
+	return n
+}
+
+// rate returns the request rate of w after elapsed time.
+func (w *Workload) rate(elapsed time.Duration) float64 {
+	from := w.StartRPS
+	for _, s := range w.Stages {
+		if elapsed < s.Duration {
+			return from + (s.RPS-from)*elapsed.Seconds()/s.Duration.Seconds()
+		}
+		elapsed -= s.Duration
+		from = s.RPS
+	}
+	return 0
+}
+
+func (w *Workload) validate() error {
+	if len(w.Methods) == 0 {
+		return errors.New("shiroload: workload has no methods")
+	}
+	for _, m := range w.Methods {
+		if m.Name == "" {
+			return errors.New("shiroload: method name is required")
+		}
+	}
+	if len(w.Stages) == 0 {
+		return errors.New("shiroload: workload has no stages")
+	}
+	if w.StartRPS < 0 {
+		return errors.New("shiroload: negative start rps")
+	}
+	for i, s := range w.Stages {
+		if s.Duration <= 0 {
+			return fmt.Errorf("shiroload: stage %d: duration must be positive", i)
+		}
+		if s.RPS < 0 {
+			return fmt.Errorf("shiroload: stage %d: negative rps", i)
+		}
+	}
+	return nil
+}
+
+// method is a Method with parsed templates.
+type method struct {
+	*Method
+	params    *template.Template
+	transient map[string]*template.Template
+}
+
+func parseMethod(m *Method) (*method, error) {
+	pm := &method{Method: m, transient: make(map[string]*template.Template, len(m.Transient))}
+	var err error
+	if m.Params != "" {
+		pm.params, err = template.New("params").Option("missingkey=error").Parse(m.Params)
+		if err != nil {
+			return nil, fmt.Errorf("shiroload: method %s: params: %w", m.Name, err)
+		}
+	}
+	for k, v := range m.Transient {
+		pm.transient[k], err = template.New(k).Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("shiroload: method %s: transient %s: %w", m.Name, k, err)
+		}
+	}
+	return pm, nil
+}
+
+// configs renders the templates of m into the configs of a call.
+func (m *method) configs(data *TemplateData) ([]shiroclient.Config, error) {
+	configs := make([]shiroclient.Config, 0, len(m.Configs)+2)
+	var buf bytes.Buffer
+	if m.params != nil {
+		if err := m.params.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("params: %w", err)
+		}
+		params := json.RawMessage(append([]byte(nil), buf.Bytes()...))
+		if !json.Valid(params) {
+			return nil, fmt.Errorf("params: invalid JSON: %s", params)
+		}
+		configs = append(configs, shiroclient.WithParams(params))
+	}
+	if len(m.transient) > 0 {
+		transient := make(map[string][]byte, len(m.transient))
+		for k, tmpl := range m.transient {
+			buf.Reset()
+			if err := tmpl.Execute(&buf, data); err != nil {
+				return nil, fmt.Errorf("transient %s: %w", k, err)
+			}
+			transient[k] = append([]byte(nil), buf.Bytes()...)
+		}
+		configs = append(configs, shiroclient.WithTransientDataMap(transient))
+	}
+	return append(configs, m.Configs...), nil
+}
+
+// MethodReport reports the calls of a single method.
+type MethodReport struct {
+	// Requests is the number of calls made.
+	Requests int
+	// Errors is the number of calls that returned an error.
+	Errors int
+	// PhylumErrors is the number of calls whose response contained a
+	// phylum error.
+	PhylumErrors int
+	// Latency is the distribution of call latency.
+	Latency phylumbench.Percentiles
+}
+
+// ErrorRate returns the fraction of calls that returned an error or a
+// phylum error.
+func (r *MethodReport) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors+r.PhylumErrors) / float64(r.Requests)
+}
+
+// Report reports the outcome of a run.
+type Report struct {
+	MethodReport
+	// Dropped is the number of scheduled calls not made because
+	// MaxInFlight calls were outstanding.
+	Dropped int
+	// Elapsed is the wall time of the run, including outstanding calls.
+	Elapsed time.Duration
+	// Methods reports the calls of each method.
+	Methods map[string]*MethodReport
+	// FirstError is the first error returned by a call, if any.
+	FirstError error
+}
+
+// Throughput returns the number of calls per second.
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "requests=%d errors=%d phylum_errors=%d dropped=%d error_rate=%.4f elapsed=%v throughput=%.1f/s\nlatency: %v",
+		r.Requests, r.Errors, r.PhylumErrors, r.Dropped, r.ErrorRate(), r.Elapsed, r.Throughput(), r.Latency)
+	names := make([]string, 0, len(r.Methods))
+	for name := range r.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m := r.Methods[name]
+		fmt.Fprintf(&b, "\n%s: requests=%d errors=%d phylum_errors=%d error_rate=%.4f latency: %v",
+			name, m.Requests, m.Errors, m.PhylumErrors, m.ErrorRate(), m.Latency)
+	}
+	return b.String()
+}
+
+// sample is the measurement of a single call.
+type sample struct {
+	method    string
+	latency   time.Duration
+	err       error
+	phylumErr bool
+}
+
+// Run replays w against client and reports the calls made.  Call errors are
+// counted in the report rather than stopping the run; Run only returns an
+// error for an invalid workload or if ctx is done before the run ends.
+func Run(ctx context.Context, client shiroclient.ShiroClient, w *Workload) (*Report, error) {
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+	methods := make([]*method, 0, len(w.Methods))
+	var totalWeight int
+	for i := range w.Methods {
+		m, err := parseMethod(&w.Methods[i])
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, m)
+		totalWeight += max(m.Weight, 1)
+	}
+	maxInFlight := w.MaxInFlight
+	if maxInFlight < 1 {
+		maxInFlight = DefaultMaxInFlight
+	}
+	rng := rand.New(rand.NewSource(w.Seed)) // #nosec G404
+	pick := func() *method {
+		n := rng.Intn(totalWeight)
+		for _, m := range methods {
+			if n -= max(m.Weight, 1); n < 0 {
+				return m
+			}
+		}
+		return methods[len(methods)-1]
+	}
+
+	var (
+		mu      sync.Mutex
+		samples []sample
+		dropped int
+		wg      sync.WaitGroup
+	)
+	slots := make(chan struct{}, maxInFlight)
+	call := func(m *method, data *TemplateData) {
+		defer func() { <-slots }()
+		defer wg.Done()
+		s := sample{method: m.Name}
+		start := time.Now()
+		configs, err := m.configs(data)
+		if err == nil {
+			var resp shiroclient.ShiroResponse
+			resp, err = client.Call(ctx, m.Name, configs...)
+			s.phylumErr = err == nil && resp.Error() != nil
+		}
+		s.latency = time.Since(start)
+		if err != nil {
+			s.err = fmt.Errorf("%s: %w", m.Name, err)
+		}
+		mu.Lock()
+		samples = append(samples, s)
+		mu.Unlock()
+	}
+
+	total := w.Duration()
+	start := time.Now()
+	var seq int
+	for {
+		elapsed := time.Since(start)
+		if elapsed >= total {
+			break
+		}
+		for due := int(w.arrivals(elapsed)); seq < due; seq++ {
+			m := pick()
+			data := &TemplateData{Seq: seq, Rand: rng.Int63(), Method: m.Name}
+			select {
+			case slots <- struct{}{}:
+				wg.Add(1)
+				go call(m, data)
+			default:
+				dropped++
+			}
+		}
+		tick := maxTick
+		if r := w.rate(elapsed); r > 0 {
+			next := time.Duration((float64(seq+1) - w.arrivals(elapsed)) / r * float64(time.Second))
+			tick = min(max(next, 0), maxTick)
+		}
+		if err := backoff.Sleep(ctx, min(tick, total-elapsed)); err != nil {
+			wg.Wait()
+			return nil, err
+		}
+	}
+	wg.Wait()
+	return report(samples, dropped, time.Since(start)), nil
+}
+
+func report(samples []sample, dropped int, elapsed time.Duration) *Report {
+	r := &Report{
+		Dropped: dropped,
+		Elapsed: elapsed,
+		Methods: make(map[string]*MethodReport),
+	}
+	latency := make([]time.Duration, 0, len(samples))
+	methodLatency := make(map[string][]time.Duration)
+	for _, s := range samples {
+		m := r.Methods[s.method]
+		if m == nil {
+			m = &MethodReport{}
+			r.Methods[s.method] = m
+		}
+		for _, mr := range []*MethodReport{&r.MethodReport, m} {
+			mr.Requests++
+			if s.err != nil {
+				mr.Errors++
+			} else if s.phylumErr {
+				mr.PhylumErrors++
+			}
+		}
+		if s.err != nil && r.FirstError == nil {
+			r.FirstError = s.err
+		}
+		latency = append(latency, s.latency)
+		methodLatency[s.method] = append(methodLatency[s.method], s.latency)
+	}
+	r.Latency = phylumbench.Summarize(latency)
+	for name, samples := range methodLatency {
+		r.Methods[name].Latency = phylumbench.Summarize(samples)
+	}
+	return r
+}
+
+// methodSettings is the file representation of a Method.
+type methodSettings struct {
+	Name      string            `json:"name" yaml:"name"`
+	Weight    int               `json:"weight" yaml:"weight"`
+	Params    string            `json:"params" yaml:"params"`
+	Transient map[string]string `json:"transient" yaml:"transient"`
+}
+
+// stageSettings is the file representation of a Stage.
+type stageSettings struct {
+	Duration string  `json:"duration" yaml:"duration"`
+	RPS      float64 `json:"rps" yaml:"rps"`
+}
+
+// workloadSettings is the file representation of a Workload.
+type workloadSettings struct {
+	Methods     []methodSettings `json:"methods" yaml:"methods"`
+	StartRPS    float64          `json:"start_rps" yaml:"start_rps"`
+	Stages      []stageSettings  `json:"stages" yaml:"stages"`
+	MaxInFlight int              `json:"max_in_flight" yaml:"max_in_flight"`
+	Seed        int64            `json:"seed" yaml:"seed"`
+}
+
+// LoadWorkload reads a workload from a YAML (.yaml or .yml) or JSON file.
+//
+//	seed: 1
+//	start_rps: 10
+//	stages:
+//	  - {duration: 30s, rps: 100}  # ramp up
+//	  - {duration: 5m, rps: 100}   # hold
+//	methods:
+//	  - name: create_account
+//	    weight: 1
+//	    params: '[{"id": "acct-{{.Seq}}"}]'
+//	  - name: get_account
+//	    weight: 9
+//	    params: '[{"id": "acct-{{.Rand}}"}]'
+func LoadWorkload(path string) (*Workload, error) {
+	b, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("read workload: %w", err)
+	}
+	s := &workloadSettings{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, s)
+	default:
+		err = json.Unmarshal(b, s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse workload %s: %w", path, err)
+	}
+	w := &Workload{
+		StartRPS:    s.StartRPS,
+		MaxInFlight: s.MaxInFlight,
+		Seed:        s.Seed,
+	}
+	for _, m := range s.Methods {
+		w.Methods = append(w.Methods, Method{
+			Name:      m.Name,
+			Weight:    m.Weight,
+			Params:    m.Params,
+			Transient: m.Transient,
+		})
+	}
+	for i, st := range s.Stages {
+		d, err := time.ParseDuration(st.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("parse workload %s: stage %d: %w", path, i, err)
+		}
+		w.Stages = append(w.Stages, Stage{Duration: d, RPS: st.RPS})
+	}
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
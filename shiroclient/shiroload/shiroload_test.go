@@ -0,0 +1,163 @@
+package shiroload_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/shiroload"
+)
+
+// loadClient records the params of calls, failing calls of the method
+// "fail" and returning a phylum error for the method "reject".
+type loadClient struct {
+	shiroclient.ShiroClient
+	delay time.Duration
+
+	mu     sync.Mutex
+	params map[string][]string
+}
+
+func (c *loadClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(opt.Params)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.params == nil {
+		c.params = make(map[string][]string)
+	}
+	c.params[method] = append(c.params[method], string(b))
+	c.mu.Unlock()
+	time.Sleep(c.delay)
+	switch method {
+	case "fail":
+		return nil, errors.New("unavailable")
+	case "reject":
+		return types.NewFailureResponse(400, "rejected", nil), nil
+	}
+	return types.NewSuccessResponse([]byte(`{}`), "tx1", 0, 0), nil
+}
+
+func TestRun(t *testing.T) {
+	client := &loadClient{}
+	w := &shiroload.Workload{
+		Methods: []shiroload.Method{
+			{Name: "create", Weight: 3, Params: `[{"id": "acct-{{.Seq}}"}]`},
+			{Name: "fail"},
+			{Name: "reject"},
+		},
+		StartRPS: 200,
+		Stages:   []shiroload.Stage{{Duration: 500 * time.Millisecond, RPS: 200}},
+		Seed:     1,
+	}
+	report, err := shiroload.Run(context.Background(), client, w)
+	require.NoError(t, err)
+	require.InDelta(t, 100, report.Requests, 10)
+	require.Zero(t, report.Dropped)
+	require.Equal(t, report.Requests, client.nCalls())
+	require.Equal(t, report.Methods["fail"].Requests, report.Errors)
+	require.Equal(t, report.Methods["reject"].Requests, report.PhylumErrors)
+	require.Equal(t, float64(1), report.Methods["fail"].ErrorRate())
+	require.Zero(t, report.Methods["create"].ErrorRate())
+	require.Greater(t, report.Methods["create"].Requests, report.Methods["fail"].Requests)
+	require.ErrorContains(t, report.FirstError, "fail: unavailable")
+	require.Contains(t, report.String(), "create: requests=")
+
+	// the same seed makes the same calls.
+	again := &loadClient{}
+	_, err = shiroload.Run(context.Background(), again, w)
+	require.NoError(t, err)
+	require.Greater(t, len(client.createSeqs(t, 80)), 20)
+	require.Equal(t, client.createSeqs(t, 80), again.createSeqs(t, 80))
+}
+
+// createSeqs returns the sorted sequence numbers below n of create calls.
+func (c *loadClient) createSeqs(t *testing.T, n int) []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var seqs []int
+	for _, p := range c.params["create"] {
+		var seq int
+		_, err := fmt.Sscanf(p, `[{"id":"acct-%d"}]`, &seq)
+		require.NoError(t, err)
+		if seq < n {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Ints(seqs)
+	return seqs
+}
+
+func (c *loadClient) nCalls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int
+	for _, p := range c.params {
+		n += len(p)
+	}
+	return n
+}
+
+func TestRunRampAndDrops(t *testing.T) {
+	client := &loadClient{delay: time.Second}
+	w := &shiroload.Workload{
+		Methods:     []shiroload.Method{{Name: "slow"}},
+		Stages:      []shiroload.Stage{{Duration: 400 * time.Millisecond, RPS: 200}},
+		MaxInFlight: 10,
+	}
+	report, err := shiroload.Run(context.Background(), client, w)
+	require.NoError(t, err)
+	// the rate ramps from 0 to 200/s, scheduling 40 calls.
+	require.Equal(t, 10, report.Requests)
+	require.InDelta(t, 30, report.Dropped, 5)
+	require.GreaterOrEqual(t, report.Latency.Min, time.Second)
+}
+
+func TestLoadWorkload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workload.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+seed: 7
+start_rps: 10
+stages:
+  - {duration: 30s, rps: 100}
+  - {duration: 1m, rps: 100}
+methods:
+  - name: create_account
+    weight: 2
+    params: '[{"id": "acct-{{.Seq}}"}]'
+    transient: {token: "t{{.Rand}}"}
+`), 0o600))
+	w, err := shiroload.LoadWorkload(path)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), w.Seed)
+	require.Equal(t, 90*time.Second, w.Duration())
+	require.Equal(t, "create_account", w.Methods[0].Name)
+	require.Equal(t, 2, w.Methods[0].Weight)
+	require.Equal(t, "t{{.Rand}}", w.Methods[0].Transient["token"])
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"stages": [{"duration": "1s", "rps": 1}]}`), 0o600))
+	_, err = shiroload.LoadWorkload(path)
+	require.ErrorContains(t, err, "no methods")
+
+	_, err = shiroload.Run(context.Background(), &loadClient{}, &shiroload.Workload{
+		Methods: []shiroload.Method{{Name: "m", Params: "{{.Missing"}},
+		Stages:  []shiroload.Stage{{Duration: time.Second, RPS: 1}},
+	})
+	require.ErrorContains(t, err, "method m: params")
+}
@@ -0,0 +1,82 @@
+// Package assert provides test helpers for checking ShiroResponse values.
+// Each helper fails the test immediately, like testify's require package.
+//
+//	resp, err := client.Call(ctx, "get_account", shiroclient.WithParams(req))
+//	require.NoError(t, err)
+//	assert.ProtoEqual(t, resp, &pb.Account{Id: "a1"})
+package assert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// RequireSuccess fails the test if resp is nil or contains a phylum error.
+func RequireSuccess(t testing.TB, resp shiroclient.ShiroResponse) {
+	t.Helper()
+	require.NotNil(t, resp, "nil response")
+	if e := resp.Error(); e != nil {
+		require.FailNowf(t, "unexpected phylum error",
+			"code: %d\nmessage: %s\ndata: %s", e.Code(), e.Message(), e.DataJSON())
+	}
+}
+
+// RequireErrorCode fails the test unless resp contains a phylum error with
+// the given code.
+func RequireErrorCode(t testing.TB, resp shiroclient.ShiroResponse, code int) {
+	t.Helper()
+	require.NotNil(t, resp, "nil response")
+	e := resp.Error()
+	if e == nil {
+		require.FailNowf(t, "expected a phylum error",
+			"want code %d, got result: %s", code, resp.ResultJSON())
+	}
+	require.Equal(t, code, e.Code(), "phylum error code (message: %s)", e.Message())
+}
+
+// RequireErrorData fails the test unless resp contains a phylum error
+// whose data is JSON equivalent to want.
+func RequireErrorData(t testing.TB, resp shiroclient.ShiroResponse, want string) {
+	t.Helper()
+	require.NotNil(t, resp, "nil response")
+	e := resp.Error()
+	if e == nil {
+		require.FailNowf(t, "expected a phylum error",
+			"got result: %s", resp.ResultJSON())
+	}
+	require.JSONEq(t, want, string(e.DataJSON()))
+}
+
+// JSONEq fails the test unless resp is successful and its result is JSON
+// equivalent to want.
+func JSONEq(t testing.TB, resp shiroclient.ShiroResponse, want string) {
+	t.Helper()
+	RequireSuccess(t, resp)
+	require.JSONEq(t, want, string(resp.ResultJSON()))
+}
+
+// RequireUnmarshal fails the test unless resp is successful and its result
+// can be unmarshaled into dst with UnmarshalTo.
+func RequireUnmarshal(t testing.TB, resp shiroclient.ShiroResponse, dst interface{}) {
+	t.Helper()
+	RequireSuccess(t, resp)
+	require.NoError(t, resp.UnmarshalTo(dst), "unmarshal result: %s", resp.ResultJSON())
+}
+
+// ProtoEqual fails the test unless resp is successful and its result,
+// unmarshaled into a message of the same type as want, equals want.
+func ProtoEqual(t testing.TB, resp shiroclient.ShiroResponse, want proto.Message) {
+	t.Helper()
+	got := want.ProtoReflect().New().Interface()
+	RequireUnmarshal(t, resp, got)
+	if !proto.Equal(want, got) {
+		m := protojson.MarshalOptions{UseProtoNames: true, Multiline: true}
+		require.FailNowf(t, "proto messages differ",
+			"want: %s\ngot: %s", m.Format(want), m.Format(got))
+	}
+}
@@ -0,0 +1,77 @@
+package assert_test
+
+import (
+	"fmt"
+	"testing"
+
+	healthcheck "buf.build/gen/go/luthersystems/protos/protocolbuffers/go/healthcheck/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/shirotest/assert"
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+)
+
+// recorder records test failures without stopping the calling test.
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failed = true
+}
+
+func (r *recorder) FailNow() {
+	r.failed = true
+	// stop the helper, like testing.T.FailNow does.
+	panic(r)
+}
+
+// fails reports whether fn fails the test.
+func fails(t *testing.T, fn func(t testing.TB)) (failed bool) {
+	r := &recorder{TB: t}
+	defer func() {
+		if v := recover(); v != nil && v != r {
+			panic(v)
+		}
+		failed = r.failed
+	}()
+	fn(r)
+	return false
+}
+
+func TestAssertions(t *testing.T) {
+	success := plugin.NewSuccessResponse([]byte(`{"reports":[{"status":"UP"}]}`), "tx1")
+	failure := plugin.NewFailureResponse(3, "not found", []byte(`{"id":"a1"}`))
+
+	for i, tc := range []struct {
+		fn   func(t testing.TB)
+		fail bool
+	}{
+		{func(t testing.TB) { assert.RequireSuccess(t, success) }, false},
+		{func(t testing.TB) { assert.RequireSuccess(t, failure) }, true},
+		{func(t testing.TB) { assert.RequireSuccess(t, nil) }, true},
+		{func(t testing.TB) { assert.RequireErrorCode(t, failure, 3) }, false},
+		{func(t testing.TB) { assert.RequireErrorCode(t, failure, 4) }, true},
+		{func(t testing.TB) { assert.RequireErrorCode(t, success, 3) }, true},
+		{func(t testing.TB) { assert.RequireErrorData(t, failure, `{"id": "a1"}`) }, false},
+		{func(t testing.TB) { assert.RequireErrorData(t, failure, `{}`) }, true},
+		{func(t testing.TB) { assert.JSONEq(t, success, `{"reports": [{"status": "UP"}]}`) }, false},
+		{func(t testing.TB) { assert.JSONEq(t, success, `{}`) }, true},
+		{func(t testing.TB) { assert.JSONEq(t, failure, `{}`) }, true},
+		{func(t testing.TB) {
+			assert.ProtoEqual(t, success, &healthcheck.GetHealthCheckResponse{
+				Reports: []*healthcheck.HealthCheckReport{{Status: "UP"}},
+			})
+		}, false},
+		{func(t testing.TB) {
+			assert.ProtoEqual(t, success, &healthcheck.GetHealthCheckResponse{})
+		}, true},
+	} {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			require.Equal(t, tc.fail, fails(t, tc.fn))
+		})
+	}
+}
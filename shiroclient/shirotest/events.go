@@ -0,0 +1,166 @@
+package shirotest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// Event is a chaincode event emitted by a transaction.
+type Event struct {
+	// Block is the number of the block holding the transaction.
+	Block uint64
+	// TxID is the ID of the transaction.
+	TxID string
+	// ChaincodeID is the chaincode that emitted the event.
+	ChaincodeID string
+	// Payload is the event payload.
+	Payload []byte
+}
+
+// Name returns the "name" field of the event payload, or an empty string
+// if the payload is not a JSON object with a string name.
+func (e *Event) Name() string {
+	var v struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(e.Payload, &v); err != nil {
+		return ""
+	}
+	return v.Name
+}
+
+// EventMatcher selects events.
+type EventMatcher func(e *Event) bool
+
+// ByName matches events whose name, as returned by Event.Name, is name.
+func ByName(name string) EventMatcher {
+	return func(e *Event) bool {
+		return e.Name() == name
+	}
+}
+
+// ByPayloadJSON matches events whose payload is JSON equivalent to want.
+// ByPayloadJSON panics if want is not valid JSON.
+func ByPayloadJSON(want string) EventMatcher {
+	var w interface{}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		panic("shirotest: invalid JSON: " + err.Error())
+	}
+	wantJSON, _ := json.Marshal(w)
+	return func(e *Event) bool {
+		var v interface{}
+		if err := json.Unmarshal(e.Payload, &v); err != nil {
+			return false
+		}
+		b, _ := json.Marshal(v)
+		return bytes.Equal(b, wantJSON)
+	}
+}
+
+// FilterEvents returns the events matching all of matchers.
+func FilterEvents(events []*Event, matchers ...EventMatcher) []*Event {
+	var matched []*Event
+outer:
+	for _, e := range events {
+		for _, m := range matchers {
+			if !m(e) {
+				continue outer
+			}
+		}
+		matched = append(matched, e)
+	}
+	return matched
+}
+
+// EventCheckpoint collects the events emitted after a point in the ledger
+// of a client.  It is meant for mock clients, whose ledgers are cheap to
+// scan.
+//
+//	events := shirotest.Checkpoint(t, client)
+//	resp, err := client.Call(ctx, "create_account", shiroclient.WithParams(req))
+//	...
+//	events.RequireEvent(t, shirotest.ByName("account_created"))
+type EventCheckpoint struct {
+	client  shiroclient.ShiroClient
+	configs []shiroclient.Config
+	height  uint64
+}
+
+// Checkpoint returns a checkpoint at the current height of the ledger of
+// client.  configs are applied to the ledger queries.  The test fails if
+// the height cannot be queried.
+func Checkpoint(t testing.TB, client shiroclient.ShiroClient, configs ...shiroclient.Config) *EventCheckpoint {
+	t.Helper()
+	height, err := client.QueryInfo(context.Background(), configs...)
+	if err != nil {
+		t.Fatalf("shirotest: query height: %v", err)
+	}
+	return &EventCheckpoint{client: client, configs: configs, height: height}
+}
+
+// Events returns the events emitted since the checkpoint, in ledger order.
+// Transactions without an event are skipped.  The test fails if the ledger
+// cannot be queried.
+func (c *EventCheckpoint) Events(t testing.TB) []*Event {
+	t.Helper()
+	ctx := context.Background()
+	height, err := c.client.QueryInfo(ctx, c.configs...)
+	if err != nil {
+		t.Fatalf("shirotest: query height: %v", err)
+	}
+	var events []*Event
+	for n := c.height; n < height; n++ {
+		blk, err := c.client.QueryBlock(ctx, n, c.configs...)
+		if err != nil {
+			t.Fatalf("shirotest: query block %d: %v", n, err)
+		}
+		for _, tx := range blk.Transactions() {
+			if len(tx.Event()) == 0 {
+				continue
+			}
+			events = append(events, &Event{
+				Block:       n,
+				TxID:        tx.ID(),
+				ChaincodeID: tx.ChaincodeID(),
+				Payload:     tx.Event(),
+			})
+		}
+	}
+	return events
+}
+
+// RequireEvent fails the test unless an event matching all of matchers was
+// emitted since the checkpoint, and returns the first one.
+func (c *EventCheckpoint) RequireEvent(t testing.TB, matchers ...EventMatcher) *Event {
+	t.Helper()
+	events := c.Events(t)
+	matched := FilterEvents(events, matchers...)
+	if len(matched) == 0 {
+		t.Fatalf("shirotest: no matching event among %d events:%s", len(events), formatEvents(events))
+	}
+	return matched[0]
+}
+
+// RequireNoEvent fails the test if an event matching all of matchers was
+// emitted since the checkpoint.
+func (c *EventCheckpoint) RequireNoEvent(t testing.TB, matchers ...EventMatcher) {
+	t.Helper()
+	if matched := FilterEvents(c.Events(t), matchers...); len(matched) > 0 {
+		t.Fatalf("shirotest: unexpected matching events:%s", formatEvents(matched))
+	}
+}
+
+func formatEvents(events []*Event) string {
+	var b bytes.Buffer
+	for _, e := range events {
+		b.WriteString("\n\t")
+		b.WriteString(e.TxID)
+		b.WriteString(": ")
+		b.Write(e.Payload)
+	}
+	return b.String()
+}
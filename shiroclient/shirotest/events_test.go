@@ -0,0 +1,55 @@
+package shirotest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/shirotest"
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+)
+
+// ledgerClient is a ShiroClient serving a fixed list of blocks.
+type ledgerClient struct {
+	shiroclient.ShiroClient
+	blocks []*plugin.Block
+}
+
+func (c *ledgerClient) QueryInfo(ctx context.Context, configs ...shiroclient.Config) (uint64, error) {
+	return uint64(len(c.blocks)), nil
+}
+
+func (c *ledgerClient) QueryBlock(ctx context.Context, n uint64, configs ...shiroclient.Config) (shiroclient.Block, error) {
+	return plugin.NewShiroClientBlock(c.blocks[n]), nil
+}
+
+func TestEvents(t *testing.T) {
+	client := &ledgerClient{blocks: []*plugin.Block{
+		{Transactions: []*plugin.Transaction{{ID: "tx0", Event: []byte(`{"name":"old"}`)}}},
+	}}
+	events := shirotest.Checkpoint(t, client)
+	require.Empty(t, events.Events(t))
+
+	client.blocks = append(client.blocks,
+		&plugin.Block{Transactions: []*plugin.Transaction{
+			{ID: "tx1"},
+			{ID: "tx2", ChaincodeID: "cc", Event: []byte(`{"name":"created","id":"a1"}`)},
+		}},
+		&plugin.Block{Transactions: []*plugin.Transaction{
+			{ID: "tx3", Event: []byte(`{"name":"created","id":"a2"}`)},
+		}},
+	)
+	all := events.Events(t)
+	require.Len(t, all, 2)
+	require.Equal(t, &shirotest.Event{Block: 1, TxID: "tx2", ChaincodeID: "cc", Payload: []byte(`{"name":"created","id":"a1"}`)}, all[0])
+	require.Equal(t, "created", all[0].Name())
+
+	e := events.RequireEvent(t, shirotest.ByName("created"), shirotest.ByPayloadJSON(`{"id": "a2", "name": "created"}`))
+	require.Equal(t, "tx3", e.TxID)
+	require.Len(t, shirotest.FilterEvents(all, shirotest.ByName("created")), 2)
+	events.RequireNoEvent(t, shirotest.ByName("old"))
+	require.Empty(t, shirotest.FilterEvents(all, shirotest.ByPayloadJSON(`{"id":"a1"}`)))
+	require.Empty(t, (&shirotest.Event{Payload: []byte("raw")}).Name())
+}
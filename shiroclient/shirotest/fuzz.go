@@ -0,0 +1,308 @@
+// Package shirotest provides utilities for testing phyla and the clients
+// that call them.
+package shirotest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// FuzzTarget is a phylum method to fuzz, with example arguments that are
+// mutated to produce inputs.
+type FuzzTarget struct {
+	// Method is the phylum method.
+	Method string
+	// Params are example params.  They must be encodable as JSON.
+	Params interface{}
+	// Transient is example transient data.
+	Transient map[string][]byte
+}
+
+// FuzzOptions configures FuzzPhylum.
+type FuzzOptions struct {
+	// Iterations is the number of inputs generated per target.  Defaults
+	// to 100.
+	Iterations int
+	// Seed seeds input generation.  A time based seed is used, and logged,
+	// if Seed is zero.
+	Seed int64
+	// MaxStringSize bounds the size of generated strings.  Defaults to
+	// 64KiB.
+	MaxStringSize int
+	// Configs are additional configs for each call.
+	Configs []shiroclient.Config
+}
+
+// rawParams are params sent to the phylum verbatim, which allows sending
+// malformed JSON to a mock client.
+type rawParams []byte
+
+// fuzzMarshal marshals rawParams verbatim and everything else as JSON.
+func fuzzMarshal(v interface{}) ([]byte, error) {
+	if raw, ok := v.(rawParams); ok {
+		return raw, nil
+	}
+	return json.Marshal(v)
+}
+
+// FuzzPhylum calls each target method with inputs derived from its example
+// arguments: malformed JSON, values of the wrong type, missing and unknown
+// fields, huge strings and random transient data.  The test fails if a call
+// returns an error rather than a response, if a phylum error carries data
+// that is not JSON, or if the client stops answering QueryInfo, all of
+// which indicate that substrate or the SDK failed to handle the input.
+//
+// FuzzPhylum is intended for clients created with NewMock.  Malformed JSON
+// params are only sent to mock clients because the RPC client cannot
+// encode them.
+func FuzzPhylum(t testing.TB, client shiroclient.ShiroClient, targets []FuzzTarget, opts FuzzOptions) {
+	t.Helper()
+	if opts.Iterations <= 0 {
+		opts.Iterations = 100
+	}
+	if opts.MaxStringSize <= 0 {
+		opts.MaxStringSize = 64 << 10
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	t.Logf("shirotest: fuzzing with seed %d", opts.Seed)
+	_, mock := client.(shiroclient.MockShiroClient)
+	f := &fuzzer{
+		rng:       rand.New(rand.NewSource(opts.Seed)), // #nosec G404
+		maxString: opts.MaxStringSize,
+		malformed: mock,
+	}
+	ctx := context.Background()
+	for _, target := range targets {
+		example, err := toJSONValue(target.Params)
+		if err != nil {
+			t.Fatalf("shirotest: %s: example params: %v", target.Method, err)
+		}
+		for i := 0; i < opts.Iterations; i++ {
+			params, desc := f.params(example)
+			transient := f.transient(target.Transient)
+			configs := append([]shiroclient.Config{
+				shiroclient.WithParams(params),
+				shiroclient.WithTransientDataMap(transient),
+				shiroclient.WithJSONCodec(fuzzMarshal, nil),
+			}, opts.Configs...)
+			resp, err := client.Call(ctx, target.Method, configs...)
+			if err != nil {
+				t.Errorf("shirotest: %s iteration %d (seed %d, %s): call failed: %v\nparams: %s",
+					target.Method, i, opts.Seed, desc, err, truncate(params))
+			} else if e := resp.Error(); e != nil {
+				if data := e.DataJSON(); len(data) > 0 && !json.Valid(data) {
+					t.Errorf("shirotest: %s iteration %d (seed %d, %s): error data is not JSON: %s",
+						target.Method, i, opts.Seed, desc, data)
+				}
+			}
+			if _, err := client.QueryInfo(ctx); err != nil {
+				t.Fatalf("shirotest: %s iteration %d (seed %d, %s): client unavailable after call: %v\nparams: %s",
+					target.Method, i, opts.Seed, desc, err, truncate(params))
+			}
+		}
+	}
+}
+
+func toJSONValue(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+func truncate(params interface{}) string {
+	var s string
+	if raw, ok := params.(rawParams); ok {
+		s = string(raw)
+	} else if b, err := json.Marshal(params); err == nil {
+		s = string(b)
+	} else {
+		s = fmt.Sprint(params)
+	}
+	const max = 256
+	if len(s) > max {
+		return fmt.Sprintf("%s... (%d bytes)", s[:max], len(s))
+	}
+	return s
+}
+
+// fuzzer generates inputs.
+type fuzzer struct {
+	rng       *rand.Rand
+	maxString int
+	malformed bool
+}
+
+// params returns mutated params and a description of the mutation.
+func (f *fuzzer) params(example interface{}) (interface{}, string) {
+	n := 4
+	if f.malformed {
+		n++
+	}
+	switch f.rng.Intn(n) {
+	case 0:
+		return f.mutate(example), "mutated value"
+	case 1:
+		return f.value(3), "random value"
+	case 2:
+		return strings.Repeat("x", f.maxString), "huge string"
+	case 3:
+		return f.nest(64), "deep nesting"
+	default:
+		return f.malformedJSON(example), "malformed JSON"
+	}
+}
+
+// mutate returns a copy of v with one value replaced, removed or added.
+func (f *fuzzer) mutate(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v)+1)
+		keys := make([]string, 0, len(v))
+		for k, val := range v {
+			out[k] = val
+			keys = append(keys, k)
+		}
+		if len(keys) == 0 || f.rng.Intn(4) == 0 {
+			out[f.str(16)] = f.value(2)
+			return out
+		}
+		sort.Strings(keys)
+		k := keys[f.rng.Intn(len(keys))]
+		if f.rng.Intn(3) == 0 {
+			delete(out, k)
+		} else {
+			out[k] = f.mutate(v[k])
+		}
+		return out
+	case []interface{}:
+		out := append([]interface{}(nil), v...)
+		if len(out) == 0 || f.rng.Intn(4) == 0 {
+			return append(out, f.value(2))
+		}
+		i := f.rng.Intn(len(out))
+		out[i] = f.mutate(out[i])
+		return out
+	default:
+		// replace scalars with a value of another type.
+		return f.value(1)
+	}
+}
+
+// value returns a random JSON value nested at most depth levels.
+func (f *fuzzer) value(depth int) interface{} {
+	n := 6
+	if depth > 0 {
+		n = 8
+	}
+	switch f.rng.Intn(n) {
+	case 0:
+		return nil
+	case 1:
+		return f.rng.Intn(2) == 0
+	case 2:
+		return f.rng.NormFloat64() * 1e12
+	case 3:
+		return f.str(32)
+	case 4:
+		return strings.Repeat("é", f.rng.Intn(f.maxString/2+1))
+	case 5:
+		return -1 << 62
+	case 6:
+		m := map[string]interface{}{}
+		for i := f.rng.Intn(4); i > 0; i-- {
+			m[f.str(8)] = f.value(depth - 1)
+		}
+		return m
+	default:
+		var a []interface{}
+		for i := f.rng.Intn(4); i > 0; i-- {
+			a = append(a, f.value(depth-1))
+		}
+		return a
+	}
+}
+
+// str returns a random string of at most n bytes including control and
+// non-ASCII characters.
+func (f *fuzzer) str(n int) string {
+	const alphabet = "abcXYZ019 _-\"\\\x00\x1fé世"
+	runes := []rune(alphabet)
+	var b strings.Builder
+	for i := f.rng.Intn(n + 1); i > 0; i-- {
+		b.WriteRune(runes[f.rng.Intn(len(runes))])
+	}
+	return b.String()
+}
+
+// nest returns a value nested depth levels deep.
+func (f *fuzzer) nest(depth int) interface{} {
+	var v interface{} = f.value(0)
+	for i := 0; i < depth; i++ {
+		if f.rng.Intn(2) == 0 {
+			v = []interface{}{v}
+		} else {
+			v = map[string]interface{}{"a": v}
+		}
+	}
+	return v
+}
+
+// malformedJSON returns invalid JSON derived from the encoding of example.
+func (f *fuzzer) malformedJSON(example interface{}) rawParams {
+	b, _ := json.Marshal(example)
+	switch f.rng.Intn(4) {
+	case 0:
+		if len(b) > 1 {
+			return rawParams(b[:f.rng.Intn(len(b)-1)+1])
+		}
+		return rawParams("{")
+	case 1:
+		return rawParams(append(b, '}'))
+	case 2:
+		return rawParams("{'single': quotes}")
+	default:
+		return rawParams([]byte{0xff, 0xfe, '[', '1'})
+	}
+}
+
+// transient returns random transient data derived from example.
+func (f *fuzzer) transient(example map[string][]byte) map[string][]byte {
+	out := make(map[string][]byte, len(example)+1)
+	for k, v := range example {
+		switch f.rng.Intn(4) {
+		case 0:
+			// drop the key.
+		case 1:
+			out[k] = nil
+		case 2:
+			b := make([]byte, f.rng.Intn(f.maxString+1))
+			_, _ = f.rng.Read(b)
+			out[k] = b
+		default:
+			out[k] = v
+		}
+	}
+	if f.rng.Intn(4) == 0 {
+		if k := f.str(16); k != "" {
+			out[k] = []byte(f.str(64))
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package shirotest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/shirotest"
+)
+
+// recorder records test failures without failing the calling test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper()                                 {}
+func (r *recorder) Logf(format string, args ...interface{}) {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// phylumGateway returns phylum errors for every call, except that the
+// "fragile" method fails with an HTTP error for string params.
+func phylumGateway(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+			Params struct {
+				Method string      `json:"method"`
+				Params interface{} `json:"params"`
+			} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if _, ok := req.Params.Params.(string); ok && req.Params.Method == "fragile" {
+			http.Error(w, "substrate crashed", http.StatusInternalServerError)
+			return
+		}
+		level, result := 0, interface{}(1)
+		if req.Method == "Call" {
+			level, result = 2, nil
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      "1",
+			"result": map[string]interface{}{
+				"error_level": level,
+				"result":      result,
+				"code":        1,
+				"message":     "bad request",
+				"data":        map[string]interface{}{"reason": "invalid"},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFuzzPhylum(t *testing.T) {
+	srv := phylumGateway(t)
+	client := shiroclient.NewRPC([]shiroclient.Config{shiroclient.WithEndpoint(srv.URL)})
+	opts := shirotest.FuzzOptions{Iterations: 50, Seed: 1, MaxStringSize: 1024}
+
+	r := &recorder{TB: t}
+	shirotest.FuzzPhylum(r, client, []shirotest.FuzzTarget{{
+		Method:    "robust",
+		Params:    []interface{}{map[string]interface{}{"id": "a1", "amount": 3}},
+		Transient: map[string][]byte{"secret": []byte("s")},
+	}}, opts)
+	require.Empty(t, r.errors)
+
+	r = &recorder{TB: t}
+	shirotest.FuzzPhylum(r, client, []shirotest.FuzzTarget{{
+		Method: "fragile",
+		Params: []interface{}{"a1"},
+	}}, opts)
+	require.NotEmpty(t, r.errors)
+	require.Contains(t, r.errors[0], "fragile")
+	require.Contains(t, r.errors[0], "seed 1")
+}
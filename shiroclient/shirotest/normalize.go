@@ -0,0 +1,145 @@
+package shirotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// MaskedValue replaces the values of the fields given to MaskFields.
+const MaskedValue = "<masked>"
+
+// NormalizeOption customizes NormalizeJSON.
+type NormalizeOption func(*normalizer)
+
+type normalizer struct {
+	strip map[string]bool
+	mask  map[string]bool
+}
+
+// StripFields removes the object fields named names, at any depth, e.g.
+// timestamps and transaction IDs that change between runs.
+func StripFields(names ...string) NormalizeOption {
+	return func(n *normalizer) {
+		for _, name := range names {
+			n.strip[name] = true
+		}
+	}
+}
+
+// MaskFields replaces the values of the object fields named names, at any
+// depth, with MaskedValue.  Unlike StripFields, the golden file still
+// shows that the fields are present.
+func MaskFields(names ...string) NormalizeOption {
+	return func(n *normalizer) {
+		for _, name := range names {
+			n.mask[name] = true
+		}
+	}
+}
+
+// NormalizeJSON returns a canonical form of the JSON document data, so that
+// golden file comparisons of phylum responses are stable across runs:
+// object keys are sorted, numbers are formatted like encoding/json formats
+// float64 values (integers are kept exactly), HTML characters are not
+// escaped and the document is indented by two spaces and ends with a
+// newline.
+func NormalizeJSON(data []byte, opts ...NormalizeOption) ([]byte, error) {
+	n := &normalizer{strip: make(map[string]bool), mask: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(n)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("shirotest: normalize: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("shirotest: normalize: trailing data after JSON value")
+	}
+	v, err := n.normalize(v)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("shirotest: normalize: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// NormalizeResult returns the normalized result of resp, see
+// NormalizeJSON.  An error is returned if resp holds a phylum error.
+func NormalizeResult(resp shiroclient.ShiroResponse, opts ...NormalizeOption) ([]byte, error) {
+	if e := resp.Error(); e != nil {
+		return nil, fmt.Errorf("shirotest: normalize: phylum error %d: %s", e.Code(), e.Message())
+	}
+	return NormalizeJSON(resp.ResultJSON(), opts...)
+}
+
+func (n *normalizer) normalize(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range v {
+			switch {
+			case n.strip[k]:
+				delete(v, k)
+			case n.mask[k]:
+				v[k] = MaskedValue
+			default:
+				elem, err := n.normalize(elem)
+				if err != nil {
+					return nil, err
+				}
+				v[k] = elem
+			}
+		}
+		return v, nil
+	case []interface{}:
+		for i, elem := range v {
+			elem, err := n.normalize(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = elem
+		}
+		return v, nil
+	case json.Number:
+		return normalizeNumber(v)
+	default:
+		return v, nil
+	}
+}
+
+// normalizeNumber formats integer literals without a sign on zero and other
+// numbers as encoding/json formats float64 values, so that 1.50, 1.5e0
+// and 15e-1 are all written as 1.5.
+func normalizeNumber(num json.Number) (json.Number, error) {
+	s := num.String()
+	if !strings.ContainsAny(s, ".eE") {
+		if s == "-0" {
+			return "0", nil
+		}
+		return num, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return "", fmt.Errorf("shirotest: normalize: number %s: %w", s, err)
+	}
+	if f == 0 {
+		f = 0 // drop the sign of -0
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return "", fmt.Errorf("shirotest: normalize: number %s: %w", s, err)
+	}
+	return json.Number(b), nil
+}
@@ -0,0 +1,42 @@
+package shirotest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/shirotest"
+)
+
+func TestNormalizeJSON(t *testing.T) {
+	got, err := shirotest.NormalizeJSON([]byte(`{"b": [1.50, 15e-1, -0, -0.0, 12345678901234567890], "a": {"tx_id": "x", "created": "now", "note": "<ok>"}}`),
+		shirotest.StripFields("tx_id"), shirotest.MaskFields("created"))
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "a": {
+    "created": "<masked>",
+    "note": "<ok>"
+  },
+  "b": [
+    1.5,
+    1.5,
+    0,
+    0,
+    12345678901234567890
+  ]
+}
+`, string(got))
+
+	_, err = shirotest.NormalizeJSON([]byte(`{} {}`))
+	require.Error(t, err)
+}
+
+func TestNormalizeResult(t *testing.T) {
+	got, err := shirotest.NormalizeResult(types.NewSuccessResponse([]byte(`{"z":1,"y":2}`), "tx1", 0, 0))
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"y\": 2,\n  \"z\": 1\n}\n", string(got))
+
+	_, err = shirotest.NormalizeResult(types.NewFailureResponse(400, "bad request", nil))
+	require.ErrorContains(t, err, "bad request")
+}
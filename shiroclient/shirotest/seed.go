@@ -0,0 +1,71 @@
+package shirotest
+
+import (
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+)
+
+// SeedEnv is the environment variable that replays a seed reported by
+// RandSeed.
+const SeedEnv = "SHIROTEST_SEED"
+
+// RandSeed returns the seed for the random values of a test, to pass to
+// mock.WithRandSeed and SeedPrivate.  The seed is read from the SHIROTEST_SEED
+// environment variable, if set, to replay a failing run; otherwise a new
+// seed is chosen.  If the test fails, the seed is logged with the command
+// line setting to replay it.
+func RandSeed(t testing.TB) int64 {
+	t.Helper()
+	var seed int64
+	if s := os.Getenv(SeedEnv); s != "" {
+		var err error
+		seed, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			t.Fatalf("invalid %s: %v", SeedEnv, err)
+		}
+	} else {
+		seed = time.Now().UnixNano()
+	}
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Logf("random seed %d; replay with %s=%d", seed, SeedEnv, seed)
+		}
+	})
+	return seed
+}
+
+// SeedPrivate makes private.SeedGen generate keys derived from seed for the
+// duration of the test.  Because SeedGen is global, tests using SeedPrivate
+// must not run in parallel with tests that encrypt private data.
+func SeedPrivate(t testing.TB, seed int64) {
+	t.Helper()
+	orig := private.SeedGen
+	private.SeedGen = DeterministicSeedGen(seed)
+	t.Cleanup(func() { private.SeedGen = orig })
+}
+
+// privateSeedSize is the size of the secret keys generated by
+// private.SeedGen.
+const privateSeedSize = 32
+
+// DeterministicSeedGen returns a generator of secret keys derived from
+// seed, for use as private.SeedGen in tests that need reproducible
+// encryption.  It lives in this test-only package so that it cannot be
+// used in production by accident; see SeedPrivate.
+func DeterministicSeedGen(seed int64) func() ([]byte, error) {
+	var mu sync.Mutex
+	r := rand.New(rand.NewSource(seed))
+	return func() ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := make([]byte, privateSeedSize)
+		_, _ = r.Read(key)
+		return key, nil
+	}
+}
@@ -0,0 +1,46 @@
+package shirotest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/private"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/shirotest"
+)
+
+func TestRandSeed(t *testing.T) {
+	t.Setenv(shirotest.SeedEnv, "42")
+	require.Equal(t, int64(42), shirotest.RandSeed(t))
+}
+
+func TestSeedPrivate(t *testing.T) {
+	orig := private.SeedGen
+	t.Run("seeded", func(t *testing.T) {
+		shirotest.SeedPrivate(t, 7)
+		a, err := private.SeedGen()
+		require.NoError(t, err)
+		shirotest.SeedPrivate(t, 7)
+		b, err := private.SeedGen()
+		require.NoError(t, err)
+		require.Equal(t, a, b)
+		require.Len(t, a, 32)
+	})
+	// the original generator is restored.
+	a, err := private.SeedGen()
+	require.NoError(t, err)
+	b, err := orig()
+	require.NoError(t, err)
+	require.NotEqual(t, a, b)
+}
+
+func TestDeterministicSeedGen(t *testing.T) {
+	a, err := shirotest.DeterministicSeedGen(7)()
+	require.NoError(t, err)
+	b, err := shirotest.DeterministicSeedGen(7)()
+	require.NoError(t, err)
+	c, err := shirotest.DeterministicSeedGen(8)()
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+	require.NotEqual(t, a, c)
+}
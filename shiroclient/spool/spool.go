@@ -0,0 +1,368 @@
+// Package spool queues write calls in a directory while the gateway is
+// unreachable and replays them in order when connectivity returns, for
+// intermittently connected deployments.
+//
+//	s, err := spool.Open("/var/lib/app/spool", client,
+//		spool.WithOutcome(func(o spool.Outcome) {
+//			log.Printf("spooled call %d %s: %v", o.Entry.Seq, o.Entry.Method, o.Err)
+//		}))
+//	if err != nil {
+//		return err
+//	}
+//	go s.Run(ctx)
+//	resp, err := s.Call(ctx, "record_reading", shiroclient.WithParams(reading))
+//	if errors.Is(err, spool.ErrSpooled) {
+//		// the outcome is reported to the WithOutcome callback.
+//	}
+//
+// Calls carry an idempotency key, generated if the caller did not set one
+// with shiroclient.WithIdempotencyKey, so that a write submitted before a
+// connection was lost is not committed twice when it is replayed.  Only the
+// method, params, transient data and idempotency key of a call are spooled;
+// other configs, like authentication, must be passed with WithConfigs.
+// Transient data is written to disk unencrypted.
+//
+// A spool directory must be used by a single Spool at a time.
+package spool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/backoff"
+)
+
+// DefaultRetryInterval is the time between replays of a Spool without
+// WithRetryInterval.
+const DefaultRetryInterval = 10 * time.Second
+
+// entryExt is the file extension of spooled entries.
+const entryExt = ".json"
+
+// ErrSpooled is returned by Call when the call was spooled for replay.
+var ErrSpooled = errors.New("spool: call spooled")
+
+// ErrFull is returned by Call when a call could not be made and the spool
+// holds the maximum number of entries.
+var ErrFull = errors.New("spool: full")
+
+// Entry is a spooled call.
+type Entry struct {
+	// Seq orders the entries of a spool.
+	Seq uint64 `json:"seq"`
+	// Method is the phylum method called.
+	Method string `json:"method"`
+	// Params are the JSON params of the call.
+	Params json.RawMessage `json:"params,omitempty"`
+	// Transient is the transient data of the call.
+	Transient map[string][]byte `json:"transient,omitempty"`
+	// IdempotencyKey is the idempotency key of the call.
+	IdempotencyKey string `json:"idempotency_key"`
+	// Spooled is the time the call was spooled.
+	Spooled time.Time `json:"spooled"`
+}
+
+// Outcome is the final outcome of a spooled call.
+type Outcome struct {
+	Entry *Entry
+	// Response is the response to the call, if Err is nil.  It may
+	// contain a phylum error.
+	Response shiroclient.ShiroResponse
+	// Err is the error of the call.
+	Err error
+}
+
+// IsOffline returns true if err indicates that the gateway could not be
+// reached or did not respond in time, so that a call failing with err
+// should be spooled or replayed later.
+func IsOffline(err error) bool {
+	if err == nil {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	return shiroclient.IsTimeoutError(err)
+}
+
+// Option configures a Spool.
+type Option func(*Spool)
+
+// WithOutcome sets a function called with the final outcome of each
+// spooled call, once it is replayed without an offline error.
+func WithOutcome(fn func(Outcome)) Option {
+	return func(s *Spool) {
+		s.outcome = fn
+	}
+}
+
+// WithConfigs sets configs applied before the spooled configs of replayed
+// calls.
+func WithConfigs(configs ...shiroclient.Config) Option {
+	return func(s *Spool) {
+		s.configs = configs
+	}
+}
+
+// WithRetryInterval sets the time between replays by Run.  It defaults to
+// DefaultRetryInterval.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(s *Spool) {
+		s.interval = interval
+	}
+}
+
+// WithMaxEntries limits the number of spooled calls.  Calls that cannot be
+// made while the spool is full fail with ErrFull.
+func WithMaxEntries(n int) Option {
+	return func(s *Spool) {
+		s.maxEntries = n
+	}
+}
+
+// WithOffline sets the function deciding whether a failed call is spooled
+// or replayed later.  It defaults to IsOffline.
+func WithOffline(offline func(error) bool) Option {
+	return func(s *Spool) {
+		s.offline = offline
+	}
+}
+
+// Spool is a ShiroClient spooling calls it cannot make to a directory.
+// Calls made while earlier calls are spooled are spooled behind them, so
+// that writes reach the gateway in order.  A Spool is safe for concurrent
+// use.
+type Spool struct {
+	shiroclient.ShiroClient
+	dir        string
+	outcome    func(Outcome)
+	configs    []shiroclient.Config
+	interval   time.Duration
+	maxEntries int
+	offline    func(error) bool
+
+	// replayMu serializes replays.
+	replayMu sync.Mutex
+
+	mu      sync.Mutex
+	entries []*Entry
+	nextSeq uint64
+}
+
+// Open returns a spool for client storing calls in dir, which is created
+// if needed.  Calls spooled by an earlier process are loaded for replay.
+func Open(dir string, client shiroclient.ShiroClient, opts ...Option) (*Spool, error) {
+	s := &Spool{
+		ShiroClient: client,
+		dir:         dir,
+		interval:    DefaultRetryInterval,
+		offline:     IsOffline,
+		nextSeq:     1,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("spool: %w", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("spool: %w", err)
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), entryExt) {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, f.Name())) // #nosec G304
+		if err != nil {
+			return nil, fmt.Errorf("spool: %w", err)
+		}
+		e := &Entry{}
+		if err := json.Unmarshal(b, e); err != nil {
+			return nil, fmt.Errorf("spool: %s: %w", f.Name(), err)
+		}
+		s.entries = append(s.entries, e)
+	}
+	sort.Slice(s.entries, func(i, j int) bool { return s.entries[i].Seq < s.entries[j].Seq })
+	if n := len(s.entries); n > 0 {
+		s.nextSeq = s.entries[n-1].Seq + 1
+	}
+	return s, nil
+}
+
+// Len returns the number of spooled calls.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// Call implements shiroclient.ShiroClient.  If earlier calls are spooled,
+// or the call fails with an offline error, the call is spooled and Call
+// returns an error wrapping ErrSpooled.
+func (s *Spool) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{
+		Method:         method,
+		Transient:      opt.Transient,
+		IdempotencyKey: opt.IdempotencyKey,
+	}
+	if opt.Params != nil {
+		e.Params, err = json.Marshal(opt.Params)
+		if err != nil {
+			return nil, fmt.Errorf("spool: params: %w", err)
+		}
+	}
+	if e.IdempotencyKey == "" {
+		e.IdempotencyKey = uuid.NewString()
+		configs = append(configs[:len(configs):len(configs)], shiroclient.WithIdempotencyKey(e.IdempotencyKey))
+	}
+	if s.Len() > 0 {
+		return nil, s.spool(e, errors.New("earlier calls are spooled"))
+	}
+	resp, err := s.ShiroClient.Call(ctx, method, configs...)
+	if err != nil && s.offline(err) && ctx.Err() == nil {
+		return nil, s.spool(e, err)
+	}
+	return resp, err
+}
+
+// spool writes e to the spool, returning an error wrapping ErrSpooled and
+// reason, the reason the call was not made.
+func (s *Spool) spool(e *Entry, reason error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		return fmt.Errorf("%w: %s: %w", ErrFull, e.Method, reason)
+	}
+	e.Seq = s.nextSeq
+	e.Spooled = time.Now()
+	if err := s.write(e); err != nil {
+		return fmt.Errorf("spool: %s: %w", e.Method, err)
+	}
+	s.nextSeq++
+	s.entries = append(s.entries, e)
+	return fmt.Errorf("%w: %s (seq %d): %w", ErrSpooled, e.Method, e.Seq, reason)
+}
+
+func (s *Spool) path(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, entryExt))
+}
+
+// write durably writes e to its file.
+func (s *Spool) write(e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // #nosec G104 -- fails after the rename.
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path(e.Seq)); err != nil {
+		return err
+	}
+	return syncDir(s.dir)
+}
+
+// syncDir makes the creation or removal of files in dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir) // #nosec G304
+	if err != nil {
+		return err
+	}
+	defer d.Close() // #nosec G307
+	return d.Sync()
+}
+
+// Replay makes the spooled calls in order until they are all made, one
+// fails with an offline error, or ctx is done.  It returns nil if the
+// spool is empty, and otherwise the error stopping the replay.
+func (s *Spool) Replay(ctx context.Context) error {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		if len(s.entries) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		e := s.entries[0]
+		s.mu.Unlock()
+
+		configs := make([]shiroclient.Config, 0, len(s.configs)+3)
+		configs = append(configs, s.configs...)
+		if e.Params != nil {
+			configs = append(configs, shiroclient.WithParams(e.Params))
+		}
+		if len(e.Transient) > 0 {
+			configs = append(configs, shiroclient.WithTransientDataMap(e.Transient))
+		}
+		configs = append(configs, shiroclient.WithIdempotencyKey(e.IdempotencyKey))
+		resp, err := s.ShiroClient.Call(ctx, e.Method, configs...)
+		if err != nil && (s.offline(err) || ctx.Err() != nil) {
+			return err
+		}
+
+		s.mu.Lock()
+		s.entries = s.entries[1:]
+		rmErr := os.Remove(s.path(e.Seq))
+		if rmErr == nil {
+			rmErr = syncDir(s.dir)
+		}
+		s.mu.Unlock()
+		if s.outcome != nil {
+			s.outcome(Outcome{Entry: e, Response: resp, Err: err})
+		}
+		if rmErr != nil && !os.IsNotExist(rmErr) {
+			return fmt.Errorf("spool: %w", rmErr)
+		}
+	}
+}
+
+// Run replays the spooled calls every retry interval until ctx is done,
+// and returns the error of ctx.
+func (s *Spool) Run(ctx context.Context) error {
+	for {
+		_ = s.Replay(ctx)
+		if err := backoff.Sleep(ctx, s.interval); err != nil {
+			return err
+		}
+	}
+}
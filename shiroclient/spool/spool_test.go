@@ -0,0 +1,136 @@
+package spool_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/spool"
+)
+
+// call is a call received by a gatewayClient.
+type call struct {
+	method string
+	params string
+	key    string
+}
+
+// gatewayClient fails calls with a dial error while offline and returns a
+// phylum error for the method "reject".
+type gatewayClient struct {
+	shiroclient.ShiroClient
+
+	mu      sync.Mutex
+	offline bool
+	calls   []call
+}
+
+func (c *gatewayClient) setOffline(offline bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offline = offline
+}
+
+func (c *gatewayClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.offline {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(opt.Params)
+	if err != nil {
+		return nil, err
+	}
+	c.calls = append(c.calls, call{method: method, params: string(b), key: opt.IdempotencyKey})
+	if method == "reject" {
+		return types.NewFailureResponse(400, "rejected", nil), nil
+	}
+	return types.NewSuccessResponse([]byte(`{}`), "tx-"+method, 0, 0), nil
+}
+
+func TestSpool(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	client := &gatewayClient{}
+	s, err := spool.Open(dir, client)
+	require.NoError(t, err)
+
+	resp, err := s.Call(ctx, "w0", shiroclient.WithParams([]int{0}))
+	require.NoError(t, err)
+	require.Equal(t, "tx-w0", resp.TransactionID())
+	require.NotEmpty(t, client.calls[0].key)
+
+	client.setOffline(true)
+	_, err = s.Call(ctx, "w1", shiroclient.WithParams([]int{1}), shiroclient.WithIdempotencyKey("key1"))
+	require.ErrorIs(t, err, spool.ErrSpooled)
+	require.ErrorContains(t, err, "connection refused")
+	client.setOffline(false)
+	// calls are spooled behind earlier calls.
+	_, err = s.Call(ctx, "reject", shiroclient.WithTransientData("k", []byte("v")))
+	require.ErrorIs(t, err, spool.ErrSpooled)
+	require.Equal(t, 2, s.Len())
+	require.Len(t, client.calls, 1)
+
+	// the spool survives a restart.
+	var outcomes []spool.Outcome
+	s, err = spool.Open(dir, client, spool.WithOutcome(func(o spool.Outcome) {
+		outcomes = append(outcomes, o)
+	}))
+	require.NoError(t, err)
+	require.Equal(t, 2, s.Len())
+
+	client.setOffline(true)
+	require.Error(t, s.Replay(ctx))
+	require.Empty(t, outcomes)
+
+	client.setOffline(false)
+	require.NoError(t, s.Replay(ctx))
+	require.Zero(t, s.Len())
+	require.Len(t, outcomes, 2)
+	require.Equal(t, "w1", outcomes[0].Entry.Method)
+	require.Equal(t, "tx-w1", outcomes[0].Response.TransactionID())
+	require.Equal(t, "rejected", outcomes[1].Response.Error().Message())
+	require.Equal(t, call{method: "w1", params: "[1]", key: "key1"}, client.calls[1])
+	require.Equal(t, "reject", client.calls[2].method)
+	require.Equal(t, outcomes[1].Entry.IdempotencyKey, client.calls[2].key)
+
+	s, err = spool.Open(dir, client)
+	require.NoError(t, err)
+	require.Zero(t, s.Len())
+	_, err = s.Call(ctx, "w3")
+	require.NoError(t, err)
+}
+
+func TestSpoolFull(t *testing.T) {
+	ctx := context.Background()
+	client := &gatewayClient{offline: true}
+	s, err := spool.Open(t.TempDir(), client, spool.WithMaxEntries(1))
+	require.NoError(t, err)
+	_, err = s.Call(ctx, "w0")
+	require.ErrorIs(t, err, spool.ErrSpooled)
+	_, err = s.Call(ctx, "w1")
+	require.ErrorIs(t, err, spool.ErrFull)
+	require.Equal(t, 1, s.Len())
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, s.Run(cctx), context.Canceled)
+}
+
+func TestIsOffline(t *testing.T) {
+	require.False(t, spool.IsOffline(nil))
+	require.False(t, spool.IsOffline(errors.New("bad request")))
+	require.True(t, spool.IsOffline(&net.OpError{Op: "dial", Err: errors.New("refused")}))
+	require.True(t, spool.IsOffline(context.DeadlineExceeded))
+}
@@ -0,0 +1,29 @@
+package shiroclient_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/x/plugin"
+)
+
+func TestErrorStack(t *testing.T) {
+	stack := []shiroclient.StackFrame{
+		{Function: "assert-positive", File: "phylum/util.lisp", Line: 12, Column: 3},
+		{Function: "create-account", File: "phylum/routes.lisp", Line: 40},
+		{Function: "lambda"},
+	}
+	failure := types.NewFailureResponse(1, "negative amount", nil)
+	failure.SetStack(stack)
+	require.Equal(t, stack, shiroclient.ErrorStack(failure))
+	require.Equal(t, "error [1] message [negative amount]"+
+		"\n\tat assert-positive (phylum/util.lisp:12:3)"+
+		"\n\tat create-account (phylum/routes.lisp:40)"+
+		"\n\tat lambda", failure.Error().Error())
+
+	require.Nil(t, shiroclient.ErrorStack(plugin.NewFailureResponse(1, "no stack", nil)))
+	require.Nil(t, shiroclient.ErrorStack(plugin.NewSuccessResponse(nil, "")))
+}
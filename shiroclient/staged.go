@@ -0,0 +1,97 @@
+package shiroclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+)
+
+// Staged params let a Call carry params larger than the gateway accepts in
+// a single request.  The contract with the phylum is:
+//
+//  1. The JSON encoded params are compressed with gzip and encoded with
+//     standard base64.  The encoded string is split into chunks.
+//  2. Each chunk is sent, in order, in a write to StageParamsMethod with
+//     the params [index, count, chunk] and the staging session ID in the
+//     StagedParamsTransientKey transient data.  The phylum stores the chunk
+//     under the session.
+//  3. The method is called with empty params and the session ID in the
+//     StagedParamsTransientKey transient data.  The phylum reassembles the
+//     staged params, decodes them as if they had been passed to the call
+//     and deletes the session.
+//
+// Sessions that are never completed should be expired by the phylum.
+const (
+	// StageParamsMethod is the phylum method receiving staged chunks.
+	StageParamsMethod = "stage_params"
+	// StagedParamsTransientKey is the transient data key holding the
+	// staging session ID.
+	StagedParamsTransientKey = "staged_params_session"
+	// StagedParamsEncoding is the encoding of staged params.
+	StagedParamsEncoding = "gzip+base64"
+)
+
+// CallStaged calls method like client.Call, staging the params set with
+// WithParams in chunks of at most chunkSize bytes when their encoded size
+// exceeds chunkSize.  Smaller params are sent with a single Call.  See
+// StageParamsMethod for the phylum contract.
+//
+// Chunks are sent with configs, without their transient data and
+// idempotency key, and must commit before the method is called, so write
+// polling must not be disabled.  A phylum error from a chunk is returned as
+// the response.
+func CallStaged(ctx context.Context, client ShiroClient, method string, chunkSize int, configs ...Config) (ShiroResponse, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("call staged: invalid chunk size %d", chunkSize)
+	}
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	params, err := opt.JSON.Marshal(opt.Params)
+	if err != nil {
+		return nil, fmt.Errorf("call staged: %w", err)
+	}
+	if len(params) <= chunkSize {
+		return client.Call(ctx, method, configs...)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(params); err != nil {
+		return nil, fmt.Errorf("call staged: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("call staged: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+	session := uuid.New().String()
+	count := (len(encoded) + chunkSize - 1) / chunkSize
+	for i := 0; i < count; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		chunk := encoded[i*chunkSize : end]
+		stage := append(append([]Config(nil), configs...),
+			WithParams([]interface{}{i, count, chunk}),
+			types.Opt(func(r *types.RequestOptions) {
+				r.Transient = map[string][]byte{StagedParamsTransientKey: []byte(session)}
+				r.IdempotencyKey = ""
+			}))
+		resp, err := client.Call(ctx, StageParamsMethod, stage...)
+		if err != nil {
+			return nil, fmt.Errorf("call staged: chunk %d of %d: %w", i, count, err)
+		}
+		if resp.Error() != nil {
+			return resp, nil
+		}
+	}
+	return client.Call(ctx, method, append(append([]Config(nil), configs...),
+		WithParams([]interface{}{}),
+		WithTransientData(StagedParamsTransientKey, []byte(session)))...)
+}
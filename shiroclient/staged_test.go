@@ -0,0 +1,96 @@
+package shiroclient_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+// stagingClient implements the phylum side of staged params: it stores
+// chunks by session and returns the params of other calls as their result.
+type stagingClient struct {
+	shiroclient.ShiroClient
+	chunks map[string][]string
+	stages int
+}
+
+func (c *stagingClient) Call(ctx context.Context, method string, configs ...shiroclient.Config) (shiroclient.ShiroResponse, error) {
+	opt, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return nil, err
+	}
+	session := string(opt.Transient[shiroclient.StagedParamsTransientKey])
+	if method == shiroclient.StageParamsMethod {
+		c.stages++
+		params := opt.Params.([]interface{})
+		if len(opt.Transient) != 1 || opt.IdempotencyKey != "" {
+			return types.NewFailureResponse(400, "unexpected transient data", nil), nil
+		}
+		c.chunks[session] = append(c.chunks[session], params[2].(string))
+		return types.NewSuccessResponse([]byte(`{}`), "", 0, 0), nil
+	}
+	params, err := json.Marshal(opt.Params)
+	if err != nil {
+		return nil, err
+	}
+	if session != "" {
+		encoded := strings.Join(c.chunks[session], "")
+		delete(c.chunks, session)
+		compressed, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, err
+		}
+		params, err = io.ReadAll(zr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return types.NewSuccessResponse(params, "", 0, 0), nil
+}
+
+func TestCallStaged(t *testing.T) {
+	client := &stagingClient{chunks: make(map[string][]string)}
+	ctx := context.Background()
+
+	// incompressible params span several chunks.
+	data := make([]byte, 4096)
+	_, _ = rand.New(rand.NewSource(1)).Read(data)
+	params := []interface{}{"import", hex.EncodeToString(data)}
+	resp, err := shiroclient.CallStaged(ctx, client, "bulk_import", 1024,
+		shiroclient.WithParams(params),
+		shiroclient.WithTransientData("secret", []byte("x")),
+		shiroclient.WithIdempotencyKey("import-1"))
+	require.NoError(t, err)
+	require.Nil(t, resp.Error())
+	require.Greater(t, client.stages, 1)
+	require.Empty(t, client.chunks)
+	var got []interface{}
+	require.NoError(t, json.Unmarshal(resp.ResultJSON(), &got))
+	require.Equal(t, params, got)
+
+	// small params are sent directly.
+	client.stages = 0
+	resp, err = shiroclient.CallStaged(ctx, client, "ping", 1024, shiroclient.WithParams([]string{"a"}))
+	require.NoError(t, err)
+	require.JSONEq(t, `["a"]`, string(resp.ResultJSON()))
+	require.Zero(t, client.stages)
+
+	_, err = shiroclient.CallStaged(ctx, client, "ping", 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,149 @@
+// Package statequery reads ledger state directly through the gateway,
+// without invoking a phylum endpoint.  It is meant for ops tooling that
+// needs raw reads for debugging and reconciliation; applications should
+// read state through their phylum.
+//
+// Queries are subject to gateway policy, which may reject them with a
+// ShiroClient level error.  Only clients created with shiroclient.NewRPC
+// whose gateway advertises the state_query capability support state
+// queries; other clients return ErrUnsupported.
+package statequery
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/rpc"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	xrpc "github.com/luthersystems/shiroclient-sdk-go/x/rpc"
+)
+
+// ErrUnsupported is returned when the client or its gateway does not
+// support state queries.
+var ErrUnsupported = rpc.ErrStateQueryUnsupported
+
+// KV is a ledger key and its value.
+type KV struct {
+	Key   string
+	Value []byte
+}
+
+// PageRequest selects a page of range or rich query results.
+type PageRequest struct {
+	// PageSize is the maximum number of entries returned.  Zero leaves
+	// the page size to the gateway.
+	PageSize int
+	// Bookmark continues a previous query.  It is the Bookmark of the
+	// previous Page, or empty for the first page.
+	Bookmark string
+}
+
+// Page is a page of query results.
+type Page struct {
+	Entries []KV
+	// Bookmark continues the query with the next page.  It is empty when
+	// there are no more results.
+	Bookmark string
+}
+
+// GetState returns the value of key, or nil if key is not set.
+func GetState(ctx context.Context, client shiroclient.ShiroClient, key string, configs ...shiroclient.Config) ([]byte, error) {
+	res, err := rpc.StateQuery(ctx, client, xrpc.MethodGetState, map[string]interface{}{
+		"key": key,
+	}, configs...)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := res.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("statequery: GetState expected an object result")
+	}
+	switch v := obj["value"].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		value, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("statequery: GetState invalid value: %w", err)
+		}
+		return value, nil
+	default:
+		return nil, errors.New("statequery: GetState expected a base64 string value")
+	}
+}
+
+// GetRange returns a page of the keys between startKey (inclusive) and
+// endKey (exclusive) in lexical order.  An empty endKey reads to the end of
+// the key space.
+func GetRange(ctx context.Context, client shiroclient.ShiroClient, startKey string, endKey string, page PageRequest, configs ...shiroclient.Config) (*Page, error) {
+	params := pageParams(page)
+	params["start_key"] = startKey
+	params["end_key"] = endKey
+	res, err := rpc.StateQuery(ctx, client, xrpc.MethodGetStateByRange, params, configs...)
+	if err != nil {
+		return nil, err
+	}
+	return decodePage(xrpc.MethodGetStateByRange, res)
+}
+
+// RichQuery returns a page of the results of a CouchDB rich query, given as
+// a JSON selector document.  Rich queries require a CouchDB state database.
+func RichQuery(ctx context.Context, client shiroclient.ShiroClient, query string, page PageRequest, configs ...shiroclient.Config) (*Page, error) {
+	if !json.Valid([]byte(query)) {
+		return nil, errors.New("statequery: rich query is not valid JSON")
+	}
+	params := pageParams(page)
+	params["query"] = query
+	res, err := rpc.StateQuery(ctx, client, xrpc.MethodQueryState, params, configs...)
+	if err != nil {
+		return nil, err
+	}
+	return decodePage(xrpc.MethodQueryState, res)
+}
+
+func pageParams(page PageRequest) map[string]interface{} {
+	params := make(map[string]interface{})
+	if page.PageSize > 0 {
+		params["page_size"] = page.PageSize
+	}
+	if page.Bookmark != "" {
+		params["bookmark"] = page.Bookmark
+	}
+	return params
+}
+
+func decodePage(method string, res interface{}) (*Page, error) {
+	obj, ok := res.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("statequery: %s expected an object result", method)
+	}
+	entries, ok := obj["entries"].([]interface{})
+	if !ok && obj["entries"] != nil {
+		return nil, fmt.Errorf("statequery: %s expected an array entries field", method)
+	}
+	page := &Page{Entries: make([]KV, 0, len(entries))}
+	page.Bookmark, _ = obj["bookmark"].(string)
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("statequery: %s expected an object entry", method)
+		}
+		key, ok := entry["key"].(string)
+		if !ok {
+			return nil, fmt.Errorf("statequery: %s expected a string key field", method)
+		}
+		encoded, ok := entry["value"].(string)
+		if !ok {
+			return nil, fmt.Errorf("statequery: %s expected a string value field", method)
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("statequery: %s invalid value for key %q: %w", method, key, err)
+		}
+		page.Entries = append(page.Entries, KV{Key: key, Value: value})
+	}
+	return page, nil
+}
@@ -0,0 +1,112 @@
+package statequery_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/statequery"
+)
+
+// stateGateway returns a gateway serving state queries from state.  If
+// capable is false the gateway does not advertise state queries.
+func stateGateway(t *testing.T, capable bool, state map[string]string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var result interface{}
+		level := 0
+		switch req.Method {
+		case "Capabilities":
+			result = []string{}
+			if capable {
+				result = []string{"state_query"}
+			}
+		case "GetState":
+			value, ok := state[req.Params["key"].(string)]
+			if ok {
+				result = map[string]interface{}{"value": base64.StdEncoding.EncodeToString([]byte(value))}
+			} else {
+				result = map[string]interface{}{"value": nil}
+			}
+		case "GetStateByRange":
+			require.Equal(t, "a", req.Params["start_key"])
+			require.Equal(t, float64(1), req.Params["page_size"])
+			bookmark, _ := req.Params["bookmark"].(string)
+			key, next := "a", "b"
+			if bookmark == "b" {
+				key, next = "b", ""
+			}
+			result = map[string]interface{}{
+				"entries":  []interface{}{map[string]interface{}{"key": key, "value": base64.StdEncoding.EncodeToString([]byte(state[key]))}},
+				"bookmark": next,
+			}
+		case "QueryState":
+			level = 1
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      "1",
+			"result": map[string]interface{}{
+				"error_level": level,
+				"result":      result,
+				"code":        0,
+				"message":     "rich queries are disabled by policy",
+				"data":        nil,
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestStateQuery(t *testing.T) {
+	srv := stateGateway(t, true, map[string]string{"a": "1", "b": "2"})
+	client := shiroclient.NewRPC([]shiroclient.Config{shiroclient.WithEndpoint(srv.URL)})
+	ctx := context.Background()
+
+	value, err := statequery.GetState(ctx, client, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), value)
+
+	value, err = statequery.GetState(ctx, client, "missing")
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	var keys []string
+	page := statequery.PageRequest{PageSize: 1}
+	for {
+		res, err := statequery.GetRange(ctx, client, "a", "c", page)
+		require.NoError(t, err)
+		for _, kv := range res.Entries {
+			keys = append(keys, kv.Key+"="+string(kv.Value))
+		}
+		if res.Bookmark == "" {
+			break
+		}
+		page.Bookmark = res.Bookmark
+	}
+	require.Equal(t, []string{"a=1", "b=2"}, keys)
+
+	_, err = statequery.RichQuery(ctx, client, `{"selector":{"type":"account"}}`, statequery.PageRequest{})
+	require.ErrorContains(t, err, "disabled by policy")
+
+	_, err = statequery.RichQuery(ctx, client, `{"selector"`, statequery.PageRequest{})
+	require.Error(t, err)
+}
+
+func TestStateQueryUnsupported(t *testing.T) {
+	srv := stateGateway(t, false, nil)
+	client := shiroclient.NewRPC([]shiroclient.Config{shiroclient.WithEndpoint(srv.URL)})
+	_, err := statequery.GetState(context.Background(), client, "a")
+	require.ErrorIs(t, err, statequery.ErrUnsupported)
+}
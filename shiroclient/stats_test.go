@@ -0,0 +1,38 @@
+package shiroclient_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+func TestWithStats(t *testing.T) {
+	var auth string
+	srv := heightServer(t, &auth)
+	var stats []shiroclient.CallStats
+	client := shiroclient.NewRPC([]shiroclient.Config{
+		shiroclient.WithEndpoint(srv.URL),
+		shiroclient.WithStats(func(s shiroclient.CallStats) { stats = append(stats, s) }),
+	})
+	ctx := context.Background()
+	_, err := client.QueryInfo(ctx)
+	require.NoError(t, err)
+	_, err = client.Call(ctx, "hello", shiroclient.WithParams([]string{"a"}))
+	require.NoError(t, err)
+
+	require.Len(t, stats, 2)
+	require.Equal(t, "QueryInfo", stats[0].Method)
+	require.Equal(t, "", stats[0].PhylumMethod)
+	require.Equal(t, srv.URL, stats[0].Endpoint)
+	require.Greater(t, stats[0].RequestSize, 0)
+	require.Greater(t, stats[0].ResponseSize, 0)
+	require.Greater(t, stats[0].Duration, time.Duration(0))
+	require.Equal(t, 0, stats[0].Retries)
+	require.NoError(t, stats[0].Err)
+	require.Equal(t, "Call", stats[1].Method)
+	require.Equal(t, "hello", stats[1].PhylumMethod)
+}
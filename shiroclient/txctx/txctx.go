@@ -0,0 +1,70 @@
+// Package txctx threads the ID of the last write made while serving a
+// request through its context, so that later calls made for the same
+// request depend on it without passing transaction IDs between handlers.
+//
+//	ctx = txctx.ContextWithID(ctx, "")
+//	_, err := phylum.Call(client, ctx, "create_account", req, resp)
+//	// depends on the create_account transaction.
+//	_, err = phylum.Call(client, ctx, "get_account", req2, resp2)
+//
+// phylum.Client and private.WrapCall record the transactions of their calls
+// and make calls depend on the recorded transaction.  A dependency passed
+// explicitly with shiroclient.WithDependentTxID takes precedence.
+package txctx
+
+import (
+	"context"
+	"sync"
+
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+type holderKey struct{}
+
+type holder struct {
+	mu   sync.Mutex
+	txID string
+}
+
+// ContextWithID returns a context recording the transactions of the calls
+// made with it, initially depending on txID if it is not empty, e.g. a
+// transaction ID received from the client of a service.
+func ContextWithID(ctx context.Context, txID string) context.Context {
+	return context.WithValue(ctx, holderKey{}, &holder{txID: txID})
+}
+
+// ID returns the ID of the last transaction recorded in ctx, or an empty
+// string if there is none.
+func ID(ctx context.Context) string {
+	h, ok := ctx.Value(holderKey{}).(*holder)
+	if !ok {
+		return ""
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.txID
+}
+
+// SetID records txID as the last transaction of ctx.  It returns false,
+// and does nothing, if txID is empty or ctx was not prepared with
+// ContextWithID.
+func SetID(ctx context.Context, txID string) bool {
+	h, ok := ctx.Value(holderKey{}).(*holder)
+	if !ok || txID == "" {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.txID = txID
+	return true
+}
+
+// Configs returns the configs making a call depend on the last transaction
+// recorded in ctx, if any.  They should precede the configs of the call.
+func Configs(ctx context.Context) []shiroclient.Config {
+	txID := ID(ctx)
+	if txID == "" {
+		return nil
+	}
+	return []shiroclient.Config{shiroclient.WithDependentTxID(txID)}
+}
@@ -0,0 +1,34 @@
+package txctx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient/txctx"
+)
+
+func TestContextWithID(t *testing.T) {
+	ctx := context.Background()
+	require.False(t, txctx.SetID(ctx, "tx1"))
+	require.Empty(t, txctx.ID(ctx))
+	require.Empty(t, txctx.Configs(ctx))
+
+	ctx = txctx.ContextWithID(ctx, "tx0")
+	require.Equal(t, "tx0", txctx.ID(ctx))
+	require.True(t, txctx.SetID(ctx, "tx1"))
+	require.False(t, txctx.SetID(ctx, ""))
+	require.Equal(t, "tx1", txctx.ID(ctx))
+
+	// IDs recorded with derived contexts are visible to the parent.
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+	require.True(t, txctx.SetID(child, "tx2"))
+	require.Equal(t, "tx2", txctx.ID(ctx))
+
+	opt, err := types.ApplyConfigs(nil, txctx.Configs(ctx)...)
+	require.NoError(t, err)
+	require.Equal(t, "tx2", opt.DependentTxID)
+}
@@ -0,0 +1,36 @@
+package shiroclient_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+func TestParseValidationCode(t *testing.T) {
+	for reason, want := range map[string]shiroclient.ValidationCode{
+		"":                                  shiroclient.ValidationValid,
+		"VALID":                             shiroclient.ValidationValid,
+		"MVCC_READ_CONFLICT":                shiroclient.ValidationMVCCReadConflict,
+		"mvcc_read_conflict":                shiroclient.ValidationMVCCReadConflict,
+		"PHANTOM_READ_CONFLICT: range read": shiroclient.ValidationPhantomReadConflict,
+		" ENDORSEMENT_POLICY_FAILURE ":      shiroclient.ValidationEndorsementPolicyFailure,
+		"SOMETHING_NEW":                     shiroclient.ValidationUnknown,
+	} {
+		require.Equal(t, want, shiroclient.ParseValidationCode(reason), "reason %q", reason)
+	}
+}
+
+func TestIsRetryableTx(t *testing.T) {
+	conflict := types.NewTransaction("tx1", "MVCC_READ_CONFLICT", nil, "cc")
+	require.Equal(t, shiroclient.ValidationMVCCReadConflict, shiroclient.TxValidationCode(conflict))
+	require.True(t, shiroclient.IsRetryableTx(conflict))
+
+	for _, reason := range []string{"", "ENDORSEMENT_POLICY_FAILURE", "DUPLICATE_TXID", "garbage"} {
+		tx := types.NewTransaction("tx2", reason, nil, "cc")
+		require.False(t, shiroclient.IsRetryableTx(tx), "reason %q", reason)
+	}
+	require.True(t, shiroclient.TxValidationCode(types.NewTransaction("tx3", "", nil, "cc")).Valid())
+}
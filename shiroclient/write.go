@@ -0,0 +1,130 @@
+package shiroclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/rpc"
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+)
+
+// WriteStage identifies a step in the life of a write transaction.
+type WriteStage = types.WriteStage
+
+const (
+	// WriteSimulated indicates that the transaction was simulated.
+	WriteSimulated = types.WriteSimulated
+	// WriteSubmitted indicates that the transaction was submitted for
+	// ordering.
+	WriteSubmitted = types.WriteSubmitted
+	// WriteCommitted indicates that the transaction was observed in a
+	// block.
+	WriteCommitted = types.WriteCommitted
+)
+
+// WriteProgress reports that a write reached a WriteStage.
+type WriteProgress = types.WriteProgress
+
+// PendingTx is a handle on a submitted transaction whose commit has not
+// been observed yet.  Its Wait method resumes waiting for the commit.
+type PendingTx = rpc.PendingTx
+
+// PendingWriteError is returned by Call when a write was submitted but its
+// commit was not observed before the context was done.  The write may still
+// commit; the Pending field can be used to resume waiting.
+//
+//	resp, err := client.Call(ctx, "pay", configs...)
+//	var perr *shiroclient.PendingWriteError
+//	if errors.As(err, &perr) {
+//		block, err := perr.Pending.Wait(laterCtx)
+//		...
+//	}
+type PendingWriteError = rpc.PendingWriteError
+
+// WithWriteProgress allows observing the progress of a write.  progress is
+// invoked synchronously with WriteSimulated, WriteSubmitted and
+// WriteCommitted as the request advances; requests that do not produce a
+// transaction only report WriteSimulated.
+//
+// In RPC mode the client, rather than the gateway, waits for the commit by
+// polling the ledger height every pollInterval (one second if zero).  If the
+// request context is done before the commit is observed, Call returns a
+// *PendingWriteError instead of a gateway timeout.  If write polling is
+// disabled with WithDisableWritePolling, WriteCommitted is not reported.
+func WithWriteProgress(progress func(WriteProgress), pollInterval time.Duration) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.WriteProgress = progress
+		r.WritePollInterval = pollInterval
+	})
+}
+
+// WithConflictRetry resubmits a call whose transaction was committed but
+// invalidated by a read conflict (MVCC_READ_CONFLICT or
+// PHANTOM_READ_CONFLICT), up to maxAttempts times in total.  The delay
+// before each resubmission starts at backoff and doubles after every
+// attempt, up to maxBackoff if it is positive.  Because the validation code
+// is needed, the client observes the commit itself as with WithWriteProgress,
+// unless write polling is disabled.  Calls invalidated on every attempt fail
+// with a *ConflictError.  Has no effect in mock mode.
+func WithConflictRetry(maxAttempts int, backoff time.Duration, maxBackoff time.Duration) Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.ConflictRetry = types.RetryPolicy{
+			MaxAttempts: maxAttempts,
+			Backoff:     backoff,
+			MaxBackoff:  maxBackoff,
+		}
+	})
+}
+
+// ConflictError is returned by calls configured with WithConflictRetry whose
+// transaction was invalidated by a read conflict on every attempt.
+type ConflictError = rpc.ConflictError
+
+// WaitForTx waits for the transaction txID to be committed in a block
+// numbered fromBlock or later and returns the block number.  The ledger is
+// polled every pollInterval (one second if zero) using client and configs.
+// It is typically used after a Call with write polling disabled, passing
+// the response's MaxSimBlockNum()+1 as fromBlock.
+func WaitForTx(ctx context.Context, client ShiroClient, txID string, fromBlock uint64, pollInterval time.Duration, configs ...Config) (uint64, error) {
+	return rpc.NewPendingTx(client, txID, fromBlock, pollInterval, configs...).Wait(ctx)
+}
+
+// ConfirmWrite waits for the transaction of resp, returned by a Call with
+// write polling disabled, to be committed, as the gateway does before
+// responding when polling is enabled.  High-throughput writers can disable
+// polling for a client and confirm their writes asynchronously:
+//
+//	client := shiroclient.NewRPC([]shiroclient.Config{
+//		shiroclient.WithEndpoint(endpoint),
+//		shiroclient.WithDisableWritePolling(true),
+//	})
+//	resp, err := client.Call(ctx, "pay", configs...)
+//	...
+//	go func() {
+//		commit, err := shiroclient.ConfirmWrite(ctx, client, resp, 0)
+//		...
+//	}()
+//
+// It returns the commit metadata of resp completed with the commit block
+// and the validation code, which must be checked: invalidated transactions
+// are committed too.  The ledger is polled every pollInterval (one second
+// if zero) using client and configs.  If ctx is done first, a
+// *PendingWriteError is returned.  Responses without a transaction or
+// whose commit was already observed are returned as is.
+func ConfirmWrite(ctx context.Context, client ShiroClient, resp ShiroResponse, pollInterval time.Duration, configs ...Config) (*CommitMetadata, error) {
+	commit := GetCommitMetadata(resp).Clone()
+	if commit == nil {
+		commit = &CommitMetadata{TxID: resp.TransactionID(), MaxSimulatedBlock: resp.MaxSimBlockNum()}
+	}
+	if commit.TxID == "" || commit.Committed() {
+		return commit, nil
+	}
+	pending := rpc.NewPendingTx(client, commit.TxID, commit.MaxSimulatedBlock+1, pollInterval, configs...)
+	block, err := pending.Wait(ctx)
+	if err != nil {
+		return nil, &PendingWriteError{Pending: pending, Err: err}
+	}
+	commit.CommitBlock = block
+	commit.ValidationCode = pending.ValidationCode()
+	return commit, nil
+}
@@ -0,0 +1,48 @@
+package shiroclient_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/shiroclient"
+)
+
+func TestConfirmWrite(t *testing.T) {
+	client := &ledgerClient{blocks: []shiroclient.Block{
+		types.NewBlock("h0", []types.Transaction{types.NewTransaction("tx0", "", nil, "cc")}),
+		types.NewBlock("h1", []types.Transaction{types.NewTransaction("tx1", "MVCC_READ_CONFLICT", nil, "cc")}),
+	}}
+	ctx := context.Background()
+
+	resp := types.NewSuccessResponse([]byte(`{}`), "", 0, 0)
+	resp.SetCommit(&types.CommitMetadata{TxID: "tx1", Endorsers: []string{"peer0"}})
+	commit, err := shiroclient.ConfirmWrite(ctx, client, resp, time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, &shiroclient.CommitMetadata{
+		TxID:           "tx1",
+		CommitBlock:    1,
+		Endorsers:      []string{"peer0"},
+		ValidationCode: shiroclient.ValidationMVCCReadConflict,
+	}, commit)
+	// the response is not modified.
+	require.Zero(t, resp.CommitBlockNum())
+
+	// read-only responses are confirmed immediately.
+	commit, err = shiroclient.ConfirmWrite(ctx, client, types.NewSuccessResponse([]byte(`{}`), "", 0, 0), time.Millisecond)
+	require.NoError(t, err)
+	require.False(t, commit.Committed())
+
+	resp = types.NewSuccessResponse([]byte(`{}`), "", 0, 0)
+	resp.SetCommit(&types.CommitMetadata{TxID: "tx2", MaxSimulatedBlock: 1})
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = shiroclient.ConfirmWrite(timeoutCtx, client, resp, time.Millisecond)
+	var perr *shiroclient.PendingWriteError
+	require.True(t, errors.As(err, &perr), "unexpected error: %v", err)
+	require.Equal(t, "tx2", perr.Pending.TxID())
+}
@@ -15,8 +15,25 @@ type pluginArgs struct {
 	ro *types.RequestOptions
 }
 
+// PluginArgs returns the arguments of a call made with configs.  Configs
+// that fail to apply are logged and ignored; use PluginArgsErr to handle
+// their errors.
 func PluginArgs(configs []types.Config) pluginArgs {
-	return pluginArgs{ro: types.ApplyConfigs(nil, configs...)}
+	ro, err := types.ApplyConfigsPartial(nil, configs...)
+	if err != nil {
+		logrus.WithError(err).Warn("plugin: ignoring invalid configs")
+	}
+	return pluginArgs{ro: ro}
+}
+
+// PluginArgsErr returns the arguments of a call made with configs, or an
+// error if any config fails to apply.
+func PluginArgsErr(configs []types.Config) (pluginArgs, error) {
+	ro, err := types.ApplyConfigs(nil, configs...)
+	if err != nil {
+		return pluginArgs{}, err
+	}
+	return pluginArgs{ro: ro}, nil
 }
 
 func PluginID(p pluginArgs) string {
@@ -62,3 +79,7 @@ func PluginPhylumVersion(p pluginArgs) string {
 func PluginNewPhylumVersion(p pluginArgs) string {
 	return p.ro.NewPhylumVersion
 }
+
+func PluginIdempotencyKey(p pluginArgs) string {
+	return p.ro.IdempotencyKey
+}
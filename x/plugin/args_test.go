@@ -0,0 +1,31 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginArgs(t *testing.T) {
+	args, err := PluginArgsErr([]types.Config{types.Opt(func(r *types.RequestOptions) {
+		r.Creator = "Org1MSP"
+	})})
+	require.NoError(t, err)
+	require.Equal(t, "Org1MSP", PluginCreator(args))
+
+	configs := []types.Config{
+		types.Opt(func(r *types.RequestOptions) {
+			r.Creator = "Org1MSP"
+		}),
+		types.OptErr(func(r *types.RequestOptions) error {
+			return errors.New("invalid config")
+		}),
+	}
+	_, err = PluginArgsErr(configs)
+	require.ErrorContains(t, err, "invalid config")
+
+	// PluginArgs ignores the failing config.
+	require.Equal(t, "Org1MSP", PluginCreator(PluginArgs(configs)))
+}
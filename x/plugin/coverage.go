@@ -0,0 +1,84 @@
+package plugin
+
+// CoverageReporter is implemented by substrates that can collect ELPS code
+// coverage of the phylum of a mock.  It is separate from Substrate so that
+// existing plugins remain valid.
+type CoverageReporter interface {
+	// EnableCoverageMock starts collecting coverage for the mock.
+	EnableCoverageMock(tag string) error
+	// CoverageReportMock returns the hit counts of each function and line
+	// of the phylum of the mock since coverage was enabled, as an LCOV
+	// tracefile.
+	CoverageReportMock(tag string) ([]byte, error)
+}
+
+var _ CoverageReporter = (*PluginRPC)(nil)
+
+// ArgsEnableCoverageMock encodes the arguments to EnableCoverageMock
+type ArgsEnableCoverageMock struct {
+	Tag string
+}
+
+// RespEnableCoverageMock encodes the response from EnableCoverageMock
+type RespEnableCoverageMock struct {
+	Err         *Error
+	Unsupported bool
+}
+
+// ArgsCoverageReportMock encodes the arguments to CoverageReportMock
+type ArgsCoverageReportMock struct {
+	Tag string
+}
+
+// RespCoverageReportMock encodes the response from CoverageReportMock
+type RespCoverageReportMock struct {
+	Report      []byte
+	Err         *Error
+	Unsupported bool
+}
+
+// EnableCoverageMock forwards the call
+func (g *PluginRPC) EnableCoverageMock(tag string) error {
+	var resp RespEnableCoverageMock
+	err := g.client.Call("Plugin.EnableCoverageMock", &ArgsEnableCoverageMock{Tag: tag}, &resp)
+	return optionalCallError(err, resp.Unsupported, resp.Err)
+}
+
+// CoverageReportMock forwards the call
+func (g *PluginRPC) CoverageReportMock(tag string) ([]byte, error) {
+	var resp RespCoverageReportMock
+	err := g.client.Call("Plugin.CoverageReportMock", &ArgsCoverageReportMock{Tag: tag}, &resp)
+	if err := optionalCallError(err, resp.Unsupported, resp.Err); err != nil {
+		return nil, err
+	}
+	return resp.Report, nil
+}
+
+// EnableCoverageMock forwards the call
+func (s *PluginRPCServer) EnableCoverageMock(args *ArgsEnableCoverageMock, resp *RespEnableCoverageMock) error {
+	impl, ok := s.Impl.(CoverageReporter)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	if err := impl.EnableCoverageMock(args.Tag); err != nil {
+		resp.Err = s.newError(err)
+	}
+	return nil
+}
+
+// CoverageReportMock forwards the call
+func (s *PluginRPCServer) CoverageReportMock(args *ArgsCoverageReportMock, resp *RespCoverageReportMock) error {
+	impl, ok := s.Impl.(CoverageReporter)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	report, err := impl.CoverageReportMock(args.Tag)
+	if err != nil {
+		resp.Err = s.newError(err)
+		return nil
+	}
+	resp.Report = report
+	return nil
+}
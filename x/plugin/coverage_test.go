@@ -0,0 +1,41 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// coverageSubstrate reports a fixed coverage for the mocks with coverage
+// enabled.
+type coverageSubstrate struct {
+	baseSubstrate
+	enabled map[string]bool
+}
+
+func (s *coverageSubstrate) EnableCoverageMock(tag string) error {
+	s.enabled[tag] = true
+	return nil
+}
+
+func (s *coverageSubstrate) CoverageReportMock(tag string) ([]byte, error) {
+	if !s.enabled[tag] {
+		return nil, nil
+	}
+	return []byte("SF:main.lisp\nDA:1,2\nend_of_record\n"), nil
+}
+
+func TestCoverageRPC(t *testing.T) {
+	impl := &coverageSubstrate{enabled: make(map[string]bool)}
+	client := rpcClient(t, impl)
+	require.NoError(t, client.EnableCoverageMock("tag"))
+	require.True(t, impl.enabled["tag"])
+	report, err := client.CoverageReportMock("tag")
+	require.NoError(t, err)
+	require.Contains(t, string(report), "DA:1,2")
+
+	unsupported := rpcClient(t, &baseSubstrate{})
+	require.ErrorIs(t, unsupported.EnableCoverageMock("tag"), ErrUnsupported)
+	_, err = unsupported.CoverageReportMock("tag")
+	require.ErrorIs(t, err, ErrUnsupported)
+}
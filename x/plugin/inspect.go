@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"time"
+)
+
+// MockInfo describes a mock open in the plugin.
+type MockInfo struct {
+	// Tag identifies the mock.
+	Tag string
+	// Created is the time the mock was created.
+	Created time.Time
+	// Height is the block height of the mock ledger.
+	Height uint64
+	// LedgerSize is the size of the mock ledger in bytes.
+	LedgerSize int64
+	// MemoryUsage estimates the memory held by the mock in bytes, or is
+	// zero if unknown.
+	MemoryUsage int64
+}
+
+// Age returns the time elapsed since the mock was created.
+func (m *MockInfo) Age() time.Duration {
+	return time.Since(m.Created)
+}
+
+// MockInspector is implemented by substrates that can list and close all
+// of their open mocks, to find mocks leaked by clients that were not
+// closed.  It is separate from Substrate so that existing plugins remain
+// valid.
+type MockInspector interface {
+	// ListMocks returns the mocks that are open.
+	ListMocks() ([]*MockInfo, error)
+	// CloseAllMocks closes every open mock and returns how many were
+	// closed.
+	CloseAllMocks() (int, error)
+}
+
+var _ MockInspector = (*PluginRPC)(nil)
+
+// ArgsListMocks encodes the arguments to ListMocks
+type ArgsListMocks struct{}
+
+// RespListMocks encodes the response from ListMocks
+type RespListMocks struct {
+	Mocks       []*MockInfo
+	Err         *Error
+	Unsupported bool
+}
+
+// ArgsCloseAllMocks encodes the arguments to CloseAllMocks
+type ArgsCloseAllMocks struct{}
+
+// RespCloseAllMocks encodes the response from CloseAllMocks
+type RespCloseAllMocks struct {
+	Closed      int
+	Err         *Error
+	Unsupported bool
+}
+
+// ListMocks forwards the call
+func (g *PluginRPC) ListMocks() ([]*MockInfo, error) {
+	var resp RespListMocks
+	err := g.client.Call("Plugin.ListMocks", &ArgsListMocks{}, &resp)
+	if err := optionalCallError(err, resp.Unsupported, resp.Err); err != nil {
+		return nil, err
+	}
+	return resp.Mocks, nil
+}
+
+// CloseAllMocks forwards the call
+func (g *PluginRPC) CloseAllMocks() (int, error) {
+	var resp RespCloseAllMocks
+	err := g.client.Call("Plugin.CloseAllMocks", &ArgsCloseAllMocks{}, &resp)
+	if err := optionalCallError(err, resp.Unsupported, resp.Err); err != nil {
+		return 0, err
+	}
+	return resp.Closed, nil
+}
+
+// ListMocks forwards the call
+func (s *PluginRPCServer) ListMocks(args *ArgsListMocks, resp *RespListMocks) error {
+	impl, ok := s.Impl.(MockInspector)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	mocks, err := impl.ListMocks()
+	if err != nil {
+		resp.Err = s.newError(err)
+		return nil
+	}
+	resp.Mocks = mocks
+	return nil
+}
+
+// CloseAllMocks forwards the call
+func (s *PluginRPCServer) CloseAllMocks(args *ArgsCloseAllMocks, resp *RespCloseAllMocks) error {
+	impl, ok := s.Impl.(MockInspector)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	closed, err := impl.CloseAllMocks()
+	if err != nil {
+		resp.Err = s.newError(err)
+	}
+	resp.Closed = closed
+	return nil
+}
@@ -0,0 +1,53 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// inspectSubstrate holds a fixed set of open mocks.
+type inspectSubstrate struct {
+	baseSubstrate
+	mocks []*MockInfo
+}
+
+func (s *inspectSubstrate) ListMocks() ([]*MockInfo, error) {
+	return s.mocks, nil
+}
+
+func (s *inspectSubstrate) CloseAllMocks() (int, error) {
+	n := len(s.mocks)
+	s.mocks = nil
+	return n, nil
+}
+
+func TestMockInspectorRPC(t *testing.T) {
+	created := time.Now().Add(-time.Minute).UTC()
+	impl := &inspectSubstrate{mocks: []*MockInfo{
+		{Tag: "a", Created: created, Height: 3, LedgerSize: 1024},
+		{Tag: "b", Created: created},
+	}}
+	client := rpcClient(t, impl)
+	mocks, err := client.ListMocks()
+	require.NoError(t, err)
+	require.Len(t, mocks, 2)
+	require.Equal(t, "a", mocks[0].Tag)
+	require.Equal(t, int64(1024), mocks[0].LedgerSize)
+	require.True(t, mocks[0].Created.Equal(created))
+	require.GreaterOrEqual(t, mocks[0].Age(), time.Minute)
+
+	n, err := client.CloseAllMocks()
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	mocks, err = client.ListMocks()
+	require.NoError(t, err)
+	require.Empty(t, mocks)
+
+	unsupported := rpcClient(t, &baseSubstrate{})
+	_, err = unsupported.ListMocks()
+	require.ErrorIs(t, err, ErrUnsupported)
+	_, err = unsupported.CloseAllMocks()
+	require.ErrorIs(t, err, ErrUnsupported)
+}
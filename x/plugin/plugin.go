@@ -39,6 +39,21 @@ type ConcreteRequestOptions struct {
 	DependentBlock      string
 	PhylumVersion       string
 	NewPhylumVersion    string
+	IdempotencyKey      string
+	// IncludeErrorStack requests the ELPS stack trace of phylum errors in
+	// Response.ErrorStack.
+	IncludeErrorStack bool
+	// CapturePhylumOutput requests that output printed by the phylum is
+	// returned in Response.PhylumOutput rather than written to the plugin's
+	// stdout.
+	CapturePhylumOutput bool
+	// PrivateCollections names the private data collections targeted by
+	// the request.
+	PrivateCollections []string
+	// RandSeed, if set, seeds the random number generator of the substrate
+	// for the request, making random values generated by the phylum
+	// reproducible.
+	RandSeed []byte
 }
 
 // Error represents a possible error.
@@ -51,6 +66,12 @@ func (e Error) Error() string {
 	return e.Diagnostic
 }
 
+// StackFrame is a frame of an ELPS stack trace.
+type StackFrame = types.StackFrame
+
+// CommitMetadata describes how the transaction of a call was committed.
+type CommitMetadata = types.CommitMetadata
+
 // Response represents a shiroclient response.
 type Response struct {
 	ResultJSON    []byte
@@ -59,6 +80,18 @@ type Response struct {
 	ErrorMessage  string
 	ErrorJSON     []byte
 	TransactionID string
+	// ErrorStack is the ELPS stack trace of the error, innermost frame
+	// first.  It is only set when requested with IncludeErrorStack.
+	ErrorStack []StackFrame
+	// PhylumOutput is the output printed by the phylum during the call.  It
+	// is only set when requested with CapturePhylumOutput.
+	PhylumOutput []byte
+	// Commit describes how the transaction was committed, if the
+	// substrate reports it.  Substrates should set its CommitBlock and
+	// MaxSimulatedBlock to the heights of the mock ledger, so that the
+	// block numbers of mock calls match those of RPC clients.  When nil,
+	// only TransactionID is known and block numbers are zero.
+	Commit *CommitMetadata
 }
 
 // UnmarshalTo unmarshals the response's result to dst.
@@ -553,6 +586,12 @@ func NewSubstrateConnection(opts ...ConnectOption) (*SubstrateConnection, error)
 	return &SubstrateConnection{client: client, substrate: substrate}, nil
 }
 
+// NewLocalConnection returns a connection to an in-process Substrate
+// implementation, e.g. a fake used in tests.
+func NewLocalConnection(substrate Substrate) *SubstrateConnection {
+	return &SubstrateConnection{substrate: substrate}
+}
+
 // GetSubstrate returns the Substrate interface associated with a
 // connection.
 func (s *SubstrateConnection) GetSubstrate() Substrate {
@@ -561,7 +600,9 @@ func (s *SubstrateConnection) GetSubstrate() Substrate {
 
 // Close closes a connection.
 func (s *SubstrateConnection) Close() error {
-	s.client.Kill()
+	if s.client != nil {
+		s.client.Kill()
+	}
 	return nil
 }
 
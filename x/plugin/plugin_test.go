@@ -0,0 +1,31 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// commitSubstrate reports the commit of each call.
+type commitSubstrate struct {
+	baseSubstrate
+}
+
+func (s *commitSubstrate) Call(tag string, method string, opts *ConcreteRequestOptions) (*Response, error) {
+	return &Response{
+		ResultJSON:    []byte(`true`),
+		TransactionID: "tx1",
+		Commit: &CommitMetadata{
+			TxID:              "tx1",
+			CommitBlock:       3,
+			MaxSimulatedBlock: 2,
+		},
+	}, nil
+}
+
+func TestResponseCommitRPC(t *testing.T) {
+	client := rpcClient(t, &commitSubstrate{})
+	resp, err := client.Call("tag", "put", &ConcreteRequestOptions{})
+	require.NoError(t, err)
+	require.Equal(t, &CommitMetadata{TxID: "tx1", CommitBlock: 3, MaxSimulatedBlock: 2}, resp.Commit)
+}
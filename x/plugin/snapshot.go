@@ -0,0 +1,306 @@
+package plugin
+
+// SnapshotChunkSize is the maximum size of the snapshot chunks exchanged
+// by SnapshotStreamer methods.  It keeps each net/rpc message small
+// whatever the size of the mock ledger.
+const SnapshotChunkSize = 4 << 20
+
+// SnapshotInfo describes a snapshot opened with OpenSnapshotMock.
+type SnapshotInfo struct {
+	// Handle identifies the snapshot transfer in ReadSnapshotMock and
+	// CloseSnapshotMock.
+	Handle string
+	// ID identifies the snapshot, to take later snapshots relative to it.
+	ID string
+	// Incremental is true if the snapshot only holds the changes since
+	// its base.
+	Incremental bool
+}
+
+// SnapshotStreamer is implemented by substrates that transfer snapshots in
+// chunks, instead of as a single message like SnapshotMock and
+// NewMockFrom, and that take incremental snapshots.  It is separate from
+// Substrate so that existing plugins remain valid.
+type SnapshotStreamer interface {
+	// OpenSnapshotMock takes a snapshot of the mock.  If base is not empty
+	// it is the ID of a previous snapshot of the mock and the snapshot
+	// only holds the changes since base.  Complete snapshots have the
+	// format of SnapshotMock.
+	OpenSnapshotMock(tag string, base string) (*SnapshotInfo, error)
+	// ReadSnapshotMock returns the next chunk of at most max bytes of the
+	// snapshot, or an empty chunk once the snapshot is read.
+	ReadSnapshotMock(handle string, max int) ([]byte, error)
+	// CloseSnapshotMock releases the snapshot.
+	CloseSnapshotMock(handle string) error
+	// OpenRestoreMock begins restoring a snapshot.  If tag is empty the
+	// snapshot must be complete and restores a new mock of the phylum;
+	// otherwise the snapshot must be incremental and is applied to the
+	// mock tag.
+	OpenRestoreMock(name string, version string, tag string) (string, error)
+	// WriteRestoreMock appends a chunk of at most SnapshotChunkSize bytes
+	// to the restored snapshot.
+	WriteRestoreMock(handle string, chunk []byte) error
+	// FinishRestoreMock completes the restore and returns the tag of the
+	// restored mock.
+	FinishRestoreMock(handle string) (string, error)
+	// AbortRestoreMock abandons a restore that was not finished, e.g.
+	// because the snapshot could not be read, releasing its chunks and
+	// leaving the mock tag unchanged.
+	AbortRestoreMock(handle string) error
+}
+
+var _ SnapshotStreamer = (*PluginRPC)(nil)
+
+// ArgsOpenSnapshotMock encodes the arguments to OpenSnapshotMock
+type ArgsOpenSnapshotMock struct {
+	Tag  string
+	Base string
+}
+
+// RespOpenSnapshotMock encodes the response from OpenSnapshotMock
+type RespOpenSnapshotMock struct {
+	Info        *SnapshotInfo
+	Err         *Error
+	Unsupported bool
+}
+
+// ArgsReadSnapshotMock encodes the arguments to ReadSnapshotMock
+type ArgsReadSnapshotMock struct {
+	Handle string
+	Max    int
+}
+
+// RespReadSnapshotMock encodes the response from ReadSnapshotMock
+type RespReadSnapshotMock struct {
+	Chunk       []byte
+	Err         *Error
+	Unsupported bool
+}
+
+// ArgsCloseSnapshotMock encodes the arguments to CloseSnapshotMock
+type ArgsCloseSnapshotMock struct {
+	Handle string
+}
+
+// RespCloseSnapshotMock encodes the response from CloseSnapshotMock
+type RespCloseSnapshotMock struct {
+	Err         *Error
+	Unsupported bool
+}
+
+// ArgsOpenRestoreMock encodes the arguments to OpenRestoreMock
+type ArgsOpenRestoreMock struct {
+	Name    string
+	Version string
+	Tag     string
+}
+
+// RespOpenRestoreMock encodes the response from OpenRestoreMock
+type RespOpenRestoreMock struct {
+	Handle      string
+	Err         *Error
+	Unsupported bool
+}
+
+// ArgsWriteRestoreMock encodes the arguments to WriteRestoreMock
+type ArgsWriteRestoreMock struct {
+	Handle string
+	Chunk  []byte
+}
+
+// RespWriteRestoreMock encodes the response from WriteRestoreMock
+type RespWriteRestoreMock struct {
+	Err         *Error
+	Unsupported bool
+}
+
+// ArgsFinishRestoreMock encodes the arguments to FinishRestoreMock
+type ArgsFinishRestoreMock struct {
+	Handle string
+}
+
+// RespFinishRestoreMock encodes the response from FinishRestoreMock
+type RespFinishRestoreMock struct {
+	Tag         string
+	Err         *Error
+	Unsupported bool
+}
+
+// ArgsAbortRestoreMock encodes the arguments to AbortRestoreMock
+type ArgsAbortRestoreMock struct {
+	Handle string
+}
+
+// RespAbortRestoreMock encodes the response from AbortRestoreMock
+type RespAbortRestoreMock struct {
+	Err         *Error
+	Unsupported bool
+}
+
+// OpenSnapshotMock forwards the call
+func (g *PluginRPC) OpenSnapshotMock(tag string, base string) (*SnapshotInfo, error) {
+	var resp RespOpenSnapshotMock
+	err := g.client.Call("Plugin.OpenSnapshotMock", &ArgsOpenSnapshotMock{Tag: tag, Base: base}, &resp)
+	if err := optionalCallError(err, resp.Unsupported, resp.Err); err != nil {
+		return nil, err
+	}
+	return resp.Info, nil
+}
+
+// ReadSnapshotMock forwards the call
+func (g *PluginRPC) ReadSnapshotMock(handle string, max int) ([]byte, error) {
+	var resp RespReadSnapshotMock
+	err := g.client.Call("Plugin.ReadSnapshotMock", &ArgsReadSnapshotMock{Handle: handle, Max: max}, &resp)
+	if err := optionalCallError(err, resp.Unsupported, resp.Err); err != nil {
+		return nil, err
+	}
+	return resp.Chunk, nil
+}
+
+// CloseSnapshotMock forwards the call
+func (g *PluginRPC) CloseSnapshotMock(handle string) error {
+	var resp RespCloseSnapshotMock
+	err := g.client.Call("Plugin.CloseSnapshotMock", &ArgsCloseSnapshotMock{Handle: handle}, &resp)
+	return optionalCallError(err, resp.Unsupported, resp.Err)
+}
+
+// OpenRestoreMock forwards the call
+func (g *PluginRPC) OpenRestoreMock(name string, version string, tag string) (string, error) {
+	var resp RespOpenRestoreMock
+	err := g.client.Call("Plugin.OpenRestoreMock", &ArgsOpenRestoreMock{Name: name, Version: version, Tag: tag}, &resp)
+	if err := optionalCallError(err, resp.Unsupported, resp.Err); err != nil {
+		return "", err
+	}
+	return resp.Handle, nil
+}
+
+// WriteRestoreMock forwards the call
+func (g *PluginRPC) WriteRestoreMock(handle string, chunk []byte) error {
+	var resp RespWriteRestoreMock
+	err := g.client.Call("Plugin.WriteRestoreMock", &ArgsWriteRestoreMock{Handle: handle, Chunk: chunk}, &resp)
+	return optionalCallError(err, resp.Unsupported, resp.Err)
+}
+
+// FinishRestoreMock forwards the call
+func (g *PluginRPC) FinishRestoreMock(handle string) (string, error) {
+	var resp RespFinishRestoreMock
+	err := g.client.Call("Plugin.FinishRestoreMock", &ArgsFinishRestoreMock{Handle: handle}, &resp)
+	if err := optionalCallError(err, resp.Unsupported, resp.Err); err != nil {
+		return "", err
+	}
+	return resp.Tag, nil
+}
+
+// AbortRestoreMock forwards the call
+func (g *PluginRPC) AbortRestoreMock(handle string) error {
+	var resp RespAbortRestoreMock
+	err := g.client.Call("Plugin.AbortRestoreMock", &ArgsAbortRestoreMock{Handle: handle}, &resp)
+	return optionalCallError(err, resp.Unsupported, resp.Err)
+}
+
+// OpenSnapshotMock forwards the call
+func (s *PluginRPCServer) OpenSnapshotMock(args *ArgsOpenSnapshotMock, resp *RespOpenSnapshotMock) error {
+	impl, ok := s.Impl.(SnapshotStreamer)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	info, err := impl.OpenSnapshotMock(args.Tag, args.Base)
+	if err != nil {
+		resp.Err = s.newError(err)
+		return nil
+	}
+	resp.Info = info
+	return nil
+}
+
+// ReadSnapshotMock forwards the call
+func (s *PluginRPCServer) ReadSnapshotMock(args *ArgsReadSnapshotMock, resp *RespReadSnapshotMock) error {
+	impl, ok := s.Impl.(SnapshotStreamer)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	max := args.Max
+	if max <= 0 || max > SnapshotChunkSize {
+		max = SnapshotChunkSize
+	}
+	chunk, err := impl.ReadSnapshotMock(args.Handle, max)
+	if err != nil {
+		resp.Err = s.newError(err)
+		return nil
+	}
+	resp.Chunk = chunk
+	return nil
+}
+
+// CloseSnapshotMock forwards the call
+func (s *PluginRPCServer) CloseSnapshotMock(args *ArgsCloseSnapshotMock, resp *RespCloseSnapshotMock) error {
+	impl, ok := s.Impl.(SnapshotStreamer)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	if err := impl.CloseSnapshotMock(args.Handle); err != nil {
+		resp.Err = s.newError(err)
+	}
+	return nil
+}
+
+// OpenRestoreMock forwards the call
+func (s *PluginRPCServer) OpenRestoreMock(args *ArgsOpenRestoreMock, resp *RespOpenRestoreMock) error {
+	impl, ok := s.Impl.(SnapshotStreamer)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	handle, err := impl.OpenRestoreMock(args.Name, args.Version, args.Tag)
+	if err != nil {
+		resp.Err = s.newError(err)
+		return nil
+	}
+	resp.Handle = handle
+	return nil
+}
+
+// WriteRestoreMock forwards the call
+func (s *PluginRPCServer) WriteRestoreMock(args *ArgsWriteRestoreMock, resp *RespWriteRestoreMock) error {
+	impl, ok := s.Impl.(SnapshotStreamer)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	if err := impl.WriteRestoreMock(args.Handle, args.Chunk); err != nil {
+		resp.Err = s.newError(err)
+	}
+	return nil
+}
+
+// FinishRestoreMock forwards the call
+func (s *PluginRPCServer) FinishRestoreMock(args *ArgsFinishRestoreMock, resp *RespFinishRestoreMock) error {
+	impl, ok := s.Impl.(SnapshotStreamer)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	tag, err := impl.FinishRestoreMock(args.Handle)
+	if err != nil {
+		resp.Err = s.newError(err)
+		return nil
+	}
+	resp.Tag = tag
+	return nil
+}
+
+// AbortRestoreMock forwards the call
+func (s *PluginRPCServer) AbortRestoreMock(args *ArgsAbortRestoreMock, resp *RespAbortRestoreMock) error {
+	impl, ok := s.Impl.(SnapshotStreamer)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	if err := impl.AbortRestoreMock(args.Handle); err != nil {
+		resp.Err = s.newError(err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// streamSubstrate serves a fixed snapshot and records restored chunks.
+type streamSubstrate struct {
+	baseSubstrate
+	snapshot []byte
+	restored bytes.Buffer
+	read     int
+	aborted  []string
+}
+
+func (s *streamSubstrate) OpenSnapshotMock(tag string, base string) (*SnapshotInfo, error) {
+	return &SnapshotInfo{Handle: "h", ID: "1", Incremental: base != ""}, nil
+}
+
+func (s *streamSubstrate) ReadSnapshotMock(handle string, max int) ([]byte, error) {
+	n := len(s.snapshot) - s.read
+	if n > max {
+		n = max
+	}
+	chunk := s.snapshot[s.read : s.read+n]
+	s.read += n
+	return chunk, nil
+}
+
+func (s *streamSubstrate) CloseSnapshotMock(handle string) error { return nil }
+
+func (s *streamSubstrate) OpenRestoreMock(name string, version string, tag string) (string, error) {
+	return "r", nil
+}
+
+func (s *streamSubstrate) WriteRestoreMock(handle string, chunk []byte) error {
+	s.restored.Write(chunk)
+	return nil
+}
+
+func (s *streamSubstrate) FinishRestoreMock(handle string) (string, error) {
+	return "tag", nil
+}
+
+func (s *streamSubstrate) AbortRestoreMock(handle string) error {
+	s.aborted = append(s.aborted, handle)
+	return nil
+}
+
+func TestSnapshotStreamRPC(t *testing.T) {
+	impl := &streamSubstrate{snapshot: []byte("ledger")}
+	client := rpcClient(t, impl)
+	info, err := client.OpenSnapshotMock("tag", "0")
+	require.NoError(t, err)
+	require.Equal(t, &SnapshotInfo{Handle: "h", ID: "1", Incremental: true}, info)
+	chunk, err := client.ReadSnapshotMock(info.Handle, 4)
+	require.NoError(t, err)
+	require.Equal(t, "ledg", string(chunk))
+	// a zero max reads up to SnapshotChunkSize.
+	chunk, err = client.ReadSnapshotMock(info.Handle, 0)
+	require.NoError(t, err)
+	require.Equal(t, "er", string(chunk))
+	require.NoError(t, client.CloseSnapshotMock(info.Handle))
+
+	handle, err := client.OpenRestoreMock("test", "test", "")
+	require.NoError(t, err)
+	require.NoError(t, client.WriteRestoreMock(handle, []byte("ledger")))
+	tag, err := client.FinishRestoreMock(handle)
+	require.NoError(t, err)
+	require.Equal(t, "tag", tag)
+	require.Equal(t, "ledger", impl.restored.String())
+	require.NoError(t, client.AbortRestoreMock("r"))
+	require.Equal(t, []string{"r"}, impl.aborted)
+
+	unsupported := rpcClient(t, &baseSubstrate{})
+	_, err = unsupported.OpenSnapshotMock("tag", "")
+	require.ErrorIs(t, err, ErrUnsupported)
+	_, err = unsupported.OpenRestoreMock("test", "test", "")
+	require.ErrorIs(t, err, ErrUnsupported)
+	require.ErrorIs(t, unsupported.AbortRestoreMock("r"), ErrUnsupported)
+}
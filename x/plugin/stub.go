@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"errors"
+	"net/rpc"
+	"strings"
+)
+
+// ErrUnsupported is returned by optional Substrate operations that the
+// plugin does not implement.
+var ErrUnsupported = errors.New("plugin: operation not supported by substrate")
+
+// ChaincodeStub is a canned response to an invocation of another chaincode
+// by the phylum.
+type ChaincodeStub struct {
+	// ChaincodeName is the name of the invoked chaincode.
+	ChaincodeName string
+	// Channel, if set, restricts the stub to invocations on the channel.
+	Channel string
+	// Function, if set, restricts the stub to invocations whose first
+	// argument is Function.
+	Function string
+	// Status is the status of the response, 200 for success.
+	Status int32
+	// Message is the message of the response, typically set for errors.
+	Message string
+	// Payload is the payload of the response.
+	Payload []byte
+}
+
+// ChaincodeStubber is implemented by substrates that can stub the
+// invocations of other chaincodes made by the phylum of a mock.  It is
+// separate from Substrate so that existing plugins remain valid.
+type ChaincodeStubber interface {
+	// StubChaincodeMock registers stub for the mock tag.  A stub replaces
+	// any stub registered for the same chaincode, channel and function.
+	// Invocations matching no stub fail.
+	StubChaincodeMock(tag string, stub *ChaincodeStub) error
+	// ClearChaincodeStubsMock removes the stubs of the mock tag.
+	ClearChaincodeStubsMock(tag string) error
+}
+
+var _ ChaincodeStubber = (*PluginRPC)(nil)
+
+// ArgsStubChaincodeMock encodes the arguments to StubChaincodeMock
+type ArgsStubChaincodeMock struct {
+	Tag  string
+	Stub *ChaincodeStub
+}
+
+// RespStubChaincodeMock encodes the response from StubChaincodeMock
+type RespStubChaincodeMock struct {
+	Err         *Error
+	Unsupported bool
+}
+
+// ArgsClearChaincodeStubsMock encodes the arguments to ClearChaincodeStubsMock
+type ArgsClearChaincodeStubsMock struct {
+	Tag string
+}
+
+// RespClearChaincodeStubsMock encodes the response from ClearChaincodeStubsMock
+type RespClearChaincodeStubsMock struct {
+	Err         *Error
+	Unsupported bool
+}
+
+// optionalCallError converts the error of a call to an optional plugin
+// method, reporting ErrUnsupported for plugins that predate the method.
+func optionalCallError(err error, unsupported bool, respErr *Error) error {
+	var serr rpc.ServerError
+	if errors.As(err, &serr) && strings.Contains(string(serr), "can't find method") {
+		return ErrUnsupported
+	}
+	if err != nil {
+		return err
+	}
+	if unsupported {
+		return ErrUnsupported
+	}
+	if respErr != nil {
+		return respErr
+	}
+	return nil
+}
+
+// StubChaincodeMock forwards the call
+func (g *PluginRPC) StubChaincodeMock(tag string, stub *ChaincodeStub) error {
+	var resp RespStubChaincodeMock
+	err := g.client.Call("Plugin.StubChaincodeMock", &ArgsStubChaincodeMock{Tag: tag, Stub: stub}, &resp)
+	return optionalCallError(err, resp.Unsupported, resp.Err)
+}
+
+// ClearChaincodeStubsMock forwards the call
+func (g *PluginRPC) ClearChaincodeStubsMock(tag string) error {
+	var resp RespClearChaincodeStubsMock
+	err := g.client.Call("Plugin.ClearChaincodeStubsMock", &ArgsClearChaincodeStubsMock{Tag: tag}, &resp)
+	return optionalCallError(err, resp.Unsupported, resp.Err)
+}
+
+// StubChaincodeMock forwards the call
+func (s *PluginRPCServer) StubChaincodeMock(args *ArgsStubChaincodeMock, resp *RespStubChaincodeMock) error {
+	impl, ok := s.Impl.(ChaincodeStubber)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	if err := impl.StubChaincodeMock(args.Tag, args.Stub); err != nil {
+		resp.Err = s.newError(err)
+	}
+	return nil
+}
+
+// ClearChaincodeStubsMock forwards the call
+func (s *PluginRPCServer) ClearChaincodeStubsMock(args *ArgsClearChaincodeStubsMock, resp *RespClearChaincodeStubsMock) error {
+	impl, ok := s.Impl.(ChaincodeStubber)
+	if !ok {
+		resp.Unsupported = true
+		return nil
+	}
+	if err := impl.ClearChaincodeStubsMock(args.Tag); err != nil {
+		resp.Err = s.newError(err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package plugin
+
+import (
+	"net"
+	"net/rpc"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// baseSubstrate is a Substrate that implements no optional interfaces.
+type baseSubstrate struct {
+	Substrate
+}
+
+// stubSubstrate records the chaincode stubs of each mock.
+type stubSubstrate struct {
+	baseSubstrate
+	stubs map[string][]*ChaincodeStub
+}
+
+func (s *stubSubstrate) StubChaincodeMock(tag string, stub *ChaincodeStub) error {
+	s.stubs[tag] = append(s.stubs[tag], stub)
+	return nil
+}
+
+func (s *stubSubstrate) ClearChaincodeStubsMock(tag string) error {
+	delete(s.stubs, tag)
+	return nil
+}
+
+// rpcClient serves impl over an in-memory net/rpc connection.
+func rpcClient(t *testing.T, impl Substrate) *PluginRPC {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("Plugin", &PluginRPCServer{Impl: impl}))
+	c1, c2 := net.Pipe()
+	go server.ServeConn(c1)
+	client := rpc.NewClient(c2)
+	t.Cleanup(func() { _ = client.Close() })
+	return &PluginRPC{client: client}
+}
+
+func TestChaincodeStubRPC(t *testing.T) {
+	impl := &stubSubstrate{stubs: make(map[string][]*ChaincodeStub)}
+	client := rpcClient(t, impl)
+	stub := &ChaincodeStub{ChaincodeName: "other", Function: "get", Status: 200, Payload: []byte("ok")}
+	require.NoError(t, client.StubChaincodeMock("tag", stub))
+	require.Equal(t, []*ChaincodeStub{stub}, impl.stubs["tag"])
+	require.NoError(t, client.ClearChaincodeStubsMock("tag"))
+	require.Empty(t, impl.stubs)
+
+	unsupported := rpcClient(t, &baseSubstrate{})
+	require.ErrorIs(t, unsupported.StubChaincodeMock("tag", stub), ErrUnsupported)
+	require.ErrorIs(t, unsupported.ClearChaincodeStubsMock("tag"), ErrUnsupported)
+}
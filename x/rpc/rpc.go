@@ -21,6 +21,67 @@ const (
 	// MethodQueryBlock is used to call the QueryBlock method which returns the
 	// block information.
 	MethodQueryBlock = "QueryBlock"
+	// MethodCapabilities is used to call the Capabilities method which
+	// returns the list of optional protocol features supported by the
+	// gateway.  Older gateways do not implement this method.
+	MethodCapabilities = "Capabilities"
+	// MethodGetState is used to call the GetState method which returns the
+	// value of a ledger key without invoking the phylum.
+	MethodGetState = "GetState"
+	// MethodGetStateByRange is used to call the GetStateByRange method
+	// which returns a page of ledger keys in a range without invoking the
+	// phylum.
+	MethodGetStateByRange = "GetStateByRange"
+	// MethodQueryState is used to call the QueryState method which runs a
+	// CouchDB rich query against the ledger without invoking the phylum.
+	MethodQueryState = "QueryState"
+	// MethodGetEndorsementPolicy is used to call the GetEndorsementPolicy
+	// method which returns the endorsement policy of the chaincode and the
+	// peers eligible to endorse.
+	MethodGetEndorsementPolicy = "GetEndorsementPolicy"
+)
+
+const (
+	// HeaderIdempotencyKey is the HTTP header carrying the idempotency key
+	// of a request, which is also sent as the idempotency_key parameter.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderRequestID is the HTTP response header carrying the gateway's
+	// ID for a request, useful when reporting issues with the gateway.
+	HeaderRequestID = "X-Request-Id"
+	// HeaderContext is the HTTP request header carrying a JSON object of
+	// the client log fields forwarded for server-side correlation.
+	HeaderContext = "X-Shiro-Context"
+	// HeaderSignature is the HTTP request header carrying the base64
+	// encoded signature of the request body by a client-side identity:
+	// an ECDSA (ASN.1) or RSA (PKCS #1 v1.5) signature of its SHA-256
+	// digest, or an Ed25519 signature of the body itself.  Requests
+	// without a HeaderSignerCertificate are signed with a key shared with
+	// the gateway and carry an HMAC-SHA256 of the body.
+	HeaderSignature = "X-Shiro-Signature"
+	// HeaderSignerCertificate is the HTTP request header carrying the
+	// base64 encoded DER X.509 certificate of the identity that signed the
+	// request.
+	HeaderSignerCertificate = "X-Shiro-Signer-Certificate"
+)
+
+const (
+	// CapabilityTransientBase64 indicates that the gateway accepts base64
+	// encoded transient data values.
+	CapabilityTransientBase64 = "transient_base64"
+	// CapabilityStateQuery indicates that the gateway implements the
+	// GetState, GetStateByRange and QueryState methods.  Gateway policy may
+	// still reject individual queries.
+	CapabilityStateQuery = "state_query"
+)
+
+const (
+	// TransientEncodingHex is the default encoding of transient data
+	// values, which is assumed when the transient_encoding parameter is
+	// absent.
+	TransientEncodingHex = "hex"
+	// TransientEncodingBase64 is the standard base64 encoding of
+	// transient data values.
+	TransientEncodingBase64 = "base64"
 )
 
 const (
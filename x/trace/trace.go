@@ -0,0 +1,114 @@
+// Package trace propagates OpenTelemetry trace context to substrate so that
+// phylum-side tracing joins the caller's trace.
+// WARNING: This package is experimental and may change.
+package trace
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+)
+
+const (
+	// TransientTraceParent is the transient data key carrying the W3C
+	// traceparent of the caller's span.
+	TransientTraceParent = types.TransientTraceParent
+	// TransientTraceState is the transient data key carrying the W3C
+	// tracestate of the caller's span, if it is not empty.
+	TransientTraceState = types.TransientTraceState
+)
+
+// WithPropagation injects the span context of the context passed to Call
+// into transient data, under the TransientTraceParent and
+// TransientTraceState keys, so that substrate can continue the trace.
+// Calls whose context carries no valid span context are unchanged.  The
+// keys are added after configs are validated, so they are not subject to a
+// transient key pattern.
+func WithPropagation() types.Config {
+	return types.Opt(func(r *types.RequestOptions) {
+		r.TraceTransient = true
+	})
+}
+
+// Transient data keys configuring the exporter used by substrate for
+// phylum-side spans.
+const (
+	// TransientCollectorURI is the transient data key carrying the URI of
+	// the trace collector, e.g. a Jaeger or OTLP endpoint.
+	TransientCollectorURI = "trace_collector_uri"
+	// TransientDataset is the transient data key carrying the dataset
+	// spans are exported to, e.g. a Honeycomb dataset.
+	TransientDataset = "trace_dataset"
+	// TransientSampleRate is the transient data key carrying the fraction
+	// of requests traced by substrate, formatted as a decimal number.
+	TransientSampleRate = "trace_sample_rate"
+)
+
+// ExporterConfig configures where substrate exports phylum-side spans.
+type ExporterConfig struct {
+	// CollectorURI is the http or https URI of the trace collector.
+	CollectorURI string
+	// Dataset is the dataset spans are exported to, if the collector
+	// requires one (e.g. Honeycomb).
+	Dataset string
+	// SampleRate is the fraction of requests traced, between 0 and 1.  Zero
+	// leaves the sample rate to substrate.
+	SampleRate float64
+}
+
+// Validate checks that the collector URI is an absolute http or https URI
+// and that the sample rate is between 0 and 1.
+func (c *ExporterConfig) Validate() error {
+	if c.CollectorURI == "" {
+		return &types.ConfigError{Field: "CollectorURI", Reason: "must not be empty"}
+	}
+	u, err := url.Parse(c.CollectorURI)
+	if err != nil {
+		return &types.ConfigError{Field: "CollectorURI", Reason: err.Error()}
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &types.ConfigError{Field: "CollectorURI", Reason: fmt.Sprintf("must be an http or https URI: %q", c.CollectorURI)}
+	}
+	if c.SampleRate < 0 || c.SampleRate > 1 || math.IsNaN(c.SampleRate) {
+		return &types.ConfigError{Field: "SampleRate", Reason: fmt.Sprintf("must be between 0 and 1: %v", c.SampleRate)}
+	}
+	return nil
+}
+
+// TransientData returns the transient data entries configuring the
+// exporter.
+func (c *ExporterConfig) TransientData() (map[string][]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	data := map[string][]byte{
+		TransientCollectorURI: []byte(c.CollectorURI),
+	}
+	if c.Dataset != "" {
+		data[TransientDataset] = []byte(c.Dataset)
+	}
+	if c.SampleRate > 0 {
+		data[TransientSampleRate] = []byte(strconv.FormatFloat(c.SampleRate, 'f', -1, 64))
+	}
+	return data, nil
+}
+
+// WithTracing configures substrate to export phylum-side spans as described
+// by cfg and, like WithPropagation, to join the trace of the caller.  An
+// invalid cfg is reported when the config is applied.
+func WithTracing(cfg ExporterConfig) types.Config {
+	return types.OptErr(func(r *types.RequestOptions) error {
+		data, err := cfg.TransientData()
+		if err != nil {
+			return err
+		}
+		for k, v := range data {
+			r.Transient[k] = v
+		}
+		r.TraceTransient = true
+		return nil
+	})
+}
@@ -0,0 +1,65 @@
+package trace_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/luthersystems/shiroclient-sdk-go/internal/types"
+	"github.com/luthersystems/shiroclient-sdk-go/x/trace"
+)
+
+func TestWithPropagation(t *testing.T) {
+	sc := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    oteltrace.TraceID{1, 2, 3},
+		SpanID:     oteltrace.SpanID{4, 5, 6},
+		TraceFlags: oteltrace.FlagsSampled,
+	})
+	ctx := oteltrace.ContextWithSpanContext(context.Background(), sc)
+
+	opt, err := types.ApplyConfigs(nil, trace.WithPropagation())
+	require.NoError(t, err)
+	opt.InjectTraceTransient(ctx)
+	require.Equal(t, "00-01020300000000000000000000000000-0405060000000000-01",
+		string(opt.Transient[trace.TransientTraceParent]))
+	_, ok := opt.Transient[trace.TransientTraceState]
+	require.False(t, ok)
+
+	opt, err = types.ApplyConfigs(nil, trace.WithPropagation())
+	require.NoError(t, err)
+	opt.InjectTraceTransient(context.Background())
+	require.Empty(t, opt.Transient)
+
+	opt, err = types.ApplyConfigs(nil)
+	require.NoError(t, err)
+	opt.InjectTraceTransient(ctx)
+	require.Empty(t, opt.Transient)
+}
+
+func TestWithTracing(t *testing.T) {
+	opt, err := types.ApplyConfigs(nil, trace.WithTracing(trace.ExporterConfig{
+		CollectorURI: "https://api.honeycomb.io",
+		Dataset:      "phylum",
+		SampleRate:   0.25,
+	}))
+	require.NoError(t, err)
+	require.True(t, opt.TraceTransient)
+	require.Equal(t, map[string][]byte{
+		trace.TransientCollectorURI: []byte("https://api.honeycomb.io"),
+		trace.TransientDataset:      []byte("phylum"),
+		trace.TransientSampleRate:   []byte("0.25"),
+	}, opt.Transient)
+
+	for _, cfg := range []trace.ExporterConfig{
+		{},
+		{CollectorURI: "jaeger:14268"},
+		{CollectorURI: "ftp://jaeger:14268"},
+		{CollectorURI: "http://jaeger:14268", SampleRate: 1.5},
+	} {
+		_, err := types.ApplyConfigs(nil, trace.WithTracing(cfg))
+		var cerr *types.ConfigError
+		require.ErrorAs(t, err, &cerr, "config %+v", cfg)
+	}
+}